@@ -7,6 +7,7 @@ import (
 
 	"github.com/PrayerLoop/initializers"
 	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
 	"github.com/doug-martin/goqu/v9"
 	"github.com/gin-gonic/gin"
 )
@@ -156,6 +157,11 @@ func CreateGroupCategory(c *gin.Context) {
 		return
 	}
 
+	if !groupRoleCan(membership.Role, groupActionManageCategories) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group owners and moderators can create group categories"})
+		return
+	}
+
 	var body models.PrayerCategoryCreate
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -238,6 +244,11 @@ func UpdateCategory(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this group"})
 			return
 		}
+
+		if !groupRoleCan(membership.Role, groupActionManageCategories) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only group owners and moderators can update group categories"})
+			return
+		}
 	}
 
 	var body models.PrayerCategoryUpdate
@@ -311,14 +322,31 @@ func DeleteCategory(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this group"})
 			return
 		}
+
+		if !groupRoleCan(membership.Role, groupActionManageCategories) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only group owners and moderators can delete group categories"})
+			return
+		}
 	}
 
 	// Delete the category (cascade will remove prayer_category_item entries)
-	_, err = initializers.DB.Delete("prayer_category").
-		Where(goqu.C("prayer_category_id").Eq(categoryID)).
-		Executor().
-		Exec()
+	// and record its tombstone in one transaction
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category", "details": err.Error()})
+		return
+	}
 
+	err = tx.Wrap(func() error {
+		_, err := tx.Delete("prayer_category").
+			Where(goqu.C("prayer_category_id").Eq(categoryID)).
+			Executor().
+			Exec()
+		if err != nil {
+			return err
+		}
+		return services.RecordCategorySyncTombstonesTx(tx, category, models.SyncEntityPrayerCategory, categoryID)
+	})
 	if err != nil {
 		log.Println("Error deleting category:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category", "details": err.Error()})
@@ -391,6 +419,11 @@ func ReorderGroupCategories(c *gin.Context) {
 		return
 	}
 
+	if !groupRoleCan(membership.Role, groupActionManageCategories) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group owners and moderators can reorder group categories"})
+		return
+	}
+
 	var body models.PrayerCategoryReorder
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -584,12 +617,23 @@ func RemovePrayerFromCategory(c *gin.Context) {
 		}
 	}
 
-	// Remove the item
-	_, err = initializers.DB.Delete("prayer_category_item").
-		Where(goqu.C("prayer_access_id").Eq(prayerAccessID)).
-		Executor().
-		Exec()
+	// Remove the item and record its tombstone in one transaction
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove prayer from category", "details": err.Error()})
+		return
+	}
 
+	err = tx.Wrap(func() error {
+		_, err := tx.Delete("prayer_category_item").
+			Where(goqu.C("prayer_access_id").Eq(prayerAccessID)).
+			Executor().
+			Exec()
+		if err != nil {
+			return err
+		}
+		return services.RecordCategorySyncTombstonesTx(tx, category, models.SyncEntityPrayerCategoryItem, item.Prayer_Category_Item_ID)
+	})
 	if err != nil {
 		log.Println("Error removing prayer from category:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove prayer from category", "details": err.Error()})
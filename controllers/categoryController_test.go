@@ -255,22 +255,37 @@ func TestCreateGroupCategory(t *testing.T) {
 		groupID        string
 		currentUser    models.UserProfile
 		isMember       bool
+		role           string
 		body           map[string]interface{}
 		expectedStatus int
 	}{
 		{
-			name:    "successful creation - member creates category",
+			name:    "successful creation - moderator creates category",
 			groupID: "1",
 			currentUser: models.UserProfile{
 				User_Profile_ID: 1,
 			},
 			isMember: true,
+			role:     models.GroupRoleModerator,
 			body: map[string]interface{}{
 				"categoryName":  "Mission",
 				"categoryColor": "#6A4C93",
 			},
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name:    "forbidden - plain member tries to create",
+			groupID: "1",
+			currentUser: models.UserProfile{
+				User_Profile_ID: 1,
+			},
+			isMember: true,
+			role:     models.GroupRoleMember,
+			body: map[string]interface{}{
+				"categoryName": "Mission",
+			},
+			expectedStatus: http.StatusForbidden,
+		},
 		{
 			name:    "forbidden - non-member tries to create",
 			groupID: "1",
@@ -292,8 +307,8 @@ func TestCreateGroupCategory(t *testing.T) {
 
 			if tt.isMember {
 				// Mock membership check
-				membershipRows := sqlmock.NewRows([]string{"user_group_id", "user_profile_id", "group_profile_id"}).
-					AddRow(1, 1, 1)
+				membershipRows := sqlmock.NewRows([]string{"user_group_id", "user_profile_id", "group_profile_id", "role"}).
+					AddRow(1, 1, 1, tt.role)
 				mock.ExpectQuery("SELECT").WillReturnRows(membershipRows)
 
 				if tt.expectedStatus == http.StatusCreated {
@@ -445,7 +460,12 @@ func TestDeleteCategory(t *testing.T) {
 
 			if tt.expectedStatus == http.StatusOK {
 				// Mock delete
+				mock.ExpectBegin()
 				mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// Mock tombstone for the owner's offline cache, in the same transaction
+				mock.ExpectExec("INSERT INTO \"sync_tombstone\"").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			}
 
 			c, w := SetupTestContext()
@@ -615,8 +635,8 @@ func TestRemovePrayerFromCategory(t *testing.T) {
 			if tt.hasCategory {
 				now := time.Now()
 				// Mock get category item
-				itemRows := sqlmock.NewRows([]string{"prayer_category_item_id", "prayer_category_id", "prayer_access_id", "datetime_create", "created_by"}).
-					AddRow(1, 1, 1, now, 1)
+				itemRows := sqlmock.NewRows([]string{"prayer_category_item_id", "prayer_category_id", "prayer_access_id", "datetime_create", "datetime_update", "created_by"}).
+					AddRow(1, 1, 1, now, now, 1)
 				mock.ExpectQuery("SELECT").WillReturnRows(itemRows)
 
 				// Mock get category
@@ -625,7 +645,12 @@ func TestRemovePrayerFromCategory(t *testing.T) {
 				mock.ExpectQuery("SELECT").WillReturnRows(categoryRows)
 
 				// Mock delete
+				mock.ExpectBegin()
 				mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
+
+				// Mock tombstone for the owner's offline cache, in the same transaction
+				mock.ExpectExec("INSERT INTO \"sync_tombstone\"").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			} else {
 				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
 			}
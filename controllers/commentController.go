@@ -89,6 +89,12 @@ func GetPrayerComments(c *gin.Context) {
 		return
 	}
 
+	page, err := parsePageRequest(c, "comments")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Get moderator IDs (prayer creator + linked subject)
 	moderatorIDs, err := getModeratorIDsForPrayer(prayerID)
 	if err != nil {
@@ -131,6 +137,8 @@ func GetPrayerComments(c *gin.Context) {
 		)
 	}
 
+	query = page.apply(query, false, goqu.I("prayer_comment.datetime_create"), goqu.I("prayer_comment.comment_id"))
+
 	var comments []models.CommentWithUser
 	err = query.ScanStructs(&comments)
 	if err != nil {
@@ -139,14 +147,18 @@ func GetPrayerComments(c *gin.Context) {
 		return
 	}
 
+	comments, pageInfo := pageResults(page, comments, func(comment models.CommentWithUser) []interface{} {
+		return []interface{}{comment.DateTime_Create, comment.Comment_ID}
+	})
+
 	// Return empty array if no comments found
 	if comments == nil {
 		comments = []models.CommentWithUser{}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, withPageInfo(gin.H{
 		"comments": comments,
-	})
+	}, page, pageInfo))
 }
 
 // CreateComment creates a new comment on a prayer
@@ -239,23 +251,51 @@ func CreateComment(c *gin.Context) {
 		Updated_By:      userID,
 	}
 
-	insert := initializers.DB.Insert("prayer_comment").
-		Rows(commentInsert).
-		Returning("comment_id", "datetime_create", "datetime_update")
-
 	var insertedComment struct {
 		Comment_ID      int    `db:"comment_id"`
 		Datetime_Create string `db:"datetime_create"`
 		Datetime_Update string `db:"datetime_update"`
 	}
 
-	_, err = insert.Executor().ScanStruct(&insertedComment)
+	// The comment and its notifications commit together
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment", "details": err.Error()})
+		return
+	}
+
+	err = tx.Wrap(func() error {
+		_, err := tx.Insert("prayer_comment").
+			Rows(commentInsert).
+			Returning("comment_id", "datetime_create", "datetime_update").
+			Executor().ScanStruct(&insertedComment)
+		if err != nil {
+			return err
+		}
+
+		return services.NotifyUsersOfNewComment(tx, prayerID, insertedComment.Comment_ID, userID)
+	})
 	if err != nil {
 		log.Printf("Failed to create comment: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment", "details": err.Error()})
 		return
 	}
 
+	// Private comments only go to the author and the prayer's moderators
+	go func(prayerID int, commentID int, authorID int, private bool) {
+		data := services.EventData{PrayerID: prayerID, CommentID: commentID, ActorID: authorID}
+		if !private {
+			services.PublishPrayerEvent(services.EventCommentCreated, data, authorID)
+			return
+		}
+		moderatorIDs, err := getModeratorIDsForPrayer(prayerID)
+		if err != nil {
+			log.Printf("Failed to get moderators for comment event: %v", err)
+			return
+		}
+		services.PublishToUsers(services.EventCommentCreated, data, append(moderatorIDs, authorID)...)
+	}(prayerID, insertedComment.Comment_ID, userID, isPrivate)
+
 	// Get commenter name for response
 	var commenterName string
 	_, _ = initializers.DB.From("user_profile").
@@ -263,9 +303,6 @@ func CreateComment(c *gin.Context) {
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		ScanVal(&commenterName)
 
-	// Trigger notifications after successful comment creation
-	services.NotifyUsersOfNewComment(prayerID, insertedComment.Comment_ID, userID)
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Comment created successfully",
 		"comment": gin.H{
@@ -404,6 +441,15 @@ func DeleteComment(c *gin.Context) {
 
 	// User must own comment OR be moderator
 	canDelete := existingComment.User_Profile_ID == userID || isModerator(userID, moderatorIDs)
+	if !canDelete {
+		// Group owners and moderators look after comments on prayers shared with their group
+		canDelete, err = isGroupModeratorForPrayer(existingComment.Prayer_ID, userID)
+		if err != nil {
+			log.Printf("Failed to check group moderator: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+	}
 	if !canDelete {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this comment"})
 		return
@@ -459,7 +505,16 @@ func HideComment(c *gin.Context) {
 	}
 
 	// Only moderators can hide comments
-	if !isModerator(userID, moderatorIDs) {
+	canHide := isModerator(userID, moderatorIDs)
+	if !canHide {
+		canHide, err = isGroupModeratorForPrayer(existingComment.Prayer_ID, userID)
+		if err != nil {
+			log.Printf("Failed to check group moderator: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			return
+		}
+	}
+	if !canHide {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Only moderators can hide comments"})
 		return
 	}
@@ -157,37 +157,32 @@ func SendConnectionRequest(c *gin.Context) {
 		Status:            "pending",
 	}
 
-	insert := initializers.DB.Insert("prayer_connection_request").Rows(newRequest).Returning("request_id")
-
-	var insertedID int
-	_, err = insert.Executor().ScanVal(&insertedID)
+	// The request, the subject's pending link and the target's notification commit together
+	tx, err := initializers.DB.Begin()
 	if err != nil {
-		log.Println("Failed to create connection request:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send connection request", "details": err.Error()})
 		return
 	}
 
-	// Update prayer subject link_status to pending
-	_, err = initializers.DB.Update("prayer_subject").
-		Set(goqu.Record{
-			"user_profile_id": requestData.Target_User_ID,
-			"link_status":     "pending",
-			"updated_by":      currentUser.User_Profile_ID,
-			"datetime_update": time.Now(),
-		}).
-		Where(goqu.C("prayer_subject_id").Eq(requestData.Prayer_Subject_ID)).
-		Executor().Exec()
-
-	if err != nil {
-		log.Printf("Warning: Failed to update prayer subject link status: %v", err)
-		// Don't fail the request, the connection request was created
-	}
+	var insertedID int
+	err = tx.Wrap(func() error {
+		_, err := tx.Insert("prayer_connection_request").Rows(newRequest).Returning("request_id").Executor().ScanVal(&insertedID)
+		if err != nil {
+			return fmt.Errorf("failed to create connection request: %w", err)
+		}
 
-	// Send push notification to target user
-	go func() {
-		pushService := services.GetPushNotificationService()
-		if pushService == nil {
-			return
+		// Update prayer subject link_status to pending
+		_, err = tx.Update("prayer_subject").
+			Set(goqu.Record{
+				"user_profile_id": requestData.Target_User_ID,
+				"link_status":     "pending",
+				"updated_by":      currentUser.User_Profile_ID,
+				"datetime_update": time.Now(),
+			}).
+			Where(goqu.C("prayer_subject_id").Eq(requestData.Prayer_Subject_ID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update prayer subject link status: %w", err)
 		}
 
 		displayName := currentUser.First_Name
@@ -195,20 +190,20 @@ func SendConnectionRequest(c *gin.Context) {
 			displayName = currentUser.Username
 		}
 
-		payload := services.NotificationPayload{
-			Title: "Prayer Connection Request",
-			Body:  fmt.Sprintf("%s wants to connect with you for prayer", displayName),
-			Data: map[string]string{
+		return sendConnectionNotification(tx, requestData.Target_User_ID, currentUser.User_Profile_ID, insertedID,
+			models.NotificationTypeConnectionRequested,
+			"Prayer Connection Request",
+			fmt.Sprintf("%s wants to connect with you for prayer", displayName),
+			map[string]string{
 				"type":      "connection_request",
 				"requestId": strconv.Itoa(insertedID),
-			},
-		}
-
-		err := pushService.SendNotificationToUsers([]int{requestData.Target_User_ID}, payload)
-		if err != nil {
-			log.Printf("Failed to send connection request notification: %v", err)
-		}
-	}()
+			})
+	})
+	if err != nil {
+		log.Println("Failed to create connection request:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send connection request", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":   "Connection request sent successfully",
@@ -405,21 +400,6 @@ func RespondToConnectionRequest(c *gin.Context) {
 
 	now := time.Now()
 
-	// Update the connection request
-	_, err = initializers.DB.Update("prayer_connection_request").
-		Set(goqu.Record{
-			"status":             responseData.Status,
-			"datetime_responded": now,
-		}).
-		Where(goqu.C("request_id").Eq(requestID)).
-		Executor().Exec()
-
-	if err != nil {
-		log.Println("Failed to update connection request:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to connection request", "details": err.Error()})
-		return
-	}
-
 	// Update prayer subject based on response
 	var linkStatus string
 	var userProfileID interface{}
@@ -431,25 +411,35 @@ func RespondToConnectionRequest(c *gin.Context) {
 		userProfileID = nil // Clear the pending link
 	}
 
-	_, err = initializers.DB.Update("prayer_subject").
-		Set(goqu.Record{
-			"link_status":     linkStatus,
-			"user_profile_id": userProfileID,
-			"datetime_update": now,
-		}).
-		Where(goqu.C("prayer_subject_id").Eq(request.Prayer_Subject_ID)).
-		Executor().Exec()
-
+	// The response, the subject's link and the requester's notification commit together
+	tx, err := initializers.DB.Begin()
 	if err != nil {
-		log.Printf("Warning: Failed to update prayer subject link status: %v", err)
-		// Don't fail - the request response was recorded
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to connection request", "details": err.Error()})
+		return
 	}
 
-	// Send notification to requester
-	go func() {
-		pushService := services.GetPushNotificationService()
-		if pushService == nil {
-			return
+	err = tx.Wrap(func() error {
+		_, err := tx.Update("prayer_connection_request").
+			Set(goqu.Record{
+				"status":             responseData.Status,
+				"datetime_responded": now,
+			}).
+			Where(goqu.C("request_id").Eq(requestID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update connection request: %w", err)
+		}
+
+		_, err = tx.Update("prayer_subject").
+			Set(goqu.Record{
+				"link_status":     linkStatus,
+				"user_profile_id": userProfileID,
+				"datetime_update": now,
+			}).
+			Where(goqu.C("prayer_subject_id").Eq(request.Prayer_Subject_ID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update prayer subject link status: %w", err)
 		}
 
 		displayName := currentUser.First_Name
@@ -457,28 +447,26 @@ func RespondToConnectionRequest(c *gin.Context) {
 			displayName = currentUser.Username
 		}
 
-		var body string
-		if responseData.Status == "accepted" {
-			body = fmt.Sprintf("%s accepted your prayer connection request", displayName)
-		} else {
-			body = fmt.Sprintf("%s declined your prayer connection request", displayName)
+		notificationType := models.NotificationTypeConnectionAccepted
+		message := fmt.Sprintf("%s accepted your prayer connection request", displayName)
+		if responseData.Status == "declined" {
+			notificationType = models.NotificationTypeConnectionDeclined
+			message = fmt.Sprintf("%s declined your prayer connection request", displayName)
 		}
 
-		payload := services.NotificationPayload{
-			Title: "Connection Request Update",
-			Body:  body,
-			Data: map[string]string{
+		return sendConnectionNotification(tx, request.Requester_ID, currentUser.User_Profile_ID, requestID, notificationType,
+			"Connection Request Update", message,
+			map[string]string{
 				"type":      "connection_response",
 				"requestId": strconv.Itoa(requestID),
 				"status":    responseData.Status,
-			},
-		}
-
-		err := pushService.SendNotificationToUsers([]int{request.Requester_ID}, payload)
-		if err != nil {
-			log.Printf("Failed to send connection response notification: %v", err)
-		}
-	}()
+			})
+	})
+	if err != nil {
+		log.Println("Failed to respond to connection request:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to connection request", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": fmt.Sprintf("Connection request %s successfully", responseData.Status),
@@ -592,3 +580,18 @@ func GetPendingConnectionRequestCount(c *gin.Context) {
 		"count": count,
 	})
 }
+
+// sendConnectionNotification sends a connection request notification in the
+// request's transaction. The request ID and type make up the idempotency key,
+// as each request is sent and answered once.
+func sendConnectionNotification(tx *goqu.TxDatabase, recipientID int, actorID int, requestID int, notificationType string, title string, message string, data map[string]string) error {
+	return services.GetNotificationRouter().SendTx(tx, services.Delivery{
+		UserID:  recipientID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+		ActorID: actorID,
+		Data:    data,
+		Key:     fmt.Sprintf("%s:%d", notificationType, requestID),
+	})
+}
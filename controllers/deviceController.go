@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserDevices lists the devices receiving the user's push notifications,
+// most recently seen first. The tokens themselves are not returned.
+func GetUserDevices(c *gin.Context) {
+	userID, ok := userParamOwner(c, "devices")
+	if !ok {
+		return
+	}
+
+	var devices []models.Device
+	err := initializers.DB.From("user_push_tokens").
+		Select("user_push_tokens_id", "platform", "device_id", "device_name", "last_seen_at", "created_at").
+		Where(goqu.C("user_profile_id").Eq(userID)).
+		Order(goqu.L("COALESCE(last_seen_at, updated_at)").Desc()).
+		ScanStructsContext(c, &devices)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get devices", "details": err.Error()})
+		return
+	}
+	if devices == nil {
+		devices = []models.Device{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+// DeleteUserDevices removes every device, so the user gets no push
+// notifications until the app registers again
+func DeleteUserDevices(c *gin.Context) {
+	userID, ok := userParamOwner(c, "devices")
+	if !ok {
+		return
+	}
+
+	result, err := initializers.DB.Delete("user_push_tokens").
+		Where(goqu.C("user_profile_id").Eq(userID)).
+		Executor().Exec()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove devices", "details": err.Error()})
+		return
+	}
+	removed, _ := result.RowsAffected()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Devices removed successfully", "devicesRemoved": removed})
+}
+
+// DeleteUserDevice removes one device
+func DeleteUserDevice(c *gin.Context) {
+	userID, ok := userParamOwner(c, "devices")
+	if !ok {
+		return
+	}
+
+	deviceID, err := strconv.Atoi(c.Param("user_push_tokens_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID", "details": err.Error()})
+		return
+	}
+
+	result, err := initializers.DB.Delete("user_push_tokens").
+		Where(
+			goqu.C("user_push_tokens_id").Eq(deviceID),
+			goqu.C("user_profile_id").Eq(userID),
+		).
+		Executor().Exec()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove device", "details": err.Error()})
+		return
+	}
+	if removed, _ := result.RowsAffected(); removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device removed successfully"})
+}
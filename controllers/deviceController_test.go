@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test GetUserDevices - List the devices receiving a user's push notifications
+func TestGetUserDevices(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		isAdmin        bool
+		expectedStatus int
+	}{
+		{
+			name:           "own devices",
+			userID:         "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin views another user's devices",
+			userID:         "2",
+			isAdmin:        true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "another user's devices",
+			userID:         "2",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectQuery(`SELECT "user_push_tokens_id", "platform", "device_id", "device_name", "last_seen_at", "created_at" FROM "user_push_tokens"`).
+					WillReturnRows(sqlmock.NewRows([]string{"user_push_tokens_id", "platform", "device_id", "device_name", "last_seen_at", "created_at"}).
+						AddRow(3, "ios", "device-123", "Test's iPhone", time.Now(), time.Now()))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), tt.isAdmin)
+			c.Params = append(c.Params, gin.Param{Key: "user_profile_id", Value: tt.userID})
+			c.Request = httptest.NewRequest("GET", "/users/"+tt.userID+"/devices", nil)
+
+			GetUserDevices(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectedStatus == http.StatusOK {
+				devices := response["devices"].([]interface{})
+				assert.Len(t, devices, 1)
+				device := devices[0].(map[string]interface{})
+				assert.Equal(t, "Test's iPhone", device["deviceName"])
+				assert.NotContains(t, device, "pushToken")
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test DeleteUserDevice - Remove one or all of a user's devices
+func TestDeleteUserDevice(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		deviceID       string
+		rowsAffected   int64
+		expectDelete   bool
+		expectedStatus int
+	}{
+		{
+			name:           "remove one device",
+			userID:         "1",
+			deviceID:       "3",
+			rowsAffected:   1,
+			expectDelete:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "device belongs to someone else",
+			userID:         "1",
+			deviceID:       "9",
+			rowsAffected:   0,
+			expectDelete:   true,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "remove all devices",
+			userID:         "1",
+			rowsAffected:   2,
+			expectDelete:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "another user's devices",
+			userID:         "2",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid device ID",
+			userID:         "1",
+			deviceID:       "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectDelete {
+				where := `WHERE \("user_profile_id" = 1\)`
+				if tt.deviceID != "" {
+					where = `WHERE \(\("user_push_tokens_id" = ` + tt.deviceID + `\) AND \("user_profile_id" = 1\)\)`
+				}
+				mock.ExpectExec(`DELETE FROM "user_push_tokens" ` + where).
+					WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = append(c.Params, gin.Param{Key: "user_profile_id", Value: tt.userID})
+			if tt.deviceID != "" {
+				c.Params = append(c.Params, gin.Param{Key: "user_push_tokens_id", Value: tt.deviceID})
+				c.Request = httptest.NewRequest("DELETE", "/users/"+tt.userID+"/devices/"+tt.deviceID, nil)
+				DeleteUserDevice(c)
+			} else {
+				c.Request = httptest.NewRequest("DELETE", "/users/"+tt.userID+"/devices", nil)
+				DeleteUserDevices(c)
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.NotNil(t, response["message"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	emailVerificationTTL         = 24 * time.Hour
+	maxEmailVerificationAttempts = 5
+	// Minimum time between resend requests for the same account
+	emailVerificationResendDelay = time.Minute
+)
+
+// newEmailVerificationRecord generates a fresh code and returns the columns that
+// store it on user_profile. Issuing a new code always resets the attempt count.
+func newEmailVerificationRecord() (string, goqu.Record, error) {
+	code, err := generate6DigitCode()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return code, goqu.Record{
+		"email_verified":          false,
+		"verification_token":      code,
+		"verification_expires_at": time.Now().Add(emailVerificationTTL),
+		"verification_attempts":   0,
+	}, nil
+}
+
+// sendEmailVerificationCode emails the code if the email service is configured.
+// Failures are logged; the user can always ask for the code again.
+func sendEmailVerificationCode(email string, code string, firstName string) {
+	emailService := services.GetEmailService()
+	if emailService == nil {
+		log.Println("Email service not initialized, skipping verification email")
+		return
+	}
+
+	if err := emailService.SendVerificationEmail(email, code, firstName); err != nil {
+		log.Printf("Failed to send verification email to %s: %v", email, err)
+	}
+}
+
+// VerifyEmail checks the 6-digit code sent to the current user's email address
+func VerifyEmail(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "6-digit code is required", "details": err.Error()})
+		return
+	}
+
+	if currentUser.Email_Verified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email is already verified"})
+		return
+	}
+
+	if currentUser.Verification_Token == nil ||
+		currentUser.Verification_Expires_At == nil ||
+		!time.Now().Before(*currentUser.Verification_Expires_At) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification code"})
+		return
+	}
+
+	// Spend an attempt before comparing, in a single conditional UPDATE, so
+	// parallel requests can't each get a guess past the limit
+	var attempts int
+	claimed, err := initializers.DB.Update("user_profile").
+		Set(goqu.Record{"verification_attempts": goqu.L("verification_attempts + 1")}).
+		Where(
+			goqu.C("user_profile_id").Eq(currentUser.User_Profile_ID),
+			goqu.C("verification_attempts").Lt(maxEmailVerificationAttempts),
+		).
+		Returning("verification_attempts").
+		Executor().ScanVal(&attempts)
+	if err != nil {
+		log.Printf("Failed to update verification attempt count: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email", "details": err.Error()})
+		return
+	}
+
+	if !claimed {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Maximum verification attempts exceeded. Please request a new code.",
+		})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(req.Code), []byte(*currentUser.Verification_Token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "Invalid or expired verification code",
+			"attemptsRemaining": maxEmailVerificationAttempts - attempts,
+		})
+		return
+	}
+
+	// Only clear the code we checked, in case a resend replaced it meanwhile
+	markVerified := initializers.DB.Update("user_profile").
+		Set(goqu.Record{
+			"email_verified":          true,
+			"verification_token":      nil,
+			"verification_expires_at": nil,
+			"verification_attempts":   0,
+			"datetime_update":         time.Now(),
+		}).
+		Where(goqu.And(
+			goqu.C("user_profile_id").Eq(currentUser.User_Profile_ID),
+			goqu.C("verification_token").Eq(req.Code),
+		)).
+		Executor()
+
+	result, err := markVerified.Exec()
+	if err != nil {
+		log.Printf("Failed to mark email verified for user %d: %v", currentUser.User_Profile_ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email", "details": err.Error()})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification code"})
+		return
+	}
+
+	log.Printf("Email verified for user %d (%s)", currentUser.User_Profile_ID, currentUser.Email)
+
+	// Only now that the user owns the address, join the groups it was invited to
+	joinedGroupIDs, err := claimGroupInvitations(currentUser)
+	if err != nil {
+		log.Printf("Failed to claim group invitations for user %d: %v", currentUser.User_Profile_ID, err)
+		// Don't fail the verification - the invitations stay pending
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully", "joinedGroupIds": joinedGroupIDs})
+}
+
+// ResendEmailVerification issues a new code to the current user's email address
+func ResendEmailVerification(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	if currentUser.Email_Verified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	// The previous code's issue time is its expiry minus the TTL
+	if currentUser.Verification_Expires_At != nil {
+		issuedAt := currentUser.Verification_Expires_At.Add(-emailVerificationTTL)
+		if time.Since(issuedAt) < emailVerificationResendDelay {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait a minute before requesting another code"})
+			return
+		}
+	}
+
+	code, record, err := newEmailVerificationRecord()
+	if err != nil {
+		log.Printf("Failed to generate verification code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate verification code"})
+		return
+	}
+
+	update := initializers.DB.Update("user_profile").
+		Set(record).
+		Where(goqu.C("user_profile_id").Eq(currentUser.User_Profile_ID)).
+		Executor()
+
+	if _, err := update.Exec(); err != nil {
+		log.Printf("Failed to store verification code for user %d: %v", currentUser.User_Profile_ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification code", "details": err.Error()})
+		return
+	}
+
+	sendEmailVerificationCode(currentUser.Email, code, currentUser.First_Name)
+
+	c.JSON(http.StatusOK, gin.H{"message": "A new verification code has been sent to your email."})
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test VerifyEmail - Check 6-digit code against the current user's pending verification
+func TestVerifyEmail(t *testing.T) {
+	code := "123456"
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		emailVerified  bool
+		token          *string
+		expiresAt      *time.Time
+		attempts       int
+		attemptClaimed bool
+		expectVerify   bool
+		verifyRows     int64
+		invitedGroup   int
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful verification",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			token:          &code,
+			expiresAt:      &future,
+			attemptClaimed: true,
+			expectVerify:   true,
+			verifyRows:     1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "verification joins invited groups",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			token:          &code,
+			expiresAt:      &future,
+			attemptClaimed: true,
+			expectVerify:   true,
+			verifyRows:     1,
+			invitedGroup:   3,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "already verified",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			emailVerified:  true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong code increments attempts",
+			requestBody:    models.VerifyEmailRequest{Code: "999999"},
+			token:          &code,
+			expiresAt:      &future,
+			attempts:       1,
+			attemptClaimed: true,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "max attempts exceeded",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			token:          &code,
+			expiresAt:      &future,
+			attempts:       maxEmailVerificationAttempts,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "parallel guesses used up the attempts",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			token:          &code,
+			expiresAt:      &future,
+			attempts:       maxEmailVerificationAttempts - 1,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "code expired",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			token:          &code,
+			expiresAt:      &past,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "no code issued",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "code replaced by resend before update",
+			requestBody:    models.VerifyEmailRequest{Code: code},
+			token:          &code,
+			expiresAt:      &future,
+			attemptClaimed: true,
+			expectVerify:   true,
+			verifyRows:     0,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "invalid code length",
+			requestBody:    map[string]interface{}{"code": "123"},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.token != nil && tt.expiresAt != nil && tt.expiresAt.After(time.Now()) {
+				// The attempt is claimed atomically; no row means the limit was reached
+				attemptRows := sqlmock.NewRows([]string{"verification_attempts"})
+				if tt.attemptClaimed {
+					attemptRows.AddRow(tt.attempts + 1)
+				}
+				mock.ExpectQuery("UPDATE \"user_profile\" SET \"verification_attempts\"").
+					WillReturnRows(attemptRows)
+			}
+			if tt.expectVerify {
+				mock.ExpectExec("UPDATE \"user_profile\"").
+					WillReturnResult(sqlmock.NewResult(0, tt.verifyRows))
+			}
+			if tt.verifyRows > 0 {
+				// Invitations to the address are claimed only once it's verified
+				invitations := sqlmock.NewRows([]string{"group_invitation_id", "group_profile_id", "email", "invitation_status", "invited_by", "datetime_expires"})
+				if tt.invitedGroup != 0 {
+					invitations.AddRow(4, tt.invitedGroup, "test@example.com", "pending", 2, future)
+				}
+				mock.ExpectQuery("SELECT .* FROM \"group_invitation\"").WillReturnRows(invitations)
+				if tt.invitedGroup != 0 {
+					mock.ExpectBegin()
+					mock.ExpectExec("UPDATE \"group_invitation\"").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("UPDATE \"user_group\"").WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("INSERT INTO \"user_group\"").WillReturnResult(sqlmock.NewResult(1, 1))
+					ExpectNotifications(mock, true, 2)
+					mock.ExpectCommit()
+				}
+			}
+
+			user := MockUser()
+			user.Email_Verified = tt.emailVerified
+			user.Verification_Token = tt.token
+			user.Verification_Expires_At = tt.expiresAt
+			user.Verification_Attempts = tt.attempts
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, user, false)
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest("POST", "/auth/verify-email", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			VerifyEmail(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectError {
+				assert.NotNil(t, response["error"])
+			} else {
+				assert.NotNil(t, response["message"])
+			}
+			if tt.invitedGroup != 0 {
+				assert.Equal(t, []interface{}{float64(tt.invitedGroup)}, response["joinedGroupIds"])
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test ResendEmailVerification - Issue a fresh code with a resend cooldown
+func TestResendEmailVerification(t *testing.T) {
+	justIssued := time.Now().Add(emailVerificationTTL)
+	issuedEarlier := time.Now().Add(emailVerificationTTL - 10*time.Minute)
+
+	tests := []struct {
+		name           string
+		emailVerified  bool
+		expiresAt      *time.Time
+		expectUpdate   bool
+		expectedStatus int
+	}{
+		{
+			name:           "successful resend",
+			expiresAt:      &issuedEarlier,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "first code after legacy signup",
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "resend too soon",
+			expiresAt:      &justIssued,
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "already verified",
+			emailVerified:  true,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectUpdate {
+				mock.ExpectExec("UPDATE \"user_profile\"").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			user := MockUser()
+			user.Email_Verified = tt.emailVerified
+			user.Verification_Expires_At = tt.expiresAt
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, user, false)
+			c.Request = httptest.NewRequest("POST", "/auth/resend-verification", nil)
+
+			ResendEmailVerification(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
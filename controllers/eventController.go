@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+)
+
+// eventHeartbeat keeps idle streams open through proxies that close quiet
+// connections
+var eventHeartbeat = 25 * time.Second
+
+// eventTopics is the user's own topic plus one per active group membership
+func eventTopics(userID int) ([]string, error) {
+	var groupIDs []int
+	err := initializers.DB.From("user_group").
+		Select("group_profile_id").
+		Where(
+			goqu.C("user_profile_id").Eq(userID),
+			goqu.C("is_active").IsTrue(),
+		).
+		ScanVals(&groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	topics := []string{services.UserTopic(userID)}
+	for _, groupID := range groupIDs {
+		topics = append(topics, services.GroupTopic(groupID))
+	}
+	return topics, nil
+}
+
+// changesMembership reports whether the event adds or removes one of the
+// user's groups, so the stream needs to update its topics
+func changesMembership(event services.Event, userID int) bool {
+	data, ok := event.Data.(services.EventData)
+	if !ok {
+		return false
+	}
+	switch event.Type {
+	case services.EventGroupMemberJoined, services.EventGroupMemberLeft:
+		return data.UserID == userID
+	case services.EventGroupDeleted:
+		return true
+	}
+	return false
+}
+
+func writeEvent(w io.Writer, event services.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// StreamEvents streams real-time events to the current user as Server-Sent
+// Events. Clients reconnect with the Last-Event-ID header (or ?lastEventId=)
+// to receive what they missed; if that's no longer possible a "resync" event
+// tells them to catch up through GET /sync instead.
+func StreamEvents(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	userID := currentUser.User_Profile_ID
+
+	topics, err := eventTopics(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load groups", "details": err.Error()})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	sub, replay, ok := services.GetEventHub().Subscribe(topics, lastEventID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !ok {
+		if _, err := fmt.Fprint(c.Writer, "event: resync\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := writeEvent(c.Writer, event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, open := <-sub.Events():
+			if !open {
+				// Fell behind; the client reconnects with its last event ID
+				if sub.Dropped() {
+					log.Printf("Dropped event stream for user %d: client fell behind", userID)
+				}
+				return
+			}
+			if err := writeEvent(c.Writer, event); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+			if changesMembership(event, userID) {
+				topics, err := eventTopics(userID)
+				if err != nil {
+					log.Printf("Failed to reload event topics for user %d: %v", userID, err)
+					continue
+				}
+				sub.SetTopics(topics)
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
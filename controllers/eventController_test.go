@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// flushRecorder signals each time the handler flushes an event
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed chan struct{}
+}
+
+func (r *flushRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	select {
+	case r.flushed <- struct{}{}:
+	default:
+	}
+}
+
+func waitForFlush(w *flushRecorder) bool {
+	select {
+	case <-w.flushed:
+		return true
+	case <-time.After(100 * time.Millisecond):
+		return false
+	}
+}
+
+// Test StreamEvents - Replay missed events, then stream live ones
+func TestStreamEvents(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	originalHub := services.GetEventHub()
+	hub := services.NewEventHub(100, 10)
+	services.SetEventHub(hub)
+	defer services.SetEventHub(originalHub)
+
+	seen := hub.Publish(services.EventCommentCreated, services.EventData{PrayerID: 1}, services.GroupTopic(7))
+	hub.Publish(services.EventPrayerUpdated, services.EventData{PrayerID: 2}, services.GroupTopic(7))
+	hub.Publish(services.EventPrayerUpdated, services.EventData{PrayerID: 3}, services.UserTopic(2))
+
+	mock.ExpectQuery(`SELECT "group_profile_id" FROM "user_group"`).
+		WillReturnRows(sqlmock.NewRows([]string{"group_profile_id"}).AddRow(7))
+	// Reloaded after joining group 9
+	mock.ExpectQuery(`SELECT "group_profile_id" FROM "user_group"`).
+		WillReturnRows(sqlmock.NewRows([]string{"group_profile_id"}).AddRow(7).AddRow(9))
+
+	gin.SetMode(gin.TestMode)
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan struct{}, 1)}
+	c, _ := gin.CreateTestContext(w)
+	SetAuthenticatedUser(c, MockUser(), false)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	c.Request.Header.Set("Last-Event-ID", seen.ID)
+
+	done := make(chan struct{})
+	go func() {
+		StreamEvents(c)
+		close(done)
+	}()
+
+	// Replay
+	assert.True(t, waitForFlush(w))
+
+	hub.Publish(services.EventGroupMemberJoined, services.EventData{GroupID: 9, UserID: 1}, services.GroupTopic(9), services.UserTopic(1))
+	assert.True(t, waitForFlush(w))
+
+	// Once the topics are reloaded, group 9's events arrive
+	received := false
+	for i := 0; i < 20 && !received; i++ {
+		hub.Publish(services.EventPrayerShared, services.EventData{PrayerID: 4, GroupID: 9}, services.GroupTopic(9))
+		received = waitForFlush(w)
+	}
+	assert.True(t, received)
+
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.NotContains(t, body, `"prayerId":1`, "already seen")
+	assert.Contains(t, body, "event: prayer.updated\ndata: {\"prayerId\":2}\n\n")
+	assert.NotContains(t, body, `"prayerId":3`, "another user's event")
+	assert.Contains(t, body, "event: group.member_joined")
+	assert.Contains(t, body, `"prayerId":4,"groupId":9`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test StreamEvents - An unknown Last-Event-ID asks the client to resync
+func TestStreamEventsResync(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT "group_profile_id" FROM "user_group"`).
+		WillReturnRows(sqlmock.NewRows([]string{"group_profile_id"}))
+
+	c, w := SetupTestContext()
+	SetAuthenticatedUser(c, MockUser(), false)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Request = httptest.NewRequest("GET", "/events?lastEventId=expired-12", nil).WithContext(ctx)
+
+	StreamEvents(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "event: resync\n")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
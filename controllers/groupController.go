@@ -28,6 +28,7 @@ func CreateGroup(c *gin.Context) {
 		Group_Name:        newGroup.Group_Name,
 		Group_Description: newGroup.Group_Description,
 		Is_Active:         true,
+		Requires_Approval: newGroup.Requires_Approval,
 		Created_By:        user.User_Profile_ID,
 		Updated_By:        user.User_Profile_ID,
 		Datetime_Create:   time.Now(),
@@ -65,6 +66,7 @@ func CreateGroup(c *gin.Context) {
 		Group_Profile_ID:       group.Group_Profile_ID,
 		Is_Active:              true,
 		Group_Display_Sequence: 0,
+		Role:                   models.GroupRoleOwner,
 		Created_By:             user.User_Profile_ID,
 		Updated_By:             user.User_Profile_ID,
 		Datetime_Create:        time.Now(),
@@ -140,6 +142,8 @@ func GetGroup(c *gin.Context) {
 			goqu.I("group_profile.datetime_create"),
 			goqu.I("group_profile.datetime_update"),
 			goqu.I("group_profile.prayer_subject_id"),
+			goqu.I("group_profile.requires_approval"),
+			goqu.I("user_group.role"),
 		).
 		Join(
 			goqu.T("user_group"),
@@ -193,6 +197,7 @@ func GetAllGroups(c *gin.Context) {
 			"updated_by",
 			"deleted",
 			"prayer_subject_id",
+			"requires_approval",
 		).
 		ScanStructs(&groups)
 
@@ -214,7 +219,6 @@ func UpdateGroup(c *gin.Context) {
 		return
 	}
 
-	// Check if user is the group creator
 	var group models.GroupProfile
 	found, err := initializers.DB.From("group_profile").
 		Select("created_by").
@@ -231,10 +235,17 @@ func UpdateGroup(c *gin.Context) {
 		return
 	}
 
-	// Only allow if user is admin OR the group creator
-	if !admin && group.Created_By != user.User_Profile_ID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Only the group creator or an admin can update this group"})
-		return
+	// Only allow if user is admin OR the group owner
+	if !admin {
+		role, err := getGroupRole(groupID, user.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return
+		}
+		if !groupRoleCan(role, groupActionUpdateGroup) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only the group owner or an admin can update this group"})
+			return
+		}
 	}
 
 	var updateGroup models.GroupUpdate
@@ -243,13 +254,18 @@ func UpdateGroup(c *gin.Context) {
 		return
 	}
 
+	record := goqu.Record{
+		"group_name":        updateGroup.Group_Name,
+		"group_description": updateGroup.Group_Description,
+		"updated_by":        user.User_Profile_ID,
+		"datetime_update":   time.Now(),
+	}
+	if updateGroup.Requires_Approval != nil {
+		record["requires_approval"] = *updateGroup.Requires_Approval
+	}
+
 	update := initializers.DB.Update("group_profile").
-		Set(goqu.Record{
-			"group_name":        updateGroup.Group_Name,
-			"group_description": updateGroup.Group_Description,
-			"updated_by":        user.User_Profile_ID,
-			"datetime_update":   time.Now(),
-		}).
+		Set(record).
 		Where(goqu.C("group_profile_id").Eq(groupID))
 
 	result, err := update.Executor().Exec()
@@ -267,7 +283,7 @@ func UpdateGroup(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Group updated successfully"})
 }
 
-// Allow group creator or admin to delete group
+// Allow group owner or admin to delete group
 func DeleteGroup(c *gin.Context) {
 	currentUser := c.MustGet("currentUser").(models.UserProfile)
 	admin := c.MustGet("admin").(bool)
@@ -278,7 +294,7 @@ func DeleteGroup(c *gin.Context) {
 		return
 	}
 
-	// Check if user is the group creator and fetch group info
+	// Fetch group info for the notifications
 	var group models.GroupProfile
 	selectStmt := initializers.DB.From("group_profile").
 		Select("created_by", "group_name").
@@ -295,80 +311,102 @@ func DeleteGroup(c *gin.Context) {
 		return
 	}
 
-	// Only allow if user is admin OR the group creator
-	if !admin && group.Created_By != currentUser.User_Profile_ID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Only the group creator or an admin can delete this group"})
-		return
+	// Only allow if user is admin OR the group owner
+	if !admin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return
+		}
+		if !groupRoleCan(role, groupActionDeleteGroup) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only the group owner or an admin can delete this group"})
+			return
+		}
 	}
 
-	// Fetch all group members BEFORE deleting for email notifications
-	var groupMembers []models.UserProfile
-	err = initializers.DB.From("user_group").
-		InnerJoin(
-			goqu.T("user_profile"),
-			goqu.On(goqu.Ex{"user_group.user_profile_id": goqu.I("user_profile.user_profile_id")}),
-		).
-		Select("user_profile.*").
-		Where(goqu.C("group_profile_id").Eq(groupID)).
-		ScanStructs(&groupMembers)
+	// The tombstones, the deletes and the members' notifications go in one transaction
+	tx, err := initializers.DB.Begin()
 	if err != nil {
-		log.Printf("Failed to fetch group members for email notifications: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group", "details": err.Error()})
+		return
 	}
 
-	// Delete all user_group records for this group first
-	deleteUserGroupStmt := initializers.DB.Delete("user_group").
-		Where(goqu.C("group_profile_id").Eq(groupID))
+	var rowsAffected int64
+	err = tx.Wrap(func() error {
+		// Fetch all group members BEFORE deleting, to tell them the group is gone
+		var memberIDs []int
+		err := tx.From("user_group").
+			Select("user_profile_id").
+			Where(goqu.C("group_profile_id").Eq(groupID)).
+			ScanVals(&memberIDs)
+		if err != nil {
+			return fmt.Errorf("failed to fetch group members: %w", err)
+		}
 
-	_, err = deleteUserGroupStmt.Executor().Exec()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group members", "details": err.Error()})
-		return
-	}
+		// Tell every member's offline cache the group is gone while the memberships still exist
+		if err := services.RecordGroupSyncTombstonesTx(tx, groupID, models.SyncEntityGroup, groupID); err != nil {
+			return err
+		}
 
-	// Delete all prayer_access records for this group
-	deletePrayerAccessStmt := initializers.DB.Delete("prayer_access").
-		Where(
-			goqu.C("access_type").Eq("group"),
-			goqu.C("access_type_id").Eq(groupID),
-		)
+		// Delete all user_group records for this group first
+		_, err = tx.Delete("user_group").
+			Where(goqu.C("group_profile_id").Eq(groupID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to delete group members: %w", err)
+		}
 
-	_, err = deletePrayerAccessStmt.Executor().Exec()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group prayers", "details": err.Error()})
-		return
-	}
+		// Delete all prayer_access records for this group
+		_, err = tx.Delete("prayer_access").
+			Where(
+				goqu.C("access_type").Eq("group"),
+				goqu.C("access_type_id").Eq(groupID),
+			).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to delete group prayers: %w", err)
+		}
 
-	// Now delete the group itself (group_invite will cascade automatically)
-	deleteGroupStmt := initializers.DB.Delete("group_profile").
-		Where(goqu.C("group_profile_id").Eq(groupID))
+		// Now delete the group itself (group_invite will cascade automatically)
+		result, err := tx.Delete("group_profile").
+			Where(goqu.C("group_profile_id").Eq(groupID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to delete group: %w", err)
+		}
+		rowsAffected, _ = result.RowsAffected()
+		if rowsAffected == 0 || len(memberIDs) == 0 {
+			return nil
+		}
 
-	result, err := deleteGroupStmt.Executor().Exec()
+		// The group is gone, so the notification doesn't link to it. Each group is
+		// deleted once, which makes its ID the idempotency key.
+		return services.GetNotificationRouter().SendToUsersTx(tx, memberIDs, services.Delivery{
+			Type:    models.NotificationTypeGroupDeleted,
+			Title:   group.Group_Name,
+			Message: fmt.Sprintf("%s has been deleted", group.Group_Name),
+			ActorID: currentUser.User_Profile_ID,
+			Data: map[string]string{
+				"type":    "group_deleted",
+				"groupId": strconv.Itoa(groupID),
+			},
+			Key: fmt.Sprintf("%s:%d", models.NotificationTypeGroupDeleted, groupID),
+		})
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group", "details": err.Error()})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
 		return
 	}
 
-	// Send email notifications to all group members
-	emailService := services.GetEmailService()
-	if emailService != nil && len(groupMembers) > 0 && group.Group_Name != "" {
-		// Send emails in background to all members
-		go func() {
-			for _, member := range groupMembers {
-				if member.Email != "" {
-					err := emailService.SendGroupDeletedEmail(member.Email, member.First_Name, group.Group_Name)
-					if err != nil {
-						log.Printf("Failed to send group deleted email to %s: %v", member.Email, err)
-					}
-				}
-			}
-		}()
-	}
+	services.PublishGroupEvent(services.EventGroupDeleted, services.EventData{
+		GroupID: groupID,
+		ActorID: currentUser.User_Profile_ID,
+	}, groupID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
 }
@@ -387,6 +425,7 @@ func GetGroupUsers(c *gin.Context) {
 			"user_profile.email",
 			"user_profile.first_name",
 			"user_profile.last_name",
+			"user_group.role",
 			"user_group.created_by",
 			"user_group.updated_by",
 		).
@@ -407,7 +446,7 @@ func GetGroupUsers(c *gin.Context) {
 		return
 	}
 
-	var users []models.UserProfile
+	var users []models.GroupMember
 	err = initializers.DB.ScanStructs(&users, sql, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group users", "details": err.Error()})
@@ -416,7 +455,7 @@ func GetGroupUsers(c *gin.Context) {
 
 	// Always return an array, even if empty (for consistent client-side handling)
 	if users == nil {
-		users = []models.UserProfile{}
+		users = []models.GroupMember{}
 	}
 
 	c.JSON(http.StatusOK, users)
@@ -438,9 +477,18 @@ func AddUserToGroup(c *gin.Context) {
 		return
 	}
 
-	if !isAdmin && userID != currentUser.User_Profile_ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add this user to the group"})
-		return
+	// Joining on your own goes through an invite code; adding someone directly
+	// is for the group's owner and moderators
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return
+		}
+		if !groupRoleCan(role, groupActionAddMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add this user to the group"})
+			return
+		}
 	}
 
 	// Check if the user is already in the group
@@ -482,6 +530,7 @@ func AddUserToGroup(c *gin.Context) {
 		Group_Profile_ID:       groupID,
 		Is_Active:              true,
 		Group_Display_Sequence: 0,
+		Role:                   models.GroupRoleMember,
 		Created_By:             currentUser.User_Profile_ID,
 		Updated_By:             currentUser.User_Profile_ID,
 		Datetime_Create:        time.Now(),
@@ -497,6 +546,12 @@ func AddUserToGroup(c *gin.Context) {
 		return
 	}
 
+	services.PublishGroupEvent(services.EventGroupMemberJoined, services.EventData{
+		GroupID: groupID,
+		UserID:  userID,
+		ActorID: currentUser.User_Profile_ID,
+	}, groupID, userID)
+
 	c.JSON(http.StatusOK, gin.H{"message": "User added to group successfully"})
 }
 
@@ -516,12 +571,32 @@ func RemoveUserFromGroup(c *gin.Context) {
 		return
 	}
 
-	if !isAdmin && userID != currentUser.User_Profile_ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to remove this user from the group"})
+	targetRole, err := getGroupRole(groupID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
 		return
 	}
 
-	// Fetch user and group information for email
+	// A group always keeps its owner; ownership has to be handed over first
+	if targetRole == models.GroupRoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "The group owner must transfer ownership before leaving the group"})
+		return
+	}
+
+	// Anyone can leave; removing someone else takes a role above theirs
+	if !isAdmin && userID != currentUser.User_Profile_ID {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return
+		}
+		if !groupRoleCan(role, groupActionRemoveMember) || !groupRoleOutranks(role, targetRole) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to remove this user from the group"})
+			return
+		}
+	}
+
+	// Fetch user and group information for the notifications
 	var user models.UserProfile
 	var group models.GroupProfile
 
@@ -530,7 +605,8 @@ func RemoveUserFromGroup(c *gin.Context) {
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		ScanStruct(&user)
 	if err != nil {
-		log.Printf("Failed to fetch user for email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user", "details": err.Error()})
+		return
 	}
 
 	_, err = initializers.DB.From("group_profile").
@@ -538,28 +614,44 @@ func RemoveUserFromGroup(c *gin.Context) {
 		Where(goqu.C("group_profile_id").Eq(groupID)).
 		ScanStruct(&group)
 	if err != nil {
-		log.Printf("Failed to fetch group for email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group", "details": err.Error()})
+		return
 	}
 
 	// Determine if this is voluntary leave or forced removal
 	isVoluntaryLeave := userID == currentUser.User_Profile_ID
 
-	deleteStmt := initializers.DB.Delete("user_group").
-		Where(
-			goqu.C("user_profile_id").Eq(userID),
-			goqu.C("group_profile_id").Eq(groupID),
-		)
-
-	result, err := deleteStmt.Executor().Exec()
+	// Remove the membership, record the user's tombstone and queue the notifications in one transaction
+	tx, err := initializers.DB.Begin()
 	if err != nil {
-		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user from group", "details": err.Error()})
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	var rowsAffected int64
+	err = tx.Wrap(func() error {
+		result, err := tx.Delete("user_group").
+			Where(
+				goqu.C("user_profile_id").Eq(userID),
+				goqu.C("group_profile_id").Eq(groupID),
+			).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err = result.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return err
+		}
+		if err := services.RecordSyncTombstoneTx(tx, userID, models.SyncEntityGroup, groupID); err != nil {
+			return err
+		}
+		return notifyGroupMemberLeft(tx, groupID, group.Group_Name, user, currentUser.User_Profile_ID, isVoluntaryLeave)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rows affected", "details": err.Error()})
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove user from group", "details": err.Error()})
 		return
 	}
 
@@ -568,67 +660,230 @@ func RemoveUserFromGroup(c *gin.Context) {
 		return
 	}
 
-	// Send appropriate email notification
-	emailService := services.GetEmailService()
-	if emailService != nil && user.Email != "" && group.Group_Name != "" {
-		if isVoluntaryLeave {
-			// User voluntarily left the group
-			go func() {
-				err := emailService.SendGroupLeftEmail(user.Email, user.First_Name, group.Group_Name)
-				if err != nil {
-					log.Printf("Failed to send group left email: %v", err)
-				}
-			}()
-		} else {
-			// User was removed by group creator/admin
-			go func() {
-				err := emailService.SendRemovedFromGroupEmail(user.Email, user.First_Name, group.Group_Name)
-				if err != nil {
-					log.Printf("Failed to send removed from group email: %v", err)
-				}
-			}()
-		}
+	services.PublishGroupEvent(services.EventGroupMemberLeft, services.EventData{
+		GroupID: groupID,
+		UserID:  userID,
+		ActorID: currentUser.User_Profile_ID,
+	}, groupID, userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "User removed from group successfully"})
+}
+
+// notifyGroupMemberLeft confirms the leave or removal to the user and tells
+// the remaining members. It runs in the removal's transaction.
+func notifyGroupMemberLeft(tx *goqu.TxDatabase, groupID int, groupName string, user models.UserProfile, actorID int, voluntary bool) error {
+	router := services.GetNotificationRouter()
+
+	// The user can't open the group anymore, so their notification doesn't link to it
+	confirmation := services.Delivery{
+		UserID:  user.User_Profile_ID,
+		Type:    models.NotificationTypeGroupLeft,
+		Title:   groupName,
+		Message: fmt.Sprintf("You left %s", groupName),
+		ActorID: actorID,
+		Data: map[string]string{
+			"type":    "group_left",
+			"groupId": strconv.Itoa(groupID),
+		},
+	}
+	if !voluntary {
+		confirmation.Type = models.NotificationTypeRemovedFromGroup
+		confirmation.Message = fmt.Sprintf("You were removed from %s", groupName)
+		confirmation.Data["type"] = "removed_from_group"
+	}
+	if err := router.SendTx(tx, confirmation); err != nil {
+		return err
+	}
+
+	memberIDs, err := GetOtherGroupMemberIDs(groupID, user.User_Profile_ID)
+	if err != nil {
+		return err
+	}
+
+	if len(memberIDs) == 0 {
+		return nil
+	}
+
+	displayName := user.First_Name
+	if displayName == "" {
+		displayName = user.Username
+	}
+
+	return router.SendToUsersTx(tx, memberIDs, services.Delivery{
+		Type:          models.NotificationTypeGroupMemberLeft,
+		Title:         groupName,
+		Message:       fmt.Sprintf("%s has left the group", displayName),
+		ActorID:       user.User_Profile_ID,
+		TargetGroupID: &groupID,
+		Data: map[string]string{
+			"type":    "group_member_left",
+			"groupId": strconv.Itoa(groupID),
+		},
+	})
+}
+
+// UpdateGroupUserRole promotes a member to moderator or demotes a moderator
+// back to member. Only the owner (or an admin) can change roles; ownership
+// itself moves through TransferGroupOwnership.
+func UpdateGroupUserRole(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
+		return
 	}
 
-	// Send push notification to remaining group members
-	go func() {
-		memberIDs, err := GetOtherGroupMemberIDs(groupID, userID)
+	userID, err := strconv.Atoi(c.Param("user_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user profile ID", "details": err.Error()})
+		return
+	}
+
+	var roleUpdate models.GroupRoleUpdate
+	if err := c.ShouldBindJSON(&roleUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	if roleUpdate.Role != models.GroupRoleModerator && roleUpdate.Role != models.GroupRoleMember {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be moderator or member; use transfer-ownership to change the owner"})
+		return
+	}
+
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
 		if err != nil {
-			log.Printf("Failed to get group member IDs for notification: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
 			return
 		}
-
-		if len(memberIDs) == 0 {
+		if !groupRoleCan(role, groupActionChangeRoles) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the group owner can change member roles"})
 			return
 		}
+	}
 
-		pushService := services.GetPushNotificationService()
-		if pushService == nil {
-			log.Println("Push notification service not available")
-			return
-		}
+	targetRole, err := getGroupRole(groupID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+		return
+	}
 
-		displayName := user.First_Name
-		if displayName == "" {
-			displayName = user.Username
-		}
+	if targetRole == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+		return
+	}
 
-		payload := services.NotificationPayload{
-			Title: group.Group_Name,
-			Body:  fmt.Sprintf("%s has left the group", displayName),
-			Data: map[string]string{
-				"type":    "group_member_left",
-				"groupId": strconv.Itoa(groupID),
-			},
-		}
+	if targetRole == models.GroupRoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "The owner's role can only change by transferring ownership"})
+		return
+	}
+
+	_, err = initializers.DB.Update("user_group").
+		Set(goqu.Record{
+			"role":            roleUpdate.Role,
+			"updated_by":      currentUser.User_Profile_ID,
+			"datetime_update": time.Now(),
+		}).
+		Where(
+			goqu.C("group_profile_id").Eq(groupID),
+			goqu.C("user_profile_id").Eq(userID),
+		).
+		Executor().Exec()
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member role", "details": err.Error()})
+		return
+	}
+
+	services.PublishGroupEvent(services.EventGroupRoleChanged, services.EventData{
+		GroupID: groupID,
+		UserID:  userID,
+		ActorID: currentUser.User_Profile_ID,
+		Status:  roleUpdate.Role,
+	}, groupID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated successfully", "role": roleUpdate.Role})
+}
+
+// TransferGroupOwnership hands the group to another active member. The
+// previous owner stays on as a moderator.
+func TransferGroupOwnership(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
+		return
+	}
 
-		err = pushService.SendNotificationToUsers(memberIDs, payload)
+	var transfer models.GroupOwnershipTransfer
+	if err := c.ShouldBindJSON(&transfer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
 		if err != nil {
-			log.Printf("Failed to send group leave notifications: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return
 		}
-	}()
+		if !groupRoleCan(role, groupActionTransferOwnership) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the group owner can transfer ownership"})
+			return
+		}
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User removed from group successfully"})
+	targetRole, err := getGroupRole(groupID, transfer.User_Profile_ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+		return
+	}
+
+	if targetRole == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+		return
+	}
+
+	if targetRole == models.GroupRoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already owns this group"})
+		return
+	}
+
+	// One statement so the group is never left with zero or two owners
+	_, err = initializers.DB.Update("user_group").
+		Set(goqu.Record{
+			"role": goqu.Case().
+				When(goqu.C("user_profile_id").Eq(transfer.User_Profile_ID), models.GroupRoleOwner).
+				Else(models.GroupRoleModerator),
+			"updated_by":      currentUser.User_Profile_ID,
+			"datetime_update": time.Now(),
+		}).
+		Where(
+			goqu.C("group_profile_id").Eq(groupID),
+			goqu.Or(
+				goqu.C("user_profile_id").Eq(transfer.User_Profile_ID),
+				goqu.C("role").Eq(models.GroupRoleOwner),
+			),
+		).
+		Executor().Exec()
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer ownership", "details": err.Error()})
+		return
+	}
+
+	services.PublishGroupEvent(services.EventGroupRoleChanged, services.EventData{
+		GroupID: groupID,
+		UserID:  transfer.User_Profile_ID,
+		ActorID: currentUser.User_Profile_ID,
+		Status:  models.GroupRoleOwner,
+	}, groupID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group ownership transferred successfully"})
 }
 
 func GetGroupPrayers(c *gin.Context) {
@@ -651,9 +906,21 @@ func GetGroupPrayers(c *gin.Context) {
 		return
 	}
 
+	page, err := parsePageRequest(c, "group_prayers")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filters, err := parsePrayerListFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var userPrayers []models.UserPrayer
 
-	dbErr := initializers.DB.From("prayer").
+	query := initializers.DB.From("prayer").
 		Select(
 			goqu.I("prayer.prayer_id"),
 			goqu.I("prayer_access.prayer_access_id"),
@@ -670,6 +937,7 @@ func GetGroupPrayers(c *gin.Context) {
 			goqu.I("prayer.updated_by"),
 			goqu.I("prayer.datetime_update"),
 			goqu.I("prayer.deleted"),
+			goqu.I("prayer.is_archived"),
 			goqu.I("prayer.prayer_subject_id"),
 			goqu.I("prayer_subject.prayer_subject_display_name"),
 			goqu.I("prayer_subject.user_profile_id").As("prayer_subject_user_profile_id"),
@@ -701,16 +969,26 @@ func GetGroupPrayers(c *gin.Context) {
 				goqu.Ex{"prayer_access.access_type_id": groupID},
 			),
 		).
-		Order(goqu.I("prayer_access.display_sequence").Asc()).
-		ScanStructsContext(c, &userPrayers)
+		Where(filters.expressions()...).
+		Order(goqu.I("prayer_access.display_sequence").Asc())
+
+	query = page.apply(query, false, goqu.I("prayer_access.display_sequence"), goqu.I("prayer_access.prayer_access_id"))
+	dbErr := query.ScanStructsContext(c, &userPrayers)
 
 	if dbErr != nil {
 		c.JSON(500, gin.H{"error": dbErr.Error()})
 		return
 	}
 
+	userPrayers, pageInfo := pageResults(page, userPrayers, func(prayer models.UserPrayer) []interface{} {
+		return []interface{}{prayer.Display_Sequence, prayer.Prayer_Access_ID}
+	})
+
 	if len(userPrayers) == 0 {
-		c.JSON(http.StatusOK, gin.H{"message": "No prayer records found."})
+		c.JSON(http.StatusOK, withPageInfo(gin.H{
+			"message": "No prayer records found.",
+			"prayers": []models.UserPrayer{},
+		}, page, pageInfo))
 		return
 	}
 
@@ -719,10 +997,10 @@ func GetGroupPrayers(c *gin.Context) {
 		the client can interpret 0 as meaning its a group prayer and not tied to one user
 		todo -- consider making a separate struct for group prayers
 	*/
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, withPageInfo(gin.H{
 		"message": "Prayer records retrieved successfully.",
 		"prayers": userPrayers,
-	})
+	}, page, pageInfo))
 }
 
 func CreateGroupPrayer(c *gin.Context) {
@@ -787,22 +1065,6 @@ func CreateGroupPrayer(c *gin.Context) {
 		}
 	}
 
-	// Shift all existing prayers in this subject down by incrementing their subject_display_sequence
-	// This makes room for the new prayer at position 0 (top of subject list)
-	updateSubjectSeqQuery := initializers.DB.Update("prayer").
-		Set(goqu.Record{"subject_display_sequence": goqu.L("subject_display_sequence + 1")}).
-		Where(
-			goqu.C("prayer_subject_id").Eq(prayerSubjectID),
-			goqu.C("deleted").Eq(false),
-		)
-
-	_, err = updateSubjectSeqQuery.Executor().Exec()
-	if err != nil {
-		log.Println("Failed to update prayer subject display sequence:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder prayers in subject", "details": err.Error()})
-		return
-	}
-
 	newPrayerEntry := models.Prayer{
 		Prayer_Type:              newPrayer.Prayer_Type,
 		Is_Private:               newPrayer.Is_Private,
@@ -819,110 +1081,88 @@ func CreateGroupPrayer(c *gin.Context) {
 		Datetime_Update:          time.Now(),
 	}
 
-	prayerInsert := initializers.DB.Insert("prayer").Rows(newPrayerEntry).Returning("prayer_id")
-
-	var insertedPrayerID int
-	_, err = prayerInsert.Executor().ScanVal(&insertedPrayerID)
+	// The prayer, its place in the group, its history and the circle's notifications commit together
+	tx, err := initializers.DB.Begin()
 	if err != nil {
-		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer record", "details": err.Error()})
 		return
 	}
 
-	// Shift all existing prayers down by incrementing their display_sequence
-	// This makes room for the new prayer at position 0 (top of list)
-	updateQuery := initializers.DB.Update("prayer_access").
-		Set(goqu.Record{"display_sequence": goqu.L("display_sequence + 1")}).
-		Where(
-			goqu.C("access_type").Eq("group"),
-			goqu.C("access_type_id").Eq(groupID),
-		)
-
-	_, err = updateQuery.Executor().Exec()
-	if err != nil {
-		log.Println("Failed to update prayer display sequence:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder prayers", "details": err.Error()})
-		return
-	}
-
-	// Insert new prayer at position 0 (top of list)
-	newPrayerAccessEntry := models.PrayerAccess{
-		Prayer_ID:        insertedPrayerID,
-		Access_Type:      "group",
-		Access_Type_ID:   groupID,
-		Display_Sequence: 0,
-		Created_By:       currentUser.User_Profile_ID,
-		Updated_By:       currentUser.User_Profile_ID,
-		Datetime_Create:  time.Now(),
-		Datetime_Update:  time.Now(),
-	}
-
-	prayerAccessInsert := initializers.DB.Insert("prayer_access").Rows(newPrayerAccessEntry).Returning("prayer_access_id")
-
+	var insertedPrayerID int
 	var insertedPrayerAccessID int
-	_, err = prayerAccessInsert.Executor().ScanVal(&insertedPrayerAccessID)
-	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer access record", "details": err.Error()})
-		return
-	}
-
-	// Get group name for notification
-	groupName, err := GetGroupNameByID(groupID)
-	if err != nil {
-		log.Printf("Failed to get group name for notification: %v", err)
-		groupName = "a circle" // Fallback
-	}
+	err = tx.Wrap(func() error {
+		// Shift all existing prayers in this subject down by incrementing their subject_display_sequence
+		// This makes room for the new prayer at position 0 (top of subject list)
+		_, err := tx.Update("prayer").
+			Set(goqu.Record{"subject_display_sequence": goqu.L("subject_display_sequence + 1")}).
+			Where(
+				goqu.C("prayer_subject_id").Eq(prayerSubjectID),
+				goqu.C("deleted").Eq(false),
+			).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to reorder prayers in subject: %w", err)
+		}
 
-	// Get display name for actor
-	displayName := currentUser.First_Name
-	if displayName == "" {
-		displayName = currentUser.Username
-	}
+		_, err = tx.Insert("prayer").Rows(newPrayerEntry).Returning("prayer_id").Executor().ScanVal(&insertedPrayerID)
+		if err != nil {
+			return fmt.Errorf("failed to create prayer record: %w", err)
+		}
 
-	// Get linked subject user ID if prayer has a linked subject
-	var linkedSubjectUserID *int
-	if newPrayer.Prayer_Subject_ID != nil {
-		var subjectUserID int
-		found, _ := initializers.DB.From("prayer_subject").
-			Select("user_profile_id").
+		// Shift all existing prayers down by incrementing their display_sequence
+		// This makes room for the new prayer at position 0 (top of list)
+		_, err = tx.Update("prayer_access").
+			Set(goqu.Record{"display_sequence": goqu.L("display_sequence + 1")}).
 			Where(
-				goqu.And(
-					goqu.C("prayer_subject_id").Eq(*newPrayer.Prayer_Subject_ID),
-					goqu.C("link_status").Eq("linked"),
-					goqu.C("user_profile_id").IsNotNull(),
-				),
-			).ScanVal(&subjectUserID)
-		if found {
-			linkedSubjectUserID = &subjectUserID
+				goqu.C("access_type").Eq("group"),
+				goqu.C("access_type_id").Eq(groupID),
+			).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to reorder prayers: %w", err)
 		}
-	}
-
-	// Send notifications to circle members (async)
-	go func(gID int, gName string, actorID int, actorName string, pID int, creatorID int, subjectID *int) {
-		services.NotifyCircleOfPrayerShared(gID, gName, actorID, actorName, pID, creatorID, subjectID)
-	}(groupID, groupName, currentUser.User_Profile_ID, displayName, insertedPrayerID, currentUser.User_Profile_ID, linkedSubjectUserID)
 
-	// Send PRAYER_CREATED_FOR_YOU notification to linked subject (async)
-	if linkedSubjectUserID != nil && *linkedSubjectUserID != currentUser.User_Profile_ID {
-		go func(subjectID int, pID int, gID int, actorID int, actorName string, gName string) {
-			services.NotifySubjectOfPrayerCreated(subjectID, pID, gID, actorID, actorName, gName)
-		}(*linkedSubjectUserID, insertedPrayerID, groupID, currentUser.User_Profile_ID, displayName, groupName)
-	}
+		// Insert new prayer at position 0 (top of list)
+		newPrayerAccessEntry := models.PrayerAccess{
+			Prayer_ID:        insertedPrayerID,
+			Access_Type:      "group",
+			Access_Type_ID:   groupID,
+			Display_Sequence: 0,
+			Created_By:       currentUser.User_Profile_ID,
+			Updated_By:       currentUser.User_Profile_ID,
+			Datetime_Create:  time.Now(),
+			Datetime_Update:  time.Now(),
+		}
+		_, err = tx.Insert("prayer_access").Rows(newPrayerAccessEntry).Returning("prayer_access_id").Executor().ScanVal(&insertedPrayerAccessID)
+		if err != nil {
+			return fmt.Errorf("failed to create prayer access record: %w", err)
+		}
 
-	// Log prayer creation to history (async, non-blocking)
-	go func(prayerID int, userID int) {
+		// Log prayer creation to history with its first version
 		historyEntry := models.PrayerEditHistory{
-			Prayer_ID:       prayerID,
-			User_Profile_ID: userID,
+			Prayer_ID:       insertedPrayerID,
+			User_Profile_ID: currentUser.User_Profile_ID,
 			Action_Type:     models.HistoryActionCreated,
+			New_Version:     encodePrayerVersion(prayerVersionOf(newPrayerEntry)),
 		}
-		insert := initializers.DB.Insert("prayer_edit_history").Rows(historyEntry)
-		_, err := insert.Executor().Exec()
+		_, err = tx.Insert("prayer_edit_history").Rows(historyEntry).Executor().Exec()
 		if err != nil {
-			log.Printf("Failed to log prayer creation to history: %v", err)
+			return fmt.Errorf("failed to log prayer creation to history: %w", err)
 		}
-	}(insertedPrayerID, currentUser.User_Profile_ID)
+
+		return notifyPrayerSharedWithGroup(tx, groupID, currentUser, insertedPrayerID, currentUser.User_Profile_ID, newPrayer.Prayer_Subject_ID)
+	})
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer record", "details": err.Error()})
+		return
+	}
+
+	services.PublishGroupEvent(services.EventPrayerShared, services.EventData{
+		PrayerID: insertedPrayerID,
+		GroupID:  groupID,
+		ActorID:  currentUser.User_Profile_ID,
+	}, groupID, currentUser.User_Profile_ID)
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Prayer created sucessfully!",
 		"prayerId":       insertedPrayerID,
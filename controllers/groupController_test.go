@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -270,7 +271,7 @@ func TestUpdateGroup(t *testing.T) {
 		groupID        string
 		currentUser    models.UserProfile
 		isAdmin        bool
-		isCreator      bool
+		role           string
 		groupExists    bool
 		updateData     models.GroupUpdate
 		rowsAffected   int64
@@ -282,7 +283,6 @@ func TestUpdateGroup(t *testing.T) {
 			groupID:     "1",
 			currentUser: MockAdminUser(),
 			isAdmin:     true,
-			isCreator:   false,
 			groupExists: true,
 			updateData: models.GroupUpdate{
 				Group_Name:        "Updated Group",
@@ -294,11 +294,11 @@ func TestUpdateGroup(t *testing.T) {
 			expectError:    false,
 		},
 		{
-			name:        "successful update - creator (non-admin)",
+			name:        "successful update - owner (non-admin)",
 			groupID:     "1",
 			currentUser: MockUser(),
 			isAdmin:     false,
-			isCreator:   true,
+			role:        models.GroupRoleOwner,
 			groupExists: true,
 			updateData: models.GroupUpdate{
 				Group_Name:        "Updated Group",
@@ -310,11 +310,26 @@ func TestUpdateGroup(t *testing.T) {
 			expectError:    false,
 		},
 		{
-			name:        "unauthorized - not creator and not admin",
+			name:        "unauthorized - moderator cannot edit the group",
 			groupID:     "1",
 			currentUser: MockUser(),
 			isAdmin:     false,
-			isCreator:   false,
+			role:        models.GroupRoleModerator,
+			groupExists: true,
+			updateData: models.GroupUpdate{
+				Group_Name:        "Updated Group",
+				Group_Description: "Updated description",
+				Is_Active:         true,
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:        "unauthorized - not owner and not admin",
+			groupID:     "1",
+			currentUser: MockUser(),
+			isAdmin:     false,
+			role:        models.GroupRoleMember,
 			groupExists: true,
 			updateData: models.GroupUpdate{
 				Group_Name:        "Updated Group",
@@ -329,7 +344,6 @@ func TestUpdateGroup(t *testing.T) {
 			groupID:     "999",
 			currentUser: MockAdminUser(),
 			isAdmin:     true,
-			isCreator:   false,
 			groupExists: false,
 			updateData: models.GroupUpdate{
 				Group_Name:        "Updated Group",
@@ -345,7 +359,6 @@ func TestUpdateGroup(t *testing.T) {
 			groupID:        "invalid",
 			currentUser:    MockAdminUser(),
 			isAdmin:        true,
-			isCreator:      false,
 			groupExists:    false,
 			updateData:     models.GroupUpdate{},
 			expectedStatus: http.StatusBadRequest,
@@ -360,16 +373,16 @@ func TestUpdateGroup(t *testing.T) {
 
 			if tt.groupID != "invalid" {
 				if tt.groupExists {
-					createdBy := 2 // Default to different user
-					if tt.isCreator {
-						createdBy = tt.currentUser.User_Profile_ID
-					}
-					// Mock group lookup to get created_by
+					// Mock group lookup
 					rows := sqlmock.NewRows([]string{"created_by"}).
-						AddRow(createdBy)
+						AddRow(2)
 					mock.ExpectQuery("SELECT").WillReturnRows(rows)
 
-					if tt.isAdmin || tt.isCreator {
+					if !tt.isAdmin {
+						ExpectGroupRole(mock, tt.role)
+					}
+
+					if tt.isAdmin || tt.role == models.GroupRoleOwner {
 						mock.ExpectExec("UPDATE \"group_profile\"").
 							WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
 					}
@@ -409,17 +422,17 @@ func TestDeleteGroup(t *testing.T) {
 		groupID        string
 		currentUser    models.UserProfile
 		isAdmin        bool
-		isCreator      bool
+		role           string
 		groupExists    bool
 		expectedStatus int
 		expectError    bool
 	}{
 		{
-			name:           "successful delete - creator",
+			name:           "successful delete - owner",
 			groupID:        "1",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			isCreator:      true,
+			role:           models.GroupRoleOwner,
 			groupExists:    true,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
@@ -429,17 +442,26 @@ func TestDeleteGroup(t *testing.T) {
 			groupID:        "1",
 			currentUser:    MockAdminUser(),
 			isAdmin:        true,
-			isCreator:      false,
 			groupExists:    true,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
 		{
-			name:           "unauthorized - not creator and not admin",
+			name:           "unauthorized - moderator cannot delete the group",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			isAdmin:        false,
+			role:           models.GroupRoleModerator,
+			groupExists:    true,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "unauthorized - not owner and not admin",
 			groupID:        "1",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			isCreator:      false,
+			role:           models.GroupRoleMember,
 			groupExists:    true,
 			expectedStatus: http.StatusUnauthorized,
 			expectError:    true,
@@ -449,7 +471,6 @@ func TestDeleteGroup(t *testing.T) {
 			groupID:        "999",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			isCreator:      false,
 			groupExists:    false,
 			expectedStatus: http.StatusNotFound,
 			expectError:    true,
@@ -459,7 +480,6 @@ func TestDeleteGroup(t *testing.T) {
 			groupID:        "invalid",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			isCreator:      false,
 			groupExists:    false,
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
@@ -473,18 +493,24 @@ func TestDeleteGroup(t *testing.T) {
 
 			if tt.groupID != "invalid" {
 				if tt.groupExists {
-					createdBy := 2 // Default to different user
-					if tt.isCreator {
-						createdBy = tt.currentUser.User_Profile_ID
-					}
 					// Mock group lookup
 					rows := sqlmock.NewRows([]string{"created_by", "group_name"}).
-						AddRow(createdBy, "Test Group")
+						AddRow(2, "Test Group")
 					mock.ExpectQuery("SELECT").WillReturnRows(rows)
 
-					if tt.isAdmin || tt.isCreator {
-						// Mock fetch group members for email
-						mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{}))
+					if !tt.isAdmin {
+						ExpectGroupRole(mock, tt.role)
+					}
+
+					if tt.isAdmin || tt.role == models.GroupRoleOwner {
+						// Mock fetch group members, in the same transaction as the deletes
+						mock.ExpectBegin()
+						mock.ExpectQuery("SELECT \"user_profile_id\" FROM \"user_group\"").
+							WillReturnRows(sqlmock.NewRows([]string{"user_profile_id"}).AddRow(1).AddRow(3))
+
+						// Mock group tombstones for every member
+						mock.ExpectExec("INSERT INTO \"sync_tombstone\"").
+							WillReturnResult(sqlmock.NewResult(0, 1))
 
 						// Mock cascade deletes
 						mock.ExpectExec("DELETE FROM \"user_group\"").
@@ -493,6 +519,13 @@ func TestDeleteGroup(t *testing.T) {
 							WillReturnResult(sqlmock.NewResult(0, 0))
 						mock.ExpectExec("DELETE FROM \"group_profile\"").
 							WillReturnResult(sqlmock.NewResult(0, 1))
+
+						// Every member hears the group was deleted
+						mock.ExpectExec("INSERT INTO \"notification\" .*'GROUP_DELETED'").
+							WillReturnResult(sqlmock.NewResult(1, 1))
+						mock.ExpectExec("INSERT INTO \"notification\" .*'GROUP_DELETED'").
+							WillReturnResult(sqlmock.NewResult(1, 1))
+						mock.ExpectCommit()
 					}
 				} else {
 					// Mock empty result (group not found)
@@ -517,6 +550,7 @@ func TestDeleteGroup(t *testing.T) {
 			} else {
 				assert.NotNil(t, response["message"])
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -560,9 +594,9 @@ func TestGetGroupUsers(t *testing.T) {
 
 			if tt.groupID != "invalid" {
 				if tt.hasUsers {
-					rows := sqlmock.NewRows([]string{"user_profile_id", "username", "email", "first_name", "last_name", "created_by", "updated_by"}).
-						AddRow(1, "testuser", "test@example.com", "Test", "User", 1, 1).
-						AddRow(2, "testuser2", "test2@example.com", "Test2", "User2", 1, 1)
+					rows := sqlmock.NewRows([]string{"user_profile_id", "username", "email", "first_name", "last_name", "role", "created_by", "updated_by"}).
+						AddRow(1, "testuser", "test@example.com", "Test", "User", "owner", 1, 1).
+						AddRow(2, "testuser2", "test2@example.com", "Test2", "User2", "member", 1, 1)
 					mock.ExpectQuery("SELECT").WillReturnRows(rows)
 				} else {
 					rows := sqlmock.NewRows([]string{"user_profile_id", "username", "email", "first_name", "last_name", "created_by", "updated_by"})
@@ -583,10 +617,12 @@ func TestGetGroupUsers(t *testing.T) {
 				_ = json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NotNil(t, response["error"])
 			} else if tt.hasUsers {
-				// Response should be an array of users
-				var users []interface{}
+				// Response should be an array of users with their group role
+				var users []map[string]interface{}
 				_ = json.Unmarshal(w.Body.Bytes(), &users)
 				assert.Greater(t, len(users), 0)
+				assert.Equal(t, "owner", users[0]["role"])
+				assert.Equal(t, "testuser", users[0]["username"])
 			} else {
 				// No users found returns an empty array
 				var users []interface{}
@@ -605,6 +641,7 @@ func TestAddUserToGroup(t *testing.T) {
 		userID         string
 		currentUser    models.UserProfile
 		isAdmin        bool
+		role           string
 		userExists     bool
 		expectedStatus int
 		expectError    bool
@@ -620,31 +657,44 @@ func TestAddUserToGroup(t *testing.T) {
 			expectError:    false,
 		},
 		{
-			name:           "successful add - user adding self",
+			name:           "successful add - moderator adding user",
 			groupID:        "1",
-			userID:         "1",
+			userID:         "2",
 			currentUser:    MockUser(),
 			isAdmin:        false,
+			role:           models.GroupRoleModerator,
 			userExists:     false,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
 		{
-			name:           "forbidden - non-admin adding other user",
+			name:           "forbidden - member adding other user",
 			groupID:        "1",
 			userID:         "2",
 			currentUser:    MockUser(),
 			isAdmin:        false,
+			role:           models.GroupRoleMember,
 			userExists:     false,
 			expectedStatus: http.StatusForbidden,
 			expectError:    true,
 		},
 		{
-			name:           "conflict - user already in group",
+			name:           "forbidden - user adding self without an invite",
 			groupID:        "1",
 			userID:         "1",
 			currentUser:    MockUser(),
 			isAdmin:        false,
+			userExists:     false,
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
+		{
+			name:           "conflict - user already in group",
+			groupID:        "1",
+			userID:         "2",
+			currentUser:    MockUser(),
+			isAdmin:        false,
+			role:           models.GroupRoleOwner,
 			userExists:     true,
 			expectedStatus: http.StatusConflict,
 			expectError:    true,
@@ -677,7 +727,10 @@ func TestAddUserToGroup(t *testing.T) {
 			defer cleanup()
 
 			if tt.groupID != "invalid" && tt.userID != "invalid" {
-				if tt.isAdmin || tt.userID == "1" {
+				if !tt.isAdmin {
+					ExpectGroupRole(mock, tt.role)
+				}
+				if tt.isAdmin || groupRoleCan(tt.role, groupActionAddMember) {
 					if tt.userExists {
 						// Mock user already exists in group
 						rows := sqlmock.NewRows([]string{"user_profile_id", "group_profile_id"}).
@@ -728,6 +781,8 @@ func TestRemoveUserFromGroup(t *testing.T) {
 		userID         string
 		currentUser    models.UserProfile
 		isAdmin        bool
+		role           string
+		targetRole     string
 		userInGroup    bool
 		expectedStatus int
 		expectError    bool
@@ -738,6 +793,7 @@ func TestRemoveUserFromGroup(t *testing.T) {
 			userID:         "2",
 			currentUser:    MockAdminUser(),
 			isAdmin:        true,
+			targetRole:     models.GroupRoleMember,
 			userInGroup:    true,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
@@ -748,20 +804,58 @@ func TestRemoveUserFromGroup(t *testing.T) {
 			userID:         "1",
 			currentUser:    MockUser(),
 			isAdmin:        false,
+			targetRole:     models.GroupRoleMember,
+			userInGroup:    true,
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "successful remove - moderator removing member",
+			groupID:        "1",
+			userID:         "2",
+			currentUser:    MockUser(),
+			isAdmin:        false,
+			role:           models.GroupRoleModerator,
+			targetRole:     models.GroupRoleMember,
 			userInGroup:    true,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
 		{
-			name:           "forbidden - non-admin removing other user",
+			name:           "forbidden - member removing other user",
+			groupID:        "1",
+			userID:         "2",
+			currentUser:    MockUser(),
+			isAdmin:        false,
+			role:           models.GroupRoleMember,
+			targetRole:     models.GroupRoleMember,
+			userInGroup:    true,
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
+		{
+			name:           "forbidden - moderator removing another moderator",
 			groupID:        "1",
 			userID:         "2",
 			currentUser:    MockUser(),
 			isAdmin:        false,
+			role:           models.GroupRoleModerator,
+			targetRole:     models.GroupRoleModerator,
 			userInGroup:    true,
 			expectedStatus: http.StatusForbidden,
 			expectError:    true,
 		},
+		{
+			name:           "conflict - owner leaving without transferring ownership",
+			groupID:        "1",
+			userID:         "1",
+			currentUser:    MockUser(),
+			isAdmin:        false,
+			targetRole:     models.GroupRoleOwner,
+			userInGroup:    true,
+			expectedStatus: http.StatusConflict,
+			expectError:    true,
+		},
 		{
 			name:           "not found - user not in group",
 			groupID:        "1",
@@ -800,30 +894,54 @@ func TestRemoveUserFromGroup(t *testing.T) {
 			defer cleanup()
 
 			if tt.groupID != "invalid" && tt.userID != "invalid" {
-				if tt.isAdmin || tt.userID == "1" {
+				ExpectGroupRole(mock, tt.targetRole)
+				isSelf := tt.userID == strconv.Itoa(tt.currentUser.User_Profile_ID)
+				if !tt.isAdmin && !isSelf {
+					ExpectGroupRole(mock, tt.role)
+				}
+				allowed := tt.isAdmin || isSelf ||
+					(groupRoleCan(tt.role, groupActionRemoveMember) && groupRoleOutranks(tt.role, tt.targetRole))
+				if allowed && tt.targetRole != models.GroupRoleOwner {
 					now := time.Now()
 					phone := "1234567890"
-					// Mock user fetch for email
+					// Mock user fetch for the notifications
 					userRows := sqlmock.NewRows([]string{"user_profile_id", "username", "first_name", "last_name", "email", "phone_number", "admin", "created_by", "updated_by", "datetime_create", "datetime_update"}).
 						AddRow(1, "testuser", "Test", "User", "test@example.com", phone, false, 1, 1, now, now)
 					mock.ExpectQuery("SELECT").WillReturnRows(userRows)
 
-					// Mock group fetch for email
+					// Mock group fetch for the notifications
 					groupRows := sqlmock.NewRows([]string{"group_name"}).
 						AddRow("Test Group")
 					mock.ExpectQuery("SELECT").WillReturnRows(groupRows)
 
 					// Mock delete
+					mock.ExpectBegin()
 					if tt.userInGroup {
 						mock.ExpectExec("DELETE FROM \"user_group\"").
 							WillReturnResult(sqlmock.NewResult(0, 1))
 
-						// Mock GetOtherGroupMemberIDs for push notification (runs in goroutine)
+						// Mock group tombstone for the removed member's offline cache
+						mock.ExpectExec("INSERT INTO \"sync_tombstone\"").
+							WillReturnResult(sqlmock.NewResult(1, 1))
+
+						// The user and the remaining members are notified in the same transaction
+						confirmation := models.NotificationTypeGroupLeft
+						if !isSelf {
+							confirmation = models.NotificationTypeRemovedFromGroup
+						}
+						mock.ExpectExec("INSERT INTO \"notification\" .*'" + confirmation + "'").
+							WillReturnResult(sqlmock.NewResult(1, 1))
 						mock.ExpectQuery("SELECT \"user_profile_id\" FROM \"user_group\"").
 							WillReturnRows(sqlmock.NewRows([]string{"user_profile_id"}).AddRow(2).AddRow(3))
+						mock.ExpectExec("INSERT INTO \"notification\" .*'GROUP_MEMBER_LEFT'").
+							WillReturnResult(sqlmock.NewResult(1, 1))
+						mock.ExpectExec("INSERT INTO \"notification\" .*'GROUP_MEMBER_LEFT'").
+							WillReturnResult(sqlmock.NewResult(1, 1))
+						mock.ExpectCommit()
 					} else {
 						mock.ExpectExec("DELETE FROM \"user_group\"").
 							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectCommit()
 					}
 				}
 			}
@@ -848,6 +966,197 @@ func TestRemoveUserFromGroup(t *testing.T) {
 			} else {
 				assert.NotNil(t, response["message"])
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test UpdateGroupUserRole - Promote or demote a member
+func TestUpdateGroupUserRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		isAdmin        bool
+		role           string
+		targetRole     string
+		body           string
+		expectUpdate   bool
+		expectedStatus int
+	}{
+		{
+			name:           "owner promotes member to moderator",
+			userID:         "2",
+			role:           models.GroupRoleOwner,
+			targetRole:     models.GroupRoleMember,
+			body:           `{"role":"moderator"}`,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "owner demotes moderator",
+			userID:         "2",
+			role:           models.GroupRoleOwner,
+			targetRole:     models.GroupRoleModerator,
+			body:           `{"role":"member"}`,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin promotes member",
+			userID:         "3",
+			isAdmin:        true,
+			targetRole:     models.GroupRoleMember,
+			body:           `{"role":"moderator"}`,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "moderator cannot change roles",
+			userID:         "2",
+			role:           models.GroupRoleModerator,
+			body:           `{"role":"moderator"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "owner role goes through transfer",
+			userID:         "2",
+			body:           `{"role":"owner"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "cannot demote the owner",
+			userID:         "1",
+			isAdmin:        true,
+			targetRole:     models.GroupRoleOwner,
+			body:           `{"role":"member"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "target not in group",
+			userID:         "9",
+			role:           models.GroupRoleOwner,
+			body:           `{"role":"moderator"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "invalid",
+			body:           `{"role":"moderator"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.userID != "invalid" && tt.body != `{"role":"owner"}` {
+				if !tt.isAdmin {
+					ExpectGroupRole(mock, tt.role)
+				}
+				if tt.isAdmin || tt.role == models.GroupRoleOwner {
+					ExpectGroupRole(mock, tt.targetRole)
+				}
+				if tt.expectUpdate {
+					mock.ExpectExec(`UPDATE "user_group" SET .*"role"`).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+
+			currentUser := MockUser()
+			if tt.isAdmin {
+				currentUser = MockAdminUser()
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, currentUser, tt.isAdmin)
+			c.Params = []gin.Param{
+				{Key: "group_profile_id", Value: "1"},
+				{Key: "user_profile_id", Value: tt.userID},
+			}
+			c.Request = httptest.NewRequest("PATCH", "/groups/1/users/"+tt.userID+"/role", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			UpdateGroupUserRole(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test TransferGroupOwnership - Hand a group to another member
+func TestTransferGroupOwnership(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           string
+		targetRole     string
+		body           string
+		expectUpdate   bool
+		expectedStatus int
+	}{
+		{
+			name:           "owner transfers to moderator",
+			role:           models.GroupRoleOwner,
+			targetRole:     models.GroupRoleModerator,
+			body:           `{"userProfileId":2}`,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "moderator cannot transfer",
+			role:           models.GroupRoleModerator,
+			body:           `{"userProfileId":2}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "target not in group",
+			role:           models.GroupRoleOwner,
+			body:           `{"userProfileId":9}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "target already owns the group",
+			role:           models.GroupRoleOwner,
+			targetRole:     models.GroupRoleOwner,
+			body:           `{"userProfileId":1}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "missing target",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.body != `{}` {
+				ExpectGroupRole(mock, tt.role)
+				if tt.role == models.GroupRoleOwner {
+					ExpectGroupRole(mock, tt.targetRole)
+				}
+				if tt.expectUpdate {
+					// New owner promoted and previous owner stepped down in one statement
+					mock.ExpectExec(`UPDATE "user_group" SET "datetime_update"=.*"role"=CASE WHEN \("user_profile_id" = 2\) THEN 'owner' ELSE 'moderator' END`).
+						WillReturnResult(sqlmock.NewResult(0, 2))
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "group_profile_id", Value: "1"}}
+			c.Request = httptest.NewRequest("POST", "/groups/1/transfer-ownership", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			TransferGroupOwnership(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -1108,6 +1417,9 @@ func TestCreateGroupPrayer(t *testing.T) {
 						mock.ExpectQuery("SELECT \"prayer_subject_id\" FROM \"prayer_subject\"").
 							WillReturnRows(sqlmock.NewRows([]string{"prayer_subject_id"}).AddRow(1))
 
+						// The prayer, its group access, its history and the circle's notifications share a transaction
+						mock.ExpectBegin()
+
 						// Mock subject_display_sequence update for prayers in this subject
 						mock.ExpectExec("UPDATE \"prayer\" SET \"subject_display_sequence\"").
 							WillReturnResult(sqlmock.NewResult(0, 0))
@@ -1124,13 +1436,13 @@ func TestCreateGroupPrayer(t *testing.T) {
 						mock.ExpectQuery("INSERT INTO \"prayer_access\"").
 							WillReturnRows(sqlmock.NewRows([]string{"prayer_access_id"}).AddRow(1))
 
-						// Mock GetGroupNameByID for push notification (runs in goroutine)
-						mock.ExpectQuery("SELECT \"group_name\" FROM \"group_profile\"").
-							WillReturnRows(sqlmock.NewRows([]string{"group_name"}).AddRow("Test Group"))
+						// Mock the "created" history entry with the first version
+						mock.ExpectExec("INSERT INTO \"prayer_edit_history\" .*'created'").
+							WillReturnResult(sqlmock.NewResult(1, 1))
 
-						// Mock GetOtherGroupMemberIDs for push notification (runs in goroutine)
-						mock.ExpectQuery("SELECT \"user_profile_id\" FROM \"user_group\"").
-							WillReturnRows(sqlmock.NewRows([]string{"user_profile_id"}).AddRow(2).AddRow(3))
+						// Mock PRAYER_SHARED to the other circle members
+						ExpectNotifications(mock, true, 2, 3)
+						mock.ExpectCommit()
 					}
 				} else {
 					// Mock group doesn't exist
@@ -1164,6 +1476,7 @@ func TestCreateGroupPrayer(t *testing.T) {
 				assert.NotNil(t, response["prayerId"])
 				assert.NotNil(t, response["prayerAccessId"])
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
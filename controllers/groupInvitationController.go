@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	groupInvitationExpiry       = 30 * 24 * time.Hour
+	maxGroupInvitationsPerCall  = 20
+	groupInvitationEmailMaxSize = 254
+)
+
+// CreateGroupInvitations emails an invitation to each address on behalf of the
+// group. Addresses that already belong to an account are reported back instead;
+// those people can be added directly or sent a code. Inviting an address that
+// already has a pending invitation sends it again with a fresh expiry.
+// POST /groups/:group_profile_id/invitations
+func CreateGroupInvitations(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
+		return
+	}
+
+	if !isGroupExists(groupID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group doesn't exist"})
+		return
+	}
+
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return
+		}
+		if !groupRoleCan(role, groupActionManageInvites) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to invite people to this group"})
+			return
+		}
+	}
+
+	var req models.GroupInvitationCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	emails, err := normalizeInvitationEmails(req.Emails)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groupName, err := GetGroupNameByID(groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group", "details": err.Error()})
+		return
+	}
+
+	inviterName := strings.TrimSpace(currentUser.First_Name + " " + currentUser.Last_Name)
+	if inviterName == "" {
+		inviterName = currentUser.Username
+	}
+
+	emailService := services.GetEmailService()
+	if emailService == nil {
+		log.Println("Email service not initialized, invitations will be saved without sending")
+	}
+
+	results := make([]models.GroupInvitationResult, 0, len(emails))
+	for _, email := range emails {
+		result := models.GroupInvitationResult{Email: email}
+
+		var existingUserID int
+		exists, err := initializers.DB.From("user_profile").
+			Select("user_profile_id").
+			Where(goqu.L("LOWER(email) = ?", email)).
+			ScanVal(&existingUserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for existing users", "details": err.Error()})
+			return
+		}
+		if exists {
+			result.Status = models.GroupInvitationResultExistingUser
+			results = append(results, result)
+			continue
+		}
+
+		invitationID, resent, err := upsertGroupInvitation(groupID, email, currentUser.User_Profile_ID)
+		if err != nil {
+			log.Printf("Failed to save invitation for %s to group %d: %v", email, groupID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save invitation", "details": err.Error()})
+			return
+		}
+		result.Group_Invitation_ID = invitationID
+		result.Status = models.GroupInvitationResultInvited
+		if resent {
+			result.Status = models.GroupInvitationResultResent
+		}
+
+		if emailService != nil {
+			if err := emailService.SendGroupInvitationEmail(email, inviterName, groupName); err != nil {
+				log.Printf("Failed to send invitation email to %s: %v", email, err)
+			} else {
+				result.Email_Sent = true
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": results})
+}
+
+// upsertGroupInvitation refreshes the group's pending invitation for the
+// address, or creates one. It reports whether an invitation already existed.
+func upsertGroupInvitation(groupID int, email string, invitedBy int) (int, bool, error) {
+	now := time.Now()
+
+	var invitationID int
+	found, err := initializers.DB.Update("group_invitation").
+		Set(goqu.Record{
+			"invited_by":       invitedBy,
+			"datetime_expires": now.Add(groupInvitationExpiry),
+			"datetime_update":  now,
+		}).
+		Where(
+			goqu.C("group_profile_id").Eq(groupID),
+			goqu.C("email").Eq(email),
+			goqu.C("invitation_status").Eq(models.GroupInvitationStatusPending),
+		).
+		Returning("group_invitation_id").
+		Executor().ScanVal(&invitationID)
+	if err != nil || found {
+		return invitationID, found, err
+	}
+
+	_, err = initializers.DB.Insert("group_invitation").
+		Rows(models.GroupInvitation{
+			Group_Profile_ID:  groupID,
+			Email:             email,
+			Invitation_Status: models.GroupInvitationStatusPending,
+			Invited_By:        invitedBy,
+			Datetime_Expires:  now.Add(groupInvitationExpiry),
+			Datetime_Update:   now,
+		}).
+		Returning("group_invitation_id").
+		Executor().ScanVal(&invitationID)
+	return invitationID, false, err
+}
+
+// claimGroupInvitations adds a user who has just verified their email address
+// to every group with a pending, unexpired invitation for it and returns the
+// IDs of the groups they joined. Each invitation is claimed together with the
+// membership it creates, so a failure leaves it pending.
+func claimGroupInvitations(user models.UserProfile) ([]int, error) {
+	var invitations []models.GroupInvitation
+	err := initializers.DB.From("group_invitation").
+		Where(
+			goqu.C("email").Eq(strings.ToLower(strings.TrimSpace(user.Email))),
+			goqu.C("invitation_status").Eq(models.GroupInvitationStatusPending),
+			goqu.C("datetime_expires").Gt(goqu.L("NOW()")),
+		).
+		Order(goqu.C("datetime_create").Asc()).
+		ScanStructs(&invitations)
+	if err != nil {
+		return nil, err
+	}
+
+	joinedGroupIDs := []int{}
+	for _, invitation := range invitations {
+		tx, err := initializers.DB.Begin()
+		if err != nil {
+			return joinedGroupIDs, err
+		}
+
+		claimed := false
+		err = tx.Wrap(func() error {
+			now := time.Now()
+			result, err := tx.Update("group_invitation").
+				Set(goqu.Record{
+					"invitation_status":        models.GroupInvitationStatusAccepted,
+					"accepted_user_profile_id": user.User_Profile_ID,
+					"datetime_accepted":        now,
+					"datetime_update":          now,
+				}).
+				Where(
+					goqu.C("group_invitation_id").Eq(invitation.Group_Invitation_ID),
+					goqu.C("invitation_status").Eq(models.GroupInvitationStatusPending),
+				).
+				Executor().Exec()
+			if err != nil {
+				return err
+			}
+			if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+				return err
+			}
+			claimed = true
+
+			// Shift existing groups down so the new one is at the top of the list
+			_, err = tx.Update("user_group").
+				Set(goqu.Record{"group_display_sequence": goqu.L("group_display_sequence + 1")}).
+				Where(goqu.C("user_profile_id").Eq(user.User_Profile_ID)).
+				Executor().Exec()
+			if err != nil {
+				return fmt.Errorf("failed to reorder groups: %w", err)
+			}
+
+			_, err = tx.Insert("user_group").
+				Rows(models.UserGroup{
+					User_Profile_ID:        user.User_Profile_ID,
+					Group_Profile_ID:       invitation.Group_Profile_ID,
+					Is_Active:              true,
+					Group_Display_Sequence: 0,
+					Role:                   models.GroupRoleMember,
+					Created_By:             invitation.Invited_By,
+					Updated_By:             invitation.Invited_By,
+					Datetime_Create:        now,
+					Datetime_Update:        now,
+				}).
+				Executor().Exec()
+			if err != nil {
+				return fmt.Errorf("failed to add user to group: %w", err)
+			}
+
+			return notifyGroupMemberJoined(tx, invitation.Group_Profile_ID, user)
+		})
+		if err != nil {
+			log.Printf("Failed to claim invitation %d for user %d: %v", invitation.Group_Invitation_ID, user.User_Profile_ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		joinedGroupIDs = append(joinedGroupIDs, invitation.Group_Profile_ID)
+		services.PublishGroupEvent(services.EventGroupMemberJoined, services.EventData{
+			GroupID: invitation.Group_Profile_ID,
+			UserID:  user.User_Profile_ID,
+			ActorID: user.User_Profile_ID,
+		}, invitation.Group_Profile_ID, user.User_Profile_ID)
+	}
+
+	return joinedGroupIDs, nil
+}
+
+// normalizeInvitationEmails lowercases, validates and de-duplicates the
+// addresses in an invitation request
+func normalizeInvitationEmails(emails []string) ([]string, error) {
+	seen := make(map[string]bool, len(emails))
+	normalized := make([]string, 0, len(emails))
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email == "" || seen[email] {
+			continue
+		}
+
+		// Only bare addresses; "Name <address>" forms are rejected
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email || len(email) > groupInvitationEmailMaxSize {
+			return nil, fmt.Errorf("invalid email address: %s", email)
+		}
+
+		seen[email] = true
+		normalized = append(normalized, email)
+	}
+
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("at least one email address is required")
+	}
+	if len(normalized) > maxGroupInvitationsPerCall {
+		return nil, fmt.Errorf("at most %d email addresses can be invited at once", maxGroupInvitationsPerCall)
+	}
+
+	return normalized, nil
+}
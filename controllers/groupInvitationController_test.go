@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test CreateGroupInvitations - Email invitations for people without an account
+func TestCreateGroupInvitations(t *testing.T) {
+	tests := []struct {
+		name           string
+		groupID        string
+		isAdmin        bool
+		role           string
+		body           string
+		existingUser   bool
+		pendingExists  bool
+		expectedStatus int
+		expectedResult string
+	}{
+		{
+			name:           "invites a new address",
+			groupID:        "1",
+			role:           models.GroupRoleModerator,
+			body:           `{"emails": ["  Friend@Example.com "]}`,
+			expectedStatus: http.StatusOK,
+			expectedResult: models.GroupInvitationResultInvited,
+		},
+		{
+			name:           "resends a pending invitation",
+			groupID:        "1",
+			role:           models.GroupRoleOwner,
+			body:           `{"emails": ["friend@example.com"]}`,
+			pendingExists:  true,
+			expectedStatus: http.StatusOK,
+			expectedResult: models.GroupInvitationResultResent,
+		},
+		{
+			name:           "address already has an account",
+			groupID:        "1",
+			isAdmin:        true,
+			body:           `{"emails": ["friend@example.com", "FRIEND@example.com"]}`,
+			existingUser:   true,
+			expectedStatus: http.StatusOK,
+			expectedResult: models.GroupInvitationResultExistingUser,
+		},
+		{
+			name:           "forbidden - plain member",
+			groupID:        "1",
+			role:           models.GroupRoleMember,
+			body:           `{"emails": ["friend@example.com"]}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid address",
+			groupID:        "1",
+			role:           models.GroupRoleOwner,
+			body:           `{"emails": ["Friend <friend@example.com>"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no addresses",
+			groupID:        "1",
+			role:           models.GroupRoleOwner,
+			body:           `{"emails": [" "]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid group ID",
+			groupID:        "invalid",
+			body:           `{"emails": ["friend@example.com"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.groupID != "invalid" {
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				if !tt.isAdmin {
+					ExpectGroupRole(mock, tt.role)
+				}
+			}
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectQuery(`SELECT "group_name" FROM "group_profile"`).
+					WillReturnRows(sqlmock.NewRows([]string{"group_name"}).AddRow("Test Group"))
+
+				userRows := sqlmock.NewRows([]string{"user_profile_id"})
+				if tt.existingUser {
+					userRows.AddRow(7)
+				}
+				mock.ExpectQuery(`SELECT "user_profile_id" FROM "user_profile" WHERE LOWER\(email\) = 'friend@example.com'`).
+					WillReturnRows(userRows)
+
+				if !tt.existingUser {
+					updateRows := sqlmock.NewRows([]string{"group_invitation_id"})
+					if tt.pendingExists {
+						updateRows.AddRow(4)
+					}
+					mock.ExpectQuery(`UPDATE "group_invitation" SET .* WHERE \(\("group_profile_id" = 1\) AND \("email" = 'friend@example.com'\) AND \("invitation_status" = 'pending'\)\) RETURNING "group_invitation_id"`).
+						WillReturnRows(updateRows)
+					if !tt.pendingExists {
+						mock.ExpectQuery(`INSERT INTO "group_invitation"`).
+							WillReturnRows(sqlmock.NewRows([]string{"group_invitation_id"}).AddRow(4))
+					}
+				}
+			}
+
+			currentUser := MockUser()
+			if tt.isAdmin {
+				currentUser = MockAdminUser()
+			}
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, currentUser, tt.isAdmin)
+			c.Params = []gin.Param{{Key: "group_profile_id", Value: tt.groupID}}
+			c.Request = httptest.NewRequest("POST", "/groups/"+tt.groupID+"/invitations", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			CreateGroupInvitations(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectedStatus != http.StatusOK {
+				assert.NotNil(t, response["error"])
+				return
+			}
+
+			invitations := response["invitations"].([]interface{})
+			// Duplicate addresses are collapsed
+			assert.Len(t, invitations, 1)
+			result := invitations[0].(map[string]interface{})
+			assert.Equal(t, "friend@example.com", result["email"])
+			assert.Equal(t, tt.expectedResult, result["status"])
+			if tt.existingUser {
+				assert.Nil(t, result["groupInvitationId"])
+			} else {
+				assert.Equal(t, float64(4), result["groupInvitationId"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test claimGroupInvitations - Pending invitations become memberships once the email is verified
+func TestClaimGroupInvitations(t *testing.T) {
+	tests := []struct {
+		name           string
+		alreadyClaimed bool
+		insertFails    bool
+		expectedGroups []int
+	}{
+		{
+			name:           "joins the invited group",
+			expectedGroups: []int{3},
+		},
+		{
+			name:           "invitation claimed concurrently",
+			alreadyClaimed: true,
+			expectedGroups: []int{},
+		},
+		{
+			name:           "membership insert fails - invitation stays pending",
+			insertFails:    true,
+			expectedGroups: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			user := MockUser()
+			user.Email = "Friend@Example.com"
+
+			mock.ExpectQuery(`SELECT .* FROM "group_invitation" WHERE \(\("email" = 'friend@example.com'\) AND \("invitation_status" = 'pending'\) AND \("datetime_expires" > NOW\(\)\)\)`).
+				WillReturnRows(sqlmock.NewRows([]string{"group_invitation_id", "group_profile_id", "email", "invitation_status", "invited_by", "datetime_expires"}).
+					AddRow(4, 3, "friend@example.com", "pending", 2, time.Now().Add(time.Hour)))
+
+			mock.ExpectBegin()
+			if tt.alreadyClaimed {
+				mock.ExpectExec(`UPDATE "group_invitation"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectExec(`UPDATE "group_invitation" SET .*"invitation_status"='accepted'.* WHERE \(\("group_invitation_id" = 4\) AND \("invitation_status" = 'pending'\)\)`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE "user_group"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				if tt.insertFails {
+					mock.ExpectExec(`INSERT INTO "user_group"`).WillReturnError(sqlmock.ErrCancelled)
+					mock.ExpectRollback()
+				} else {
+					mock.ExpectExec(`INSERT INTO "user_group" .*'member'`).WillReturnResult(sqlmock.NewResult(1, 1))
+					// The inviter hears about the join in the same transaction
+					ExpectNotifications(mock, true, 2)
+					mock.ExpectCommit()
+				}
+			}
+
+			groupIDs, err := claimGroupInvitations(user)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedGroups, groupIDs)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
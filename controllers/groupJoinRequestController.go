@@ -0,0 +1,467 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+)
+
+// groupRequiresApproval reports whether joining the group with an invite code
+// creates a join request instead of a membership
+func groupRequiresApproval(groupID int) (bool, error) {
+	var requiresApproval bool
+	_, err := initializers.DB.From("group_profile").
+		Select("requires_approval").
+		Where(goqu.C("group_profile_id").Eq(groupID)).
+		ScanVal(&requiresApproval)
+	return requiresApproval, err
+}
+
+// requestGroupJoin is redeemGroupInvite for groups that require approval. The
+// invite use is claimed together with the pending request, so a code can't be
+// used for more requests than its max_uses.
+func requestGroupJoin(c *gin.Context, groupInvite models.GroupInvite) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	groupID := groupInvite.Group_Profile_ID
+
+	var existingID int
+	found, err := initializers.DB.From("group_join_request").
+		Select("group_join_request_id").
+		Where(
+			goqu.C("group_profile_id").Eq(groupID),
+			goqu.C("user_profile_id").Eq(currentUser.User_Profile_ID),
+			goqu.C("request_status").Eq(models.GroupJoinRequestStatusPending),
+		).
+		ScanVal(&existingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing join requests", "details": err.Error()})
+		return
+	}
+	if found {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already asked to join this group", "groupJoinRequestId": existingID})
+		return
+	}
+
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request to join group", "details": err.Error()})
+		return
+	}
+
+	claimed := false
+	var requestID int
+	var approverIDs []int
+	err = tx.Wrap(func() error {
+		var err error
+		claimed, err = claimGroupInvite(tx, groupInvite.Group_Invite_ID, currentUser.User_Profile_ID)
+		if err != nil || !claimed {
+			return err
+		}
+
+		_, err = tx.Insert("group_join_request").
+			Rows(models.GroupJoinRequest{
+				Group_Profile_ID: groupID,
+				User_Profile_ID:  currentUser.User_Profile_ID,
+				Group_Invite_ID:  &groupInvite.Group_Invite_ID,
+				Request_Status:   models.GroupJoinRequestStatusPending,
+			}).
+			Returning("group_join_request_id").
+			Executor().ScanVal(&requestID)
+		if err != nil {
+			return fmt.Errorf("failed to create join request: %w", err)
+		}
+
+		approverIDs, err = notifyGroupJoinRequested(tx, groupID, currentUser, requestID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to create join request for invite %d: %v", groupInvite.Group_Invite_ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request to join group", "details": err.Error()})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invite code is no longer valid"})
+		return
+	}
+
+	services.PublishToUsers(services.EventGroupJoinRequested, services.EventData{
+		GroupID:   groupID,
+		UserID:    currentUser.User_Profile_ID,
+		RequestID: requestID,
+	}, approverIDs...)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":            "Join request sent. A group owner or moderator needs to approve it.",
+		"groupId":            groupID,
+		"groupJoinRequestId": requestID,
+		"status":             models.GroupJoinRequestStatusPending,
+	})
+}
+
+// GetGroupJoinRequests lists the group's join requests for its owners and
+// moderators. Only pending requests are returned unless ?status= says otherwise.
+func GetGroupJoinRequests(c *gin.Context) {
+	groupID, ok := groupJoinRequestAccess(c)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", models.GroupJoinRequestStatusPending)
+	if status != models.GroupJoinRequestStatusPending && status != models.GroupJoinRequestStatusApproved &&
+		status != models.GroupJoinRequestStatusDenied && status != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter. Must be 'pending', 'approved', 'denied', or 'all'"})
+		return
+	}
+
+	query := initializers.DB.From("group_join_request").
+		Select(
+			goqu.I("group_join_request.group_join_request_id"),
+			goqu.I("group_join_request.group_profile_id"),
+			goqu.I("group_join_request.user_profile_id"),
+			goqu.I("group_join_request.group_invite_id"),
+			goqu.I("group_join_request.request_status"),
+			goqu.I("group_join_request.responded_by"),
+			goqu.I("group_join_request.datetime_responded"),
+			goqu.I("group_join_request.datetime_create"),
+			goqu.I("user_profile.username"),
+			goqu.I("user_profile.first_name"),
+			goqu.I("user_profile.last_name"),
+		).
+		Join(
+			goqu.T("user_profile"),
+			goqu.On(goqu.Ex{"group_join_request.user_profile_id": goqu.I("user_profile.user_profile_id")}),
+		).
+		Where(goqu.I("group_join_request.group_profile_id").Eq(groupID))
+
+	if status != "all" {
+		query = query.Where(goqu.I("group_join_request.request_status").Eq(status))
+	}
+
+	query = query.Order(goqu.I("group_join_request.datetime_create").Desc())
+
+	var requests []models.GroupJoinRequestDetail
+	err := query.ScanStructs(&requests)
+	if err != nil {
+		log.Println("Failed to fetch group join requests:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch join requests", "details": err.Error()})
+		return
+	}
+
+	if requests == nil {
+		requests = []models.GroupJoinRequestDetail{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Join requests retrieved successfully",
+		"requests": requests,
+	})
+}
+
+// RespondToGroupJoinRequest approves or denies a pending join request.
+// Approving adds the requester as a member; either way they are notified.
+func RespondToGroupJoinRequest(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	groupID, ok := groupJoinRequestAccess(c)
+	if !ok {
+		return
+	}
+
+	requestID, err := strconv.Atoi(c.Param("group_join_request_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid join request ID", "details": err.Error()})
+		return
+	}
+
+	var request models.GroupJoinRequestDetail
+	found, err := initializers.DB.From("group_join_request").
+		Select(
+			goqu.I("group_join_request.group_join_request_id"),
+			goqu.I("group_join_request.group_profile_id"),
+			goqu.I("group_join_request.user_profile_id"),
+			goqu.I("group_join_request.group_invite_id"),
+			goqu.I("group_join_request.request_status"),
+			goqu.I("user_profile.username"),
+			goqu.I("user_profile.first_name"),
+			goqu.I("user_profile.last_name"),
+		).
+		Join(
+			goqu.T("user_profile"),
+			goqu.On(goqu.Ex{"group_join_request.user_profile_id": goqu.I("user_profile.user_profile_id")}),
+		).
+		Where(
+			goqu.I("group_join_request.group_join_request_id").Eq(requestID),
+			goqu.I("group_join_request.group_profile_id").Eq(groupID),
+		).
+		ScanStruct(&request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch join request", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Join request not found"})
+		return
+	}
+
+	if request.Request_Status != models.GroupJoinRequestStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("This request has already been %s", request.Request_Status)})
+		return
+	}
+
+	var responseData models.GroupJoinRequestResponse
+	if err := c.ShouldBindJSON(&responseData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if responseData.Status != models.GroupJoinRequestStatusApproved && responseData.Status != models.GroupJoinRequestStatusDenied {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be 'approved' or 'denied'"})
+		return
+	}
+
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to join request", "details": err.Error()})
+		return
+	}
+
+	// The conditional update claims the request, so two moderators answering
+	// at once can't both add the member
+	claimed, joined := false, false
+	err = tx.Wrap(func() error {
+		now := time.Now()
+		result, err := tx.Update("group_join_request").
+			Set(goqu.Record{
+				"request_status":     responseData.Status,
+				"responded_by":       currentUser.User_Profile_ID,
+				"datetime_responded": now,
+			}).
+			Where(
+				goqu.C("group_join_request_id").Eq(requestID),
+				goqu.C("request_status").Eq(models.GroupJoinRequestStatusPending),
+			).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+			return err
+		}
+		claimed = true
+
+		if err := notifyGroupJoinResponse(tx, groupID, request.User_Profile_ID, currentUser.User_Profile_ID, requestID, responseData.Status); err != nil {
+			return err
+		}
+		if responseData.Status == models.GroupJoinRequestStatusDenied {
+			return nil
+		}
+
+		// They may have been added directly while the request was waiting
+		var memberCount int
+		_, err = tx.From("user_group").
+			Select(goqu.COUNT("user_group_id")).
+			Where(
+				goqu.C("group_profile_id").Eq(groupID),
+				goqu.C("user_profile_id").Eq(request.User_Profile_ID),
+			).
+			ScanVal(&memberCount)
+		if err != nil {
+			return fmt.Errorf("failed to check membership: %w", err)
+		}
+		if memberCount > 0 {
+			return nil
+		}
+
+		// Shift existing groups down so the new one is at the top of the list
+		_, err = tx.Update("user_group").
+			Set(goqu.Record{"group_display_sequence": goqu.L("group_display_sequence + 1")}).
+			Where(goqu.C("user_profile_id").Eq(request.User_Profile_ID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to reorder groups: %w", err)
+		}
+
+		_, err = tx.Insert("user_group").
+			Rows(models.UserGroup{
+				User_Profile_ID:        request.User_Profile_ID,
+				Group_Profile_ID:       groupID,
+				Is_Active:              true,
+				Group_Display_Sequence: 0,
+				Role:                   models.GroupRoleMember,
+				Created_By:             currentUser.User_Profile_ID,
+				Updated_By:             currentUser.User_Profile_ID,
+				Datetime_Create:        now,
+				Datetime_Update:        now,
+			}).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to add user to group: %w", err)
+		}
+
+		if request.Group_Invite_ID != nil {
+			_, err = tx.Insert("group_invite_use").
+				Rows(models.GroupInviteUse{
+					Group_Invite_ID:  *request.Group_Invite_ID,
+					Group_Profile_ID: groupID,
+					User_Profile_ID:  request.User_Profile_ID,
+				}).
+				Executor().Exec()
+			if err != nil {
+				return fmt.Errorf("failed to record invite use: %w", err)
+			}
+		}
+
+		joined = true
+		return notifyGroupMemberJoined(tx, groupID, models.UserProfile{
+			User_Profile_ID: request.User_Profile_ID,
+			Username:        request.Username,
+			First_Name:      request.First_Name,
+			Last_Name:       request.Last_Name,
+		})
+	})
+	if err != nil {
+		log.Printf("Failed to respond to join request %d: %v", requestID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to join request", "details": err.Error()})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusConflict, gin.H{"error": "This request has already been answered"})
+		return
+	}
+
+	services.PublishToUsers(services.EventGroupJoinResponded, services.EventData{
+		GroupID:   groupID,
+		RequestID: requestID,
+		ActorID:   currentUser.User_Profile_ID,
+		Status:    responseData.Status,
+	}, request.User_Profile_ID)
+	if joined {
+		services.PublishGroupEvent(services.EventGroupMemberJoined, services.EventData{
+			GroupID: groupID,
+			UserID:  request.User_Profile_ID,
+			ActorID: currentUser.User_Profile_ID,
+		}, groupID, request.User_Profile_ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Join request %s successfully", responseData.Status),
+		"status":  responseData.Status,
+	})
+}
+
+// groupJoinRequestAccess parses the group ID and checks that the current user
+// can approve members. It writes the error response itself and returns false
+// when the request should stop.
+func groupJoinRequestAccess(c *gin.Context) (int, bool) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
+		return 0, false
+	}
+
+	if !isGroupExists(groupID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group doesn't exist"})
+		return 0, false
+	}
+
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return 0, false
+		}
+		if !groupRoleCan(role, groupActionAddMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage join requests for this group"})
+			return 0, false
+		}
+	}
+
+	return groupID, true
+}
+
+// notifyGroupJoinRequested lets the group's owner and moderators know someone
+// is waiting for approval, and returns who they are. It runs in the request's
+// transaction.
+func notifyGroupJoinRequested(tx *goqu.TxDatabase, groupID int, requester models.UserProfile, requestID int) ([]int, error) {
+	groupName, err := GetGroupNameByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var approverIDs []int
+	err = tx.From("user_group").
+		Select("user_profile_id").
+		Where(
+			goqu.C("group_profile_id").Eq(groupID),
+			goqu.C("is_active").IsTrue(),
+			goqu.C("role").In(groupRolesThatCan(groupActionAddMember)),
+		).
+		ScanVals(&approverIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group approvers: %w", err)
+	}
+
+	displayName := requester.First_Name
+	if displayName == "" {
+		displayName = requester.Username
+	}
+
+	return approverIDs, sendGroupJoinNotification(tx, groupID, groupName, approverIDs, requester.User_Profile_ID, requestID,
+		models.NotificationTypeGroupJoinRequested,
+		fmt.Sprintf("%s asked to join %s", displayName, groupName),
+		map[string]string{
+			"type":               "group_join_requested",
+			"groupId":            strconv.Itoa(groupID),
+			"groupJoinRequestId": strconv.Itoa(requestID),
+		})
+}
+
+// notifyGroupJoinResponse tells the requester whether they were let in. It
+// runs in the response's transaction.
+func notifyGroupJoinResponse(tx *goqu.TxDatabase, groupID int, requesterID int, responderID int, requestID int, status string) error {
+	groupName, err := GetGroupNameByID(groupID)
+	if err != nil {
+		return err
+	}
+
+	notificationType := models.NotificationTypeGroupJoinApproved
+	message := fmt.Sprintf("Your request to join %s was approved", groupName)
+	if status == models.GroupJoinRequestStatusDenied {
+		notificationType = models.NotificationTypeGroupJoinDenied
+		message = fmt.Sprintf("Your request to join %s was not approved", groupName)
+	}
+
+	return sendGroupJoinNotification(tx, groupID, groupName, []int{requesterID}, responderID, requestID, notificationType, message,
+		map[string]string{
+			"type":               "group_join_response",
+			"groupId":            strconv.Itoa(groupID),
+			"groupJoinRequestId": strconv.Itoa(requestID),
+			"status":             status,
+		})
+}
+
+// sendGroupJoinNotification sends a join request notification about the group
+// to each recipient. The request ID and type make up the idempotency key, as
+// each request is asked and answered once.
+func sendGroupJoinNotification(tx *goqu.TxDatabase, groupID int, groupName string, recipientIDs []int, actorID int, requestID int, notificationType string, message string, data map[string]string) error {
+	return services.GetNotificationRouter().SendToUsersTx(tx, recipientIDs, services.Delivery{
+		Type:          notificationType,
+		Title:         groupName,
+		Message:       message,
+		ActorID:       actorID,
+		TargetGroupID: &groupID,
+		Data:          data,
+		Key:           fmt.Sprintf("%s:%d", notificationType, requestID),
+	})
+}
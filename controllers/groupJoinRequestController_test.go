@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test GetGroupJoinRequests - Owners and moderators list pending requests
+func TestGetGroupJoinRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           string
+		status         string
+		expectedStatus int
+	}{
+		{
+			name:           "moderator lists pending requests",
+			role:           models.GroupRoleModerator,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "owner lists all requests",
+			role:           models.GroupRoleOwner,
+			status:         "all",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid status filter",
+			role:           models.GroupRoleOwner,
+			status:         "accepted",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "forbidden - plain member",
+			role:           models.GroupRoleMember,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			ExpectGroupRole(mock, tt.role)
+
+			if tt.expectedStatus == http.StatusOK {
+				query := `SELECT .* FROM "group_join_request" INNER JOIN "user_profile" .* WHERE \("group_join_request"."group_profile_id" = 1\) ORDER BY`
+				if tt.status == "" {
+					query = `SELECT .* FROM "group_join_request" INNER JOIN "user_profile" .* WHERE \(\("group_join_request"."group_profile_id" = 1\) AND \("group_join_request"."request_status" = 'pending'\)\)`
+				}
+				mock.ExpectQuery(query).
+					WillReturnRows(sqlmock.NewRows([]string{"group_join_request_id", "group_profile_id", "user_profile_id", "request_status", "datetime_create", "username", "first_name", "last_name"}).
+						AddRow(8, 1, 5, "pending", time.Now(), "jdoe", "Jane", "Doe"))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "group_profile_id", Value: "1"}}
+			url := "/groups/1/join-requests"
+			if tt.status != "" {
+				url += "?status=" + tt.status
+			}
+			c.Request = httptest.NewRequest("GET", url, nil)
+
+			GetGroupJoinRequests(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus != http.StatusOK {
+				assert.NotNil(t, response["error"])
+				return
+			}
+
+			requests := response["requests"].([]interface{})
+			assert.Len(t, requests, 1)
+			request := requests[0].(map[string]interface{})
+			assert.Equal(t, float64(8), request["groupJoinRequestId"])
+			assert.Equal(t, "Jane", request["firstName"])
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test RespondToGroupJoinRequest - Approve or deny a pending request
+func TestRespondToGroupJoinRequest(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		requestStatus   string
+		requestFound    bool
+		answeredByOther bool
+		alreadyMember   bool
+		insertFails     bool
+		expectedStatus  int
+	}{
+		{
+			name:           "approve adds the member",
+			body:           `{"status": "approved"}`,
+			requestStatus:  "pending",
+			requestFound:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "approve when already added directly",
+			body:           `{"status": "approved"}`,
+			requestStatus:  "pending",
+			requestFound:   true,
+			alreadyMember:  true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "deny",
+			body:           `{"status": "denied"}`,
+			requestStatus:  "pending",
+			requestFound:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:            "answered by another moderator first",
+			body:            `{"status": "approved"}`,
+			requestStatus:   "pending",
+			requestFound:    true,
+			answeredByOther: true,
+			expectedStatus:  http.StatusConflict,
+		},
+		{
+			name:           "membership insert fails - request stays pending",
+			body:           `{"status": "approved"}`,
+			requestStatus:  "pending",
+			requestFound:   true,
+			insertFails:    true,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "already denied",
+			body:           `{"status": "approved"}`,
+			requestStatus:  "denied",
+			requestFound:   true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid status",
+			body:           `{"status": "accepted"}`,
+			requestStatus:  "pending",
+			requestFound:   true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "request not found",
+			body:           `{"status": "approved"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			ExpectGroupRole(mock, models.GroupRoleModerator)
+
+			requestRows := sqlmock.NewRows([]string{"group_join_request_id", "group_profile_id", "user_profile_id", "group_invite_id", "request_status", "username", "first_name", "last_name"})
+			if tt.requestFound {
+				requestRows.AddRow(8, 1, 5, 3, tt.requestStatus, "jdoe", "Jane", "Doe")
+			}
+			mock.ExpectQuery(`SELECT .* FROM "group_join_request" .* WHERE \(\("group_join_request"."group_join_request_id" = 8\) AND \("group_join_request"."group_profile_id" = 1\)\)`).
+				WillReturnRows(requestRows)
+
+			approving := tt.body == `{"status": "approved"}`
+			if tt.requestStatus == "pending" && tt.body != `{"status": "accepted"}` {
+				mock.ExpectBegin()
+				claim := mock.ExpectExec(`UPDATE "group_join_request" SET .* WHERE \(\("group_join_request_id" = 8\) AND \("request_status" = 'pending'\)\)`)
+				if tt.answeredByOther {
+					claim.WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectCommit()
+				} else {
+					claim.WillReturnResult(sqlmock.NewResult(0, 1))
+					// The requester hears back in the same transaction
+					ExpectNotifications(mock, false, 5)
+					if approving {
+						memberCount := 0
+						if tt.alreadyMember {
+							memberCount = 1
+						}
+						mock.ExpectQuery(`SELECT COUNT\("user_group_id"\) FROM "user_group"`).
+							WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(memberCount))
+						if !tt.alreadyMember {
+							mock.ExpectExec(`UPDATE "user_group"`).WillReturnResult(sqlmock.NewResult(0, 0))
+							if tt.insertFails {
+								mock.ExpectExec(`INSERT INTO "user_group"`).WillReturnError(sqlmock.ErrCancelled)
+							} else {
+								mock.ExpectExec(`INSERT INTO "user_group" .*'member'`).WillReturnResult(sqlmock.NewResult(1, 1))
+								mock.ExpectExec(`INSERT INTO "group_invite_use"`).WillReturnResult(sqlmock.NewResult(1, 1))
+								ExpectNotifications(mock, true, 2, 3)
+							}
+						}
+					}
+					if tt.insertFails {
+						mock.ExpectRollback()
+					} else {
+						mock.ExpectCommit()
+					}
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{
+				{Key: "group_profile_id", Value: "1"},
+				{Key: "group_join_request_id", Value: "8"},
+			}
+			c.Request = httptest.NewRequest("PATCH", "/groups/1/join-requests/8", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			RespondToGroupJoinRequest(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus == http.StatusOK {
+				assert.NotNil(t, response["status"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
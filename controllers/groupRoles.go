@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// groupAction is something a member may be allowed to do in a group
+type groupAction int
+
+const (
+	groupActionUpdateGroup groupAction = iota
+	groupActionDeleteGroup
+	groupActionChangeRoles
+	groupActionTransferOwnership
+	groupActionManageInvites
+	groupActionAddMember
+	groupActionRemoveMember
+	groupActionModerateComments
+	groupActionManageCategories
+	groupActionRemovePrayer
+)
+
+// groupPermissions is the permission matrix for group roles. Owners run the
+// group; moderators look after its members and content without being able to
+// edit, delete or give away the group. Site admins bypass the matrix, and the
+// prayer creator and linked subject keep their own rights over a prayer.
+var groupPermissions = map[string][]groupAction{
+	models.GroupRoleOwner: {
+		groupActionUpdateGroup,
+		groupActionDeleteGroup,
+		groupActionChangeRoles,
+		groupActionTransferOwnership,
+		groupActionManageInvites,
+		groupActionAddMember,
+		groupActionRemoveMember,
+		groupActionModerateComments,
+		groupActionManageCategories,
+		groupActionRemovePrayer,
+	},
+	models.GroupRoleModerator: {
+		groupActionManageInvites,
+		groupActionAddMember,
+		groupActionRemoveMember,
+		groupActionModerateComments,
+		groupActionManageCategories,
+		groupActionRemovePrayer,
+	},
+	models.GroupRoleMember: {},
+}
+
+// groupRoleCan reports whether the role may perform the action. An empty role
+// (not a member) can do nothing.
+func groupRoleCan(role string, action groupAction) bool {
+	for _, allowed := range groupPermissions[role] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// groupRolesThatCan lists the roles allowed to perform the action, for use in queries
+func groupRolesThatCan(action groupAction) []string {
+	var roles []string
+	for _, role := range []string{models.GroupRoleOwner, models.GroupRoleModerator, models.GroupRoleMember} {
+		if groupRoleCan(role, action) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+func groupRoleRank(role string) int {
+	switch role {
+	case models.GroupRoleOwner:
+		return 3
+	case models.GroupRoleModerator:
+		return 2
+	case models.GroupRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// groupRoleOutranks reports whether actor sits above target, so a moderator can
+// remove members but not other moderators or the owner
+func groupRoleOutranks(actor string, target string) bool {
+	return groupRoleRank(actor) > groupRoleRank(target)
+}
+
+func isValidGroupRole(role string) bool {
+	_, ok := groupPermissions[role]
+	return ok
+}
+
+// getGroupRole returns the user's role in the group, or "" if they are not an
+// active member
+func getGroupRole(groupID int, userID int) (string, error) {
+	var role string
+	_, err := initializers.DB.From("user_group").
+		Select("role").
+		Where(
+			goqu.C("group_profile_id").Eq(groupID),
+			goqu.C("user_profile_id").Eq(userID),
+			goqu.C("is_active").IsTrue(),
+		).
+		ScanVal(&role)
+	if err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+// isGroupModeratorForPrayer reports whether the user can moderate comments on
+// the prayer through a group it is shared with
+func isGroupModeratorForPrayer(prayerID int, userID int) (bool, error) {
+	var count int
+	_, err := initializers.DB.From("prayer_access").
+		Select(goqu.COUNT("prayer_access.prayer_access_id")).
+		InnerJoin(
+			goqu.T("user_group"),
+			goqu.On(goqu.Ex{"user_group.group_profile_id": goqu.I("prayer_access.access_type_id")}),
+		).
+		Where(goqu.Ex{
+			"prayer_access.prayer_id":    prayerID,
+			"prayer_access.access_type":  "group",
+			"user_group.user_profile_id": userID,
+			"user_group.is_active":       true,
+			"user_group.role":            groupRolesThatCan(groupActionModerateComments),
+		}).
+		ScanVal(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// promoteGroupSuccessors hands every group the user owns to its
+// longest-standing moderator, or failing that its longest-standing member, the
+// same way migration 033 filled in owners whose creator had left. Groups with
+// nobody else in them are left as they are.
+func promoteGroupSuccessors(userID int) error {
+	successors := goqu.L(`user_group_id IN (
+		SELECT DISTINCT ON (successor.group_profile_id) successor.user_group_id
+		FROM user_group successor
+		JOIN user_group owner ON owner.group_profile_id = successor.group_profile_id
+		WHERE owner.user_profile_id = ? AND owner.role = ?
+			AND successor.user_profile_id <> ? AND successor.is_active
+		ORDER BY successor.group_profile_id, successor.role = ? DESC,
+			successor.datetime_create, successor.user_group_id
+	)`, userID, models.GroupRoleOwner, userID, models.GroupRoleModerator)
+
+	_, err := initializers.DB.Update("user_group").
+		Set(goqu.Record{"role": models.GroupRoleOwner}).
+		Where(successors).
+		Executor().Exec()
+	return err
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupRolePermissions(t *testing.T) {
+	// Owners can do everything
+	for _, action := range groupPermissions[models.GroupRoleOwner] {
+		assert.True(t, groupRoleCan(models.GroupRoleOwner, action))
+	}
+
+	// Moderators handle members and content but never the group itself
+	assert.True(t, groupRoleCan(models.GroupRoleModerator, groupActionManageInvites))
+	assert.True(t, groupRoleCan(models.GroupRoleModerator, groupActionRemoveMember))
+	assert.True(t, groupRoleCan(models.GroupRoleModerator, groupActionModerateComments))
+	assert.True(t, groupRoleCan(models.GroupRoleModerator, groupActionManageCategories))
+	assert.False(t, groupRoleCan(models.GroupRoleModerator, groupActionUpdateGroup))
+	assert.False(t, groupRoleCan(models.GroupRoleModerator, groupActionDeleteGroup))
+	assert.False(t, groupRoleCan(models.GroupRoleModerator, groupActionChangeRoles))
+	assert.False(t, groupRoleCan(models.GroupRoleModerator, groupActionTransferOwnership))
+
+	assert.False(t, groupRoleCan(models.GroupRoleMember, groupActionManageInvites))
+	assert.False(t, groupRoleCan("", groupActionManageInvites))
+
+	assert.True(t, groupRoleOutranks(models.GroupRoleModerator, models.GroupRoleMember))
+	assert.False(t, groupRoleOutranks(models.GroupRoleModerator, models.GroupRoleModerator))
+	assert.False(t, groupRoleOutranks(models.GroupRoleModerator, models.GroupRoleOwner))
+
+	assert.Equal(t, []string{models.GroupRoleOwner, models.GroupRoleModerator}, groupRolesThatCan(groupActionModerateComments))
+}
+
+func TestIsGroupModeratorForPrayer(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT COUNT\("prayer_access"."prayer_access_id"\) FROM "prayer_access" INNER JOIN "user_group" .*"user_group"."role" IN \('owner', 'moderator'\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	isModerator, err := isGroupModeratorForPrayer(7, 1)
+	assert.NoError(t, err)
+	assert.True(t, isModerator)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test promoteGroupSuccessors - A departing owner's groups go to the
+// longest-standing moderator before any member
+func TestPromoteGroupSuccessors(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`UPDATE "user_group" SET "role"='owner' WHERE user_group_id IN \(\s*SELECT DISTINCT ON \(successor.group_profile_id\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, promoteGroupSuccessors(1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// groupStatsTopPrayers caps the most-prayed-for list
+const groupStatsTopPrayers = 5
+
+// GetGroupStats summarizes how a group engages with the prayers shared to it
+// GET /groups/:group_profile_id/stats
+func GetGroupStats(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
+		return
+	}
+
+	if !isGroupExists(groupID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group doesn't exist"})
+		return
+	}
+
+	if !isUserInGroup(c, groupID) &&
+		!isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view stats for this group"})
+		return
+	}
+
+	// Activity only counts when it comes from a current member and touches a
+	// prayer shared to this group. Hidden comments are left out.
+	query := `
+		WITH group_prayers AS (
+			SELECT DISTINCT prayer.prayer_id, prayer.is_answered
+			FROM prayer
+			JOIN prayer_access ON prayer_access.prayer_id = prayer.prayer_id
+			WHERE prayer_access.access_type = 'group'
+				AND prayer_access.access_type_id = $1
+				AND prayer.deleted = false
+		),
+		members AS (
+			SELECT user_profile_id FROM user_group WHERE group_profile_id = $1
+		),
+		activity AS (
+			SELECT user_profile_id, datetime_prayed AS datetime_activity
+			FROM prayer_event WHERE prayer_id IN (SELECT prayer_id FROM group_prayers)
+			UNION ALL
+			SELECT user_profile_id, datetime_create
+			FROM prayer_comment WHERE prayer_id IN (SELECT prayer_id FROM group_prayers) AND is_hidden = false
+			UNION ALL
+			SELECT user_profile_id, datetime_create
+			FROM prayer_edit_history WHERE prayer_id IN (SELECT prayer_id FROM group_prayers)
+		)
+		SELECT
+			(SELECT COUNT(*) FROM members),
+			(SELECT COUNT(*) FROM group_prayers),
+			(SELECT COUNT(*) FROM group_prayers WHERE is_answered),
+			(SELECT COALESCE(SUM(total_prayers), 0) FROM prayer_analytics
+				WHERE prayer_id IN (SELECT prayer_id FROM group_prayers)),
+			(SELECT COUNT(*) FROM prayer_comment
+				WHERE prayer_id IN (SELECT prayer_id FROM group_prayers) AND is_hidden = false),
+			(SELECT COUNT(DISTINCT activity.user_profile_id) FROM activity
+				JOIN members ON members.user_profile_id = activity.user_profile_id
+				WHERE activity.datetime_activity >= NOW() - INTERVAL '7 days'),
+			(SELECT COUNT(DISTINCT activity.user_profile_id) FROM activity
+				JOIN members ON members.user_profile_id = activity.user_profile_id
+				WHERE activity.datetime_activity >= NOW() - INTERVAL '30 days')
+	`
+
+	stats := models.GroupStats{Group_Profile_ID: groupID}
+	err = initializers.DB.QueryRow(query, groupID).Scan(
+		&stats.Member_Count,
+		&stats.Prayers_Posted,
+		&stats.Prayers_Answered,
+		&stats.Prayer_Events,
+		&stats.Comment_Count,
+		&stats.Active_Members_7Day,
+		&stats.Active_Members_30Day,
+	)
+	if err != nil {
+		log.Printf("Failed to fetch stats for group %d: %v", groupID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group stats"})
+		return
+	}
+
+	// Private prayers are counted above but only listed for their creator
+	stats.Most_Prayed_For = []models.GroupTopPrayer{}
+	err = initializers.DB.From("prayer").
+		Select(
+			goqu.I("prayer.prayer_id"),
+			goqu.I("prayer.title"),
+			goqu.I("prayer.is_answered"),
+			goqu.I("prayer_analytics.total_prayers"),
+			goqu.I("prayer_analytics.num_unique_users"),
+		).
+		Join(
+			goqu.T("prayer_access"),
+			goqu.On(goqu.Ex{"prayer.prayer_id": goqu.I("prayer_access.prayer_id")}),
+		).
+		Join(
+			goqu.T("prayer_analytics"),
+			goqu.On(goqu.Ex{"prayer.prayer_id": goqu.I("prayer_analytics.prayer_id")}),
+		).
+		Where(
+			goqu.Ex{"prayer_access.access_type": "group"},
+			goqu.Ex{"prayer_access.access_type_id": groupID},
+			goqu.I("prayer.deleted").Eq(false),
+			goqu.I("prayer_analytics.total_prayers").Gt(0),
+			goqu.Or(
+				goqu.I("prayer.is_private").IsNotTrue(),
+				goqu.I("prayer.created_by").Eq(currentUser.User_Profile_ID),
+			),
+		).
+		Order(goqu.I("prayer_analytics.total_prayers").Desc(), goqu.I("prayer.prayer_id").Asc()).
+		Limit(groupStatsTopPrayers).
+		ScanStructsContext(c, &stats.Most_Prayed_For)
+	if err != nil {
+		log.Printf("Failed to fetch most prayed-for requests for group %d: %v", groupID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
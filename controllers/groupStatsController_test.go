@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test GetGroupStats - Group engagement summary
+func TestGetGroupStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		groupID        string
+		currentUser    models.UserProfile
+		isAdmin        bool
+		groupExists    bool
+		userInGroup    bool
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful fetch - member",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			groupExists:    true,
+			userInGroup:    true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "successful fetch - admin not in group",
+			groupID:        "1",
+			currentUser:    MockAdminUser(),
+			isAdmin:        true,
+			groupExists:    true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "forbidden - not a member",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			groupExists:    true,
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
+		{
+			name:           "group doesn't exist",
+			groupID:        "99",
+			currentUser:    MockUser(),
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "invalid group ID",
+			groupID:        "abc",
+			currentUser:    MockUser(),
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.groupID != "abc" {
+				groupCount := 0
+				if tt.groupExists {
+					groupCount = 1
+				}
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(groupCount))
+
+				if tt.groupExists {
+					memberCount := 0
+					if tt.userInGroup {
+						memberCount = 1
+					}
+					mock.ExpectQuery("SELECT COUNT").
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(memberCount))
+				}
+
+				if !tt.expectError {
+					mock.ExpectQuery("WITH group_prayers AS").
+						WithArgs(1).
+						WillReturnRows(sqlmock.NewRows([]string{
+							"members", "posted", "answered", "events", "comments", "active_7", "active_30",
+						}).AddRow(6, 12, 3, 85, 14, 4, 5))
+
+					mock.ExpectQuery("FROM \"prayer\"").
+						WillReturnRows(sqlmock.NewRows([]string{"prayer_id", "title", "is_answered", "total_prayers", "num_unique_users"}).
+							AddRow(4, "Surgery recovery", false, 30, 5).
+							AddRow(2, "New job", true, 12, 4))
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, tt.currentUser, tt.isAdmin)
+			c.Params = []gin.Param{{Key: "group_profile_id", Value: tt.groupID}}
+			c.Request = httptest.NewRequest("GET", "/groups/"+tt.groupID+"/stats", nil)
+
+			GetGroupStats(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectError {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Contains(t, response, "error")
+			} else {
+				var response struct {
+					Stats models.GroupStats `json:"stats"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Equal(t, 12, response.Stats.Prayers_Posted)
+				assert.Equal(t, 3, response.Stats.Prayers_Answered)
+				assert.Equal(t, 85, response.Stats.Prayer_Events)
+				assert.Equal(t, 4, response.Stats.Active_Members_7Day)
+				assert.Equal(t, 5, response.Stats.Active_Members_30Day)
+				if assert.Len(t, response.Stats.Most_Prayed_For, 2) {
+					assert.Equal(t, 4, response.Stats.Most_Prayed_For[0].Prayer_ID)
+				}
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
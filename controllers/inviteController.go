@@ -2,10 +2,13 @@ package controllers
 
 import (
 	"crypto/rand"
-	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
+	"math/big"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +20,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultInviteExpiry  = 7 * 24 * time.Hour
+	maxInviteExpiryHours = 30 * 24
+	defaultInviteMaxUses = 1
+	maxInviteMaxUses     = 500
+	inviteCodeLength     = 10
+	inviteCodeAlphabet   = "ABCDEFGHJKMNPQRSTUVWXYZ23456789" // no 0/O or 1/I/L
+)
+
+// CreateGroupInviteCode mints an invite code for the group. The body is
+// optional: codes default to a single use and a 7-day expiry.
 func CreateGroupInviteCode(c *gin.Context) {
 	currentUser := c.MustGet("currentUser").(models.UserProfile)
 	isAdmin := c.MustGet("admin").(bool)
@@ -32,42 +46,83 @@ func CreateGroupInviteCode(c *gin.Context) {
 		return
 	}
 
-	if !isUserInGroup(c, groupID) &&
-		!isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to generate an invite code for this group"})
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+			return
+		}
+		if !groupRoleCan(role, groupActionManageInvites) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to generate an invite code for this group"})
+			return
+		}
+	}
+
+	var options models.GroupInviteCreate
+	if err := c.ShouldBindJSON(&options); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
 		return
 	}
 
-	inviteCode := generateInviteCode(groupID)
+	expiry := defaultInviteExpiry
+	if options.Expires_In_Hours != nil {
+		if *options.Expires_In_Hours < 1 || *options.Expires_In_Hours > maxInviteExpiryHours {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expiresInHours must be between 1 and %d", maxInviteExpiryHours)})
+			return
+		}
+		expiry = time.Duration(*options.Expires_In_Hours) * time.Hour
+	}
 
+	maxUses := defaultInviteMaxUses
 	groupInvite := models.GroupInvite{
 		Group_Profile_ID: groupID,
-		Invite_Code:      inviteCode,
+		Invite_Code:      generateInviteCode(),
 		Datetime_Create:  time.Now(),
 		Datetime_Update:  time.Now(),
 		Created_By:       currentUser.User_Profile_ID,
 		Updated_By:       currentUser.User_Profile_ID,
-		Datetime_Expires: time.Now().AddDate(0, 0, 7),
+		Datetime_Expires: time.Now().Add(expiry),
 		Is_Active:        true,
+		Max_Uses:         &maxUses,
+	}
+
+	if options.Max_Uses != nil {
+		if *options.Max_Uses < 0 || *options.Max_Uses > maxInviteMaxUses {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("maxUses must be between 0 (unlimited) and %d", maxInviteMaxUses)})
+			return
+		}
+		if *options.Max_Uses == 0 {
+			groupInvite.Max_Uses = nil
+		} else {
+			groupInvite.Max_Uses = options.Max_Uses
+		}
 	}
 
-	insert := initializers.DB.Insert("group_invite").Rows(groupInvite).Returning("invite_code")
+	insert := initializers.DB.Insert("group_invite").Rows(groupInvite).Returning("group_invite_id", "invite_code")
 
-	var insertedInviteCode string
-	_, insertErr := insert.Executor().ScanVal(&insertedInviteCode)
+	var inserted models.GroupInvite
+	_, insertErr := insert.Executor().ScanStruct(&inserted)
 	if insertErr != nil {
-		log.Println(err)
+		log.Println(insertErr)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invite code", "details": insertErr.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"inviteCode": insertedInviteCode, "expiresAt": groupInvite.Datetime_Expires})
+	response := gin.H{
+		"groupInviteId": inserted.Group_Invite_ID,
+		"inviteCode":    inserted.Invite_Code,
+		"expiresAt":     groupInvite.Datetime_Expires,
+		"maxUses":       groupInvite.Max_Uses,
+	}
+	if link := inviteLink(inserted.Invite_Code); link != "" {
+		response["inviteLink"] = link
+	}
+
+	c.JSON(http.StatusOK, response)
 
 }
 
 func JoinGroup(c *gin.Context) {
-	currentUser := c.MustGet("currentUser").(models.UserProfile)
-
 	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
@@ -85,6 +140,91 @@ func JoinGroup(c *gin.Context) {
 		return
 	}
 
+	groupInvite, found, err := findGroupInvite(joinRequest.Invite_Code)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group_invite", "details": err.Error()})
+		return
+	}
+	if !found || groupInvite.Group_Profile_ID != groupID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid invite code"})
+		return
+	}
+
+	redeemGroupInvite(c, groupInvite)
+}
+
+// RedeemInviteCode joins the group an invite code belongs to, so a shared
+// link works without the client knowing the group ID
+func RedeemInviteCode(c *gin.Context) {
+	var joinRequest models.JoinRequest
+	if err := c.ShouldBindJSON(&joinRequest); err != nil || strings.TrimSpace(joinRequest.Invite_Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": "inviteCode is required"})
+		return
+	}
+
+	groupInvite, found, err := findGroupInvite(joinRequest.Invite_Code)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group_invite", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid invite code"})
+		return
+	}
+
+	redeemGroupInvite(c, groupInvite)
+}
+
+// PreviewInviteCode shows what group an invite code leads to before the user
+// signs in or joins. Unknown, revoked, expired and used up codes all look the
+// same so the endpoint can't be used to probe for codes.
+func PreviewInviteCode(c *gin.Context) {
+	var preview models.GroupInvitePreview
+	found, err := initializers.DB.From("group_invite").
+		Select(
+			goqu.I("group_profile.group_name"),
+			goqu.I("group_profile.group_description"),
+			initializers.DB.From("user_group").
+				Select(goqu.COUNT("user_group.user_group_id")).
+				Where(
+					goqu.I("user_group.group_profile_id").Eq(goqu.I("group_invite.group_profile_id")),
+					goqu.I("user_group.is_active").IsTrue(),
+				).
+				As("member_count"),
+			goqu.I("group_invite.datetime_expires"),
+		).
+		InnerJoin(
+			goqu.T("group_profile"),
+			goqu.On(goqu.Ex{"group_invite.group_profile_id": goqu.I("group_profile.group_profile_id")}),
+		).
+		Where(
+			goqu.I("group_invite.invite_code").Eq(normalizeInviteCode(c.Param("code"))),
+			goqu.I("group_invite.is_active").IsTrue(),
+			goqu.I("group_invite.datetime_expires").Gt(goqu.L("NOW()")),
+			goqu.Or(
+				goqu.I("group_invite.max_uses").IsNull(),
+				goqu.I("group_invite.use_count").Lt(goqu.I("group_invite.max_uses")),
+			),
+			goqu.I("group_profile.is_active").IsTrue(),
+		).
+		ScanStruct(&preview)
+
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invite", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite code is invalid or has expired"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func findGroupInvite(code string) (models.GroupInvite, bool, error) {
 	var groupInvite models.GroupInvite
 	found, err := initializers.DB.From("group_invite").
 		Select(
@@ -97,17 +237,23 @@ func JoinGroup(c *gin.Context) {
 			goqu.I("updated_by"),
 			goqu.I("datetime_expires"),
 			goqu.I("is_active"),
+			goqu.I("max_uses"),
+			goqu.I("use_count"),
 		).
 		Where(
-			goqu.Ex{"invite_code": joinRequest.Invite_Code},
+			goqu.Ex{"invite_code": normalizeInviteCode(code)},
 		).ScanStruct(&groupInvite)
 
-	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group_invite", "details": err.Error()})
-		return
-	}
-	if !found || groupInvite.Group_Profile_ID != groupID || !groupInvite.Is_Active {
+	return groupInvite, found, err
+}
+
+// redeemGroupInvite adds the current user to the invite's group, counting the
+// use and letting the other members know
+func redeemGroupInvite(c *gin.Context, groupInvite models.GroupInvite) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	groupID := groupInvite.Group_Profile_ID
+
+	if !groupInvite.Is_Active {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid invite code"})
 		return
 	}
@@ -122,130 +268,336 @@ func JoinGroup(c *gin.Context) {
 		return
 	}
 
-	// Shift all existing groups down by incrementing their group_display_sequence
-	// This makes room for the new group at position 0 (top of list)
-	updateQuery := initializers.DB.Update("user_group").
-		Set(goqu.Record{"group_display_sequence": goqu.L("group_display_sequence + 1")}).
-		Where(goqu.C("user_profile_id").Eq(currentUser.User_Profile_ID))
-
-	_, err = updateQuery.Executor().Exec()
+	requiresApproval, err := groupRequiresApproval(groupID)
 	if err != nil {
-		log.Println("Failed to update group display sequence:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder groups", "details": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group settings", "details": err.Error()})
+		return
+	}
+	if requiresApproval {
+		requestGroupJoin(c, groupInvite)
 		return
 	}
 
-	// Insert new group at position 0 (top of list)
-	newUserGroupEntry := models.UserGroup{
-		User_Profile_ID:        currentUser.User_Profile_ID,
-		Group_Profile_ID:       groupID,
-		Is_Active:              true,
-		Group_Display_Sequence: 0,
-		Created_By:             groupInvite.Created_By,
-		Updated_By:             groupInvite.Created_By,
-		Datetime_Create:        time.Now(),
-		Datetime_Update:        time.Now(),
+	// Claiming the use, adding the member and recording the audit entry happen
+	// in one transaction: the claim keeps concurrent joins from going past
+	// max_uses, and a failed insert can't burn a use without a membership
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem invite code", "details": err.Error()})
+		return
 	}
 
-	insert := initializers.DB.Insert("user_group").Rows(newUserGroupEntry)
+	claimed := false
+	err = tx.Wrap(func() error {
+		var err error
+		claimed, err = claimGroupInvite(tx, groupInvite.Group_Invite_ID, currentUser.User_Profile_ID)
+		if err != nil || !claimed {
+			return err
+		}
+
+		// Shift all existing groups down by incrementing their group_display_sequence
+		// This makes room for the new group at position 0 (top of list)
+		_, err = tx.Update("user_group").
+			Set(goqu.Record{"group_display_sequence": goqu.L("group_display_sequence + 1")}).
+			Where(goqu.C("user_profile_id").Eq(currentUser.User_Profile_ID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to reorder groups: %w", err)
+		}
 
-	_, err = insert.Executor().Exec()
+		// Insert new group at position 0 (top of list)
+		newUserGroupEntry := models.UserGroup{
+			User_Profile_ID:        currentUser.User_Profile_ID,
+			Group_Profile_ID:       groupID,
+			Is_Active:              true,
+			Group_Display_Sequence: 0,
+			Role:                   models.GroupRoleMember,
+			Created_By:             groupInvite.Created_By,
+			Updated_By:             groupInvite.Created_By,
+			Datetime_Create:        time.Now(),
+			Datetime_Update:        time.Now(),
+		}
+
+		_, err = tx.Insert("user_group").Rows(newUserGroupEntry).Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to add user to group: %w", err)
+		}
+
+		_, err = tx.Insert("group_invite_use").
+			Rows(models.GroupInviteUse{
+				Group_Invite_ID:  groupInvite.Group_Invite_ID,
+				Group_Profile_ID: groupID,
+				User_Profile_ID:  currentUser.User_Profile_ID,
+			}).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to record invite use: %w", err)
+		}
+
+		return notifyGroupMemberJoined(tx, groupID, currentUser)
+	})
 	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add user to group", "details": err.Error()})
+		log.Printf("Failed to redeem invite %d: %v", groupInvite.Group_Invite_ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem invite code", "details": err.Error()})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invite code is no longer valid"})
 		return
 	}
 
-	update := initializers.DB.Update("group_invite").
-		Set(goqu.Record{
-			"is_active":       false,
-			"updated_by":      currentUser.User_Profile_ID,
-			"datetime_update": time.Now(),
-		}).
-		Where(goqu.C("group_invite_id").Eq(groupInvite.Group_Invite_ID))
+	services.PublishGroupEvent(services.EventGroupMemberJoined, services.EventData{
+		GroupID: groupID,
+		UserID:  currentUser.User_Profile_ID,
+		ActorID: currentUser.User_Profile_ID,
+	}, groupID, currentUser.User_Profile_ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Successfully joined group %d", groupID), "groupId": groupID})
+}
+
+// notifyGroupMemberJoined tells the group's other members that user joined,
+// with a notification record each and a push notification. It runs in the
+// join's transaction, so the notifications are only queued if the join commits.
+func notifyGroupMemberJoined(tx *goqu.TxDatabase, groupID int, user models.UserProfile) error {
+	groupName, err := GetGroupNameByID(groupID)
+	if err != nil {
+		return err
+	}
+
+	memberIDs, err := GetOtherGroupMemberIDs(groupID, user.User_Profile_ID)
+	if err != nil {
+		return err
+	}
+
+	if len(memberIDs) == 0 {
+		return nil
+	}
+
+	displayName := user.First_Name
+	if displayName == "" {
+		displayName = user.Username
+	}
+
+	return services.GetNotificationRouter().SendToUsersTx(tx, memberIDs, services.Delivery{
+		Type:    models.NotificationTypeGroupMemberJoined,
+		Title:   groupName,
+		Message: fmt.Sprintf("%s has joined %s", displayName, groupName),
+		ActorID: user.User_Profile_ID,
+		Data: map[string]string{
+			"type":    "group_member_joined",
+			"groupId": strconv.Itoa(groupID),
+		},
+	})
+}
+
+// GetGroupInvites lists the group's invite codes, newest first, with their
+// status and who joined through each one
+func GetGroupInvites(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
 
-	_, updateErr := update.Executor().Exec()
-	if updateErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark group_invite as inactive", "details": updateErr.Error()})
+	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
 		return
 	}
 
-	// Send push notification and create notification records for other group members
-	go func() {
-		groupName, err := GetGroupNameByID(groupID)
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
 		if err != nil {
-			log.Printf("Failed to get group name for notification: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
 			return
 		}
-
-		memberIDs, err := GetOtherGroupMemberIDs(groupID, currentUser.User_Profile_ID)
-		if err != nil {
-			log.Printf("Failed to get group member IDs for notification: %v", err)
+		if !groupRoleCan(role, groupActionManageInvites) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view invite codes for this group"})
 			return
 		}
+	}
 
-		if len(memberIDs) == 0 {
-			return
-		}
+	var invites []models.GroupInvite
+	err = initializers.DB.From("group_invite").
+		Where(goqu.C("group_profile_id").Eq(groupID)).
+		Order(goqu.C("datetime_create").Desc()).
+		ScanStructs(&invites)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invite codes", "details": err.Error()})
+		return
+	}
+
+	var uses []models.GroupInviteUse
+	err = initializers.DB.From("group_invite_use").
+		Select(
+			"group_invite_use.group_invite_use_id",
+			"group_invite_use.group_invite_id",
+			"group_invite_use.group_profile_id",
+			"group_invite_use.user_profile_id",
+			"user_profile.username",
+			"user_profile.first_name",
+			"user_profile.last_name",
+			"group_invite_use.datetime_create",
+		).
+		InnerJoin(
+			goqu.T("user_profile"),
+			goqu.On(goqu.Ex{"group_invite_use.user_profile_id": goqu.I("user_profile.user_profile_id")}),
+		).
+		Where(goqu.I("group_invite_use.group_profile_id").Eq(groupID)).
+		Order(goqu.I("group_invite_use.datetime_create").Asc()).
+		ScanStructs(&uses)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invite history", "details": err.Error()})
+		return
+	}
+
+	usesByInvite := make(map[int][]models.GroupInviteUse)
+	for _, use := range uses {
+		usesByInvite[use.Group_Invite_ID] = append(usesByInvite[use.Group_Invite_ID], use)
+	}
 
-		displayName := currentUser.First_Name
-		if displayName == "" {
-			displayName = currentUser.Username
+	now := time.Now()
+	details := make([]models.GroupInviteDetail, 0, len(invites))
+	for _, invite := range invites {
+		inviteUses := usesByInvite[invite.Group_Invite_ID]
+		if inviteUses == nil {
+			inviteUses = []models.GroupInviteUse{}
 		}
+		details = append(details, models.GroupInviteDetail{
+			GroupInvite: invite,
+			Status:      groupInviteStatus(invite, now),
+			Invite_Link: inviteLink(invite.Invite_Code),
+			Uses:        inviteUses,
+		})
+	}
 
-		notificationMessage := fmt.Sprintf("%s has joined %s", displayName, groupName)
+	c.JSON(http.StatusOK, details)
+}
 
-		// Create notification records in database for each group member
-		for _, memberID := range memberIDs {
-			notification := models.Notification{
-				User_Profile_ID:      memberID,
-				Notification_Type:    models.NotificationTypeGroupMemberJoined,
-				Notification_Message: notificationMessage,
-				Notification_Status:  models.NotificationStatusUnread,
-				Created_By:           currentUser.User_Profile_ID,
-				Updated_By:           currentUser.User_Profile_ID,
-			}
+// RevokeGroupInvite deactivates an invite code. The row is kept so the join
+// history stays intact.
+func RevokeGroupInvite(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
 
-			insert := initializers.DB.Insert("notification").Rows(notification)
-			_, err := insert.Executor().Exec()
-			if err != nil {
-				log.Printf("Failed to create notification record for user %d: %v", memberID, err)
-			}
-		}
+	groupID, err := strconv.Atoi(c.Param("group_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
+		return
+	}
+
+	inviteID, err := strconv.Atoi(c.Param("group_invite_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID", "details": err.Error()})
+		return
+	}
 
-		// Send push notification
-		pushService := services.GetPushNotificationService()
-		if pushService == nil {
-			log.Println("Push notification service not available")
+	if !isAdmin {
+		role, err := getGroupRole(groupID, currentUser.User_Profile_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
 			return
 		}
-
-		payload := services.NotificationPayload{
-			Title: groupName,
-			Body:  notificationMessage,
-			Data: map[string]string{
-				"type":    "group_member_joined",
-				"groupId": strconv.Itoa(groupID),
-			},
+		if !groupRoleCan(role, groupActionManageInvites) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to revoke invite codes for this group"})
+			return
 		}
+	}
 
-		err = pushService.SendNotificationToUsers(memberIDs, payload)
-		if err != nil {
-			log.Printf("Failed to send group join notifications: %v", err)
-		}
-	}()
+	result, err := initializers.DB.Update("group_invite").
+		Set(goqu.Record{
+			"is_active":       false,
+			"updated_by":      currentUser.User_Profile_ID,
+			"datetime_update": time.Now(),
+		}).
+		Where(
+			goqu.C("group_invite_id").Eq(inviteID),
+			goqu.C("group_profile_id").Eq(groupID),
+		).
+		Executor().Exec()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invite code", "details": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Successfully joined group %d", groupID)})
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invite code not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite code revoked successfully"})
 }
 
-func generateInviteCode(id int) string {
-	randomBytes := make([]byte, 2)
-	_, err := rand.Read(randomBytes)
+// claimGroupInvite counts one use of the invite within tx, deactivating it
+// when that was the last one. It reports false if the invite was revoked,
+// expired or used up in the meantime.
+func claimGroupInvite(tx *goqu.TxDatabase, inviteID int, userID int) (bool, error) {
+	result, err := tx.Update("group_invite").
+		Set(goqu.Record{
+			"use_count":       goqu.L("use_count + 1"),
+			"is_active":       goqu.L("max_uses IS NULL OR use_count + 1 < max_uses"),
+			"updated_by":      userID,
+			"datetime_update": time.Now(),
+		}).
+		Where(
+			goqu.C("group_invite_id").Eq(inviteID),
+			goqu.C("is_active").IsTrue(),
+			goqu.C("datetime_expires").Gt(goqu.L("NOW()")),
+			goqu.Or(
+				goqu.C("max_uses").IsNull(),
+				goqu.C("use_count").Lt(goqu.I("max_uses")),
+			),
+		).
+		Executor().Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		panic(err)
+		return false, err
 	}
+	return rowsAffected == 1, nil
+}
 
-	randomString := hex.EncodeToString(randomBytes)
+func groupInviteStatus(invite models.GroupInvite, now time.Time) string {
+	switch {
+	case invite.Max_Uses != nil && invite.Use_Count >= *invite.Max_Uses:
+		return models.GroupInviteStatusUsedUp
+	case !invite.Is_Active:
+		return models.GroupInviteStatusRevoked
+	case invite.Datetime_Expires.Before(now):
+		return models.GroupInviteStatusExpired
+	}
+	return models.GroupInviteStatusActive
+}
+
+// generateInviteCode returns a random code like "K7QX2-MHP9D", about 49 bits
+// of entropy. Codes no longer include the group ID.
+func generateInviteCode() string {
+	alphabetSize := big.NewInt(int64(len(inviteCodeAlphabet)))
+	code := make([]byte, inviteCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			panic(err)
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+
+	return string(code[:inviteCodeLength/2]) + "-" + string(code[inviteCodeLength/2:])
+}
+
+// inviteLink builds the shareable link for a code from INVITE_LINK_BASE_URL,
+// e.g. https://prayerloop.app/invite/K7QX2-MHP9D. The app resolves it with
+// GET /invites/:code/preview. Empty when the base URL isn't configured.
+func inviteLink(code string) string {
+	base := strings.TrimRight(os.Getenv("INVITE_LINK_BASE_URL"), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/" + url.PathEscape(code)
+}
 
-	return strings.ToUpper(fmt.Sprintf("%04d-%s", id, randomString))
+// normalizeInviteCode lets users type codes in any case and with stray spaces
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
 }
@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 	"time"
 
@@ -21,37 +23,80 @@ func TestCreateGroupInviteCode(t *testing.T) {
 		groupID        string
 		currentUser    models.UserProfile
 		isAdmin        bool
-		userInGroup    bool
+		role           string
 		groupExists    bool
+		body           string
+		expectInsert   bool
 		expectedStatus int
 		expectError    bool
 	}{
 		{
-			name:           "successful creation - user in group",
+			name:           "successful creation - moderator",
 			groupID:        "1",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			userInGroup:    true,
+			role:           models.GroupRoleModerator,
+			groupExists:    true,
+			expectInsert:   true,
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "successful creation - unlimited uses with custom expiry",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			role:           models.GroupRoleOwner,
 			groupExists:    true,
+			body:           `{"expiresInHours": 48, "maxUses": 0}`,
+			expectInsert:   true,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
+		{
+			name:           "expiry beyond 30 days",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			role:           models.GroupRoleOwner,
+			groupExists:    true,
+			body:           `{"expiresInHours": 1000}`,
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "negative max uses",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			role:           models.GroupRoleOwner,
+			groupExists:    true,
+			body:           `{"maxUses": -1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
 		{
 			name:           "successful creation - admin not in group",
 			groupID:        "1",
 			currentUser:    MockAdminUser(),
 			isAdmin:        true,
-			userInGroup:    false,
 			groupExists:    true,
+			expectInsert:   true,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
+		{
+			name:           "forbidden - plain member",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			isAdmin:        false,
+			role:           models.GroupRoleMember,
+			groupExists:    true,
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
 		{
 			name:           "forbidden - user not in group",
 			groupID:        "1",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			userInGroup:    false,
 			groupExists:    true,
 			expectedStatus: http.StatusForbidden,
 			expectError:    true,
@@ -61,7 +106,6 @@ func TestCreateGroupInviteCode(t *testing.T) {
 			groupID:        "999",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			userInGroup:    false,
 			groupExists:    false,
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
@@ -71,7 +115,6 @@ func TestCreateGroupInviteCode(t *testing.T) {
 			groupID:        "invalid",
 			currentUser:    MockUser(),
 			isAdmin:        false,
-			userInGroup:    false,
 			groupExists:    false,
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
@@ -89,18 +132,14 @@ func TestCreateGroupInviteCode(t *testing.T) {
 					groupRows := sqlmock.NewRows([]string{"count"}).AddRow(1)
 					mock.ExpectQuery("SELECT").WillReturnRows(groupRows)
 
-					// Mock isUserInGroup check (returns COUNT) - always called even for admins
-					if tt.userInGroup {
-						userGroupRows := sqlmock.NewRows([]string{"count"}).AddRow(1)
-						mock.ExpectQuery("SELECT").WillReturnRows(userGroupRows)
-					} else {
-						mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+					if !tt.isAdmin {
+						ExpectGroupRole(mock, tt.role)
 					}
 
-					if tt.userInGroup || tt.isAdmin {
+					if tt.expectInsert {
 						// Mock invite code insert
 						mock.ExpectQuery("INSERT INTO \"group_invite\"").
-							WillReturnRows(sqlmock.NewRows([]string{"invite_code"}).AddRow("0001-A4F2"))
+							WillReturnRows(sqlmock.NewRows([]string{"group_invite_id", "invite_code"}).AddRow(5, "K7QX2-MHP9D"))
 					}
 				} else {
 					// Mock group doesn't exist (returns COUNT of 0)
@@ -111,7 +150,8 @@ func TestCreateGroupInviteCode(t *testing.T) {
 			c, w := SetupTestContext()
 			SetAuthenticatedUser(c, tt.currentUser, tt.isAdmin)
 			c.Params = []gin.Param{{Key: "group_profile_id", Value: tt.groupID}}
-			c.Request = httptest.NewRequest("POST", "/groups/"+tt.groupID+"/invite", nil)
+			c.Request = httptest.NewRequest("POST", "/groups/"+tt.groupID+"/invite", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
 
 			CreateGroupInviteCode(c)
 
@@ -125,6 +165,13 @@ func TestCreateGroupInviteCode(t *testing.T) {
 			} else {
 				assert.NotNil(t, response["inviteCode"])
 				assert.NotNil(t, response["expiresAt"])
+				assert.Equal(t, float64(5), response["groupInviteId"])
+				if tt.body == "" {
+					// Codes are single use unless asked otherwise
+					assert.Equal(t, float64(1), response["maxUses"])
+				} else {
+					assert.Nil(t, response["maxUses"])
+				}
 			}
 		})
 	}
@@ -142,6 +189,9 @@ func TestJoinGroup(t *testing.T) {
 		inviteInactive bool
 		wrongGroup     bool
 		userInGroup    bool
+		claimFails     bool
+		insertFails    bool
+		needsApproval  bool
 		groupExists    bool
 		invalidJSON    bool
 		expectedStatus int
@@ -237,6 +287,39 @@ func TestJoinGroup(t *testing.T) {
 			expectedStatus: http.StatusConflict,
 			expectError:    true,
 		},
+		{
+			name:           "membership insert fails - invite use rolled back",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			inviteCode:     "K7QX2-MHP9D",
+			inviteValid:    true,
+			insertFails:    true,
+			groupExists:    true,
+			expectedStatus: http.StatusInternalServerError,
+			expectError:    true,
+		},
+		{
+			name:           "group requires approval - join request created",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			inviteCode:     "K7QX2-MHP9D",
+			inviteValid:    true,
+			needsApproval:  true,
+			groupExists:    true,
+			expectedStatus: http.StatusAccepted,
+			expectError:    false,
+		},
+		{
+			name:           "invite used up by a concurrent join",
+			groupID:        "1",
+			currentUser:    MockUser(),
+			inviteCode:     "K7QX2-MHP9D",
+			inviteValid:    true,
+			claimFails:     true,
+			groupExists:    true,
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
 		{
 			name:           "group doesn't exist",
 			groupID:        "999",
@@ -327,6 +410,45 @@ func TestJoinGroup(t *testing.T) {
 							} else {
 								mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 
+								// Mock the group's approval setting
+								mock.ExpectQuery("SELECT \"requires_approval\" FROM \"group_profile\"").
+									WillReturnRows(sqlmock.NewRows([]string{"requires_approval"}).AddRow(tt.needsApproval))
+							}
+							if !tt.userInGroup && tt.needsApproval {
+								// Mock the pending request check, then the use and the request saved together
+								mock.ExpectQuery("SELECT \"group_join_request_id\" FROM \"group_join_request\"").
+									WillReturnRows(sqlmock.NewRows([]string{"group_join_request_id"}))
+								mock.ExpectBegin()
+								mock.ExpectExec("UPDATE \"group_invite\" SET .*\"use_count\"=use_count \\+ 1").
+									WillReturnResult(sqlmock.NewResult(0, 1))
+								mock.ExpectQuery("INSERT INTO \"group_join_request\" .*'pending'").
+									WillReturnRows(sqlmock.NewRows([]string{"group_join_request_id"}).AddRow(8))
+
+								// The owner and moderators are notified in the same transaction
+								ExpectNotifications(mock, true, 2)
+								mock.ExpectCommit()
+							} else if !tt.userInGroup && tt.claimFails {
+								// Mock the invite running out before this join claimed it
+								mock.ExpectBegin()
+								mock.ExpectExec("UPDATE \"group_invite\" SET .*\"use_count\"=use_count \\+ 1").
+									WillReturnResult(sqlmock.NewResult(0, 0))
+								mock.ExpectCommit()
+							} else if !tt.userInGroup && tt.insertFails {
+								// The claimed use is rolled back with the failed insert
+								mock.ExpectBegin()
+								mock.ExpectExec("UPDATE \"group_invite\" SET .*\"use_count\"=use_count \\+ 1").
+									WillReturnResult(sqlmock.NewResult(0, 1))
+								mock.ExpectExec("UPDATE \"user_group\"").
+									WillReturnResult(sqlmock.NewResult(0, 0))
+								mock.ExpectExec("INSERT INTO \"user_group\"").
+									WillReturnError(sql.ErrConnDone)
+								mock.ExpectRollback()
+							} else if !tt.userInGroup {
+								// Mock claiming one use of the invite
+								mock.ExpectBegin()
+								mock.ExpectExec("UPDATE \"group_invite\" SET .*\"use_count\"=use_count \\+ 1").
+									WillReturnResult(sqlmock.NewResult(0, 1))
+
 								// Mock display sequence update
 								mock.ExpectExec("UPDATE \"user_group\"").
 									WillReturnResult(sqlmock.NewResult(0, 0))
@@ -335,17 +457,13 @@ func TestJoinGroup(t *testing.T) {
 								mock.ExpectExec("INSERT INTO \"user_group\"").
 									WillReturnResult(sqlmock.NewResult(1, 1))
 
-								// Mock invite deactivation
-								mock.ExpectExec("UPDATE \"group_invite\"").
-									WillReturnResult(sqlmock.NewResult(0, 1))
-
-								// Mock GetGroupNameByID for push notification (runs in goroutine)
-								mock.ExpectQuery("SELECT \"group_name\" FROM \"group_profile\"").
-									WillReturnRows(sqlmock.NewRows([]string{"group_name"}).AddRow("Test Group"))
+								// Mock join audit entry
+								mock.ExpectExec("INSERT INTO \"group_invite_use\"").
+									WillReturnResult(sqlmock.NewResult(1, 1))
 
-								// Mock GetOtherGroupMemberIDs for push notification (runs in goroutine)
-								mock.ExpectQuery("SELECT \"user_profile_id\" FROM \"user_group\"").
-									WillReturnRows(sqlmock.NewRows([]string{"user_profile_id"}).AddRow(2).AddRow(3))
+								// The other members are notified in the same transaction
+								ExpectNotifications(mock, true, 2, 3)
+								mock.ExpectCommit()
 							}
 						}
 					} else if !tt.invalidJSON {
@@ -388,6 +506,301 @@ func TestJoinGroup(t *testing.T) {
 			} else {
 				assert.NotNil(t, response["message"])
 			}
+			if tt.needsApproval {
+				assert.Equal(t, float64(8), response["groupJoinRequestId"])
+				assert.Equal(t, models.GroupJoinRequestStatusPending, response["status"])
+			}
+		})
+	}
+}
+
+// Test GetGroupInvites - List invite codes with status and join history
+func TestGetGroupInvites(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           string
+		expectedStatus int
+	}{
+		{
+			name:           "moderator lists invites",
+			role:           models.GroupRoleModerator,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "member cannot list invites",
+			role:           models.GroupRoleMember,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			now := time.Now()
+			ExpectGroupRole(mock, tt.role)
+			if tt.expectedStatus == http.StatusOK {
+				inviteColumns := []string{"group_invite_id", "group_profile_id", "invite_code", "datetime_expires", "is_active", "max_uses", "use_count"}
+				mock.ExpectQuery(`SELECT .* FROM "group_invite" WHERE .* ORDER BY "datetime_create" DESC`).
+					WillReturnRows(sqlmock.NewRows(inviteColumns).
+						AddRow(3, 1, "K7QX2-MHP9D", now.Add(time.Hour), true, nil, 2).
+						AddRow(2, 1, "ABCDE-FGHJK", now.Add(time.Hour), false, 1, 1).
+						AddRow(1, 1, "0001-A4F2", now.Add(-time.Hour), true, 1, 0))
+				mock.ExpectQuery(`SELECT .* FROM "group_invite_use" INNER JOIN "user_profile"`).
+					WillReturnRows(sqlmock.NewRows([]string{"group_invite_use_id", "group_invite_id", "user_profile_id", "username", "datetime_create"}).
+						AddRow(1, 3, 4, "newmember", now).
+						AddRow(2, 3, 5, "another", now).
+						AddRow(3, 2, 6, "first", now))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "group_profile_id", Value: "1"}}
+			c.Request = httptest.NewRequest("GET", "/groups/1/invites", nil)
+
+			GetGroupInvites(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var invites []models.GroupInviteDetail
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &invites))
+			assert.Len(t, invites, 3)
+			assert.Equal(t, models.GroupInviteStatusActive, invites[0].Status)
+			assert.Len(t, invites[0].Uses, 2)
+			assert.Equal(t, models.GroupInviteStatusUsedUp, invites[1].Status)
+			assert.Equal(t, "first", invites[1].Uses[0].Username)
+			assert.Equal(t, models.GroupInviteStatusExpired, invites[2].Status)
+			assert.NotNil(t, invites[2].Uses)
 		})
 	}
 }
+
+// Test RevokeGroupInvite - Deactivate an invite code
+func TestRevokeGroupInvite(t *testing.T) {
+	tests := []struct {
+		name           string
+		inviteID       string
+		role           string
+		rowsAffected   int64
+		expectedStatus int
+	}{
+		{
+			name:           "owner revokes invite",
+			inviteID:       "3",
+			role:           models.GroupRoleOwner,
+			rowsAffected:   1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invite from another group",
+			inviteID:       "9",
+			role:           models.GroupRoleOwner,
+			rowsAffected:   0,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "member cannot revoke",
+			inviteID:       "3",
+			role:           models.GroupRoleMember,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid invite ID",
+			inviteID:       "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.inviteID != "abc" {
+				ExpectGroupRole(mock, tt.role)
+				if groupRoleCan(tt.role, groupActionManageInvites) {
+					mock.ExpectExec(`UPDATE "group_invite" SET .*"is_active"=FALSE.* WHERE \(\("group_invite_id" = ` + tt.inviteID + `\) AND \("group_profile_id" = 1\)\)`).
+						WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{
+				{Key: "group_profile_id", Value: "1"},
+				{Key: "group_invite_id", Value: tt.inviteID},
+			}
+			c.Request = httptest.NewRequest("DELETE", "/groups/1/invites/"+tt.inviteID, nil)
+
+			RevokeGroupInvite(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGenerateInviteCode(t *testing.T) {
+	format := regexp.MustCompile(`^[A-HJKMNP-Z2-9]{5}-[A-HJKMNP-Z2-9]{5}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code := generateInviteCode()
+		assert.Regexp(t, format, code)
+		assert.False(t, seen[code], "duplicate invite code %s", code)
+		seen[code] = true
+	}
+
+	assert.Equal(t, "K7QX2-MHP9D", normalizeInviteCode("  k7qx2-mhp9d "))
+}
+
+// Test RedeemInviteCode - Join whichever group the code belongs to
+func TestRedeemInviteCode(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		inviteFound    bool
+		userInGroup    bool
+		expectedStatus int
+	}{
+		{
+			name:           "redeems code for its group",
+			body:           `{"inviteCode": "k7qx2-mhp9d"}`,
+			inviteFound:    true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "already a member",
+			body:           `{"inviteCode": "K7QX2-MHP9D"}`,
+			inviteFound:    true,
+			userInGroup:    true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "unknown code",
+			body:           `{"inviteCode": "ZZZZZ-ZZZZZ"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "missing code",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			inviteColumns := []string{"group_invite_id", "group_profile_id", "invite_code", "datetime_expires", "is_active", "max_uses", "use_count"}
+			if tt.body != `{}` {
+				rows := sqlmock.NewRows(inviteColumns)
+				if tt.inviteFound {
+					rows.AddRow(5, 3, "K7QX2-MHP9D", time.Now().Add(time.Hour), true, nil, 0)
+				}
+				mock.ExpectQuery(`SELECT .* FROM "group_invite" WHERE \("invite_code" = 'K7QX2-MHP9D'|ZZZZZ-ZZZZZ`).WillReturnRows(rows)
+			}
+			if tt.inviteFound {
+				count := 0
+				if tt.userInGroup {
+					count = 1
+				}
+				mock.ExpectQuery(`SELECT COUNT\("user_group_id"\) FROM "user_group" WHERE \(\("user_group"."group_profile_id" = 3\)`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+				if !tt.userInGroup {
+					mock.ExpectQuery(`SELECT "requires_approval" FROM "group_profile" WHERE \("group_profile_id" = 3\)`).
+						WillReturnRows(sqlmock.NewRows([]string{"requires_approval"}).AddRow(false))
+					mock.ExpectBegin()
+					mock.ExpectExec(`UPDATE "group_invite"`).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec(`UPDATE "user_group"`).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(`INSERT INTO "user_group"`).WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec(`INSERT INTO "group_invite_use"`).WillReturnResult(sqlmock.NewResult(1, 1))
+					ExpectNotifications(mock, true)
+					mock.ExpectCommit()
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Request = httptest.NewRequest("POST", "/invites/redeem", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			RedeemInviteCode(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, float64(3), response["groupId"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+		})
+	}
+}
+
+// Test PreviewInviteCode - Public group preview for an invite link
+func TestPreviewInviteCode(t *testing.T) {
+	tests := []struct {
+		name           string
+		code           string
+		found          bool
+		expectedStatus int
+	}{
+		{
+			name:           "valid code shows the group",
+			code:           "k7qx2-mhp9d",
+			found:          true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid, expired or used up code",
+			code:           "ZZZZZ-ZZZZZ",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			rows := sqlmock.NewRows([]string{"group_name", "group_description", "member_count", "datetime_expires"})
+			if tt.found {
+				rows.AddRow("Tuesday Small Group", "We meet weekly", 12, time.Now().Add(time.Hour))
+			}
+			mock.ExpectQuery(`SELECT "group_profile"."group_name", "group_profile"."group_description", \(SELECT COUNT.*\) AS "member_count".* WHERE \(\("group_invite"."invite_code" = '` + normalizeInviteCode(tt.code) + `'\) AND \("group_invite"."is_active" IS TRUE\)`).
+				WillReturnRows(rows)
+
+			// No authenticated user: the preview is public
+			c, w := SetupTestContext()
+			c.Params = []gin.Param{{Key: "code", Value: tt.code}}
+			c.Request = httptest.NewRequest("GET", "/invites/"+tt.code+"/preview", nil)
+
+			PreviewInviteCode(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			if tt.found {
+				var preview models.GroupInvitePreview
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+				assert.Equal(t, "Tuesday Small Group", preview.Group_Name)
+				assert.Equal(t, 12, preview.Member_Count)
+			}
+		})
+	}
+}
+
+func TestInviteLink(t *testing.T) {
+	t.Setenv("INVITE_LINK_BASE_URL", "")
+	assert.Equal(t, "", inviteLink("K7QX2-MHP9D"))
+
+	t.Setenv("INVITE_LINK_BASE_URL", "https://prayerloop.app/invite/")
+	assert.Equal(t, "https://prayerloop.app/invite/K7QX2-MHP9D", inviteLink("K7QX2-MHP9D"))
+}
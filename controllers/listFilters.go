@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// prayerListFilters are the optional prayer filters shared by the prayer list
+// endpoints and search: ?answered=, ?archived=, ?categoryId=, ?subjectId=,
+// ?createdAfter= and ?createdBefore=
+type prayerListFilters struct {
+	answered      *bool
+	archived      *bool
+	categoryID    *int
+	subjectID     *int
+	createdAfter  *time.Time
+	createdBefore *time.Time
+}
+
+func parsePrayerListFilters(c *gin.Context) (prayerListFilters, error) {
+	var filters prayerListFilters
+
+	if answeredParam := c.Query("answered"); answeredParam != "" {
+		answered, err := strconv.ParseBool(answeredParam)
+		if err != nil {
+			return filters, errors.New("answered must be true or false")
+		}
+		filters.answered = &answered
+	}
+
+	if archivedParam := c.Query("archived"); archivedParam != "" {
+		archived, err := strconv.ParseBool(archivedParam)
+		if err != nil {
+			return filters, errors.New("archived must be true or false")
+		}
+		filters.archived = &archived
+	}
+
+	if categoryParam := c.Query("categoryId"); categoryParam != "" {
+		categoryID, err := strconv.Atoi(categoryParam)
+		if err != nil {
+			return filters, errors.New("invalid category ID")
+		}
+		filters.categoryID = &categoryID
+	}
+
+	if subjectParam := c.Query("subjectId"); subjectParam != "" {
+		subjectID, err := strconv.Atoi(subjectParam)
+		if err != nil {
+			return filters, errors.New("invalid prayer subject ID")
+		}
+		filters.subjectID = &subjectID
+	}
+
+	var err error
+	if filters.createdAfter, err = parseFilterTime(c.Query("createdAfter")); err != nil {
+		return filters, errors.New("createdAfter must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+	if filters.createdBefore, err = parseFilterTime(c.Query("createdBefore")); err != nil {
+		return filters, errors.New("createdBefore must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+
+	return filters, nil
+}
+
+// parseFilterTime accepts either a bare date (midnight UTC) or a full timestamp
+func parseFilterTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (f prayerListFilters) isSet() bool {
+	return f.answered != nil || f.archived != nil || f.categoryID != nil || f.subjectID != nil ||
+		f.createdAfter != nil || f.createdBefore != nil
+}
+
+// expressions returns WHERE conditions against the prayer table. The category
+// filter is a subquery so it works whether or not the caller joined
+// prayer_category_item.
+func (f prayerListFilters) expressions() []exp.Expression {
+	var expressions []exp.Expression
+
+	if f.answered != nil {
+		if *f.answered {
+			expressions = append(expressions, goqu.I("prayer.is_answered").IsTrue())
+		} else {
+			expressions = append(expressions, goqu.I("prayer.is_answered").IsNotTrue())
+		}
+	}
+
+	if f.archived != nil {
+		expressions = append(expressions, goqu.I("prayer.is_archived").Eq(*f.archived))
+	}
+
+	if f.categoryID != nil {
+		expressions = append(expressions, goqu.I("prayer.prayer_id").In(
+			initializers.DB.From("prayer_category_item").
+				Select("prayer_access.prayer_id").
+				Join(
+					goqu.T("prayer_access"),
+					goqu.On(goqu.Ex{"prayer_category_item.prayer_access_id": goqu.I("prayer_access.prayer_access_id")}),
+				).
+				Where(goqu.Ex{"prayer_category_item.prayer_category_id": *f.categoryID}),
+		))
+	}
+
+	if f.subjectID != nil {
+		expressions = append(expressions, goqu.I("prayer.prayer_subject_id").Eq(*f.subjectID))
+	}
+
+	if f.createdAfter != nil {
+		expressions = append(expressions, goqu.I("prayer.datetime_create").Gte(*f.createdAfter))
+	}
+
+	if f.createdBefore != nil {
+		expressions = append(expressions, goqu.I("prayer.datetime_create").Lt(*f.createdBefore))
+	}
+
+	return expressions
+}
+
+// notificationListFilters are ?status= (READ or UNREAD) and ?type= for GetUserNotifications
+type notificationListFilters struct {
+	status           string
+	notificationType string
+}
+
+func parseNotificationListFilters(c *gin.Context) (notificationListFilters, error) {
+	filters := notificationListFilters{
+		status:           strings.ToUpper(c.Query("status")),
+		notificationType: strings.ToUpper(c.Query("type")),
+	}
+
+	if filters.status != "" &&
+		filters.status != models.NotificationStatusRead &&
+		filters.status != models.NotificationStatusUnread {
+		return filters, errors.New("status must be READ or UNREAD")
+	}
+
+	return filters, nil
+}
+
+func (f notificationListFilters) expressions() []exp.Expression {
+	var expressions []exp.Expression
+
+	if f.status != "" {
+		expressions = append(expressions, goqu.C("notification_status").Eq(f.status))
+	}
+	if f.notificationType != "" {
+		expressions = append(expressions, goqu.C("notification_type").Eq(f.notificationType))
+	}
+
+	return expressions
+}
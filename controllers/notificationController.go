@@ -27,9 +27,21 @@ func GetUserNotifications(c *gin.Context) {
 		return
 	}
 
+	page, err := parsePageRequest(c, "notifications")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filters, err := parseNotificationListFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var notifications []models.Notification
 
-	dbErr := initializers.DB.From("notification").
+	query := initializers.DB.From("notification").
 		Select("notification_id",
 			"user_profile_id",
 			"notification_type",
@@ -42,15 +54,34 @@ func GetUserNotifications(c *gin.Context) {
 			"target_prayer_id",
 			"target_group_id").
 		Where(goqu.C("user_profile_id").Eq(userID)).
-		Order(goqu.C("datetime_create").Desc()).
-		ScanStructs(&notifications)
+		Where(filters.expressions()...).
+		Order(goqu.C("datetime_create").Desc())
+
+	query = page.apply(query, true, goqu.C("datetime_create"), goqu.C("notification_id"))
+	dbErr := query.ScanStructs(&notifications)
 
 	if dbErr != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": dbErr.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, notifications)
+	// Unpaginated requests keep returning the bare array older clients expect
+	if !page.active {
+		c.JSON(http.StatusOK, notifications)
+		return
+	}
+
+	notifications, pageInfo := pageResults(page, notifications, func(notification models.Notification) []interface{} {
+		return []interface{}{notification.DateTime_Create, notification.Notification_ID}
+	})
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"pagination":    pageInfo,
+	})
 }
 
 func ToggleUserNotificationStatus(c *gin.Context) {
@@ -151,17 +182,33 @@ func DeleteUserNotification(c *gin.Context) {
 		return
 	}
 
-	// Delete the notification
-	deleteQuery := initializers.DB.Delete("notification").
-		Where(goqu.C("notification_id").Eq(notificationID))
+	// Delete the notification and record its tombstone in one transaction
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification", "details": err.Error()})
+		return
+	}
 
-	result, err := deleteQuery.Executor().Exec()
+	var rowsAffected int64
+	err = tx.Wrap(func() error {
+		result, err := tx.Delete("notification").
+			Where(goqu.C("notification_id").Eq(notificationID)).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, _ = result.RowsAffected()
+		if rowsAffected == 0 {
+			return nil
+		}
+		return services.RecordSyncTombstoneTx(tx, userID, models.SyncEntityNotification, notificationID)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification", "details": err.Error()})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
 		return
@@ -123,6 +123,88 @@ func TestGetUserNotifications(t *testing.T) {
 	}
 }
 
+// Test GetUserNotifications with ?limit= / ?cursor= and filters
+func TestGetUserNotificationsPaginated(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		rows            int
+		expectedStatus  int
+		expectedCount   int
+		expectedHasMore bool
+	}{
+		{
+			name:            "first page with more to come",
+			query:           "?limit=2",
+			rows:            3,
+			expectedStatus:  http.StatusOK,
+			expectedCount:   2,
+			expectedHasMore: true,
+		},
+		{
+			name:            "last page filtered by status",
+			query:           "?limit=2&status=unread",
+			rows:            1,
+			expectedStatus:  http.StatusOK,
+			expectedCount:   1,
+			expectedHasMore: false,
+		},
+		{
+			name:           "invalid status filter",
+			query:          "?limit=2&status=ARCHIVED",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=bogus",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectedStatus == http.StatusOK {
+				now := time.Now()
+				notificationRows := sqlmock.NewRows([]string{
+					"notification_id", "user_profile_id", "notification_type", "notification_message",
+					"notification_status", "datetime_create", "datetime_update", "created_by", "updated_by",
+				})
+				for i := 0; i < tt.rows; i++ {
+					created := now.Add(-time.Duration(i) * time.Minute)
+					notificationRows.AddRow(10-i, 1, "PRAYER_SHARED", "Someone shared a prayer with you", "UNREAD", created, created, 1, 1)
+				}
+				mock.ExpectQuery("SELECT .* FROM \"notification\" .* LIMIT 3").WillReturnRows(notificationRows)
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "user_profile_id", Value: "1"}}
+			c.Request = httptest.NewRequest("GET", "/users/1/notifications"+tt.query, nil)
+
+			GetUserNotifications(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Notifications []models.Notification `json:"notifications"`
+				Pagination    models.PageInfo       `json:"pagination"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Len(t, response.Notifications, tt.expectedCount)
+			assert.Equal(t, 2, response.Pagination.Limit)
+			assert.Equal(t, tt.expectedHasMore, response.Pagination.HasMore)
+			assert.Equal(t, tt.expectedHasMore, response.Pagination.NextCursor != nil)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 // Test ToggleUserNotificationStatus - Toggle notification READ/UNREAD status
 func TestToggleUserNotificationStatus(t *testing.T) {
 	tests := []struct {
@@ -489,8 +571,14 @@ func TestDeleteUserNotification(t *testing.T) {
 
 						if tt.notificationBelongsToUser {
 							// Mock delete
+							mock.ExpectBegin()
 							mock.ExpectExec("DELETE FROM \"notification\"").
 								WillReturnResult(sqlmock.NewResult(0, 1))
+
+							// Mock tombstone for the user's offline cache, in the same transaction
+							mock.ExpectExec("INSERT INTO \"sync_tombstone\"").
+								WillReturnResult(sqlmock.NewResult(1, 1))
+							mock.ExpectCommit()
 						}
 					} else {
 						// Mock ownership check (not found) - return error to simulate no rows found
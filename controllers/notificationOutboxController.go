@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotificationOutbox lists push and email deliveries for admins, newest
+// first. ?status= picks dead (the default), pending, processing, sent or
+// failing (pending with an error from an earlier attempt).
+func GetNotificationOutbox(c *gin.Context) {
+	page, err := parsePageRequest(c, "notification-outbox")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := initializers.DB.From("notification_outbox").
+		Select("outbox_id",
+			"idempotency_key",
+			"user_profile_id",
+			"notification_type",
+			"channel",
+			"payload",
+			"status",
+			"attempts",
+			"next_attempt_at",
+			"locked_until",
+			"last_error",
+			"delivered_to",
+			"sent_at",
+			"datetime_create",
+			"datetime_update")
+
+	status := c.DefaultQuery("status", models.OutboxStatusDead)
+	switch status {
+	case models.OutboxStatusDead, models.OutboxStatusPending, models.OutboxStatusProcessing, models.OutboxStatusSent:
+		query = query.Where(goqu.C("status").Eq(status))
+	case "failing":
+		query = query.Where(
+			goqu.C("status").Eq(models.OutboxStatusPending),
+			goqu.C("last_error").IsNotNull(),
+		)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be one of dead, failing, pending, processing or sent"})
+		return
+	}
+
+	if userParam := c.Query("user_profile_id"); userParam != "" {
+		userID, err := strconv.Atoi(userParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user profile ID", "details": err.Error()})
+			return
+		}
+		query = query.Where(goqu.C("user_profile_id").Eq(userID))
+	}
+
+	query = query.Order(goqu.C("outbox_id").Desc())
+	query = page.apply(query, true, goqu.C("outbox_id"))
+
+	var deliveries []models.NotificationOutbox
+	if err := query.ScanStructs(&deliveries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get outbox deliveries", "details": err.Error()})
+		return
+	}
+
+	deliveries, pageInfo := pageResults(page, deliveries, func(delivery models.NotificationOutbox) []interface{} {
+		return []interface{}{delivery.Outbox_ID}
+	})
+	if deliveries == nil {
+		deliveries = []models.NotificationOutbox{}
+	}
+
+	c.JSON(http.StatusOK, withPageInfo(gin.H{"deliveries": deliveries}, page, pageInfo))
+}
+
+// ReplayNotificationOutbox queues a dead or failing delivery again with a
+// fresh set of attempts
+func ReplayNotificationOutbox(c *gin.Context) {
+	outboxID, err := strconv.Atoi(c.Param("outbox_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid outbox ID", "details": err.Error()})
+		return
+	}
+
+	replayed, err := services.ReplayOutboxDelivery(outboxID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay delivery", "details": err.Error()})
+		return
+	}
+	if !replayed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No dead or pending delivery with that ID"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery queued for replay", "outboxId": outboxID})
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test GetNotificationOutbox - Admins list deliveries by status
+func TestGetNotificationOutbox(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedWhere  string
+		expectedStatus int
+	}{
+		{
+			name:           "dead letters by default",
+			expectedWhere:  `WHERE \("status" = 'dead'\)`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failing deliveries for one user",
+			query:          "?status=failing&user_profile_id=4",
+			expectedWhere:  `WHERE \(\("status" = 'pending'\) AND \("last_error" IS NOT NULL\) AND \("user_profile_id" = 4\)\)`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid status",
+			query:          "?status=lost",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectQuery(`SELECT .* FROM "notification_outbox" ` + tt.expectedWhere + ` ORDER BY "outbox_id" DESC`).
+					WillReturnRows(sqlmock.NewRows([]string{"outbox_id", "idempotency_key", "user_profile_id", "notification_type", "channel", "payload", "status", "attempts", "next_attempt_at", "last_error"}).
+						AddRow(12, "comment:5:4:push", 4, "PRAYER_COMMENT_ADDED", "push", `{"UserID":4}`, "dead", 8, time.Now(), "no credentials"))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockAdminUser(), true)
+			c.Request = httptest.NewRequest("GET", "/notifications/outbox"+tt.query, nil)
+
+			GetNotificationOutbox(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus != http.StatusOK {
+				assert.NotNil(t, response["error"])
+				return
+			}
+
+			deliveries := response["deliveries"].([]interface{})
+			assert.Len(t, deliveries, 1)
+			delivery := deliveries[0].(map[string]interface{})
+			assert.Equal(t, float64(12), delivery["outboxId"])
+			assert.Equal(t, "no credentials", delivery["lastError"])
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test ReplayNotificationOutbox - Dead or failing deliveries go back in the queue
+func TestReplayNotificationOutbox(t *testing.T) {
+	tests := []struct {
+		name           string
+		outboxID       string
+		rowsAffected   int64
+		expectedStatus int
+	}{
+		{
+			name:           "replays a dead delivery",
+			outboxID:       "12",
+			rowsAffected:   1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "already sent or missing",
+			outboxID:       "13",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid ID",
+			outboxID:       "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectedStatus != http.StatusBadRequest {
+				mock.ExpectExec(`UPDATE "notification_outbox" SET .*"attempts"=0.* WHERE \(\("outbox_id" = ` + tt.outboxID + `\) AND \("status" IN \('dead', 'pending'\)\)\)`).
+					WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockAdminUser(), true)
+			c.Params = []gin.Param{{Key: "outbox_id", Value: tt.outboxID}}
+			c.Request = httptest.NewRequest("POST", "/notifications/outbox/"+tt.outboxID+"/replay", nil)
+
+			ReplayNotificationOutbox(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
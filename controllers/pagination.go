@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageRequest is the parsed ?limit=&cursor= for one list endpoint. Requests
+// without either parameter are not paginated and keep the old full response,
+// so existing clients are unaffected.
+type pageRequest struct {
+	kind   string
+	limit  int
+	after  []interface{}
+	active bool
+}
+
+// pageCursor is what the opaque cursor decodes to: the sort key of the last
+// row returned, tagged with the endpoint it came from
+type pageCursor struct {
+	Kind   string        `json:"k"`
+	Values []interface{} `json:"v"`
+}
+
+// parsePageRequest reads limit and cursor for the list identified by kind.
+// A cursor issued by a different list is rejected.
+func parsePageRequest(c *gin.Context, kind string) (pageRequest, error) {
+	page := pageRequest{kind: kind, limit: defaultPageLimit}
+
+	limitParam := c.Query("limit")
+	cursorParam := c.Query("cursor")
+	if limitParam == "" && cursorParam == "" {
+		return page, nil
+	}
+	page.active = true
+
+	if limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageLimit))
+		}
+		page.limit = limit
+	}
+
+	if cursorParam != "" {
+		values, err := decodePageCursor(cursorParam, kind)
+		if err != nil {
+			return page, err
+		}
+		page.after = values
+	}
+
+	return page, nil
+}
+
+// apply orders the query by the keyset columns, skips everything up to the
+// cursor and fetches one extra row to tell whether another page exists.
+// Columns must uniquely identify a row and share one direction.
+func (p pageRequest) apply(ds *goqu.SelectDataset, desc bool, columns ...exp.IdentifierExpression) *goqu.SelectDataset {
+	if !p.active {
+		return ds
+	}
+
+	order := make([]exp.OrderedExpression, len(columns))
+	for i, column := range columns {
+		if desc {
+			order[i] = column.Desc()
+		} else {
+			order[i] = column.Asc()
+		}
+	}
+	ds = ds.Order(order...).Limit(uint(p.limit + 1))
+
+	if len(p.after) == len(columns) {
+		// Row comparison keeps this a single index range scan in Postgres
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+		operator := ">"
+		if desc {
+			operator = "<"
+		}
+		args := make([]interface{}, 0, len(columns)*2)
+		for _, column := range columns {
+			args = append(args, column)
+		}
+		args = append(args, p.after...)
+		ds = ds.Where(goqu.L("("+placeholders+") "+operator+" ("+placeholders+")", args...))
+	}
+
+	return ds
+}
+
+// pageResults trims the extra row fetched by apply and builds the PageInfo.
+// keyOf returns the keyset values of a row in the same order as the columns
+// given to apply.
+func pageResults[T any](p pageRequest, rows []T, keyOf func(T) []interface{}) ([]T, models.PageInfo) {
+	info := models.PageInfo{Limit: p.limit}
+	if !p.active || len(rows) <= p.limit {
+		return rows, info
+	}
+
+	rows = rows[:p.limit]
+	info.HasMore = true
+	if cursor, err := encodePageCursor(p.kind, keyOf(rows[len(rows)-1])); err == nil {
+		info.NextCursor = &cursor
+	}
+
+	return rows, info
+}
+
+func encodePageCursor(kind string, values []interface{}) (string, error) {
+	payload, err := json.Marshal(pageCursor{Kind: kind, Values: values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+func decodePageCursor(cursor string, kind string) ([]interface{}, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var decoded pageCursor
+	if err := decoder.Decode(&decoded); err != nil || decoded.Kind != kind || len(decoded.Values) == 0 {
+		return nil, errInvalidCursor
+	}
+
+	// Only scalar keys are ever encoded; anything else was not produced by us
+	for i, value := range decoded.Values {
+		switch v := value.(type) {
+		case json.Number:
+			n, err := v.Int64()
+			if err != nil {
+				return nil, errInvalidCursor
+			}
+			decoded.Values[i] = n
+		case string:
+		default:
+			return nil, errInvalidCursor
+		}
+	}
+
+	return decoded.Values, nil
+}
+
+// withPageInfo adds the pagination block to a list response, but only for
+// paginated requests so unpaginated responses keep their original shape
+func withPageInfo(response gin.H, page pageRequest, info models.PageInfo) gin.H {
+	if page.active {
+		response["pagination"] = info
+	}
+	return response
+}
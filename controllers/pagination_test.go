@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePageRequest(t *testing.T) {
+	validCursor, _ := encodePageCursor("comments", []interface{}{"2026-03-01T10:00:00Z", 42})
+	otherCursor, _ := encodePageCursor("notifications", []interface{}{"2026-03-01T10:00:00Z", 42})
+
+	tests := []struct {
+		name          string
+		query         string
+		expectActive  bool
+		expectedLimit int
+		expectedAfter []interface{}
+		expectError   bool
+	}{
+		{name: "no parameters keeps legacy behavior", query: "", expectedLimit: defaultPageLimit},
+		{name: "limit only", query: "?limit=10", expectActive: true, expectedLimit: 10},
+		{name: "cursor only uses default limit", query: "?cursor=" + validCursor, expectActive: true, expectedLimit: defaultPageLimit, expectedAfter: []interface{}{"2026-03-01T10:00:00Z", int64(42)}},
+		{name: "limit too large", query: "?limit=500", expectError: true},
+		{name: "limit not a number", query: "?limit=ten", expectError: true},
+		{name: "cursor from another list", query: "?cursor=" + otherCursor, expectError: true},
+		{name: "garbage cursor", query: "?cursor=not-a-cursor", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := SetupTestContext()
+			c.Request = httptest.NewRequest("GET", "/list"+tt.query, nil)
+
+			page, err := parsePageRequest(c, "comments")
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectActive, page.active)
+			assert.Equal(t, tt.expectedLimit, page.limit)
+			assert.Equal(t, tt.expectedAfter, page.after)
+		})
+	}
+}
+
+func TestPageRequestApply(t *testing.T) {
+	initializers.DB = goqu.New("postgres", nil)
+	base := initializers.DB.From("notification").Select("notification_id")
+
+	t.Run("inactive leaves the query alone", func(t *testing.T) {
+		sql, _, _ := pageRequest{limit: defaultPageLimit}.apply(base, true, goqu.C("datetime_create")).ToSQL()
+		assert.NotContains(t, sql, "LIMIT")
+	})
+
+	t.Run("first page orders and over-fetches by one", func(t *testing.T) {
+		page := pageRequest{active: true, limit: 20}
+		sql, _, _ := page.apply(base, true, goqu.C("datetime_create"), goqu.C("notification_id")).ToSQL()
+		assert.Contains(t, sql, `ORDER BY "datetime_create" DESC, "notification_id" DESC`)
+		assert.Contains(t, sql, "LIMIT 21")
+		assert.NotContains(t, sql, "WHERE")
+	})
+
+	t.Run("cursor continues after the last key", func(t *testing.T) {
+		page := pageRequest{active: true, limit: 20, after: []interface{}{"2026-03-01T10:00:00Z", int64(42)}}
+		sql, _, _ := page.apply(base, true, goqu.C("datetime_create"), goqu.C("notification_id")).ToSQL()
+		assert.Contains(t, sql, `("datetime_create", "notification_id") < ('2026-03-01T10:00:00Z', 42)`)
+
+		page.after = []interface{}{int64(3), int64(7)}
+		sql, _, _ = page.apply(base, false, goqu.C("display_sequence"), goqu.C("prayer_access_id")).ToSQL()
+		assert.True(t, strings.Contains(sql, `("display_sequence", "prayer_access_id") > (3, 7)`), sql)
+	})
+}
+
+func TestPageResults(t *testing.T) {
+	type row struct {
+		created time.Time
+		id      int
+	}
+	created := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	rows := []row{{created, 3}, {created, 2}, {created.Add(-time.Hour), 1}}
+	keyOf := func(r row) []interface{} { return []interface{}{r.created, r.id} }
+
+	t.Run("extra row means another page", func(t *testing.T) {
+		page := pageRequest{kind: "notifications", active: true, limit: 2}
+		trimmed, info := pageResults(page, rows, keyOf)
+
+		assert.Len(t, trimmed, 2)
+		assert.True(t, info.HasMore)
+		if assert.NotNil(t, info.NextCursor) {
+			values, err := decodePageCursor(*info.NextCursor, "notifications")
+			assert.NoError(t, err)
+			assert.Equal(t, []interface{}{"2026-03-01T10:00:00Z", int64(2)}, values)
+		}
+	})
+
+	t.Run("last page has no cursor", func(t *testing.T) {
+		page := pageRequest{kind: "notifications", active: true, limit: 5}
+		trimmed, info := pageResults(page, rows, keyOf)
+
+		assert.Len(t, trimmed, 3)
+		assert.False(t, info.HasMore)
+		assert.Nil(t, info.NextCursor)
+	})
+}
@@ -1,12 +1,17 @@
 package controllers
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/PrayerLoop/initializers"
@@ -146,8 +151,8 @@ func VerifyResetCode(c *gin.Context) {
 		log.Printf("Failed to update attempt count: %v", err)
 	}
 
-	// Generate a temporary token (valid for 5 minutes) for the final reset step
-	tempToken, err := createTempToken(user.User_Profile_ID)
+	// Generate a signed temporary token (valid for 5 minutes) bound to this reset row
+	tempToken, err := createResetToken(resetToken.Token_ID, user.User_Profile_ID, time.Now().Add(resetTokenTTL))
 	if err != nil {
 		log.Printf("Failed to generate temporary token: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
@@ -178,28 +183,14 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	// Decode the temporary token to get user ID
-	// For simplicity, we're using base64(userID:timestamp:code)
-	// In production, you might want to use JWT or store tokens in database
-	userID, valid := validateTempToken(req.Token)
-	if !valid {
+	// Verify the signature and expiry before touching the database
+	tokenID, userID, err := parseResetToken(req.Token, time.Now())
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 		return
 	}
 
-	// Get user
-	var user models.UserProfile
-	found, err := initializers.DB.From("user_profile").
-		Select("*").
-		Where(goqu.C("user_profile_id").Eq(userID)).
-		ScanStruct(&user)
-
-	if err != nil || !found {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-		return
-	}
-
-	// Hash the new password
+	// Hash before claiming so a slow or failed hash can't burn the token
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("Failed to hash password: %v", err)
@@ -207,31 +198,81 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	// Update password
-	updatePassword := initializers.DB.Update("user_profile").
-		Set(goqu.Record{
-			"password":        string(passwordHash),
-			"updated_by":      userID,
-			"datetime_update": time.Now(),
-		}).
-		Where(goqu.C("user_profile_id").Eq(userID)).
-		Executor()
-
-	if _, err := updatePassword.Exec(); err != nil {
-		log.Printf("Failed to update password: %v", err)
+	// Claiming the token and changing the password happen in one transaction,
+	// so a failed update leaves the token unused for another try
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		log.Printf("Failed to begin password reset: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
 		return
 	}
 
-	// Mark all reset tokens for this user as used
-	markUsed := initializers.DB.Update("password_reset_tokens").
-		Set(goqu.Record{"used": true}).
-		Where(goqu.C("user_profile_id").Eq(userID)).
-		Executor()
+	var user models.UserProfile
+	err = tx.Wrap(func() error {
+		// Claim the reset row. The used=false guard makes this the single point where
+		// a token is consumed, so a replayed token affects no rows.
+		result, err := tx.Update("password_reset_tokens").
+			Set(goqu.Record{"used": true}).
+			Where(goqu.And(
+				goqu.C("password_reset_tokens_id").Eq(tokenID),
+				goqu.C("user_profile_id").Eq(userID),
+				goqu.C("used").Eq(false),
+				goqu.C("expires_at").Gt(time.Now()),
+			)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to claim password reset token: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return errInvalidResetToken
+		}
+
+		found, err := tx.From("user_profile").
+			Select("*").
+			Where(goqu.C("user_profile_id").Eq(userID)).
+			ScanStruct(&user)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if !found {
+			return errInvalidResetToken
+		}
+
+		_, err = tx.Update("user_profile").
+			Set(goqu.Record{
+				"password":        string(passwordHash),
+				"updated_by":      userID,
+				"datetime_update": time.Now(),
+			}).
+			Where(goqu.C("user_profile_id").Eq(userID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+
+		// Mark any other outstanding reset tokens for this user as used
+		_, err = tx.Update("password_reset_tokens").
+			Set(goqu.Record{"used": true}).
+			Where(goqu.C("user_profile_id").Eq(userID)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to mark reset tokens as used: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, errInvalidResetToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to reset password for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
 
-	if _, err := markUsed.Exec(); err != nil {
-		log.Printf("Failed to mark reset tokens as used: %v", err)
-		// Non-critical error, continue
+	// Whoever requested the reset may not be the only one holding a session
+	if _, err := services.RevokeAllUserSessions(userID, 0); err != nil {
+		log.Printf("Failed to revoke sessions after password reset for user %d: %v", userID, err)
 	}
 
 	log.Printf("Password successfully reset for user %d (%s)", user.User_Profile_ID, user.Email)
@@ -255,44 +296,64 @@ func generate6DigitCode() (string, error) {
 	return code, nil
 }
 
-// Helper function to validate temporary token
-// In a production system, you might want to store these tokens in the database
-// or use JWT with expiration
-func validateTempToken(token string) (int, bool) {
-	// For now, we'll use a simple approach:
-	// Token format: base64(userID:timestamp)
-	// In production, use JWT or store tokens in database with expiration
+// resetTokenTTL is how long the signed token from VerifyResetCode stays valid
+const resetTokenTTL = 5 * time.Minute
 
-	// Decode base64
-	decoded, err := base64.URLEncoding.DecodeString(token)
-	if err != nil {
-		return 0, false
+var errInvalidResetToken = errors.New("invalid reset token")
+
+// createResetToken signs tokenID:userID:expiry with the server secret.
+// The token ID ties it to a single password_reset_tokens row, which
+// ResetPassword consumes, so the token cannot be reused.
+func createResetToken(tokenID int, userID int, expiresAt time.Time) (string, error) {
+	secret := os.Getenv("SECRET")
+	if secret == "" {
+		return "", errors.New("SECRET is not configured")
+	}
+
+	payload := fmt.Sprintf("%d:%d:%d", tokenID, userID, expiresAt.Unix())
+	encodedPayload := base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	return encodedPayload + "." + signResetPayload(encodedPayload, secret), nil
+}
+
+// parseResetToken checks the signature and expiry of a token produced by
+// createResetToken and returns the reset row ID and user ID it was issued for
+func parseResetToken(token string, now time.Time) (int, int, error) {
+	secret := os.Getenv("SECRET")
+	if secret == "" {
+		return 0, 0, errInvalidResetToken
 	}
 
-	// Parse userID:timestamp
-	var userID int
-	var timestamp int64
-	_, err = fmt.Sscanf(string(decoded), "%d:%d", &userID, &timestamp)
+	encodedPayload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, 0, errInvalidResetToken
+	}
+
+	expected := signResetPayload(encodedPayload, secret)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return 0, 0, errInvalidResetToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encodedPayload)
 	if err != nil {
-		return 0, false
+		return 0, 0, errInvalidResetToken
 	}
 
-	// Check if token is expired (5 minutes)
-	tokenTime := time.Unix(timestamp, 0)
-	if time.Since(tokenTime) > 5*time.Minute {
-		return 0, false
+	var tokenID, userID int
+	var expiresAt int64
+	if _, err := fmt.Sscanf(string(decoded), "%d:%d:%d", &tokenID, &userID, &expiresAt); err != nil {
+		return 0, 0, errInvalidResetToken
 	}
 
-	return userID, true
-}
+	if !now.Before(time.Unix(expiresAt, 0)) {
+		return 0, 0, errInvalidResetToken
+	}
 
-// Helper function to create temporary token (called from VerifyResetCode)
-func createTempToken(userID int) (string, error) {
-	// Create token: userID:timestamp
-	timestamp := time.Now().Unix()
-	tokenData := fmt.Sprintf("%d:%d", userID, timestamp)
+	return tokenID, userID, nil
+}
 
-	// Encode as base64
-	token := base64.URLEncoding.EncodeToString([]byte(tokenData))
-	return token, nil
+func signResetPayload(encodedPayload string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("password-reset:" + encodedPayload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -140,6 +142,9 @@ func TestForgotPassword(t *testing.T) {
 
 // Test VerifyResetCode - Verify 6-digit code and return temporary token
 func TestVerifyResetCode(t *testing.T) {
+	os.Setenv("SECRET", "test-secret-key")
+	defer os.Unsetenv("SECRET")
+
 	tests := []struct {
 		name            string
 		requestBody     interface{}
@@ -345,18 +350,30 @@ func TestVerifyResetCode(t *testing.T) {
 	}
 }
 
-// Test ResetPassword - Complete password reset using temporary token
+// Test ResetPassword - Complete password reset using signed single-use token
 func TestResetPassword(t *testing.T) {
-	// Create a valid temp token for testing
-	validToken, _ := createTempToken(1)
-	// Manually create an expired token (>5 minutes old)
-	// base64 of "1:0" where timestamp 0 = Jan 1, 1970
-	expiredToken := "MTow"
+	os.Setenv("SECRET", "test-secret-key")
+	defer os.Unsetenv("SECRET")
+
+	validToken, _ := createResetToken(1, 1, time.Now().Add(resetTokenTTL))
+	expiredToken, _ := createResetToken(1, 1, time.Now().Add(-time.Minute))
+
+	// Signed with a different secret
+	os.Setenv("SECRET", "attacker-secret")
+	forgedToken, _ := createResetToken(1, 1, time.Now().Add(resetTokenTTL))
+	os.Setenv("SECRET", "test-secret-key")
+
+	// Valid signature, but the payload is swapped for another user's
+	otherUserToken, _ := createResetToken(1, 2, time.Now().Add(resetTokenTTL))
+	_, validSignature, _ := strings.Cut(validToken, ".")
+	otherUserPayload, _, _ := strings.Cut(otherUserToken, ".")
+	tamperedToken := otherUserPayload + "." + validSignature
 
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		token          string
+		tokenClaimed   bool
+		tokenReplayed  bool
 		userExists     bool
 		updateFails    bool
 		expectedStatus int
@@ -368,9 +385,8 @@ func TestResetPassword(t *testing.T) {
 				Token:       validToken,
 				NewPassword: "newpassword123",
 			},
-			token:          validToken,
+			tokenClaimed:   true,
 			userExists:     true,
-			updateFails:    false,
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
@@ -380,9 +396,6 @@ func TestResetPassword(t *testing.T) {
 				Token:       validToken,
 				NewPassword: "short",
 			},
-			token:          validToken,
-			userExists:     true,
-			updateFails:    false,
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
 		},
@@ -392,9 +405,33 @@ func TestResetPassword(t *testing.T) {
 				Token:       "invalid-token",
 				NewPassword: "newpassword123",
 			},
-			token:          "invalid-token",
-			userExists:     false,
-			updateFails:    false,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name: "legacy unsigned token",
+			requestBody: models.ResetPasswordRequest{
+				Token:       "MTow",
+				NewPassword: "newpassword123",
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name: "forged token - wrong secret",
+			requestBody: models.ResetPasswordRequest{
+				Token:       forgedToken,
+				NewPassword: "newpassword123",
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name: "forged token - tampered payload",
+			requestBody: models.ResetPasswordRequest{
+				Token:       tamperedToken,
+				NewPassword: "newpassword123",
+			},
 			expectedStatus: http.StatusUnauthorized,
 			expectError:    true,
 		},
@@ -404,9 +441,16 @@ func TestResetPassword(t *testing.T) {
 				Token:       expiredToken,
 				NewPassword: "newpassword123",
 			},
-			token:          expiredToken,
-			userExists:     false,
-			updateFails:    false,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name: "replayed token - already used",
+			requestBody: models.ResetPasswordRequest{
+				Token:       validToken,
+				NewPassword: "newpassword123",
+			},
+			tokenReplayed:  true,
 			expectedStatus: http.StatusUnauthorized,
 			expectError:    true,
 		},
@@ -416,9 +460,8 @@ func TestResetPassword(t *testing.T) {
 				Token:       validToken,
 				NewPassword: "newpassword123",
 			},
-			token:          validToken,
+			tokenClaimed:   true,
 			userExists:     false,
-			updateFails:    false,
 			expectedStatus: http.StatusUnauthorized,
 			expectError:    true,
 		},
@@ -428,7 +471,7 @@ func TestResetPassword(t *testing.T) {
 				Token:       validToken,
 				NewPassword: "newpassword123",
 			},
-			token:          validToken,
+			tokenClaimed:   true,
 			userExists:     true,
 			updateFails:    true,
 			expectedStatus: http.StatusInternalServerError,
@@ -437,9 +480,6 @@ func TestResetPassword(t *testing.T) {
 		{
 			name:           "invalid JSON",
 			requestBody:    "{invalid json}",
-			token:          "",
-			userExists:     false,
-			updateFails:    false,
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
 		},
@@ -448,9 +488,6 @@ func TestResetPassword(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"newPassword": "newpassword123",
 			},
-			token:          "",
-			userExists:     false,
-			updateFails:    false,
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
 		},
@@ -461,35 +498,54 @@ func TestResetPassword(t *testing.T) {
 			_, mock, cleanup := SetupTestDB(t)
 			defer cleanup()
 
-			// Only mock database operations for valid tokens
-			if tt.token == validToken && tt.userExists {
-				// Mock user lookup
+			if tt.tokenReplayed {
+				// The used=false guard matches nothing on a second attempt
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE \"password_reset_tokens\"").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			}
+
+			if tt.tokenClaimed {
+				// Mock claiming the reset row
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE \"password_reset_tokens\"").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
 				now := time.Now()
-				userRows := sqlmock.NewRows([]string{
+				userColumns := []string{
 					"user_profile_id", "email", "first_name", "last_name", "password",
 					"datetime_create", "datetime_update", "created_by", "updated_by", "admin",
-				}).AddRow(1, "test@example.com", "Test", "User", "hashedpassword", now, now, 1, 1, false)
-				mock.ExpectQuery("SELECT").WillReturnRows(userRows)
+				}
+				if tt.userExists {
+					// Mock user lookup
+					mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(userColumns).
+						AddRow(1, "test@example.com", "Test", "User", "hashedpassword", now, now, 1, 1, false))
+				} else {
+					// Mock user not found
+					mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(userColumns))
+					mock.ExpectRollback()
+				}
 
-				if tt.updateFails {
-					// Mock failed password update
+				if tt.userExists && tt.updateFails {
+					// A failed update rolls back the claim, so the token can be used again
 					mock.ExpectExec("UPDATE \"user_profile\"").
 						WillReturnError(sqlmock.ErrCancelled)
-				} else {
+					mock.ExpectRollback()
+				} else if tt.userExists {
 					// Mock successful password update
 					mock.ExpectExec("UPDATE \"user_profile\"").
 						WillReturnResult(sqlmock.NewResult(0, 1))
 
-					// Mock marking tokens as used
+					// Mock marking remaining tokens as used
 					mock.ExpectExec("UPDATE \"password_reset_tokens\"").
 						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+
+					// Mock revoking all sessions
+					mock.ExpectExec("UPDATE \"user_session\"").
+						WillReturnResult(sqlmock.NewResult(0, 1))
 				}
-			} else if tt.token == validToken && !tt.userExists {
-				// Mock user not found
-				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{
-					"user_profile_id", "email", "first_name", "last_name", "password",
-					"datetime_create", "datetime_update", "created_by", "updated_by", "admin",
-				}))
 			}
 
 			c, w := SetupTestContext()
@@ -516,6 +572,35 @@ func TestResetPassword(t *testing.T) {
 			} else {
 				assert.NotNil(t, response["message"])
 			}
+
+			// Forged, expired and malformed tokens must be rejected before any query runs
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
+
+// Test parseResetToken - Signed token round trip
+func TestParseResetToken(t *testing.T) {
+	os.Setenv("SECRET", "test-secret-key")
+	defer os.Unsetenv("SECRET")
+
+	now := time.Now()
+	token, err := createResetToken(42, 7, now.Add(resetTokenTTL))
+	assert.NoError(t, err)
+
+	tokenID, userID, err := parseResetToken(token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, tokenID)
+	assert.Equal(t, 7, userID)
+
+	_, _, err = parseResetToken(token, now.Add(resetTokenTTL+time.Second))
+	assert.Error(t, err, "token should be rejected once expired")
+
+	os.Setenv("SECRET", "rotated-secret")
+	_, _, err = parseResetToken(token, now)
+	assert.Error(t, err, "token should be rejected after the secret changes")
+
+	os.Unsetenv("SECRET")
+	_, err = createResetToken(42, 7, now.Add(resetTokenTTL))
+	assert.Error(t, err, "token should not be issued without a secret")
+}
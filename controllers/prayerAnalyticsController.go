@@ -1,6 +1,9 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -50,108 +53,85 @@ func RecordPrayer(c *gin.Context) {
 		return
 	}
 
-	// Check if prayer_analytics record exists
-	var existingAnalytics models.PrayerAnalytics
-	analyticsFound, err := initializers.DB.From("prayer_analytics").
-		Where(goqu.C("prayer_id").Eq(prayerID)).
-		ScanStruct(&existingAnalytics)
-
+	analytics, counted, err := recordPrayerAnalytics(prayerID, userID)
 	if err != nil {
-		log.Printf("Failed to fetch prayer analytics: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer analytics"})
+		log.Printf("Failed to record prayer analytics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer analytics"})
 		return
 	}
 
-	if analyticsFound {
-		// Check 5-minute cooldown: if same user prayed within last 5 minutes, return existing stats
-		if existingAnalytics.Last_Prayed_By != nil && *existingAnalytics.Last_Prayed_By == userID &&
-			existingAnalytics.Datetime_Last_Prayed != nil {
-			timeSinceLastPrayer := time.Since(*existingAnalytics.Datetime_Last_Prayed)
-			if timeSinceLastPrayer < 5*time.Minute {
-				// Within cooldown - return existing stats without updating
-				c.JSON(http.StatusOK, gin.H{
-					"message": "Prayer recorded",
-					"analytics": models.PrayerAnalyticsResponse{
-						TotalPrayers:   existingAnalytics.Total_Prayers,
-						NumUniqueUsers: existingAnalytics.Num_Unique_Users,
-					},
-				})
-				return
-			}
-		}
-
-		// Update existing record
-		updateRecord := goqu.Record{
-			"total_prayers":        goqu.L("total_prayers + 1"),
-			"datetime_last_prayed": goqu.L("NOW()"),
-			"last_prayed_by":       userID,
-		}
-
-		// Increment num_unique_users if this is a different user than last time
-		if existingAnalytics.Last_Prayed_By == nil || *existingAnalytics.Last_Prayed_By != userID {
-			updateRecord["num_unique_users"] = goqu.L("num_unique_users + 1")
-		}
-
-		updateQuery := initializers.DB.Update("prayer_analytics").
-			Set(updateRecord).
-			Where(goqu.C("prayer_id").Eq(prayerID)).
-			Returning("total_prayers", "num_unique_users")
-
-		var updatedAnalytics struct {
-			Total_Prayers    int `db:"total_prayers"`
-			Num_Unique_Users int `db:"num_unique_users"`
-		}
-
-		_, err = updateQuery.Executor().ScanStruct(&updatedAnalytics)
-		if err != nil {
-			log.Printf("Failed to update prayer analytics: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer analytics"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Prayer recorded",
-			"analytics": models.PrayerAnalyticsResponse{
-				TotalPrayers:   updatedAnalytics.Total_Prayers,
-				NumUniqueUsers: updatedAnalytics.Num_Unique_Users,
-			},
-		})
-	} else {
-		// Create new record (omit prayer_analytics_id to let SERIAL auto-generate)
-		now := time.Now()
-		newAnalyticsRecord := goqu.Record{
-			"prayer_id":            prayerID,
-			"total_prayers":        1,
-			"datetime_last_prayed": now,
-			"last_prayed_by":       userID,
-			"num_unique_users":     1,
-			"num_shares":           0,
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Prayer recorded",
+		"analytics": analytics,
+		"counted":   counted,
+	})
+}
 
-		insert := initializers.DB.Insert("prayer_analytics").
-			Rows(newAnalyticsRecord).
-			Returning("total_prayers", "num_unique_users")
+// prayerCooldownMinutes is how long a user must wait before praying for the
+// same prayer counts again
+const prayerCooldownMinutes = 5
 
-		var insertedAnalytics struct {
-			Total_Prayers    int `db:"total_prayers"`
-			Num_Unique_Users int `db:"num_unique_users"`
-		}
+// recordPrayerAnalytics counts one prayer by userID against prayerID and returns
+// the updated aggregates. Callers are responsible for the access check.
+//
+// Everything happens in one statement so concurrent requests can't double count:
+//   - prayer_user_analytics holds one row per (prayer, user). The conditional
+//     upsert is the cooldown gate (same approach as shouldSendDebounced) and
+//     xmax = 0 tells us it was this user's first prayer for the prayer.
+//   - prayer_event is the append-only log the daily timeline is built from.
+//   - prayer_analytics keeps the running totals.
+//
+// Within the cooldown nothing is written and counted is false.
+func recordPrayerAnalytics(prayerID int, userID int) (models.PrayerAnalyticsResponse, bool, error) {
+	query := `
+		WITH gate AS (
+			INSERT INTO prayer_user_analytics (prayer_id, user_profile_id, prayer_count, datetime_first_prayed, datetime_last_prayed)
+			VALUES ($1, $2, 1, NOW(), NOW())
+			ON CONFLICT (prayer_id, user_profile_id)
+			DO UPDATE SET
+				prayer_count = prayer_user_analytics.prayer_count + 1,
+				datetime_last_prayed = NOW()
+			WHERE prayer_user_analytics.datetime_last_prayed < NOW() - make_interval(mins => $3)
+			RETURNING (xmax = 0) AS first_prayer
+		),
+		event AS (
+			INSERT INTO prayer_event (prayer_id, user_profile_id, datetime_prayed)
+			SELECT $1, $2, NOW() FROM gate
+		)
+		INSERT INTO prayer_analytics (prayer_id, total_prayers, datetime_last_prayed, last_prayed_by, num_unique_users, num_shares)
+		SELECT $1, 1, NOW(), $2, CASE WHEN gate.first_prayer THEN 1 ELSE 0 END, 0 FROM gate
+		ON CONFLICT (prayer_id)
+		DO UPDATE SET
+			total_prayers = prayer_analytics.total_prayers + 1,
+			datetime_last_prayed = NOW(),
+			last_prayed_by = EXCLUDED.last_prayed_by,
+			num_unique_users = prayer_analytics.num_unique_users + EXCLUDED.num_unique_users
+		RETURNING total_prayers, num_unique_users
+	`
 
-		_, err = insert.Executor().ScanStruct(&insertedAnalytics)
-		if err != nil {
-			log.Printf("Failed to create prayer analytics: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer analytics"})
-			return
-		}
+	var analytics models.PrayerAnalyticsResponse
+	err := initializers.DB.QueryRow(query, prayerID, userID, prayerCooldownMinutes).
+		Scan(&analytics.TotalPrayers, &analytics.NumUniqueUsers)
+	if err == nil {
+		return analytics, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return analytics, false, err
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Prayer recorded",
-			"analytics": models.PrayerAnalyticsResponse{
-				TotalPrayers:   insertedAnalytics.Total_Prayers,
-				NumUniqueUsers: insertedAnalytics.Num_Unique_Users,
-			},
-		})
+	// No rows means the gate rejected the prayer - report current totals unchanged
+	var existing models.PrayerAnalytics
+	_, err = initializers.DB.From("prayer_analytics").
+		Select("total_prayers", "num_unique_users").
+		Where(goqu.C("prayer_id").Eq(prayerID)).
+		ScanStruct(&existing)
+	if err != nil {
+		return analytics, false, err
 	}
+
+	analytics.TotalPrayers = existing.Total_Prayers
+	analytics.NumUniqueUsers = existing.Num_Unique_Users
+	return analytics, false, nil
 }
 
 // GetPrayerAnalytics retrieves aggregate analytics for a prayer
@@ -191,6 +171,15 @@ func GetPrayerAnalytics(c *gin.Context) {
 		return
 	}
 
+	days := defaultAnalyticsTimelineDays
+	if daysParam := c.Query("days"); daysParam != "" {
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days < 1 || days > maxAnalyticsTimelineDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxAnalyticsTimelineDays)})
+			return
+		}
+	}
+
 	// Query prayer_analytics for this prayer_id
 	var analytics models.PrayerAnalytics
 	analyticsFound, err := initializers.DB.From("prayer_analytics").
@@ -204,21 +193,73 @@ func GetPrayerAnalytics(c *gin.Context) {
 		return
 	}
 
-	if !analyticsFound {
-		// No analytics record exists yet - return zeros
-		c.JSON(http.StatusOK, gin.H{
-			"analytics": models.PrayerAnalyticsResponse{
-				TotalPrayers:   0,
-				NumUniqueUsers: 0,
-			},
-		})
+	// No analytics record yet leaves the totals at zero
+	response := models.PrayerAnalyticsResponse{}
+	if analyticsFound {
+		response.TotalPrayers = analytics.Total_Prayers
+		response.NumUniqueUsers = analytics.Num_Unique_Users
+	}
+
+	timeline, err := getPrayerTimeline(prayerID, days, time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to fetch prayer timeline: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer analytics"})
 		return
 	}
+	response.Timeline = timeline
 
 	c.JSON(http.StatusOK, gin.H{
-		"analytics": models.PrayerAnalyticsResponse{
-			TotalPrayers:   analytics.Total_Prayers,
-			NumUniqueUsers: analytics.Num_Unique_Users,
-		},
+		"analytics": response,
 	})
 }
+
+const (
+	defaultAnalyticsTimelineDays = 30
+	maxAnalyticsTimelineDays     = 365
+)
+
+// getPrayerTimeline returns one entry per UTC day for the last `days` days,
+// ending today, including days with no prayers
+func getPrayerTimeline(prayerID int, days int, now time.Time) ([]models.PrayerAnalyticsDay, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	var counts []struct {
+		Day          time.Time `db:"day"`
+		Prayer_Count int       `db:"prayer_count"`
+		Unique_Users int       `db:"unique_users"`
+	}
+	err := initializers.DB.From("prayer_event").
+		Select(
+			goqu.L("DATE(datetime_prayed AT TIME ZONE 'UTC')").As("day"),
+			goqu.COUNT("*").As("prayer_count"),
+			goqu.COUNT(goqu.DISTINCT("user_profile_id")).As("unique_users"),
+		).
+		Where(goqu.And(
+			goqu.C("prayer_id").Eq(prayerID),
+			goqu.C("datetime_prayed").Gte(since),
+		)).
+		GroupBy(goqu.L("DATE(datetime_prayed AT TIME ZONE 'UTC')")).
+		ScanStructs(&counts)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]models.PrayerAnalyticsDay, len(counts))
+	for _, count := range counts {
+		date := count.Day.Format("2006-01-02")
+		byDay[date] = models.PrayerAnalyticsDay{Date: date, Prayers: count.Prayer_Count, UniqueUsers: count.Unique_Users}
+	}
+
+	timeline := make([]models.PrayerAnalyticsDay, 0, days)
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		entry, ok := byDay[date]
+		if !ok {
+			entry = models.PrayerAnalyticsDay{Date: date}
+		}
+		timeline = append(timeline, entry)
+	}
+
+	return timeline, nil
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test RecordPrayer - Count a prayer once per cooldown window
+func TestRecordPrayer(t *testing.T) {
+	tests := []struct {
+		name            string
+		prayerID        string
+		hasAccess       bool
+		withinCooldown  bool
+		expectedStatus  int
+		expectedCounted bool
+		expectedTotals  models.PrayerAnalyticsResponse
+		expectError     bool
+	}{
+		{
+			name:            "prayer counted",
+			prayerID:        "1",
+			hasAccess:       true,
+			expectedStatus:  http.StatusOK,
+			expectedCounted: true,
+			expectedTotals:  models.PrayerAnalyticsResponse{TotalPrayers: 4, NumUniqueUsers: 2},
+		},
+		{
+			name:           "within cooldown returns existing totals",
+			prayerID:       "1",
+			hasAccess:      true,
+			withinCooldown: true,
+			expectedStatus: http.StatusOK,
+			expectedTotals: models.PrayerAnalyticsResponse{TotalPrayers: 3, NumUniqueUsers: 2},
+		},
+		{
+			name:           "no access to prayer",
+			prayerID:       "1",
+			hasAccess:      false,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "invalid prayer ID",
+			prayerID:       "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.prayerID != "abc" {
+				accessCount := 0
+				if tt.hasAccess {
+					accessCount = 1
+				}
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(accessCount))
+
+				if tt.hasAccess {
+					if tt.withinCooldown {
+						// The gate rejects the prayer so nothing is returned
+						mock.ExpectQuery("WITH gate AS").
+							WithArgs(1, 1, prayerCooldownMinutes).
+							WillReturnRows(sqlmock.NewRows([]string{"total_prayers", "num_unique_users"}))
+						mock.ExpectQuery("SELECT \"total_prayers\", \"num_unique_users\" FROM \"prayer_analytics\"").
+							WillReturnRows(sqlmock.NewRows([]string{"total_prayers", "num_unique_users"}).AddRow(3, 2))
+					} else {
+						mock.ExpectQuery("WITH gate AS").
+							WithArgs(1, 1, prayerCooldownMinutes).
+							WillReturnRows(sqlmock.NewRows([]string{"total_prayers", "num_unique_users"}).AddRow(4, 2))
+					}
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_id", Value: tt.prayerID}}
+			c.Request = httptest.NewRequest("POST", "/prayers/"+tt.prayerID+"/analytics", nil)
+
+			RecordPrayer(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectError {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Contains(t, response, "error")
+			} else {
+				var response struct {
+					Analytics models.PrayerAnalyticsResponse `json:"analytics"`
+					Counted   bool                           `json:"counted"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedCounted, response.Counted)
+				assert.Equal(t, tt.expectedTotals, response.Analytics)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test GetPrayerAnalytics - Aggregates plus a zero-filled daily timeline
+func TestGetPrayerAnalytics(t *testing.T) {
+	today := time.Now().UTC()
+	yesterday := today.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name             string
+		query            string
+		hasRecord        bool
+		expectedStatus   int
+		expectedDays     int
+		expectedTotals   [2]int
+		expectedLastDay  models.PrayerAnalyticsDay
+		expectedPrevious models.PrayerAnalyticsDay
+		expectError      bool
+	}{
+		{
+			name:            "analytics with timeline",
+			query:           "?days=7",
+			hasRecord:       true,
+			expectedStatus:  http.StatusOK,
+			expectedDays:    7,
+			expectedTotals:  [2]int{5, 3},
+			expectedLastDay: models.PrayerAnalyticsDay{Date: today.Format("2006-01-02"), Prayers: 3, UniqueUsers: 2},
+			expectedPrevious: models.PrayerAnalyticsDay{
+				Date: yesterday.Format("2006-01-02"),
+			},
+		},
+		{
+			name:            "no analytics record defaults to zero",
+			hasRecord:       false,
+			expectedStatus:  http.StatusOK,
+			expectedDays:    defaultAnalyticsTimelineDays,
+			expectedLastDay: models.PrayerAnalyticsDay{Date: today.Format("2006-01-02")},
+			expectedPrevious: models.PrayerAnalyticsDay{
+				Date: yesterday.Format("2006-01-02"),
+			},
+		},
+		{
+			name:           "days out of range",
+			query:          "?days=0",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			mock.ExpectQuery("SELECT COUNT").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+			if !tt.expectError {
+				analyticsRows := sqlmock.NewRows([]string{"total_prayers", "num_unique_users"})
+				timelineRows := sqlmock.NewRows([]string{"day", "prayer_count", "unique_users"})
+				if tt.hasRecord {
+					analyticsRows.AddRow(5, 3)
+					timelineRows.AddRow(today.Truncate(24*time.Hour), 3, 2)
+				}
+				mock.ExpectQuery("SELECT \"total_prayers\", \"num_unique_users\" FROM \"prayer_analytics\"").
+					WillReturnRows(analyticsRows)
+				mock.ExpectQuery("FROM \"prayer_event\"").
+					WillReturnRows(timelineRows)
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_id", Value: "1"}}
+			c.Request = httptest.NewRequest("GET", "/prayers/1/analytics"+tt.query, nil)
+
+			GetPrayerAnalytics(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectError {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Contains(t, response, "error")
+			} else {
+				var response struct {
+					Analytics models.PrayerAnalyticsResponse `json:"analytics"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Equal(t, tt.expectedTotals[0], response.Analytics.TotalPrayers)
+				assert.Equal(t, tt.expectedTotals[1], response.Analytics.NumUniqueUsers)
+				if assert.Len(t, response.Analytics.Timeline, tt.expectedDays) {
+					timeline := response.Analytics.Timeline
+					assert.Equal(t, tt.expectedLastDay, timeline[len(timeline)-1])
+					assert.Equal(t, tt.expectedPrevious, timeline[len(timeline)-2])
+				}
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/PrayerLoop/models"
 	"github.com/PrayerLoop/services"
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 )
 
 func GetPrayer(c *gin.Context) {
@@ -51,6 +52,7 @@ func GetPrayer(c *gin.Context) {
 			goqu.I("prayer.updated_by"),
 			goqu.I("prayer.datetime_update"),
 			goqu.I("prayer.deleted"),
+			goqu.I("prayer.is_archived"),
 			goqu.L("COALESCE(COUNT(DISTINCT prayer_comment.comment_id), 0)").As("comment_count"),
 		).
 		LeftJoin(goqu.T("prayer_access"), goqu.On(goqu.Ex{"prayer.prayer_id": goqu.I("prayer_access.prayer_id")})).
@@ -117,9 +119,21 @@ func GetPrayers(c *gin.Context) {
 
 	log.Println(user.User_Profile_ID)
 
+	page, err := parsePageRequest(c, "prayers")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filters, err := parsePrayerListFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var userPrayers []models.UserPrayer
 
-	err := initializers.DB.From("prayer_access").
+	query := initializers.DB.From("prayer_access").
 		Select(
 			goqu.DISTINCT("user_profile_id"),
 			goqu.Case().
@@ -128,6 +142,7 @@ func GetPrayers(c *gin.Context) {
 				Else(nil).
 				As("user_profile_id"),
 			goqu.I("prayer.prayer_id"),
+			goqu.I("prayer_access.prayer_access_id"),
 			goqu.I("prayer.prayer_type"),
 			goqu.I("prayer.is_private"),
 			goqu.I("prayer.title"),
@@ -140,6 +155,7 @@ func GetPrayers(c *gin.Context) {
 			goqu.I("prayer.updated_by"),
 			goqu.I("prayer.datetime_update"),
 			goqu.I("prayer.deleted"),
+			goqu.I("prayer.is_archived"),
 			goqu.L("COALESCE(COUNT(DISTINCT prayer_comment.comment_id), 0)").As("comment_count"),
 		).
 		Join(
@@ -170,28 +186,40 @@ func GetPrayers(c *gin.Context) {
 			),
 		).
 		Where(goqu.Ex{"user_group.user_profile_id": user.User_Profile_ID}).
-		GroupBy("prayer.prayer_id", "prayer_access.access_type", "prayer_access.access_type_id", "user_group.user_profile_id").
-		Order(goqu.I("prayer.prayer_id").Asc()).
-		ScanStructsContext(c, &userPrayers)
+		Where(filters.expressions()...).
+		GroupBy("prayer.prayer_id", "prayer_access.prayer_access_id", "prayer_access.access_type", "prayer_access.access_type_id", "user_group.user_profile_id").
+		Order(goqu.I("prayer.prayer_id").Asc())
+
+	// A prayer appears once per access row, so the access row is part of the key
+	query = page.apply(query, false, goqu.I("prayer.prayer_id"), goqu.I("prayer_access.prayer_access_id"))
+	err = query.ScanStructsContext(c, &userPrayers)
 
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
+	userPrayers, pageInfo := pageResults(page, userPrayers, func(prayer models.UserPrayer) []interface{} {
+		return []interface{}{prayer.Prayer_ID, prayer.Prayer_Access_ID}
+	})
+
 	if len(userPrayers) == 0 {
-		c.JSON(200, gin.H{"message": "No prayer records found."})
+		c.JSON(200, withPageInfo(gin.H{
+			"message": "No prayer records found.",
+			"prayers": []models.UserPrayer{},
+		}, page, pageInfo))
 		return
 	}
 
-	c.JSON(200, gin.H{
+	c.JSON(200, withPageInfo(gin.H{
 		"message": "Prayer records retrieved successfully.",
 		"prayers": userPrayers,
-	})
+	}, page, pageInfo))
 }
 
 func AddPrayerAccess(c *gin.Context) {
-	userID := c.MustGet("currentUser").(models.UserProfile).User_Profile_ID
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	userID := currentUser.User_Profile_ID
 	admin := c.MustGet("admin").(bool)
 
 	prayerId, err := strconv.Atoi(c.Param("prayer_id"))
@@ -354,21 +382,29 @@ func AddPrayerAccess(c *gin.Context) {
 				Updated_By:     userID,
 			}
 
-			insert := initializers.DB.Insert("prayer_access").Rows(prayerAccessInsert).Returning("prayer_access_id")
-
-			var insertedPrayerAccessID int
-			_, err = insert.Executor().ScanVal(&insertedPrayerAccessID)
+			// The access, its history entry and the share notifications commit together
+			tx, err := initializers.DB.Begin()
 			if err != nil {
-				log.Println(err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add prayer access record", "details": err.Error()})
 				return
 			}
 
-			// Auto-create user access when sharing to group (share-to-self fix)
-			if newPrayerAccess.Access_Type == "group" {
-				// Check if user access already exists
+			err = tx.Wrap(func() error {
+				insert := tx.Insert("prayer_access").Rows(prayerAccessInsert).Returning("prayer_access_id")
+
+				var insertedPrayerAccessID int
+				_, err := insert.Executor().ScanVal(&insertedPrayerAccessID)
+				if err != nil {
+					return err
+				}
+
+				if newPrayerAccess.Access_Type != "group" {
+					return nil
+				}
+
+				// Auto-create user access when sharing to group (share-to-self fix)
 				var existingUserAccess models.PrayerAccess
-				userAccessExists, _ := initializers.DB.From("prayer_access").
+				userAccessExists, err := tx.From("prayer_access").
 					Select("prayer_access_id").
 					Where(
 						goqu.C("prayer_id").Eq(prayerId),
@@ -376,6 +412,9 @@ func AddPrayerAccess(c *gin.Context) {
 						goqu.C("access_type_id").Eq(userID),
 					).
 					ScanStruct(&existingUserAccess)
+				if err != nil {
+					return err
+				}
 
 				if !userAccessExists {
 					userAccessInsert := models.PrayerAccess{
@@ -385,128 +424,36 @@ func AddPrayerAccess(c *gin.Context) {
 						Created_By:     userID,
 						Updated_By:     userID,
 					}
-					userInsert := initializers.DB.Insert("prayer_access").Rows(userAccessInsert)
-					_, userErr := userInsert.Executor().Exec()
-					if userErr != nil {
-						log.Printf("Failed to create user access for group share: %v", userErr)
-						// Non-fatal - group share still succeeded
-					}
-				}
-			}
-
-			// Log prayer share to history (async, non-blocking) - only for group shares
-			if newPrayerAccess.Access_Type == "group" {
-				go func(prayerID int, uid int) {
-					historyEntry := models.PrayerEditHistory{
-						Prayer_ID:       prayerID,
-						User_Profile_ID: uid,
-						Action_Type:     models.HistoryActionShared,
-					}
-					insertHistory := initializers.DB.Insert("prayer_edit_history").Rows(historyEntry)
-					_, err := insertHistory.Executor().Exec()
+					_, err = tx.Insert("prayer_access").Rows(userAccessInsert).Executor().Exec()
 					if err != nil {
-						log.Printf("Failed to log prayer share to history: %v", err)
-					}
-				}(prayerId, userID)
-			}
-
-			// Send circle notification for group shares (async)
-			if newPrayerAccess.Access_Type == "group" {
-				go func(groupID int, actorID int, prayerID int, creatorID int) {
-					// Get group name
-					groupName, err := GetGroupNameByID(groupID)
-					if err != nil {
-						log.Printf("Failed to get group name for notification: %v", err)
-						return
-					}
-
-					// Get actor display name
-					var actorName string
-					_, nameErr := initializers.DB.From("user_profile").
-						Select("first_name").
-						Where(goqu.C("user_profile_id").Eq(actorID)).
-						Executor().ScanVal(&actorName)
-					if nameErr != nil || actorName == "" {
-						_, nameErr = initializers.DB.From("user_profile").
-							Select("username").
-							Where(goqu.C("user_profile_id").Eq(actorID)).
-							Executor().ScanVal(&actorName)
-						if nameErr != nil {
-							actorName = "Someone" // Fallback if both queries fail
-						}
+						return fmt.Errorf("failed to create user access for group share: %w", err)
 					}
+				}
 
-					// Get linked subject if prayer has one
-					var linkedSubjectUserID *int
-					if existingPrayer.Prayer_Subject_ID != nil {
-						var subjectUserID int
-						found, _ := initializers.DB.From("prayer_subject").
-							Select("user_profile_id").
-							Where(
-								goqu.And(
-									goqu.C("prayer_subject_id").Eq(*existingPrayer.Prayer_Subject_ID),
-									goqu.C("link_status").Eq("linked"),
-									goqu.C("user_profile_id").IsNotNull(),
-								),
-							).ScanVal(&subjectUserID)
-						if found {
-							linkedSubjectUserID = &subjectUserID
-						}
-					}
+				// Log prayer share to history
+				historyEntry := models.PrayerEditHistory{
+					Prayer_ID:       prayerId,
+					User_Profile_ID: userID,
+					Action_Type:     models.HistoryActionShared,
+				}
+				_, err = tx.Insert("prayer_edit_history").Rows(historyEntry).Executor().Exec()
+				if err != nil {
+					return fmt.Errorf("failed to log prayer share to history: %w", err)
+				}
 
-					services.NotifyCircleOfPrayerShared(groupID, groupName, actorID, actorName, prayerID, creatorID, linkedSubjectUserID)
-				}(newPrayerAccess.Access_Type_ID, userID, prayerId, existingPrayer.Created_By)
+				return notifyPrayerSharedWithGroup(tx, newPrayerAccess.Access_Type_ID, currentUser, prayerId, existingPrayer.Created_By, existingPrayer.Prayer_Subject_ID)
+			})
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add prayer access record", "details": err.Error()})
+				return
 			}
 
-			// Send PRAYER_CREATED_FOR_YOU notification to linked subject (async)
-			if newPrayerAccess.Access_Type == "group" && existingPrayer.Prayer_Subject_ID != nil {
-				go func(subjectPrayerID int, existingPrayerSubjectID int, actorID int, groupID int) {
-					// Check if prayer has a linked subject
-					var subjectUserID int
-					found, err := initializers.DB.From("prayer_subject").
-						Select("user_profile_id").
-						Where(
-							goqu.And(
-								goqu.C("prayer_subject_id").Eq(existingPrayerSubjectID),
-								goqu.C("link_status").Eq("linked"),
-								goqu.C("user_profile_id").IsNotNull(),
-							),
-						).ScanVal(&subjectUserID)
-
-					if err != nil || !found {
-						return // No linked subject
-					}
-
-					// Don't notify if subject is the actor (sharing prayer about themselves)
-					if subjectUserID == actorID {
-						return
-					}
-
-					// Get actor display name
-					var actorName string
-					_, nameErr := initializers.DB.From("user_profile").
-						Select("first_name").
-						Where(goqu.C("user_profile_id").Eq(actorID)).
-						Executor().ScanVal(&actorName)
-					if nameErr != nil || actorName == "" {
-						_, nameErr = initializers.DB.From("user_profile").
-							Select("username").
-							Where(goqu.C("user_profile_id").Eq(actorID)).
-							Executor().ScanVal(&actorName)
-						if nameErr != nil {
-							actorName = "Someone" // Fallback if both queries fail
-						}
-					}
-
-					// Get group name
-					groupName, err := GetGroupNameByID(groupID)
-					if err != nil {
-						groupName = "a circle"
-					}
-
-					services.NotifySubjectOfPrayerCreated(subjectUserID, subjectPrayerID, groupID, actorID, actorName, groupName)
-				}(prayerId, *existingPrayer.Prayer_Subject_ID, userID, newPrayerAccess.Access_Type_ID)
-			}
+			services.PublishPrayerAccessEvent(services.EventPrayerShared, services.EventData{
+				PrayerID: prayerId,
+				GroupID:  groupIDForEvent(newPrayerAccess.Access_Type, newPrayerAccess.Access_Type_ID),
+				ActorID:  userID,
+			}, newPrayerAccess.Access_Type, newPrayerAccess.Access_Type_ID, userID)
 
 			c.JSON(http.StatusOK, gin.H{"message": "Prayer access added successfully"})
 		}
@@ -611,10 +558,18 @@ func RemovePrayerAccess(c *gin.Context) {
 			return
 		}
 
-		// Allow deletion if user is admin, prayer creator, or group creator
-		canDelete := admin || existingPrayer.Created_By == userID || group.Created_By == userID
-		log.Printf("[RemovePrayerAccess] Group access removal - userID: %d, prayerCreator: %d, groupCreator: %d, admin: %v, canDelete (before subject check): %v",
-			userID, existingPrayer.Created_By, group.Created_By, admin, canDelete)
+		// Allow deletion if user is admin, prayer creator, or a group owner/moderator
+		canDelete := admin || existingPrayer.Created_By == userID
+		if !canDelete {
+			role, err := getGroupRole(group.Group_Profile_ID, userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check group role", "details": err.Error()})
+				return
+			}
+			canDelete = groupRoleCan(role, groupActionRemovePrayer)
+		}
+		log.Printf("[RemovePrayerAccess] Group access removal - userID: %d, prayerCreator: %d, admin: %v, canDelete (before subject check): %v",
+			userID, existingPrayer.Created_By, admin, canDelete)
 
 		// Check if user is linked subject (needed for notification regardless of other auth)
 		var isLinkedSubject bool
@@ -660,7 +615,7 @@ func RemovePrayerAccess(c *gin.Context) {
 			}
 		}
 
-		// If not already authorized (admin/creator/group creator), linked subject can delete
+		// If not already authorized (admin/creator/group owner or moderator), linked subject can delete
 		if !canDelete && isLinkedSubject {
 			canDelete = true
 		}
@@ -700,37 +655,52 @@ func RemovePrayerAccess(c *gin.Context) {
 		// When user is deleting their own prayer (access_type = "user"), delete ALL prayer_access records
 		// and then delete the prayer itself
 		if existingPrayerAccess.Access_Type_ID == userID && existingPrayer.Created_By == userID {
-			// First, delete all prayer_access records for this prayer
-			deleteAllAccessQuery := initializers.DB.Delete("prayer_access").
-				Where(goqu.C("prayer_id").Eq(prayerId))
-
-			_, err := deleteAllAccessQuery.Executor().Exec()
+			// The tombstones, the access rows and the prayer go in one transaction
+			tx, err := initializers.DB.Begin()
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete all prayer access records", "details": err.Error()})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer", "details": err.Error()})
 				return
 			}
 
-			// Then, mark the prayer as deleted
-			deletePrayerQuery := initializers.DB.Update("prayer").
-				Set(goqu.Record{
-					"deleted": true,
-					"updated_by": userID,
-					"datetime_update": goqu.L("NOW()"),
-				}).
-				Where(goqu.C("prayer_id").Eq(prayerId))
+			var rowsAffected int64
+			err = tx.Wrap(func() error {
+				err := services.RecordPrayerSyncTombstonesTx(tx, goqu.I("prayer_access.prayer_id").Eq(prayerId))
+				if err != nil {
+					return err
+				}
 
-			result, err := deletePrayerQuery.Executor().Exec()
+				// First, delete all prayer_access records for this prayer
+				_, err = tx.Delete("prayer_access").
+					Where(goqu.C("prayer_id").Eq(prayerId)).
+					Executor().Exec()
+				if err != nil {
+					return fmt.Errorf("failed to delete all prayer access records: %w", err)
+				}
+
+				// Then, mark the prayer as deleted
+				result, err := tx.Update("prayer").
+					Set(goqu.Record{
+						"deleted":         true,
+						"updated_by":      userID,
+						"datetime_update": goqu.L("NOW()"),
+					}).
+					Where(goqu.C("prayer_id").Eq(prayerId)).
+					Executor().Exec()
+				if err != nil {
+					return fmt.Errorf("failed to delete prayer: %w", err)
+				}
+
+				rowsAffected, _ = result.RowsAffected()
+				if rowsAffected == 0 {
+					return fmt.Errorf("no prayer rows were deleted")
+				}
+				return nil
+			})
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer", "details": err.Error()})
 				return
 			}
 
-			rowsAffected, _ := result.RowsAffected()
-			if rowsAffected == 0 {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "No prayer rows were deleted"})
-				return
-			}
-
 			c.JSON(http.StatusOK, gin.H{"message": "Prayer and all access records removed successfully"})
 			return
 		}
@@ -764,19 +734,34 @@ func RemovePrayerAccess(c *gin.Context) {
 		}
 	}
 
-	// Default behavior: delete only the specific prayer_access record (for group deletions or other cases)
-	deleteQuery := initializers.DB.Delete("prayer_access").
-		Where(goqu.C("prayer_access_id").Eq(accessId))
+	// Default behavior: delete only the specific prayer_access record (for group deletions or other cases),
+	// recording its tombstone in the same transaction
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer access record", "details": err.Error()})
+		return
+	}
 
-	result, err := deleteQuery.Executor().Exec()
+	var rowsAffected int64
+	err = tx.Wrap(func() error {
+		if err := services.RecordPrayerAccessSyncTombstoneTx(tx, accessId); err != nil {
+			return err
+		}
 
+		result, err := tx.Delete("prayer_access").
+			Where(goqu.C("prayer_access_id").Eq(accessId)).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
+		rowsAffected, _ = result.RowsAffected()
+		return nil
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer access record", "details": err.Error()})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-
 	if rowsAffected == 0 {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No rows were deleted"})
 		return
@@ -796,6 +781,12 @@ func RemovePrayerAccess(c *gin.Context) {
 		)
 	}
 
+	services.PublishPrayerAccessEvent(services.EventPrayerUnshared, services.EventData{
+		PrayerID: prayerId,
+		GroupID:  groupIDForEvent(existingPrayerAccess.Access_Type, existingPrayerAccess.Access_Type_ID),
+		ActorID:  userID,
+	}, existingPrayerAccess.Access_Type, existingPrayerAccess.Access_Type_ID, existingPrayer.Created_By)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Prayer access removed successfully"})
 
 }
@@ -868,109 +859,204 @@ func UpdatePrayer(c *gin.Context) {
 		}
 	}
 
-	// if any incoming field is nil, retain the existing value
-	// pass updatedPrayer by reference to modify the original (underlying) struct
-	existingPrayerValue := reflect.ValueOf(existingPrayer)
-	updatedPrayerValue := reflect.ValueOf(&updatedPrayer).Elem()
+	// The edit, its history entry and the PRAYER_EDITED_BY_SUBJECT notification commit together
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer record", "details": err.Error()})
+		return
+	}
+
+	var rowsAffected int64
+	actionType := models.HistoryActionEdited
+	err = tx.Wrap(func() error {
+		// Read the prayer again under a row lock, so the history's previous
+		// version is the one this edit replaces even if another edit got in first
+		var current models.Prayer
+		found, err := tx.From("prayer").
+			Where(goqu.C("prayer_id").Eq(prayerId), goqu.C("deleted").Eq(false)).
+			ForUpdate(exp.Wait).
+			ScanStruct(&current)
+		if err != nil || !found {
+			return err
+		}
 
-	// if any field in updatedPrayer is zero value, get the corresponding field value
-	// from the existingPrayer struct
-	for i := 0; i < updatedPrayerValue.NumField(); i++ {
-		field := updatedPrayerValue.Field(i)
-		if field.IsZero() {
-			existingField := existingPrayerValue.Field(i)
-			if field.Type().AssignableTo(existingField.Type()) {
-				field.Set(existingField)
+		// if any incoming field is nil, retain the existing value
+		// pass updatedPrayer by reference to modify the original (underlying) struct
+		currentValue := reflect.ValueOf(current)
+		updatedPrayerValue := reflect.ValueOf(&updatedPrayer).Elem()
+
+		// if any field in updatedPrayer is zero value, get the corresponding field value
+		// from the current prayer
+		for i := 0; i < updatedPrayerValue.NumField(); i++ {
+			field := updatedPrayerValue.Field(i)
+			if field.IsZero() {
+				currentField := currentValue.Field(i)
+				if field.Type().AssignableTo(currentField.Type()) {
+					field.Set(currentField)
+				}
 			}
 		}
-	}
 
-	// Auto-set datetime_answered when marking as answered for the first time
-	if updatedPrayer.Is_Answered != nil && *updatedPrayer.Is_Answered &&
-		(existingPrayer.Is_Answered == nil || !*existingPrayer.Is_Answered) &&
-		updatedPrayer.Datetime_Answered == nil {
-		now := time.Now()
-		updatedPrayer.Datetime_Answered = &now
-	}
+		// Auto-set datetime_answered when marking as answered for the first time,
+		// and log it as "answered" rather than "edited"
+		if updatedPrayer.Is_Answered != nil && *updatedPrayer.Is_Answered &&
+			(current.Is_Answered == nil || !*current.Is_Answered) {
+			actionType = models.HistoryActionAnswered
+			if updatedPrayer.Datetime_Answered == nil {
+				now := time.Now()
+				updatedPrayer.Datetime_Answered = &now
+			}
+		}
 
-	updateQuery := initializers.DB.Update("prayer").
-		Set(goqu.Record{
-			"prayer_type":        updatedPrayer.Prayer_Type,
-			"is_private":         updatedPrayer.Is_Private,
-			"title":              updatedPrayer.Title,
-			"prayer_description": updatedPrayer.Prayer_Description,
-			"is_answered":        updatedPrayer.Is_Answered,
-			"datetime_answered":  updatedPrayer.Datetime_Answered,
-			"prayer_priority":    updatedPrayer.Prayer_Priority,
-			"prayer_subject_id":  updatedPrayer.Prayer_Subject_ID,
-			"updated_by":         userID,
-			"datetime_update":    goqu.L("NOW()"),
-		}).
-		Where(goqu.C("prayer_id").Eq(prayerId))
+		result, err := tx.Update("prayer").
+			Set(goqu.Record{
+				"prayer_type":        updatedPrayer.Prayer_Type,
+				"is_private":         updatedPrayer.Is_Private,
+				"title":              updatedPrayer.Title,
+				"prayer_description": updatedPrayer.Prayer_Description,
+				"is_answered":        updatedPrayer.Is_Answered,
+				"datetime_answered":  updatedPrayer.Datetime_Answered,
+				"prayer_priority":    updatedPrayer.Prayer_Priority,
+				"prayer_subject_id":  updatedPrayer.Prayer_Subject_ID,
+				"updated_by":         userID,
+				"datetime_update":    goqu.L("NOW()"),
+			}).
+			Where(goqu.C("prayer_id").Eq(prayerId)).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
 
-	result, err := updateQuery.Executor().Exec()
+		rowsAffected, _ = result.RowsAffected()
+		if rowsAffected == 0 {
+			return nil
+		}
 
+		// Log prayer edit to history with the before and after versions
+		historyEntry := models.PrayerEditHistory{
+			Prayer_ID:        prayerId,
+			User_Profile_ID:  userID,
+			Action_Type:      actionType,
+			Previous_Version: encodePrayerVersion(prayerVersionOf(current)),
+			New_Version: encodePrayerVersion(models.PrayerVersion{
+				Title:              updatedPrayer.Title,
+				Prayer_Description: updatedPrayer.Prayer_Description,
+				Prayer_Priority:    updatedPrayer.Prayer_Priority,
+				Prayer_Subject_ID:  updatedPrayer.Prayer_Subject_ID,
+				Is_Private:         updatedPrayer.Is_Private,
+				Is_Answered:        updatedPrayer.Is_Answered,
+			}),
+		}
+		_, err = tx.Insert("prayer_edit_history").Rows(historyEntry).Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to log prayer %s to history: %w", actionType, err)
+		}
+
+		if !isSubjectEdit {
+			return nil
+		}
+		return services.NotifyCreatorOfSubjectEdit(tx, current.Created_By, prayerId, userID, getDisplayName(userID))
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer record", "details": err.Error()})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-
 	if rowsAffected == 0 {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No rows were updated"})
 		return
 	}
 
-	// Determine action type - use "answered" if prayer is being marked answered for first time
-	actionType := models.HistoryActionEdited
-	if updatedPrayer.Is_Answered != nil && *updatedPrayer.Is_Answered &&
-		(existingPrayer.Is_Answered == nil || !*existingPrayer.Is_Answered) {
-		actionType = models.HistoryActionAnswered
+	eventType := services.EventPrayerUpdated
+	if actionType == models.HistoryActionAnswered {
+		eventType = services.EventPrayerAnswered
 	}
+	go services.PublishPrayerEvent(eventType, services.EventData{PrayerID: prayerId, ActorID: userID})
 
-	// Log prayer edit to history (async, non-blocking)
-	go func(prayerID int, uid int, action string) {
-		historyEntry := models.PrayerEditHistory{
-			Prayer_ID:       prayerID,
-			User_Profile_ID: uid,
-			Action_Type:     action,
-		}
-		insert := initializers.DB.Insert("prayer_edit_history").Rows(historyEntry)
-		_, err := insert.Executor().Exec()
-		if err != nil {
-			log.Printf("Failed to log prayer %s to history: %v", action, err)
-		}
-	}(prayerId, userID, actionType)
+	c.JSON(http.StatusOK, gin.H{"message": "Prayer record updated successfully"})
 
-	// Send PRAYER_EDITED_BY_SUBJECT notification to creator (async)
-	if isSubjectEdit {
-		go func(creatorID int, pID int, subjectUID int) {
-			// Get subject's display name
-			var subjectName string
-			_, nameErr := initializers.DB.From("user_profile").
-				Select("first_name").
-				Where(goqu.C("user_profile_id").Eq(subjectUID)).
-				Executor().ScanVal(&subjectName)
-			if nameErr != nil || subjectName == "" {
-				_, nameErr = initializers.DB.From("user_profile").
-					Select("username").
-					Where(goqu.C("user_profile_id").Eq(subjectUID)).
-					Executor().ScanVal(&subjectName)
-				if nameErr != nil {
-					subjectName = "Someone" // Fallback if both queries fail
-				}
-			}
-			if subjectName == "" {
-				subjectName = "Someone"
-			}
+}
 
-			services.NotifyCreatorOfSubjectEdit(creatorID, pID, subjectUID, subjectName)
-		}(existingPrayer.Created_By, prayerId, userID)
+// ArchivePrayer archives a prayer the user created, or restores it with
+// archived false. Archived prayers stay shared and listed (filter them with
+// ?archived=false) but get no follow-up prompts.
+func ArchivePrayer(c *gin.Context) {
+	userID := c.MustGet("currentUser").(models.UserProfile).User_Profile_ID
+	admin := c.MustGet("admin").(bool)
+
+	prayerID, err := strconv.Atoi(c.Param("prayer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer ID", "details": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Prayer record updated successfully"})
+	var request models.PrayerArchiveRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	var prayer models.Prayer
+	found, err := initializers.DB.From("prayer").
+		Select("prayer_id", "created_by", "is_archived").
+		Where(goqu.C("prayer_id").Eq(prayerID), goqu.C("deleted").IsFalse()).
+		ScanStruct(&prayer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get prayer", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer record not found"})
+		return
+	}
+	if !admin && prayer.Created_By != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the prayer creator can archive it"})
+		return
+	}
+
+	archived := *request.Archived
+	if prayer.Is_Archived == archived {
+		c.JSON(http.StatusOK, gin.H{"message": "Prayer archive status unchanged", "isArchived": archived})
+		return
+	}
 
+	var archivedAt interface{}
+	actionType := models.HistoryActionUnarchived
+	if archived {
+		archivedAt = goqu.L("NOW()")
+		actionType = models.HistoryActionArchived
+	}
+
+	_, err = initializers.DB.Update("prayer").
+		Set(goqu.Record{
+			"is_archived":       archived,
+			"datetime_archived": archivedAt,
+			"updated_by":        userID,
+			"datetime_update":   goqu.L("NOW()"),
+		}).
+		Where(goqu.C("prayer_id").Eq(prayerID)).
+		Executor().Exec()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive prayer", "details": err.Error()})
+		return
+	}
+
+	historyEntry := models.PrayerEditHistory{
+		Prayer_ID:       prayerID,
+		User_Profile_ID: userID,
+		Action_Type:     actionType,
+	}
+	if _, err := initializers.DB.Insert("prayer_edit_history").Rows(historyEntry).Executor().Exec(); err != nil {
+		log.Printf("Failed to log prayer %s to history: %v", actionType, err)
+	}
+
+	go services.PublishPrayerEvent(services.EventPrayerUpdated, services.EventData{PrayerID: prayerID, ActorID: userID})
+
+	message := "Prayer restored successfully"
+	if archived {
+		message = "Prayer archived successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "isArchived": archived})
 }
 
 func DeletePrayer(c *gin.Context) {
@@ -1074,22 +1160,29 @@ func DeletePrayer(c *gin.Context) {
 		if err != nil {
 			log.Printf("Failed to log prayer deletion to history: %v", err)
 		}
+
+		services.PublishPrayerEvent(services.EventPrayerDeleted, services.EventData{PrayerID: prayerID, ActorID: uid})
 	}(prayerId, userID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Prayer record marked as deleted successfully"})
 }
 
-// HistoryEntry represents a single entry in the prayer edit history response
+// HistoryEntry represents a single entry in the prayer edit history response.
+// Changes lists the fields an edit or restore changed.
 type HistoryEntry struct {
-	History_ID      int       `json:"historyId" db:"prayer_edit_history_id"`
-	Action_Type     string    `json:"actionType" db:"action_type"`
-	Actor_ID        int       `json:"actorId" db:"user_profile_id"`
-	Actor_Name      string    `json:"actorName" db:"actor_name"`
-	DateTime_Create time.Time `json:"datetimeCreate" db:"datetime_create"`
+	History_ID       int                        `json:"historyId" db:"prayer_edit_history_id"`
+	Action_Type      string                     `json:"actionType" db:"action_type"`
+	Actor_ID         int                        `json:"actorId" db:"user_profile_id"`
+	Actor_Name       string                     `json:"actorName" db:"actor_name"`
+	Restored_From_ID *int                       `json:"restoredFromId,omitempty" db:"restored_from_id"`
+	Previous_Version *string                    `json:"-" db:"previous_version"`
+	New_Version      *string                    `json:"-" db:"new_version"`
+	Changes          []models.PrayerFieldChange `json:"changes,omitempty" db:"-"`
+	DateTime_Create  time.Time                  `json:"datetimeCreate" db:"datetime_create"`
 }
 
-// GetPrayerHistory returns the chronological edit history of a prayer.
-// Only the prayer creator can view history (privacy requirement).
+// GetPrayerHistory returns the chronological edit history of a prayer, with
+// the fields each edit changed. Anyone the prayer is shared with can view it.
 func GetPrayerHistory(c *gin.Context) {
 	userID := c.MustGet("currentUser").(models.UserProfile).User_Profile_ID
 	admin := c.MustGet("admin").(bool)
@@ -1100,6 +1193,22 @@ func GetPrayerHistory(c *gin.Context) {
 		return
 	}
 
+	page, err := parsePageRequest(c, "prayer_history")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actionType := c.Query("actionType")
+	switch actionType {
+	case "", models.HistoryActionCreated, models.HistoryActionEdited, models.HistoryActionAnswered,
+		models.HistoryActionShared, models.HistoryActionDeleted, models.HistoryActionArchived,
+		models.HistoryActionUnarchived, models.HistoryActionUpdatePosted, models.HistoryActionRestored:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action type"})
+		return
+	}
+
 	// Fetch the prayer to check authorization
 	var prayer models.Prayer
 	prayerFound, err := initializers.DB.From("prayer").
@@ -1153,12 +1262,15 @@ func GetPrayerHistory(c *gin.Context) {
 
 	// Fetch history with actor names
 	var history []HistoryEntry
-	err = initializers.DB.From("prayer_edit_history").
+	query := initializers.DB.From("prayer_edit_history").
 		Select(
 			goqu.I("prayer_edit_history.prayer_edit_history_id"),
 			goqu.I("prayer_edit_history.action_type"),
 			goqu.I("prayer_edit_history.user_profile_id"),
 			goqu.L("COALESCE(user_profile.first_name, user_profile.username, 'Unknown')").As("actor_name"),
+			goqu.I("prayer_edit_history.restored_from_id"),
+			goqu.I("prayer_edit_history.previous_version"),
+			goqu.I("prayer_edit_history.new_version"),
 			goqu.I("prayer_edit_history.datetime_create"),
 		).
 		Join(
@@ -1166,17 +1278,28 @@ func GetPrayerHistory(c *gin.Context) {
 			goqu.On(goqu.I("prayer_edit_history.user_profile_id").Eq(goqu.I("user_profile.user_profile_id"))),
 		).
 		Where(goqu.I("prayer_edit_history.prayer_id").Eq(prayerID)).
-		Order(goqu.I("prayer_edit_history.datetime_create").Asc()).
-		ScanStructs(&history)
+		Order(goqu.I("prayer_edit_history.datetime_create").Asc())
+
+	if actionType != "" {
+		query = query.Where(goqu.I("prayer_edit_history.action_type").Eq(actionType))
+	}
+
+	query = page.apply(query, false, goqu.I("prayer_edit_history.datetime_create"), goqu.I("prayer_edit_history.prayer_edit_history_id"))
+	err = query.ScanStructs(&history)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer history", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"history": history,
+	history, pageInfo := pageResults(page, history, func(entry HistoryEntry) []interface{} {
+		return []interface{}{entry.DateTime_Create, entry.History_ID}
 	})
+	historyEntryChanges(history)
+
+	c.JSON(http.StatusOK, withPageInfo(gin.H{
+		"history": history,
+	}, page, pageInfo))
 }
 
 func GetPrayerAccessRecords(c *gin.Context) {
@@ -1253,3 +1376,72 @@ func GetPrayerAccessRecords(c *gin.Context) {
 		"groupIds": groupIds,
 	})
 }
+
+// getDisplayName returns the user's first name, or their username when they
+// haven't set one, for notification messages
+func getDisplayName(userID int) string {
+	var user models.UserProfile
+	found, err := initializers.DB.From("user_profile").
+		Select("username", "first_name").
+		Where(goqu.C("user_profile_id").Eq(userID)).
+		ScanStruct(&user)
+	if err != nil || !found {
+		return "Someone"
+	}
+	if user.First_Name != "" {
+		return user.First_Name
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	return "Someone"
+}
+
+// groupIDForEvent is the group a prayer_access row shares with, or 0
+func groupIDForEvent(accessType string, accessTypeID int) int {
+	if accessType == "group" {
+		return accessTypeID
+	}
+	return 0
+}
+
+// notifyPrayerSharedWithGroup tells the group's members that a prayer was
+// shared with it, and tells the prayer's linked subject it was made for them.
+// It runs in the share's transaction.
+func notifyPrayerSharedWithGroup(tx *goqu.TxDatabase, groupID int, actor models.UserProfile, prayerID int, creatorID int, prayerSubjectID *int) error {
+	groupName, err := GetGroupNameByID(groupID)
+	if err != nil {
+		return err
+	}
+
+	actorName := actor.First_Name
+	if actorName == "" {
+		actorName = actor.Username
+	}
+
+	// Get linked subject if prayer has one
+	var linkedSubjectUserID *int
+	if prayerSubjectID != nil {
+		var subjectUserID int
+		found, err := initializers.DB.From("prayer_subject").
+			Select("user_profile_id").
+			Where(
+				goqu.C("prayer_subject_id").Eq(*prayerSubjectID),
+				goqu.C("link_status").Eq("linked"),
+				goqu.C("user_profile_id").IsNotNull(),
+			).
+			ScanVal(&subjectUserID)
+		if err != nil {
+			return fmt.Errorf("failed to get linked subject: %v", err)
+		}
+		if found {
+			linkedSubjectUserID = &subjectUserID
+		}
+	}
+
+	err = services.NotifyCircleOfPrayerShared(tx, groupID, groupName, actor.User_Profile_ID, actorName, prayerID, creatorID, linkedSubjectUserID)
+	if err != nil || linkedSubjectUserID == nil {
+		return err
+	}
+	return services.NotifySubjectOfPrayerCreated(tx, *linkedSubjectUserID, prayerID, groupID, actor.User_Profile_ID, actorName, groupName)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 	"time"
 
@@ -207,6 +208,77 @@ func TestGetPrayers(t *testing.T) {
 	}
 }
 
+// Test GetPrayers pagination - Following nextCursor continues after the last
+// access row instead of repeating the prayer it belongs to
+func TestGetPrayersPagination(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	columns := []string{
+		"user_profile_id", "prayer_id", "prayer_access_id", "prayer_type", "is_private", "title",
+		"prayer_description", "is_answered", "prayer_priority", "datetime_answered",
+		"created_by", "datetime_create", "updated_by", "datetime_update", "deleted",
+	}
+
+	// Page 1: limit 2, one extra row fetched to detect the next page. Prayer 1
+	// is shared twice, so it shows up once per access row.
+	mock.ExpectQuery(`SELECT .*"prayer_access"."prayer_access_id".* LIMIT 3`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 1, 10, "personal", false, "First", "", false, 1, nil, 1, now, 1, now, false).
+			AddRow(1, 1, 11, "personal", false, "First", "", false, 1, nil, 1, now, 1, now, false).
+			AddRow(1, 2, 12, "personal", false, "Second", "", false, 1, nil, 1, now, 1, now, false))
+
+	c, w := SetupTestContext()
+	SetAuthenticatedUser(c, MockAdminUser(), true)
+	c.Request = httptest.NewRequest("GET", "/prayers?limit=2", nil)
+
+	GetPrayers(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var firstPage struct {
+		Prayers    []models.UserPrayer `json:"prayers"`
+		Pagination models.PageInfo     `json:"pagination"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &firstPage))
+	if !assert.Len(t, firstPage.Prayers, 2) {
+		return
+	}
+	assert.Equal(t, 11, firstPage.Prayers[1].Prayer_Access_ID)
+	assert.True(t, firstPage.Pagination.HasMore)
+	if !assert.NotNil(t, firstPage.Pagination.NextCursor) {
+		return
+	}
+
+	// Page 2 starts after (prayer 1, access row 11)
+	mock.ExpectQuery(regexp.QuoteMeta(`("prayer"."prayer_id", "prayer_access"."prayer_access_id") > (1, 11)`)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 2, 12, "personal", false, "Second", "", false, 1, nil, 1, now, 1, now, false))
+
+	c, w = SetupTestContext()
+	SetAuthenticatedUser(c, MockAdminUser(), true)
+	c.Request = httptest.NewRequest("GET", "/prayers?limit=2&cursor="+*firstPage.Pagination.NextCursor, nil)
+
+	GetPrayers(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var secondPage struct {
+		Prayers    []models.UserPrayer `json:"prayers"`
+		Pagination models.PageInfo     `json:"pagination"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &secondPage))
+	if !assert.Len(t, secondPage.Prayers, 1) {
+		return
+	}
+	assert.Equal(t, 2, secondPage.Prayers[0].Prayer_ID)
+	assert.False(t, secondPage.Pagination.HasMore)
+	assert.Nil(t, secondPage.Pagination.NextCursor)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // Test AddPrayerAccess - Share a prayer with a user or group
 func TestAddPrayerAccess(t *testing.T) {
 	tests := []struct {
@@ -357,8 +429,22 @@ func TestAddPrayerAccess(t *testing.T) {
 
 						// If authorized (hasPermission or isAdmin), mock the insert
 						if tt.hasPermission || tt.isAdmin {
+							mock.ExpectBegin()
 							mock.ExpectQuery("INSERT INTO \"prayer_access\"").
 								WillReturnRows(sqlmock.NewRows([]string{"prayer_access_id"}).AddRow(1))
+
+							// A group share also gives the sharer user access, logs the share
+							// and notifies the circle, all in the same transaction
+							if tt.accessData.Access_Type == "group" {
+								mock.ExpectQuery("SELECT \"prayer_access_id\" FROM \"prayer_access\"").
+									WillReturnRows(sqlmock.NewRows([]string{"prayer_access_id"}))
+								mock.ExpectExec("INSERT INTO \"prayer_access\"").
+									WillReturnResult(sqlmock.NewResult(2, 1))
+								mock.ExpectExec("INSERT INTO \"prayer_edit_history\" .*'shared'").
+									WillReturnResult(sqlmock.NewResult(1, 1))
+								ExpectNotifications(mock, true, 3)
+							}
+							mock.ExpectCommit()
 						}
 					}
 				} else {
@@ -391,6 +477,7 @@ func TestAddPrayerAccess(t *testing.T) {
 			} else {
 				assert.NotNil(t, response["message"])
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -554,6 +641,11 @@ func TestRemovePrayerAccess(t *testing.T) {
 							// Only mock DELETE/UPDATE for authorized users
 							if tt.isOwner {
 								if tt.removingOwn {
+									// Prayer tombstones for everyone who can see it
+									mock.ExpectBegin()
+									mock.ExpectExec("INSERT INTO \"sync_tombstone\"").
+										WillReturnResult(sqlmock.NewResult(0, 2))
+
 									// Owner removing own access - cascade delete all access records
 									mock.ExpectExec("DELETE FROM \"prayer_access\"").
 										WillReturnResult(sqlmock.NewResult(0, 2))
@@ -561,10 +653,15 @@ func TestRemovePrayerAccess(t *testing.T) {
 									// Soft delete the prayer
 									mock.ExpectExec("UPDATE \"prayer\"").
 										WillReturnResult(sqlmock.NewResult(0, 1))
+									mock.ExpectCommit()
 								} else {
-									// Normal access removal
+									// Access tombstones, then normal access removal
+									mock.ExpectBegin()
+									mock.ExpectExec("INSERT INTO \"sync_tombstone\"").
+										WillReturnResult(sqlmock.NewResult(0, 1))
 									mock.ExpectExec("DELETE FROM \"prayer_access\"").
 										WillReturnResult(sqlmock.NewResult(0, 1))
+									mock.ExpectCommit()
 								}
 							}
 					} else if tt.accessID != "invalid" {
@@ -747,13 +844,15 @@ func TestUpdatePrayer(t *testing.T) {
 					if !tt.isCreator {
 						creatorID = 2
 					}
-					prayerRows := sqlmock.NewRows([]string{
-						"prayer_id", "prayer_type", "is_private", "title", "prayer_description",
-						"is_answered", "prayer_priority", "datetime_answered", "created_by",
-						"datetime_create", "updated_by", "datetime_update", "deleted",
-						"prayer_subject_id", "subject_display_sequence",
-					}).AddRow(1, "personal", false, "Original prayer title", "Original prayer description", false, 1, nil, creatorID, now, creatorID, now, false, tt.prayerSubjectID, 0)
-					mock.ExpectQuery("SELECT").WillReturnRows(prayerRows)
+					prayerRows := func() *sqlmock.Rows {
+						return sqlmock.NewRows([]string{
+							"prayer_id", "prayer_type", "is_private", "title", "prayer_description",
+							"is_answered", "prayer_priority", "datetime_answered", "created_by",
+							"datetime_create", "updated_by", "datetime_update", "deleted",
+							"prayer_subject_id", "subject_display_sequence",
+						}).AddRow(1, "personal", false, "Original prayer title", "Original prayer description", false, 1, nil, creatorID, now, creatorID, now, false, tt.prayerSubjectID, 0)
+					}
+					mock.ExpectQuery("SELECT").WillReturnRows(prayerRows())
 
 					// If not creator and has subject, mock subject lookup
 					if !tt.isCreator && tt.prayerSubjectID != nil {
@@ -767,8 +866,29 @@ func TestUpdatePrayer(t *testing.T) {
 					isLinkedSubject := tt.subjectLinkStatus == "linked" && tt.subjectUserID != nil && *tt.subjectUserID == 1
 					isForbidden := tt.expectedStatus == http.StatusForbidden
 					if tt.isCreator || (isLinkedSubject && !isForbidden) {
+						// The prayer is read again under a row lock for the history's previous version
+						mock.ExpectBegin()
+						mock.ExpectQuery(`SELECT .* FROM "prayer" .* FOR UPDATE`).WillReturnRows(prayerRows())
 						mock.ExpectExec("UPDATE \"prayer\"").
 							WillReturnResult(sqlmock.NewResult(0, 1))
+						actionType := models.HistoryActionEdited
+						if tt.updateData.Is_Answered != nil && *tt.updateData.Is_Answered {
+							actionType = models.HistoryActionAnswered
+						}
+						mock.ExpectExec(`INSERT INTO "prayer_edit_history" .*'` + actionType + `'.*"title":"Original prayer title"`).
+							WillReturnResult(sqlmock.NewResult(1, 1))
+						if !tt.isCreator {
+							// The creator is notified of the subject's edit in the same transaction
+							mock.ExpectQuery(`SELECT "username", "first_name" FROM "user_profile"`).
+								WillReturnRows(sqlmock.NewRows([]string{"username", "first_name"}).AddRow("subject", "Sam"))
+							mock.ExpectExec(`DELETE FROM "notification_debounce"`).WillReturnResult(sqlmock.NewResult(0, 0))
+							mock.ExpectQuery(`INSERT INTO notification_debounce`).
+								WillReturnRows(sqlmock.NewRows([]string{"debounce_id"}).AddRow(1))
+							mock.ExpectQuery(`SELECT DISTINCT pa.access_type_id AS group_id`).
+								WillReturnRows(sqlmock.NewRows([]string{"group_profile_id"}))
+							mock.ExpectExec(`INSERT INTO "notification"`).WillReturnResult(sqlmock.NewResult(1, 1))
+						}
+						mock.ExpectCommit()
 					}
 				} else {
 					// Mock prayer not found
@@ -801,6 +921,7 @@ func TestUpdatePrayer(t *testing.T) {
 			} else {
 				assert.NotNil(t, response["message"])
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -978,6 +1099,110 @@ func TestDeletePrayer(t *testing.T) {
 	}
 }
 
+// Test ArchivePrayer - Creators archive prayers and restore them
+func TestArchivePrayer(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		prayerExists   bool
+		creatorID      int
+		isArchived     bool
+		isAdmin        bool
+		expectUpdate   bool
+		expectedStatus int
+	}{
+		{
+			name:           "creator archives prayer",
+			body:           `{"archived":true}`,
+			prayerExists:   true,
+			creatorID:      1,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "creator restores prayer",
+			body:           `{"archived":false}`,
+			prayerExists:   true,
+			creatorID:      1,
+			isArchived:     true,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "already archived",
+			body:           `{"archived":true}`,
+			prayerExists:   true,
+			creatorID:      1,
+			isArchived:     true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin archives another user's prayer",
+			body:           `{"archived":true}`,
+			prayerExists:   true,
+			creatorID:      2,
+			isAdmin:        true,
+			expectUpdate:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not the creator",
+			body:           `{"archived":true}`,
+			prayerExists:   true,
+			creatorID:      2,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "prayer not found",
+			body:           `{"archived":true}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing archived",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.body != `{}` {
+				rows := sqlmock.NewRows([]string{"prayer_id", "created_by", "is_archived"})
+				if tt.prayerExists {
+					rows.AddRow(1, tt.creatorID, tt.isArchived)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "prayer_id", "created_by", "is_archived" FROM "prayer"`)).WillReturnRows(rows)
+			}
+			if tt.expectUpdate {
+				mock.ExpectExec(`UPDATE "prayer" SET .*"is_archived"=`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO "prayer_edit_history"`).WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), tt.isAdmin)
+			c.Params = []gin.Param{{Key: "prayer_id", Value: "1"}}
+			c.Request = httptest.NewRequest("PATCH", "/prayers/1/archive", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			ArchivePrayer(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus == http.StatusOK {
+				assert.NotNil(t, response["isArchived"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 // Helper functions for pointer types
 func StrPtr(s string) *string {
 	return &s
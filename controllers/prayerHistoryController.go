@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// prayerVersionOf captures the fields an edit can change and a restore puts back
+func prayerVersionOf(prayer models.Prayer) models.PrayerVersion {
+	return models.PrayerVersion{
+		Title:              prayer.Title,
+		Prayer_Description: prayer.Prayer_Description,
+		Prayer_Priority:    prayer.Prayer_Priority,
+		Prayer_Subject_ID:  prayer.Prayer_Subject_ID,
+		Is_Private:         prayer.Is_Private,
+		Is_Answered:        prayer.Is_Answered,
+	}
+}
+
+// encodePrayerVersion stores a version in a prayer_edit_history column
+func encodePrayerVersion(version models.PrayerVersion) *string {
+	encoded, err := json.Marshal(version)
+	if err != nil {
+		log.Printf("Failed to encode prayer version: %v", err)
+		return nil
+	}
+	value := string(encoded)
+	return &value
+}
+
+// decodePrayerVersion reads a saved version; entries logged before versions
+// were saved, and actions that don't change content, have none
+func decodePrayerVersion(value *string) (*models.PrayerVersion, error) {
+	if value == nil {
+		return nil, nil
+	}
+	var version models.PrayerVersion
+	if err := json.Unmarshal([]byte(*value), &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// diffPrayerVersions lists the fields that differ, in a fixed order. Unset
+// pointer fields are reported as null.
+func diffPrayerVersions(before models.PrayerVersion, after models.PrayerVersion) []models.PrayerFieldChange {
+	changes := []models.PrayerFieldChange{}
+	add := func(field string, beforeValue interface{}, afterValue interface{}) {
+		if beforeValue != afterValue {
+			changes = append(changes, models.PrayerFieldChange{Field: field, Before: beforeValue, After: afterValue})
+		}
+	}
+
+	add(models.PrayerFieldTitle, before.Title, after.Title)
+	add(models.PrayerFieldDescription, before.Prayer_Description, after.Prayer_Description)
+	add(models.PrayerFieldPriority, optionalInt(before.Prayer_Priority), optionalInt(after.Prayer_Priority))
+	add(models.PrayerFieldSubject, optionalInt(before.Prayer_Subject_ID), optionalInt(after.Prayer_Subject_ID))
+	add(models.PrayerFieldPrivate, optionalBool(before.Is_Private), optionalBool(after.Is_Private))
+	add(models.PrayerFieldAnswered, optionalBool(before.Is_Answered), optionalBool(after.Is_Answered))
+	return changes
+}
+
+func optionalInt(value *int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func optionalBool(value *bool) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// historyEntryChanges fills in what each edit or restore changed
+func historyEntryChanges(entries []HistoryEntry) {
+	for i := range entries {
+		before, err := decodePrayerVersion(entries[i].Previous_Version)
+		if err != nil {
+			log.Printf("Invalid previous version on history entry %d: %v", entries[i].History_ID, err)
+			continue
+		}
+		after, err := decodePrayerVersion(entries[i].New_Version)
+		if err != nil {
+			log.Printf("Invalid new version on history entry %d: %v", entries[i].History_ID, err)
+			continue
+		}
+		if before != nil && after != nil {
+			entries[i].Changes = diffPrayerVersions(*before, *after)
+		}
+	}
+}
+
+// loadHistoryVersion returns the version a history entry of the prayer left
+// it at. found is false when the entry doesn't exist, and the version is nil
+// when the entry saved none.
+func loadHistoryVersion(prayerID int, historyID int) (*models.PrayerVersion, bool, error) {
+	var saved struct {
+		New_Version *string `db:"new_version"`
+	}
+	found, err := initializers.DB.From("prayer_edit_history").
+		Select("new_version").
+		Where(goqu.C("prayer_edit_history_id").Eq(historyID), goqu.C("prayer_id").Eq(prayerID)).
+		ScanStruct(&saved)
+	if err != nil || !found {
+		return nil, found, err
+	}
+
+	version, err := decodePrayerVersion(saved.New_Version)
+	return version, true, err
+}
+
+// GetPrayerHistoryDiff compares the version a history entry left the prayer
+// at with the current prayer, or with the version left by ?to=<history_id>
+func GetPrayerHistoryDiff(c *gin.Context) {
+	userID := c.MustGet("currentUser").(models.UserProfile).User_Profile_ID
+	admin := c.MustGet("admin").(bool)
+
+	prayerID, err := strconv.Atoi(c.Param("prayer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer ID", "details": err.Error()})
+		return
+	}
+
+	historyID, err := strconv.Atoi(c.Param("history_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid history ID", "details": err.Error()})
+		return
+	}
+
+	var toHistoryID *int
+	if value := c.Query("to"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a history ID", "details": err.Error()})
+			return
+		}
+		toHistoryID = &id
+	}
+
+	var prayer models.Prayer
+	found, err := initializers.DB.From("prayer").
+		Where(goqu.C("prayer_id").Eq(prayerID), goqu.C("deleted").IsFalse()).
+		ScanStruct(&prayer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer not found"})
+		return
+	}
+
+	if !admin {
+		allowed, err := hasPrayerAccess(prayerID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check prayer access", "details": err.Error()})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have access to this prayer"})
+			return
+		}
+	}
+
+	from, ok := historyVersionOrError(c, prayerID, historyID)
+	if !ok {
+		return
+	}
+
+	to := prayerVersionOf(prayer)
+	if toHistoryID != nil {
+		version, ok := historyVersionOrError(c, prayerID, *toHistoryID)
+		if !ok {
+			return
+		}
+		to = *version
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"fromHistoryId": historyID,
+		"toHistoryId":   toHistoryID,
+		"from":          from,
+		"to":            to,
+		"changes":       diffPrayerVersions(*from, to),
+	})
+}
+
+// historyVersionOrError loads a history entry's version, writing the error
+// response and returning false when it can't be used
+func historyVersionOrError(c *gin.Context, prayerID int, historyID int) (*models.PrayerVersion, bool) {
+	version, found, err := loadHistoryVersion(prayerID, historyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer history", "details": err.Error()})
+		return nil, false
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "History entry not found"})
+		return nil, false
+	}
+	if version == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "History entry has no saved version"})
+		return nil, false
+	}
+	return version, true
+}
+
+// RestorePrayerVersion puts the prayer back to the version a history entry
+// left it at and logs the restore, with its own before and after versions.
+// Only the creator can restore.
+func RestorePrayerVersion(c *gin.Context) {
+	userID := c.MustGet("currentUser").(models.UserProfile).User_Profile_ID
+
+	prayerID, err := strconv.Atoi(c.Param("prayer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer ID", "details": err.Error()})
+		return
+	}
+
+	historyID, err := strconv.Atoi(c.Param("history_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid history ID", "details": err.Error()})
+		return
+	}
+
+	var prayer models.Prayer
+	found, err := initializers.DB.From("prayer").
+		Where(goqu.C("prayer_id").Eq(prayerID), goqu.C("deleted").IsFalse()).
+		ScanStruct(&prayer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer not found"})
+		return
+	}
+	if prayer.Created_By != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the prayer creator can restore earlier versions"})
+		return
+	}
+
+	target, ok := historyVersionOrError(c, prayerID, historyID)
+	if !ok {
+		return
+	}
+
+	current := prayerVersionOf(prayer)
+	changes := diffPrayerVersions(current, *target)
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Prayer already matches this version", "changes": changes})
+		return
+	}
+
+	// The subject may have been deleted since this version was saved
+	if target.Prayer_Subject_ID != nil && optionalInt(target.Prayer_Subject_ID) != optionalInt(current.Prayer_Subject_ID) {
+		var subjectCount int64
+		_, err := initializers.DB.From("prayer_subject").
+			Select(goqu.COUNT("*")).
+			Where(goqu.C("prayer_subject_id").Eq(*target.Prayer_Subject_ID), goqu.C("created_by").Eq(prayer.Created_By)).
+			ScanVal(&subjectCount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check prayer subject", "details": err.Error()})
+			return
+		}
+		if subjectCount == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "The person this version was for no longer exists"})
+			return
+		}
+	}
+
+	wasAnswered := current.Is_Answered != nil && *current.Is_Answered
+	answered := target.Is_Answered != nil && *target.Is_Answered
+	var datetimeAnswered interface{} = prayer.Datetime_Answered
+	if !answered {
+		datetimeAnswered = nil
+	} else if !wasAnswered {
+		datetimeAnswered = goqu.L("NOW()")
+	}
+
+	// The restore and its history entry commit together
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore prayer", "details": err.Error()})
+		return
+	}
+
+	err = tx.Wrap(func() error {
+		_, err := tx.Update("prayer").
+			Set(goqu.Record{
+				"title":              target.Title,
+				"prayer_description": target.Prayer_Description,
+				"prayer_priority":    target.Prayer_Priority,
+				"prayer_subject_id":  target.Prayer_Subject_ID,
+				"is_private":         target.Is_Private,
+				"is_answered":        target.Is_Answered,
+				"datetime_answered":  datetimeAnswered,
+				"updated_by":         userID,
+				"datetime_update":    goqu.L("NOW()"),
+			}).
+			Where(goqu.C("prayer_id").Eq(prayerID)).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
+
+		historyEntry := models.PrayerEditHistory{
+			Prayer_ID:        prayerID,
+			User_Profile_ID:  userID,
+			Action_Type:      models.HistoryActionRestored,
+			Previous_Version: encodePrayerVersion(current),
+			New_Version:      encodePrayerVersion(*target),
+			Restored_From_ID: &historyID,
+		}
+		_, err = tx.Insert("prayer_edit_history").Rows(historyEntry).Executor().Exec()
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore prayer", "details": err.Error()})
+		return
+	}
+
+	eventType := services.EventPrayerUpdated
+	if answered && !wasAnswered {
+		eventType = services.EventPrayerAnswered
+	}
+	go services.PublishPrayerEvent(eventType, services.EventData{PrayerID: prayerID, ActorID: userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Prayer restored successfully", "changes": changes})
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPrayerVersion(title string, answered bool) string {
+	version := models.PrayerVersion{
+		Title:              title,
+		Prayer_Description: "Please pray",
+		Prayer_Priority:    IntPtr(1),
+		Prayer_Subject_ID:  IntPtr(4),
+		Is_Private:         BoolPtr(false),
+		Is_Answered:        BoolPtr(answered),
+	}
+	return *encodePrayerVersion(version)
+}
+
+func testPrayerRows(title string, answered bool, creatorID int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"prayer_id", "prayer_type", "is_private", "title", "prayer_description", "is_answered",
+		"prayer_priority", "prayer_subject_id", "datetime_answered", "created_by", "deleted",
+	}).AddRow(1, "general", false, title, "Please pray", answered, 1, 4, nil, creatorID, false)
+}
+
+func TestDiffPrayerVersions(t *testing.T) {
+	before := models.PrayerVersion{Title: "Surgery", Prayer_Description: "Monday", Prayer_Priority: IntPtr(1), Is_Answered: BoolPtr(false)}
+	after := models.PrayerVersion{Title: "Surgery", Prayer_Description: "Tuesday", Prayer_Subject_ID: IntPtr(4), Is_Answered: BoolPtr(true)}
+
+	changes := diffPrayerVersions(before, after)
+
+	assert.Equal(t, []models.PrayerFieldChange{
+		{Field: models.PrayerFieldDescription, Before: "Monday", After: "Tuesday"},
+		{Field: models.PrayerFieldPriority, Before: 1, After: nil},
+		{Field: models.PrayerFieldSubject, Before: nil, After: 4},
+		{Field: models.PrayerFieldAnswered, Before: false, After: true},
+	}, changes)
+	assert.Empty(t, diffPrayerVersions(before, before))
+}
+
+// Test GetPrayerHistory - Edit entries list the fields they changed
+func TestGetPrayerHistoryChanges(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT "prayer_id", "created_by" FROM "prayer"`).
+		WillReturnRows(sqlmock.NewRows([]string{"prayer_id", "created_by"}).AddRow(1, 1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "prayer_access"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT .*"prayer_edit_history"."previous_version", "prayer_edit_history"."new_version".* FROM "prayer_edit_history"`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"prayer_edit_history_id", "action_type", "user_profile_id", "actor_name",
+			"restored_from_id", "previous_version", "new_version", "datetime_create",
+		}).
+			AddRow(1, "created", 1, "Test", nil, nil, testPrayerVersion("Surgery", false), time.Now()).
+			AddRow(2, "shared", 1, "Test", nil, nil, nil, time.Now()).
+			AddRow(3, "edited", 1, "Test", nil, testPrayerVersion("Surgery", false), testPrayerVersion("Surgery on Tuesday", false), time.Now()))
+
+	c, w := SetupTestContext()
+	SetAuthenticatedUser(c, MockUser(), false)
+	c.Params = []gin.Param{{Key: "prayer_id", Value: "1"}}
+	c.Request = httptest.NewRequest("GET", "/prayers/1/history", nil)
+
+	GetPrayerHistory(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		History []struct {
+			ActionType string                     `json:"actionType"`
+			Changes    []models.PrayerFieldChange `json:"changes"`
+		} `json:"history"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.History, 3)
+	assert.Empty(t, response.History[0].Changes)
+	assert.Empty(t, response.History[1].Changes)
+	assert.Equal(t, []models.PrayerFieldChange{
+		{Field: models.PrayerFieldTitle, Before: "Surgery", After: "Surgery on Tuesday"},
+	}, response.History[2].Changes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test GetPrayerHistoryDiff - Compare a saved version with the current prayer or another version
+func TestGetPrayerHistoryDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		hasAccess      bool
+		savedVersion   bool
+		expectChanges  int
+		expectedStatus int
+	}{
+		{
+			name:           "against the current prayer",
+			hasAccess:      true,
+			savedVersion:   true,
+			expectChanges:  2,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "against another version",
+			query:          "?to=3",
+			hasAccess:      true,
+			savedVersion:   true,
+			expectChanges:  1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "entry without a saved version",
+			hasAccess:      true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no access",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			mock.ExpectQuery(`SELECT .* FROM "prayer"`).
+				WillReturnRows(testPrayerRows("Surgery on Tuesday", true, 2))
+			access := 0
+			if tt.hasAccess {
+				access = 1
+			}
+			mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "prayer_access"`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(access))
+
+			if tt.hasAccess {
+				rows := sqlmock.NewRows([]string{"new_version"})
+				if tt.savedVersion {
+					rows.AddRow(testPrayerVersion("Surgery", false))
+				} else {
+					rows.AddRow(nil)
+				}
+				mock.ExpectQuery(`SELECT "new_version" FROM "prayer_edit_history" .*"prayer_edit_history_id" = 2`).WillReturnRows(rows)
+			}
+			if tt.query != "" {
+				mock.ExpectQuery(`SELECT "new_version" FROM "prayer_edit_history" .*"prayer_edit_history_id" = 3`).
+					WillReturnRows(sqlmock.NewRows([]string{"new_version"}).AddRow(testPrayerVersion("Surgery on Tuesday", false)))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_id", Value: "1"}, {Key: "history_id", Value: "2"}}
+			c.Request = httptest.NewRequest("GET", "/prayers/1/history/2/diff"+tt.query, nil)
+
+			GetPrayerHistoryDiff(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Len(t, response["changes"], tt.expectChanges)
+				assert.Equal(t, "Surgery", response["from"].(map[string]interface{})["title"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test RestorePrayerVersion - The creator puts a prayer back to an earlier version
+func TestRestorePrayerVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		creatorID      int
+		current        string
+		historyFound   bool
+		expectRestore  bool
+		expectedStatus int
+	}{
+		{
+			name:           "creator restores an earlier version",
+			creatorID:      1,
+			current:        "Surgery on Tuesday",
+			historyFound:   true,
+			expectRestore:  true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "prayer already matches the version",
+			creatorID:      1,
+			current:        "Surgery",
+			historyFound:   true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "history entry not found",
+			creatorID:      1,
+			current:        "Surgery on Tuesday",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "not the creator",
+			creatorID:      2,
+			current:        "Surgery on Tuesday",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			mock.ExpectQuery(`SELECT .* FROM "prayer"`).
+				WillReturnRows(testPrayerRows(tt.current, false, tt.creatorID))
+
+			if tt.creatorID == 1 {
+				rows := sqlmock.NewRows([]string{"new_version"})
+				if tt.historyFound {
+					rows.AddRow(testPrayerVersion("Surgery", false))
+				}
+				mock.ExpectQuery(`SELECT "new_version" FROM "prayer_edit_history"`).WillReturnRows(rows)
+			}
+			if tt.expectRestore {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "prayer" SET .*"title"='Surgery'`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO "prayer_edit_history" \("action_type", "new_version", "prayer_id", "previous_version", "restored_from_id", "user_profile_id"\) .*'restored'`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_id", Value: "1"}, {Key: "history_id", Value: "2"}}
+			c.Request = httptest.NewRequest("POST", "/prayers/1/history/2/restore", nil)
+
+			RestorePrayerVersion(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus == http.StatusOK {
+				assert.NotNil(t, response["message"])
+				if tt.expectRestore {
+					assert.Len(t, response["changes"], 1)
+				} else {
+					assert.Empty(t, response["changes"])
+				}
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserReminders lists the user's prayer reminders, soonest first. Filter
+// with ?targetType= and ?targetId=.
+func GetUserReminders(c *gin.Context) {
+	userID, ok := userParamOwner(c, "reminders")
+	if !ok {
+		return
+	}
+
+	query := initializers.DB.From("prayer_reminder").
+		Where(goqu.C("user_profile_id").Eq(userID))
+
+	if targetType := c.Query("targetType"); targetType != "" {
+		query = query.Where(goqu.C("target_type").Eq(targetType))
+	}
+	if targetParam := c.Query("targetId"); targetParam != "" {
+		targetID, err := strconv.Atoi(targetParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID", "details": err.Error()})
+			return
+		}
+		query = query.Where(goqu.C("target_id").Eq(targetID))
+	}
+
+	var reminders []models.PrayerReminder
+	err := query.
+		Order(goqu.C("next_fire_at").Asc().NullsLast(), goqu.C("prayer_reminder_id").Asc()).
+		ScanStructsContext(c, &reminders)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reminders", "details": err.Error()})
+		return
+	}
+	if reminders == nil {
+		reminders = []models.PrayerReminder{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reminders": reminders})
+}
+
+// CreateUserReminder adds a reminder for a prayer, prayer subject or category
+// the user can see. The timezone defaults to the user's timezone preference.
+func CreateUserReminder(c *gin.Context) {
+	userID, ok := userParamOwner(c, "reminders")
+	if !ok {
+		return
+	}
+
+	var request models.PrayerReminderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if request.TargetType == nil || request.TargetID == nil || request.ScheduleType == nil || request.Time == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "targetType, targetId, scheduleType and time are required"})
+		return
+	}
+
+	reminder := models.PrayerReminder{
+		User_Profile_ID: userID,
+		Is_Active:       true,
+	}
+	if request.Timezone == nil {
+		reminder.Timezone = services.GetUserLocation(userID).String()
+	}
+	if !applyReminderRequest(c, &reminder, request) {
+		return
+	}
+
+	var err error
+	reminder.Prayer_Reminder_ID, err = insertReminder(reminder)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reminder", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Reminder created successfully", "reminder": reminder})
+}
+
+// UpdateUserReminder changes the fields set in the request and recalculates
+// when the reminder next goes out
+func UpdateUserReminder(c *gin.Context) {
+	userID, ok := userParamOwner(c, "reminders")
+	if !ok {
+		return
+	}
+
+	reminderID, err := strconv.Atoi(c.Param("prayer_reminder_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID", "details": err.Error()})
+		return
+	}
+
+	var request models.PrayerReminderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	var reminder models.PrayerReminder
+	found, err := initializers.DB.From("prayer_reminder").
+		Where(
+			goqu.C("prayer_reminder_id").Eq(reminderID),
+			goqu.C("user_profile_id").Eq(userID),
+		).
+		ScanStructContext(c, &reminder)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reminder", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		return
+	}
+
+	if !applyReminderRequest(c, &reminder, request) {
+		return
+	}
+
+	_, err = initializers.DB.Update("prayer_reminder").
+		Set(goqu.Record{
+			"target_type":     reminder.Target_Type,
+			"target_id":       reminder.Target_ID,
+			"schedule_type":   reminder.Schedule_Type,
+			"remind_time":     reminder.Remind_Time,
+			"remind_date":     reminder.Remind_Date,
+			"days_of_week":    reminder.Days_Of_Week,
+			"timezone":        reminder.Timezone,
+			"label":           reminder.Label,
+			"is_active":       reminder.Is_Active,
+			"next_fire_at":    reminder.Next_Fire_At,
+			"datetime_update": goqu.L("NOW()"),
+		}).
+		Where(goqu.C("prayer_reminder_id").Eq(reminderID)).
+		Executor().Exec()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reminder", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder updated successfully", "reminder": reminder})
+}
+
+// DeleteUserReminder removes a reminder
+func DeleteUserReminder(c *gin.Context) {
+	userID, ok := userParamOwner(c, "reminders")
+	if !ok {
+		return
+	}
+
+	reminderID, err := strconv.Atoi(c.Param("prayer_reminder_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID", "details": err.Error()})
+		return
+	}
+
+	result, err := initializers.DB.Delete("prayer_reminder").
+		Where(
+			goqu.C("prayer_reminder_id").Eq(reminderID),
+			goqu.C("user_profile_id").Eq(userID),
+		).
+		Executor().Exec()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reminder", "details": err.Error()})
+		return
+	}
+	if removed, _ := result.RowsAffected(); removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder deleted successfully"})
+}
+
+// applyReminderRequest copies the request's fields onto the reminder, checks
+// the target and schedule, and sets Next_Fire_At. It writes the error
+// response and returns false if the result isn't valid.
+func applyReminderRequest(c *gin.Context, reminder *models.PrayerReminder, request models.PrayerReminderRequest) bool {
+	targetChanged := false
+	if request.TargetType != nil && *request.TargetType != reminder.Target_Type {
+		reminder.Target_Type = *request.TargetType
+		targetChanged = true
+	}
+	if request.TargetID != nil && *request.TargetID != reminder.Target_ID {
+		reminder.Target_ID = *request.TargetID
+		targetChanged = true
+	}
+	if request.ScheduleType != nil {
+		reminder.Schedule_Type = *request.ScheduleType
+		// Fields from the old schedule don't carry over
+		if reminder.Schedule_Type != models.ReminderScheduleOnce {
+			reminder.Remind_Date = nil
+		}
+		if reminder.Schedule_Type == models.ReminderScheduleOnce || reminder.Schedule_Type == models.ReminderScheduleDaily {
+			reminder.Days_Of_Week = nil
+		}
+	}
+	if request.Time != nil {
+		reminder.Remind_Time = *request.Time
+	}
+	if request.Date != nil {
+		reminder.Remind_Date = request.Date
+	}
+	if request.DaysOfWeek != nil {
+		days, err := services.NormalizeReminderDays(request.DaysOfWeek)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		reminder.Days_Of_Week = &days
+	}
+	if request.Timezone != nil {
+		reminder.Timezone = *request.Timezone
+	}
+	if request.Label != nil {
+		reminder.Label = request.Label
+		if *request.Label == "" {
+			reminder.Label = nil
+		}
+	}
+	if request.IsActive != nil {
+		reminder.Is_Active = *request.IsActive
+	}
+
+	if err := services.ValidateReminderSchedule(*reminder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if targetChanged {
+		switch reminder.Target_Type {
+		case models.ReminderTargetPrayer, models.ReminderTargetSubject, models.ReminderTargetCategory:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "targetType must be prayer, subject or category"})
+			return false
+		}
+
+		_, found, err := services.ReminderTargetName(reminder.User_Profile_ID, reminder.Target_Type, reminder.Target_ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check reminder target", "details": err.Error()})
+			return false
+		}
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reminder target not found"})
+			return false
+		}
+	}
+
+	reminder.Next_Fire_At = nil
+	if !reminder.Is_Active {
+		return true
+	}
+	next, ok, err := services.NextReminderTime(*reminder, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The reminder's date and time have already passed"})
+		return false
+	}
+	reminder.Next_Fire_At = &next
+	return true
+}
+
+func insertReminder(reminder models.PrayerReminder) (int, error) {
+	var reminderID int
+	_, err := initializers.DB.Insert("prayer_reminder").
+		Rows(reminder).
+		Returning("prayer_reminder_id").
+		Executor().ScanVal(&reminderID)
+	return reminderID, err
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test CreateUserReminder - Schedule a reminder for a prayer, subject or category
+func TestCreateUserReminder(t *testing.T) {
+	tests := []struct {
+		name             string
+		userID           string
+		body             string
+		expectTimezone   string
+		expectTarget     bool
+		targetFound      bool
+		expectInsert     bool
+		expectedStatus   int
+		expectedTimezone string
+	}{
+		{
+			name:             "daily reminder for a prayer",
+			userID:           "1",
+			body:             `{"targetType":"prayer","targetId":9,"scheduleType":"daily","time":"07:00","timezone":"Europe/London"}`,
+			expectTarget:     true,
+			targetFound:      true,
+			expectInsert:     true,
+			expectedStatus:   http.StatusCreated,
+			expectedTimezone: "Europe/London",
+		},
+		{
+			name:             "timezone defaults to the user's preference",
+			userID:           "1",
+			body:             `{"targetType":"prayer","targetId":9,"scheduleType":"custom","time":"21:00","daysOfWeek":["fri","mon"]}`,
+			expectTimezone:   "America/Chicago",
+			expectTarget:     true,
+			targetFound:      true,
+			expectInsert:     true,
+			expectedStatus:   http.StatusCreated,
+			expectedTimezone: "America/Chicago",
+		},
+		{
+			name:           "prayer not visible to the user",
+			userID:         "1",
+			body:           `{"targetType":"prayer","targetId":9,"scheduleType":"daily","time":"07:00","timezone":"UTC"}`,
+			expectTarget:   true,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "one-off in the past",
+			userID:         "1",
+			body:           `{"targetType":"prayer","targetId":9,"scheduleType":"once","date":"2020-01-01","time":"07:00","timezone":"UTC"}`,
+			expectTarget:   true,
+			targetFound:    true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid day",
+			userID:         "1",
+			body:           `{"targetType":"prayer","targetId":9,"scheduleType":"weekly","time":"07:00","daysOfWeek":["someday"],"timezone":"UTC"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "weekly needs exactly one day",
+			userID:         "1",
+			body:           `{"targetType":"prayer","targetId":9,"scheduleType":"weekly","time":"07:00","daysOfWeek":["mon","tue"],"timezone":"UTC"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing time",
+			userID:         "1",
+			body:           `{"targetType":"prayer","targetId":9,"scheduleType":"daily"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "another user's reminders",
+			userID:         "2",
+			body:           `{"targetType":"prayer","targetId":9,"scheduleType":"daily","time":"07:00"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectTimezone != "" {
+				mock.ExpectQuery(`SELECT COALESCE\("up"."preference_value", "p"."default_value"\)`).
+					WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(tt.expectTimezone))
+			}
+			if tt.expectTarget {
+				titles := sqlmock.NewRows([]string{"title"})
+				if tt.targetFound {
+					titles.AddRow("Healing for Sam")
+				}
+				mock.ExpectQuery(`SELECT "title" FROM "prayer"`).WillReturnRows(titles)
+			}
+			if tt.expectInsert {
+				mock.ExpectQuery(`INSERT INTO "prayer_reminder" .* RETURNING "prayer_reminder_id"`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_reminder_id"}).AddRow(12))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = append(c.Params, gin.Param{Key: "user_profile_id", Value: tt.userID})
+			c.Request = httptest.NewRequest("POST", "/users/"+tt.userID+"/reminders", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			CreateUserReminder(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectedStatus == http.StatusCreated {
+				reminder := response["reminder"].(map[string]interface{})
+				assert.Equal(t, float64(12), reminder["prayerReminderId"])
+				assert.Equal(t, tt.expectedTimezone, reminder["timezone"])
+				assert.NotNil(t, reminder["nextFireAt"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test UpdateUserReminder - Pausing a reminder clears its next occurrence
+func TestUpdateUserReminder(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT .* FROM "prayer_reminder" WHERE \(\("prayer_reminder_id" = 12\) AND \("user_profile_id" = 1\)\)`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"prayer_reminder_id", "user_profile_id", "target_type", "target_id", "schedule_type",
+			"remind_time", "remind_date", "days_of_week", "timezone", "label", "is_active",
+			"next_fire_at", "last_fired_at", "datetime_create", "datetime_update",
+		}).AddRow(12, 1, "prayer", 9, "daily", "07:00", nil, nil, "UTC", nil, true, now.Add(time.Hour), nil, now, now))
+	mock.ExpectExec(`UPDATE "prayer_reminder" SET .*"is_active"=FALSE.*"next_fire_at"=NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	c, w := SetupTestContext()
+	SetAuthenticatedUser(c, MockUser(), false)
+	c.Params = []gin.Param{
+		{Key: "user_profile_id", Value: "1"},
+		{Key: "prayer_reminder_id", Value: "12"},
+	}
+	c.Request = httptest.NewRequest("PATCH", "/users/1/reminders/12", bytes.NewBufferString(`{"isActive":false}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	UpdateUserReminder(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	reminder := response["reminder"].(map[string]interface{})
+	assert.Equal(t, false, reminder["isActive"])
+	assert.Nil(t, reminder["nextFireAt"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Test DeleteUserReminder - Remove one of the user's reminders
+func TestDeleteUserReminder(t *testing.T) {
+	tests := []struct {
+		name           string
+		rowsAffected   int64
+		expectedStatus int
+	}{
+		{
+			name:           "deleted",
+			rowsAffected:   1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not found",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			mock.ExpectExec(`DELETE FROM "prayer_reminder" WHERE \(\("prayer_reminder_id" = 12\) AND \("user_profile_id" = 1\)\)`).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{
+				{Key: "user_profile_id", Value: "1"},
+				{Key: "prayer_reminder_id", Value: "12"},
+			}
+			c.Request = httptest.NewRequest("DELETE", "/users/1/reminders/12", nil)
+
+			DeleteUserReminder(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// StartPrayerSession creates a guided prayer session over a user's list, a group,
+// a prayer subject or a category. The prayers are snapshotted into
+// prayer_session_detail in display order so the client can walk through them.
+// POST /prayer-sessions
+func StartPrayerSession(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	var req models.PrayerSessionStart
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session request", "details": err.Error()})
+		return
+	}
+
+	query := initializers.DB.From("prayer_access").
+		Select(goqu.I("prayer.prayer_id")).
+		Join(
+			goqu.T("prayer"),
+			goqu.On(goqu.Ex{"prayer_access.prayer_id": goqu.I("prayer.prayer_id")}),
+		)
+
+	conditions := []goqu.Expression{
+		goqu.Ex{"prayer.deleted": false},
+	}
+	if !req.Include_Answered {
+		conditions = append(conditions, goqu.Or(
+			goqu.I("prayer.is_answered").IsNull(),
+			goqu.I("prayer.is_answered").IsFalse(),
+		))
+	}
+
+	switch req.Source_Type {
+	case models.PrayerSessionSourceUser:
+		// A user session is always over the caller's own list
+		if req.Source_ID != 0 && req.Source_ID != currentUser.User_Profile_ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only start a session over your own prayers"})
+			return
+		}
+		req.Source_ID = currentUser.User_Profile_ID
+		conditions = append(conditions,
+			goqu.Ex{"prayer_access.access_type": "user"},
+			goqu.Ex{"prayer_access.access_type_id": currentUser.User_Profile_ID},
+		)
+
+	case models.PrayerSessionSourceGroup:
+		if !isGroupExists(req.Source_ID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return
+		}
+		if !isUserInGroup(c, req.Source_ID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this group"})
+			return
+		}
+		conditions = append(conditions,
+			goqu.Ex{"prayer_access.access_type": "group"},
+			goqu.Ex{"prayer_access.access_type_id": req.Source_ID},
+		)
+
+	case models.PrayerSessionSourceSubject:
+		var subject models.PrayerSubject
+		found, err := initializers.DB.From("prayer_subject").
+			Select("*").
+			Where(goqu.C("prayer_subject_id").Eq(req.Source_ID)).
+			ScanStruct(&subject)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer subject", "details": err.Error()})
+			return
+		}
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prayer subject not found"})
+			return
+		}
+		if subject.Created_By != currentUser.User_Profile_ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to pray through this prayer subject"})
+			return
+		}
+		conditions = append(conditions,
+			goqu.Ex{"prayer_access.access_type": "user"},
+			goqu.Ex{"prayer_access.access_type_id": currentUser.User_Profile_ID},
+			goqu.Ex{"prayer.prayer_subject_id": req.Source_ID},
+		)
+
+	case models.PrayerSessionSourceCategory:
+		var category models.PrayerCategory
+		found, err := initializers.DB.From("prayer_category").
+			Where(goqu.C("prayer_category_id").Eq(req.Source_ID)).
+			ScanStruct(&category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category", "details": err.Error()})
+			return
+		}
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		if category.Category_Type == "user" && category.Category_Type_ID != currentUser.User_Profile_ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to pray through this category"})
+			return
+		}
+		if category.Category_Type == "group" && !isUserInGroup(c, category.Category_Type_ID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this group"})
+			return
+		}
+		query = query.Join(
+			goqu.T("prayer_category_item"),
+			goqu.On(goqu.Ex{"prayer_access.prayer_access_id": goqu.I("prayer_category_item.prayer_access_id")}),
+		)
+		conditions = append(conditions, goqu.Ex{"prayer_category_item.prayer_category_id": req.Source_ID})
+	}
+
+	var prayerIDs []int
+	err := query.
+		Where(goqu.And(conditions...)).
+		Order(goqu.I("prayer_access.display_sequence").Asc()).
+		ScanVals(&prayerIDs)
+	if err != nil {
+		log.Printf("Failed to load prayers for session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load prayers for session", "details": err.Error()})
+		return
+	}
+
+	if len(prayerIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "There are no prayers to pray through in this selection"})
+		return
+	}
+
+	session := models.PrayerSession{
+		User_Profile_ID: currentUser.User_Profile_ID,
+		Source_Type:     req.Source_Type,
+		Source_ID:       req.Source_ID,
+		Session_Status:  models.PrayerSessionActive,
+		Datetime_Start:  time.Now(),
+	}
+
+	// The session and its prayers go in together so a failed detail insert
+	// can't leave an empty active session behind
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start prayer session", "details": err.Error()})
+		return
+	}
+
+	err = tx.Wrap(func() error {
+		_, err := tx.Insert("prayer_session").Rows(session).Returning("prayer_session_id").
+			Executor().ScanVal(&session.Prayer_Session_ID)
+		if err != nil {
+			return err
+		}
+
+		details := make([]models.PrayerSessionDetail, 0, len(prayerIDs))
+		for i, prayerID := range prayerIDs {
+			details = append(details, models.PrayerSessionDetail{
+				Prayer_Session_ID: session.Prayer_Session_ID,
+				Prayer_ID:         prayerID,
+				Display_Sequence:  i,
+			})
+		}
+
+		_, err = tx.Insert("prayer_session_detail").Rows(details).Executor().Exec()
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to create prayer session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start prayer session", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Prayer session started",
+		"session":   session,
+		"prayerIds": prayerIDs,
+	})
+}
+
+// GetPrayerSession returns a session and the prayers in it
+// GET /prayer-sessions/:prayer_session_id
+func GetPrayerSession(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	sessionID, err := strconv.Atoi(c.Param("prayer_session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer session ID", "details": err.Error()})
+		return
+	}
+
+	session, found, err := getPrayerSession(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer session", "details": err.Error()})
+		return
+	}
+	if !found || session.User_Profile_ID != currentUser.User_Profile_ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer session not found"})
+		return
+	}
+
+	var details []models.PrayerSessionDetail
+	err = initializers.DB.From("prayer_session_detail").
+		Select(
+			goqu.I("prayer_session_detail.prayer_session_detail_id"),
+			goqu.I("prayer_session_detail.prayer_session_id"),
+			goqu.I("prayer_session_detail.prayer_id"),
+			goqu.I("prayer_session_detail.display_sequence"),
+			goqu.I("prayer_session_detail.duration_seconds"),
+			goqu.I("prayer_session_detail.datetime_prayed"),
+			goqu.I("prayer.title"),
+		).
+		Join(
+			goqu.T("prayer"),
+			goqu.On(goqu.Ex{"prayer_session_detail.prayer_id": goqu.I("prayer.prayer_id")}),
+		).
+		Where(goqu.Ex{"prayer_session_detail.prayer_session_id": sessionID}).
+		Order(goqu.I("prayer_session_detail.display_sequence").Asc()).
+		ScanStructs(&details)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer session details", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+		"prayers": details,
+	})
+}
+
+// RecordSessionPrayer marks a prayer in the session as prayed and stores how long
+// the user spent on it. Recording the same prayer again overwrites the duration.
+// POST /prayer-sessions/:prayer_session_id/prayers
+func RecordSessionPrayer(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	sessionID, err := strconv.Atoi(c.Param("prayer_session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer session ID", "details": err.Error()})
+		return
+	}
+
+	var req models.PrayerSessionRecord
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer record", "details": err.Error()})
+		return
+	}
+
+	session, found, err := getPrayerSession(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer session", "details": err.Error()})
+		return
+	}
+	if !found || session.User_Profile_ID != currentUser.User_Profile_ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer session not found"})
+		return
+	}
+	if session.Session_Status != models.PrayerSessionActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prayer session has already finished"})
+		return
+	}
+
+	result, err := initializers.DB.Update("prayer_session_detail").
+		Set(goqu.Record{
+			"duration_seconds": req.Duration_Seconds,
+			"datetime_prayed":  time.Now(),
+		}).
+		Where(goqu.And(
+			goqu.C("prayer_session_id").Eq(sessionID),
+			goqu.C("prayer_id").Eq(req.Prayer_ID),
+		)).
+		Executor().Exec()
+	if err != nil {
+		log.Printf("Failed to record session prayer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record prayer", "details": err.Error()})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer is not part of this session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Prayer recorded"})
+}
+
+// FinishPrayerSession closes the session, counts every prayed prayer toward
+// prayer_analytics and rolls the totals into user_stats
+// POST /prayer-sessions/:prayer_session_id/finish
+func FinishPrayerSession(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	sessionID, err := strconv.Atoi(c.Param("prayer_session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer session ID", "details": err.Error()})
+		return
+	}
+
+	session, found, err := getPrayerSession(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer session", "details": err.Error()})
+		return
+	}
+	if !found || session.User_Profile_ID != currentUser.User_Profile_ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer session not found"})
+		return
+	}
+	if session.Session_Status != models.PrayerSessionActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prayer session has already finished"})
+		return
+	}
+
+	var prayed []models.PrayerSessionDetail
+	err = initializers.DB.From("prayer_session_detail").
+		Select("prayer_session_detail_id", "prayer_session_id", "prayer_id", "display_sequence", "duration_seconds", "datetime_prayed").
+		Where(goqu.And(
+			goqu.C("prayer_session_id").Eq(sessionID),
+			goqu.C("datetime_prayed").IsNotNull(),
+		)).
+		Order(goqu.C("display_sequence").Asc()).
+		ScanStructs(&prayed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer session details", "details": err.Error()})
+		return
+	}
+
+	totalSeconds := 0
+	for _, detail := range prayed {
+		if detail.Duration_Seconds != nil {
+			totalSeconds += *detail.Duration_Seconds
+		}
+	}
+
+	now := time.Now()
+	// The status guard makes finishing idempotent under concurrent requests
+	result, err := initializers.DB.Update("prayer_session").
+		Set(goqu.Record{
+			"session_status":         models.PrayerSessionCompleted,
+			"datetime_end":           now,
+			"prayer_count":           len(prayed),
+			"total_duration_seconds": totalSeconds,
+		}).
+		Where(goqu.And(
+			goqu.C("prayer_session_id").Eq(sessionID),
+			goqu.C("session_status").Eq(models.PrayerSessionActive),
+		)).
+		Executor().Exec()
+	if err != nil {
+		log.Printf("Failed to finish prayer session %d: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finish prayer session", "details": err.Error()})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Prayer session has already finished"})
+		return
+	}
+
+	session.Session_Status = models.PrayerSessionCompleted
+	session.Datetime_End = &now
+	session.Prayer_Count = len(prayed)
+	session.Total_Duration_Seconds = totalSeconds
+
+	// Analytics and stats are derived data; a failure here shouldn't undo the session
+	for _, detail := range prayed {
+		if _, _, err := recordPrayerAnalytics(detail.Prayer_ID, currentUser.User_Profile_ID); err != nil {
+			log.Printf("Failed to record analytics for prayer %d in session %d: %v", detail.Prayer_ID, sessionID, err)
+		}
+	}
+
+	stats, err := addSessionToUserStats(currentUser.User_Profile_ID, len(prayed), totalSeconds)
+	if err != nil {
+		log.Printf("Failed to update user stats for user %d: %v", currentUser.User_Profile_ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Prayer session completed",
+		"session": session,
+		"stats":   stats,
+	})
+}
+
+func getPrayerSession(sessionID int) (models.PrayerSession, bool, error) {
+	var session models.PrayerSession
+	found, err := initializers.DB.From("prayer_session").
+		Where(goqu.C("prayer_session_id").Eq(sessionID)).
+		ScanStruct(&session)
+	return session, found, err
+}
+
+// addSessionToUserStats atomically folds one finished session into the user's
+// running totals, creating the user_stats row on first use
+func addSessionToUserStats(userID int, prayerCount int, durationSeconds int) (models.UserStats, error) {
+	query := `
+		INSERT INTO user_stats (user_profile_id, total_sessions, total_prayers, total_duration_seconds, datetime_last_session)
+		VALUES ($1, 1, $2, $3, NOW())
+		ON CONFLICT (user_profile_id)
+		DO UPDATE SET
+			total_sessions = user_stats.total_sessions + 1,
+			total_prayers = user_stats.total_prayers + EXCLUDED.total_prayers,
+			total_duration_seconds = user_stats.total_duration_seconds + EXCLUDED.total_duration_seconds,
+			datetime_last_session = EXCLUDED.datetime_last_session
+		RETURNING user_profile_id, total_sessions, total_prayers, total_duration_seconds, datetime_last_session
+	`
+
+	var stats models.UserStats
+	err := initializers.DB.QueryRow(query, userID, prayerCount, durationSeconds).Scan(
+		&stats.User_Profile_ID,
+		&stats.Total_Sessions,
+		&stats.Total_Prayers,
+		&stats.Total_Duration_Seconds,
+		&stats.Datetime_Last_Session,
+	)
+	return stats, err
+}
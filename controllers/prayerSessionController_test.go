@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var prayerSessionColumns = []string{
+	"prayer_session_id", "user_profile_id", "source_type", "source_id", "session_status",
+	"datetime_start", "datetime_end", "prayer_count", "total_duration_seconds",
+}
+
+// Test StartPrayerSession - Snapshot prayers from a source into a new session
+func TestStartPrayerSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		groupMember    bool
+		prayerIDs      []int
+		detailsFail    bool
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful start - user list",
+			requestBody:    models.PrayerSessionStart{Source_Type: "user"},
+			prayerIDs:      []int{3, 1, 2},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "successful start - group",
+			requestBody:    models.PrayerSessionStart{Source_Type: "group", Source_ID: 1},
+			groupMember:    true,
+			prayerIDs:      []int{5},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "detail insert fails - session rolled back",
+			requestBody:    models.PrayerSessionStart{Source_Type: "user"},
+			prayerIDs:      []int{3, 1},
+			detailsFail:    true,
+			expectedStatus: http.StatusInternalServerError,
+			expectError:    true,
+		},
+		{
+			name:           "forbidden - not a group member",
+			requestBody:    models.PrayerSessionStart{Source_Type: "group", Source_ID: 1},
+			groupMember:    false,
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
+		{
+			name:           "forbidden - another user's list",
+			requestBody:    models.PrayerSessionStart{Source_Type: "user", Source_ID: 2},
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
+		{
+			name:           "no prayers in selection",
+			requestBody:    models.PrayerSessionStart{Source_Type: "user"},
+			prayerIDs:      []int{},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "invalid source type",
+			requestBody:    map[string]interface{}{"sourceType": "everything"},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			start, _ := tt.requestBody.(models.PrayerSessionStart)
+			if start.Source_Type == "group" {
+				// Group exists, then membership check
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				memberCount := 0
+				if tt.groupMember {
+					memberCount = 1
+				}
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(memberCount))
+			}
+
+			if tt.prayerIDs != nil {
+				rows := sqlmock.NewRows([]string{"prayer_id"})
+				for _, id := range tt.prayerIDs {
+					rows.AddRow(id)
+				}
+				mock.ExpectQuery("SELECT \"prayer\".\"prayer_id\" FROM \"prayer_access\"").WillReturnRows(rows)
+
+				if len(tt.prayerIDs) > 0 {
+					mock.ExpectBegin()
+					mock.ExpectQuery("INSERT INTO \"prayer_session\"").
+						WillReturnRows(sqlmock.NewRows([]string{"prayer_session_id"}).AddRow(7))
+					if tt.detailsFail {
+						mock.ExpectExec("INSERT INTO \"prayer_session_detail\"").
+							WillReturnError(sql.ErrConnDone)
+						mock.ExpectRollback()
+					} else {
+						mock.ExpectExec("INSERT INTO \"prayer_session_detail\"").
+							WillReturnResult(sqlmock.NewResult(0, int64(len(tt.prayerIDs))))
+						mock.ExpectCommit()
+					}
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest("POST", "/prayer-sessions", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			StartPrayerSession(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectError {
+				assert.NotNil(t, response["error"])
+			} else {
+				session := response["session"].(map[string]interface{})
+				assert.Equal(t, float64(7), session["prayerSessionId"])
+				assert.Equal(t, "active", session["sessionStatus"])
+				assert.Len(t, response["prayerIds"], len(tt.prayerIDs))
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test RecordSessionPrayer - Store a prayed prayer and its duration
+func TestRecordSessionPrayer(t *testing.T) {
+	tests := []struct {
+		name           string
+		sessionID      string
+		requestBody    interface{}
+		sessionOwner   int
+		sessionStatus  string
+		detailRows     int64
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful record",
+			sessionID:      "7",
+			requestBody:    models.PrayerSessionRecord{Prayer_ID: 3, Duration_Seconds: 45},
+			sessionOwner:   1,
+			sessionStatus:  "active",
+			detailRows:     1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "prayer not in session",
+			sessionID:      "7",
+			requestBody:    models.PrayerSessionRecord{Prayer_ID: 99, Duration_Seconds: 45},
+			sessionOwner:   1,
+			sessionStatus:  "active",
+			detailRows:     0,
+			expectedStatus: http.StatusNotFound,
+			expectError:    true,
+		},
+		{
+			name:           "session belongs to another user",
+			sessionID:      "7",
+			requestBody:    models.PrayerSessionRecord{Prayer_ID: 3, Duration_Seconds: 45},
+			sessionOwner:   2,
+			sessionStatus:  "active",
+			expectedStatus: http.StatusNotFound,
+			expectError:    true,
+		},
+		{
+			name:           "session already finished",
+			sessionID:      "7",
+			requestBody:    models.PrayerSessionRecord{Prayer_ID: 3, Duration_Seconds: 45},
+			sessionOwner:   1,
+			sessionStatus:  "completed",
+			expectedStatus: http.StatusConflict,
+			expectError:    true,
+		},
+		{
+			name:           "negative duration",
+			sessionID:      "7",
+			requestBody:    map[string]interface{}{"prayerId": 3, "durationSeconds": -5},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "invalid session ID",
+			sessionID:      "abc",
+			requestBody:    models.PrayerSessionRecord{Prayer_ID: 3},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.sessionOwner != 0 {
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(prayerSessionColumns).
+					AddRow(7, tt.sessionOwner, "user", tt.sessionOwner, tt.sessionStatus, time.Now(), nil, 0, 0))
+
+				if tt.sessionOwner == 1 && tt.sessionStatus == "active" {
+					mock.ExpectExec("UPDATE \"prayer_session_detail\"").
+						WillReturnResult(sqlmock.NewResult(0, tt.detailRows))
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_session_id", Value: tt.sessionID}}
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest("POST", "/prayer-sessions/"+tt.sessionID+"/prayers", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			RecordSessionPrayer(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectError {
+				assert.NotNil(t, response["error"])
+			} else {
+				assert.NotNil(t, response["message"])
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test FinishPrayerSession - Close session and feed analytics and user stats
+func TestFinishPrayerSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		sessionStatus  string
+		finishRows     int64
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful finish",
+			sessionStatus:  "active",
+			finishRows:     1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "already finished",
+			sessionStatus:  "completed",
+			expectedStatus: http.StatusConflict,
+			expectError:    true,
+		},
+		{
+			name:           "finished concurrently",
+			sessionStatus:  "active",
+			finishRows:     0,
+			expectedStatus: http.StatusConflict,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			now := time.Now()
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(prayerSessionColumns).
+				AddRow(7, 1, "user", 1, tt.sessionStatus, now, nil, 0, 0))
+
+			if tt.sessionStatus == "active" {
+				// Two of the planned prayers were prayed
+				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{
+					"prayer_session_detail_id", "prayer_session_id", "prayer_id", "display_sequence", "duration_seconds", "datetime_prayed",
+				}).
+					AddRow(1, 7, 3, 0, 30, now).
+					AddRow(2, 7, 1, 1, 90, now))
+
+				mock.ExpectExec("UPDATE \"prayer_session\"").
+					WillReturnResult(sqlmock.NewResult(0, tt.finishRows))
+
+				if tt.finishRows == 1 {
+					// One analytics write per prayed prayer
+					for _, prayerID := range []int{3, 1} {
+						mock.ExpectQuery("WITH gate AS").
+							WithArgs(prayerID, 1, prayerCooldownMinutes).
+							WillReturnRows(sqlmock.NewRows([]string{"total_prayers", "num_unique_users"}).AddRow(1, 1))
+					}
+
+					mock.ExpectQuery("INSERT INTO user_stats").
+						WithArgs(1, 2, 120).
+						WillReturnRows(sqlmock.NewRows([]string{
+							"user_profile_id", "total_sessions", "total_prayers", "total_duration_seconds", "datetime_last_session",
+						}).AddRow(1, 4, 20, 900, now))
+				}
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_session_id", Value: "7"}}
+			c.Request = httptest.NewRequest("POST", "/prayer-sessions/7/finish", nil)
+
+			FinishPrayerSession(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectError {
+				assert.NotNil(t, response["error"])
+			} else {
+				session := response["session"].(map[string]interface{})
+				assert.Equal(t, "completed", session["sessionStatus"])
+				assert.Equal(t, float64(2), session["prayerCount"])
+				assert.Equal(t, float64(120), session["totalDurationSeconds"])
+
+				stats := response["stats"].(map[string]interface{})
+				assert.Equal(t, float64(4), stats["totalSessions"])
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/PrayerLoop/initializers"
 	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
 	"github.com/doug-martin/goqu/v9"
 )
 
@@ -79,6 +80,7 @@ func GetUserPrayerSubjects(c *gin.Context) {
 				goqu.I("prayer.updated_by"),
 				goqu.I("prayer.datetime_update"),
 				goqu.I("prayer.deleted"),
+				goqu.I("prayer.is_archived"),
 				goqu.I("prayer_category.prayer_category_id"),
 				goqu.I("prayer_category.category_name"),
 				goqu.I("prayer_category.category_color"),
@@ -172,6 +174,7 @@ func GetUserPrayerSubjects(c *gin.Context) {
 							goqu.I("prayer.updated_by"),
 							goqu.I("prayer.datetime_update"),
 							goqu.I("prayer.deleted"),
+							goqu.I("prayer.is_archived"),
 							goqu.I("prayer_category.prayer_category_id"),
 							goqu.I("prayer_category.category_name"),
 							goqu.I("prayer_category.category_color"),
@@ -631,11 +634,22 @@ func DeletePrayerSubject(c *gin.Context) {
 		}
 	}
 
-	// Delete the prayer subject
-	_, err = initializers.DB.Delete("prayer_subject").
-		Where(goqu.C("prayer_subject_id").Eq(subjectID)).
-		Executor().Exec()
+	// Delete the prayer subject and record its tombstone in one transaction
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer subject", "details": err.Error()})
+		return
+	}
 
+	err = tx.Wrap(func() error {
+		_, err := tx.Delete("prayer_subject").
+			Where(goqu.C("prayer_subject_id").Eq(subjectID)).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
+		return services.RecordSyncTombstoneTx(tx, existingSubject.Created_By, models.SyncEntityPrayerSubject, subjectID)
+	})
 	if err != nil {
 		log.Println("Failed to delete prayer subject:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer subject", "details": err.Error()})
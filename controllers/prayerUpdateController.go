@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// hasPrayerAccess reports whether the prayer is shared with the user, directly
+// or through one of their groups
+func hasPrayerAccess(prayerID int, userID int) (bool, error) {
+	var count int64
+	_, err := initializers.DB.From("prayer_access").
+		Select(goqu.COUNT("*")).
+		Join(
+			goqu.T("user_group"),
+			goqu.On(
+				goqu.Or(
+					goqu.Ex{"prayer_access.access_type": "group", "prayer_access.access_type_id": goqu.I("user_group.group_profile_id")},
+					goqu.Ex{"prayer_access.access_type": "user", "prayer_access.access_type_id": goqu.I("user_group.user_profile_id")},
+				),
+			),
+		).
+		Where(
+			goqu.I("prayer_access.prayer_id").Eq(prayerID),
+			goqu.I("user_group.user_profile_id").Eq(userID),
+		).
+		ScanVal(&count)
+	return count > 0, err
+}
+
+// isPrayerAuthor reports whether the user created the prayer or is its linked
+// subject, the people who can speak for how the request is going
+func isPrayerAuthor(prayer models.Prayer, userID int) (bool, error) {
+	if prayer.Created_By == userID {
+		return true, nil
+	}
+	if prayer.Prayer_Subject_ID == nil {
+		return false, nil
+	}
+
+	var count int64
+	_, err := initializers.DB.From("prayer_subject").
+		Select(goqu.COUNT("*")).
+		Where(
+			goqu.C("prayer_subject_id").Eq(*prayer.Prayer_Subject_ID),
+			goqu.C("user_profile_id").Eq(userID),
+			goqu.C("link_status").Eq("linked"),
+		).
+		ScanVal(&count)
+	return count > 0, err
+}
+
+// GetPrayerUpdates returns the prayer's updates timeline, oldest first, to
+// anyone the prayer is shared with. Filter with ?updateType=.
+func GetPrayerUpdates(c *gin.Context) {
+	userID := c.MustGet("currentUser").(models.UserProfile).User_Profile_ID
+	admin := c.MustGet("admin").(bool)
+
+	prayerID, err := strconv.Atoi(c.Param("prayer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer ID", "details": err.Error()})
+		return
+	}
+
+	page, err := parsePageRequest(c, "prayer_updates")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updateType := c.Query("updateType")
+	switch updateType {
+	case "", models.PrayerUpdateTypeUpdate, models.PrayerUpdateTypeAnswered:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "updateType must be update or answered"})
+		return
+	}
+
+	var prayerCount int64
+	_, err = initializers.DB.From("prayer").
+		Select(goqu.COUNT("*")).
+		Where(goqu.C("prayer_id").Eq(prayerID), goqu.C("deleted").IsFalse()).
+		ScanVal(&prayerCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer", "details": err.Error()})
+		return
+	}
+	if prayerCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer not found"})
+		return
+	}
+
+	if !admin {
+		allowed, err := hasPrayerAccess(prayerID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check prayer access", "details": err.Error()})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have access to this prayer"})
+			return
+		}
+	}
+
+	query := initializers.DB.From("prayer_update").
+		Select(
+			goqu.I("prayer_update.prayer_update_id"),
+			goqu.I("prayer_update.prayer_id"),
+			goqu.I("prayer_update.user_profile_id"),
+			goqu.I("prayer_update.update_type"),
+			goqu.I("prayer_update.update_text"),
+			goqu.L("COALESCE(user_profile.first_name, user_profile.username, 'Unknown')").As("author_name"),
+			goqu.I("prayer_update.datetime_create"),
+		).
+		Join(
+			goqu.T("user_profile"),
+			goqu.On(goqu.I("prayer_update.user_profile_id").Eq(goqu.I("user_profile.user_profile_id"))),
+		).
+		Where(goqu.I("prayer_update.prayer_id").Eq(prayerID)).
+		Order(goqu.I("prayer_update.datetime_create").Asc(), goqu.I("prayer_update.prayer_update_id").Asc())
+
+	if updateType != "" {
+		query = query.Where(goqu.I("prayer_update.update_type").Eq(updateType))
+	}
+
+	var updates []models.PrayerUpdate
+	query = page.apply(query, false, goqu.I("prayer_update.datetime_create"), goqu.I("prayer_update.prayer_update_id"))
+	if err := query.ScanStructs(&updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer updates", "details": err.Error()})
+		return
+	}
+	if updates == nil {
+		updates = []models.PrayerUpdate{}
+	}
+
+	updates, pageInfo := pageResults(page, updates, func(update models.PrayerUpdate) []interface{} {
+		return []interface{}{update.Datetime_Create, update.Prayer_Update_ID}
+	})
+
+	c.JSON(http.StatusOK, withPageInfo(gin.H{
+		"updates": updates,
+	}, page, pageInfo))
+}
+
+// CreatePrayerUpdate appends an update to the prayer's timeline. Only the
+// creator or linked subject can post. An answered update also marks the prayer
+// answered. Everyone the prayer is shared with is notified.
+func CreatePrayerUpdate(c *gin.Context) {
+	userID := c.MustGet("currentUser").(models.UserProfile).User_Profile_ID
+
+	prayerID, err := strconv.Atoi(c.Param("prayer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prayer ID", "details": err.Error()})
+		return
+	}
+
+	var request models.PrayerUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if request.UpdateType == "" {
+		request.UpdateType = models.PrayerUpdateTypeUpdate
+	}
+	if request.UpdateType != models.PrayerUpdateTypeUpdate && request.UpdateType != models.PrayerUpdateTypeAnswered {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "updateType must be update or answered"})
+		return
+	}
+
+	var prayer models.Prayer
+	found, err := initializers.DB.From("prayer").
+		Select("prayer_id", "created_by", "prayer_subject_id", "is_answered").
+		Where(goqu.C("prayer_id").Eq(prayerID), goqu.C("deleted").IsFalse()).
+		ScanStruct(&prayer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer not found"})
+		return
+	}
+
+	canPost, err := isPrayerAuthor(prayer, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions", "details": err.Error()})
+		return
+	}
+	if !canPost {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the prayer creator or subject can post updates"})
+		return
+	}
+
+	markAnswered := request.UpdateType == models.PrayerUpdateTypeAnswered &&
+		(prayer.Is_Answered == nil || !*prayer.Is_Answered)
+
+	authorName := getDisplayName(userID)
+	update := models.PrayerUpdate{
+		Prayer_ID:       prayerID,
+		User_Profile_ID: userID,
+		Update_Type:     request.UpdateType,
+		Update_Text:     request.UpdateText,
+	}
+
+	// The update, the answered flag and the notifications commit together
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post prayer update", "details": err.Error()})
+		return
+	}
+
+	err = tx.Wrap(func() error {
+		_, err := tx.Insert("prayer_update").
+			Rows(update).
+			Returning("prayer_update_id", "datetime_create").
+			Executor().ScanStruct(&update)
+		if err != nil {
+			return err
+		}
+
+		history := []models.PrayerEditHistory{
+			{Prayer_ID: prayerID, User_Profile_ID: userID, Action_Type: models.HistoryActionUpdatePosted},
+		}
+
+		if markAnswered {
+			_, err = tx.Update("prayer").
+				Set(goqu.Record{
+					"is_answered":       true,
+					"datetime_answered": goqu.L("NOW()"),
+					"updated_by":        userID,
+					"datetime_update":   goqu.L("NOW()"),
+				}).
+				Where(goqu.C("prayer_id").Eq(prayerID)).
+				Executor().Exec()
+			if err != nil {
+				return err
+			}
+			history = append(history, models.PrayerEditHistory{
+				Prayer_ID: prayerID, User_Profile_ID: userID, Action_Type: models.HistoryActionAnswered,
+			})
+		}
+
+		if _, err := tx.Insert("prayer_edit_history").Rows(history).Executor().Exec(); err != nil {
+			return err
+		}
+
+		return services.NotifyPrayerUpdatePosted(tx, prayerID, update.Prayer_Update_ID, update.Update_Type, userID, authorName)
+	})
+	if err != nil {
+		log.Printf("Failed to post prayer update: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post prayer update", "details": err.Error()})
+		return
+	}
+
+	go func(update models.PrayerUpdate, answered bool) {
+		data := services.EventData{
+			PrayerID: update.Prayer_ID,
+			UpdateID: update.Prayer_Update_ID,
+			ActorID:  update.User_Profile_ID,
+			Status:   update.Update_Type,
+		}
+		services.PublishPrayerEvent(services.EventPrayerUpdatePosted, data)
+		if answered {
+			services.PublishPrayerEvent(services.EventPrayerAnswered, services.EventData{PrayerID: update.Prayer_ID, ActorID: update.User_Profile_ID})
+		}
+	}(update, markAnswered)
+
+	update.Author_Name = authorName
+	c.JSON(http.StatusCreated, gin.H{"message": "Prayer update posted successfully", "update": update})
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test CreatePrayerUpdate - The creator or linked subject posts to the timeline
+func TestCreatePrayerUpdate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		creatorID      int
+		subjectID      *int
+		subjectLinked  bool
+		alreadyAnswer  bool
+		expectAnswered bool
+		expectedStatus int
+	}{
+		{
+			name:           "creator posts an update",
+			body:           `{"updateText":"Surgery moved to Tuesday"}`,
+			creatorID:      1,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "answered testimony marks the prayer answered",
+			body:           `{"updateType":"answered","updateText":"Biopsy came back clear"}`,
+			creatorID:      1,
+			expectAnswered: true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "answered testimony on an answered prayer",
+			body:           `{"updateType":"answered","updateText":"Still clear a year on"}`,
+			creatorID:      1,
+			alreadyAnswer:  true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "linked subject posts an update",
+			body:           `{"updateText":"Feeling much better"}`,
+			creatorID:      2,
+			subjectID:      IntPtr(4),
+			subjectLinked:  true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "unlinked subject cannot post",
+			body:           `{"updateText":"Feeling much better"}`,
+			creatorID:      2,
+			subjectID:      IntPtr(4),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "not the creator",
+			body:           `{"updateText":"Surgery moved to Tuesday"}`,
+			creatorID:      2,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid update type",
+			body:           `{"updateType":"edited","updateText":"Surgery moved to Tuesday"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing text",
+			body:           `{"updateType":"update"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.creatorID != 0 {
+				mock.ExpectQuery(`SELECT "prayer_id", "created_by", "prayer_subject_id", "is_answered" FROM "prayer"`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_id", "created_by", "prayer_subject_id", "is_answered"}).
+						AddRow(1, tt.creatorID, tt.subjectID, tt.alreadyAnswer))
+
+				if tt.subjectID != nil {
+					linked := 0
+					if tt.subjectLinked {
+						linked = 1
+					}
+					mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "prayer_subject" .*"link_status" = 'linked'`).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(linked))
+				}
+			}
+
+			if tt.expectedStatus == http.StatusCreated {
+				mock.ExpectQuery(`SELECT "username", "first_name" FROM "user_profile"`).
+					WillReturnRows(sqlmock.NewRows([]string{"username", "first_name"}).AddRow("testuser", "Test"))
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "prayer_update" .* RETURNING "prayer_update_id", "datetime_create"`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_update_id", "datetime_create"}).AddRow(7, time.Now()))
+				if tt.expectAnswered {
+					mock.ExpectExec(`UPDATE "prayer" SET .*"is_answered"=TRUE`).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec(`INSERT INTO "prayer_edit_history" .*'update_posted'.*'answered'`).WillReturnResult(sqlmock.NewResult(1, 2))
+				} else {
+					mock.ExpectExec(`INSERT INTO "prayer_edit_history" .*'update_posted'`).WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+
+				// Shared with the creator directly and with group 5 (members 2 and 3)
+				mock.ExpectQuery(`SELECT "access_type", "access_type_id" FROM "prayer_access"`).
+					WillReturnRows(sqlmock.NewRows([]string{"access_type", "access_type_id"}).
+						AddRow("user", tt.creatorID).
+						AddRow("group", 5))
+				mock.ExpectQuery(`SELECT "user_profile_id" FROM "user_group"`).
+					WillReturnRows(sqlmock.NewRows([]string{"user_profile_id"}).AddRow(2).AddRow(3))
+
+				// The author is never notified and everyone else once: members 2 and
+				// 3, with the creator being member 2 when the subject posts
+				for i := 0; i < 2; i++ {
+					mock.ExpectExec(`INSERT INTO "notification"`).WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+				mock.ExpectCommit()
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_id", Value: "1"}}
+			c.Request = httptest.NewRequest("POST", "/prayers/1/updates", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			CreatePrayerUpdate(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus == http.StatusCreated {
+				update := response["update"].(map[string]interface{})
+				assert.Equal(t, float64(7), update["prayerUpdateId"])
+				assert.Equal(t, "Test", update["authorName"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test GetPrayerUpdates - Anyone the prayer is shared with sees the timeline
+func TestGetPrayerUpdates(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		hasAccess      bool
+		expectedStatus int
+	}{
+		{
+			name:           "shared prayer",
+			hasAccess:      true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "answered updates only",
+			query:          "?updateType=answered",
+			hasAccess:      true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no access",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid update type",
+			query:          "?updateType=edited",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectedStatus != http.StatusBadRequest {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "prayer"`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				access := 0
+				if tt.hasAccess {
+					access = 1
+				}
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "prayer_access"`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(access))
+			}
+			if tt.expectedStatus == http.StatusOK {
+				expectSQL := `SELECT .* FROM "prayer_update" .* ORDER BY "prayer_update"."datetime_create" ASC`
+				if tt.query != "" {
+					expectSQL = `SELECT .* FROM "prayer_update" .*"prayer_update"."update_type" = 'answered'`
+				}
+				mock.ExpectQuery(expectSQL).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"prayer_update_id", "prayer_id", "user_profile_id", "update_type", "update_text", "author_name", "datetime_create",
+					}).AddRow(7, 1, 2, "answered", "Biopsy came back clear", "Sam", time.Now()))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Params = []gin.Param{{Key: "prayer_id", Value: "1"}}
+			c.Request = httptest.NewRequest("GET", "/prayers/1/updates"+tt.query, nil)
+
+			GetPrayerUpdates(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if tt.expectedStatus == http.StatusOK {
+				updates := response["updates"].([]interface{})
+				assert.Len(t, updates, 1)
+				assert.Equal(t, "Biopsy came back clear", updates[0].(map[string]interface{})["updateText"])
+			} else {
+				assert.NotNil(t, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+const (
+	searchMinQueryLength = 2
+	searchMaxQueryLength = 200
+	searchDefaultLimit   = 20
+	searchMaxLimit       = 50
+
+	// searchHeadlineOptions controls the ts_headline snippets
+	searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=8, MaxFragments=2"
+)
+
+// Search documents. These must stay identical to the GIN expression indexes
+// from migration 030 or Postgres falls back to a sequential scan.
+const (
+	prayerSearchDocument = "(setweight(to_tsvector('english', coalesce(prayer.title, '')), 'A') || " +
+		"setweight(to_tsvector('english', coalesce(prayer.prayer_description, '')), 'B'))"
+	prayerSubjectSearchDocument = "(setweight(to_tsvector('english', coalesce(prayer_subject.prayer_subject_display_name, '')), 'A') || " +
+		"setweight(to_tsvector('english', coalesce(prayer_subject.notes, '')), 'B'))"
+	commentSearchDocument = "to_tsvector('english', prayer_comment.comment_text)"
+)
+
+// searchFilters narrows prayer and comment results. Subjects have no answered
+// state, category or group, so they are skipped when any filter is set.
+type searchFilters struct {
+	prayerListFilters
+	groupID *int
+}
+
+func (f searchFilters) any() bool {
+	return f.isSet() || f.groupID != nil
+}
+
+// Search runs a full-text search over the caller's prayers, prayer subjects and
+// the comments they can see
+// GET /search?q=
+func Search(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	term := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(term) < searchMinQueryLength || utf8.RuneCountInString(term) > searchMaxQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query must be between 2 and 200 characters"})
+		return
+	}
+
+	limit := searchDefaultLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 || parsed > searchMaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+			return
+		}
+		limit = parsed
+	}
+
+	prayerFilters, err := parsePrayerListFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filters := searchFilters{prayerListFilters: prayerFilters}
+	if groupParam := c.Query("groupId"); groupParam != "" {
+		groupID, err := strconv.Atoi(groupParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group profile ID", "details": err.Error()})
+			return
+		}
+		if !isGroupExists(groupID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Group doesn't exist"})
+			return
+		}
+		if !isUserInGroup(c, groupID) && !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to search this group"})
+			return
+		}
+		filters.groupID = &groupID
+	}
+
+	searchTypes := map[string]bool{"prayers": true, "subjects": true, "comments": true}
+	if typesParam := c.Query("types"); typesParam != "" {
+		searchTypes = map[string]bool{}
+		for _, searchType := range strings.Split(typesParam, ",") {
+			searchType = strings.TrimSpace(searchType)
+			if searchType != "prayers" && searchType != "subjects" && searchType != "comments" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "types must be a comma-separated list of prayers, subjects, comments"})
+				return
+			}
+			searchTypes[searchType] = true
+		}
+	}
+
+	results := models.SearchResults{
+		Prayers:  []models.PrayerSearchResult{},
+		Subjects: []models.PrayerSubjectSearchResult{},
+		Comments: []models.CommentSearchResult{},
+	}
+	userID := currentUser.User_Profile_ID
+
+	if searchTypes["prayers"] {
+		err := searchPrayers(userID, term, filters, limit).ScanStructsContext(c, &results.Prayers)
+		if err != nil {
+			log.Printf("Failed to search prayers: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search prayers"})
+			return
+		}
+	}
+
+	if searchTypes["subjects"] && !filters.any() {
+		err := searchPrayerSubjects(userID, term, limit).ScanStructsContext(c, &results.Subjects)
+		if err != nil {
+			log.Printf("Failed to search prayer subjects: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search prayer subjects"})
+			return
+		}
+	}
+
+	if searchTypes["comments"] {
+		err := searchComments(userID, term, filters, limit).ScanStructsContext(c, &results.Comments)
+		if err != nil {
+			log.Printf("Failed to search comments: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search comments"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   term,
+		"results": results,
+	})
+}
+
+// searchHeadlineSource HTML-escapes the text handed to ts_headline. Snippets are
+// returned as HTML, so only the <mark> tags it adds may be markup; anything a
+// user wrote comes back as text. Ampersands go first so the other entities
+// aren't escaped twice.
+func searchHeadlineSource(text string) string {
+	for _, r := range []struct{ from, to string }{
+		{"&", "&amp;"},
+		{"<", "&lt;"},
+		{">", "&gt;"},
+		{`"`, "&quot;"},
+		{"''", "&#39;"},
+	} {
+		text = fmt.Sprintf("replace(%s, '%s', '%s')", text, r.from, r.to)
+	}
+	return text
+}
+
+// searchQuery is the parsed tsquery. websearch_to_tsquery accepts free text
+// (quotes, "or", -exclusions) and never fails on user input.
+func searchQuery(term string) exp.LiteralExpression {
+	return goqu.L("websearch_to_tsquery('english', ?)", term)
+}
+
+// accessiblePrayerIDs selects the prayers the user can see: shared with them
+// directly or with a group they belong to. A group filter narrows this to
+// that group's prayers (membership is checked by the caller).
+func accessiblePrayerIDs(userID int, groupID *int) *goqu.SelectDataset {
+	if groupID != nil {
+		return initializers.DB.From("prayer_access").
+			Select("prayer_access.prayer_id").
+			Where(goqu.Ex{"prayer_access.access_type": "group", "prayer_access.access_type_id": *groupID})
+	}
+
+	return initializers.DB.From("prayer_access").
+		Select("prayer_access.prayer_id").
+		Where(goqu.Or(
+			goqu.Ex{"prayer_access.access_type": "user", "prayer_access.access_type_id": userID},
+			goqu.And(
+				goqu.Ex{"prayer_access.access_type": "group"},
+				goqu.I("prayer_access.access_type_id").In(
+					initializers.DB.From("user_group").
+						Select("group_profile_id").
+						Where(goqu.C("user_profile_id").Eq(userID)),
+				),
+			),
+		))
+}
+
+func searchPrayers(userID int, term string, filters searchFilters, limit int) *goqu.SelectDataset {
+	where := []exp.Expression{
+		goqu.Ex{"prayer.deleted": false},
+		goqu.L(prayerSearchDocument+" @@ ?", searchQuery(term)),
+		goqu.I("prayer.prayer_id").In(accessiblePrayerIDs(userID, filters.groupID)),
+	}
+	where = append(where, filters.expressions()...)
+
+	return initializers.DB.From("prayer").
+		Select(
+			goqu.I("prayer.prayer_id"),
+			goqu.I("prayer.title"),
+			goqu.I("prayer.is_answered"),
+			goqu.I("prayer.prayer_subject_id"),
+			goqu.I("prayer.datetime_create"),
+			goqu.L("ts_headline('english', "+searchHeadlineSource("coalesce(prayer.title, '') || ' - ' || coalesce(prayer.prayer_description, '')")+", ?, ?)",
+				searchQuery(term), searchHeadlineOptions).As("snippet"),
+			goqu.L("ts_rank_cd("+prayerSearchDocument+", ?)", searchQuery(term)).As("rank"),
+		).
+		Where(where...).
+		Order(goqu.C("rank").Desc(), goqu.I("prayer.prayer_id").Desc()).
+		Limit(uint(limit))
+}
+
+// searchPrayerSubjects only covers the caller's own subjects, matching
+// GetUserPrayerSubjects
+func searchPrayerSubjects(userID int, term string, limit int) *goqu.SelectDataset {
+	return initializers.DB.From("prayer_subject").
+		Select(
+			goqu.I("prayer_subject.prayer_subject_id"),
+			goqu.I("prayer_subject.prayer_subject_type"),
+			goqu.I("prayer_subject.prayer_subject_display_name"),
+			goqu.L("ts_headline('english', "+searchHeadlineSource("prayer_subject.prayer_subject_display_name || ' - ' || coalesce(prayer_subject.notes, '')")+", ?, ?)",
+				searchQuery(term), searchHeadlineOptions).As("snippet"),
+			goqu.L("ts_rank_cd("+prayerSubjectSearchDocument+", ?)", searchQuery(term)).As("rank"),
+		).
+		Where(
+			goqu.I("prayer_subject.created_by").Eq(userID),
+			goqu.L(prayerSubjectSearchDocument+" @@ ?", searchQuery(term)),
+		).
+		Order(goqu.C("rank").Desc(), goqu.I("prayer_subject.prayer_subject_id").Desc()).
+		Limit(uint(limit))
+}
+
+// searchComments applies the same visibility as GetPrayerComments: hidden
+// comments never match, and private ones only for their author or the
+// prayer's moderators (creator and linked subject)
+func searchComments(userID int, term string, filters searchFilters, limit int) *goqu.SelectDataset {
+	where := []exp.Expression{
+		goqu.Ex{"prayer_comment.is_hidden": false},
+		goqu.Ex{"prayer.deleted": false},
+		goqu.L(commentSearchDocument+" @@ ?", searchQuery(term)),
+		goqu.I("prayer.prayer_id").In(accessiblePrayerIDs(userID, filters.groupID)),
+		goqu.Or(
+			goqu.I("prayer_comment.is_private").Eq(false),
+			goqu.I("prayer_comment.user_profile_id").Eq(userID),
+			goqu.I("prayer.created_by").Eq(userID),
+			goqu.I("prayer_subject.user_profile_id").Eq(userID),
+		),
+	}
+	where = append(where, filters.expressions()...)
+
+	return initializers.DB.From("prayer_comment").
+		Select(
+			goqu.I("prayer_comment.comment_id"),
+			goqu.I("prayer_comment.prayer_id"),
+			goqu.I("prayer.title").As("prayer_title"),
+			goqu.I("prayer_comment.user_profile_id"),
+			goqu.I("prayer_comment.datetime_create"),
+			goqu.L("ts_headline('english', "+searchHeadlineSource("prayer_comment.comment_text")+", ?, ?)",
+				searchQuery(term), searchHeadlineOptions).As("snippet"),
+			goqu.L("ts_rank_cd("+commentSearchDocument+", ?)", searchQuery(term)).As("rank"),
+		).
+		Join(
+			goqu.T("prayer"),
+			goqu.On(goqu.Ex{"prayer_comment.prayer_id": goqu.I("prayer.prayer_id")}),
+		).
+		LeftJoin(
+			goqu.T("prayer_subject"),
+			goqu.On(goqu.Ex{"prayer.prayer_subject_id": goqu.I("prayer_subject.prayer_subject_id")}),
+		).
+		Where(where...).
+		Order(goqu.C("rank").Desc(), goqu.I("prayer_comment.comment_id").Desc()).
+		Limit(uint(limit))
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Search - Full-text search over prayers, subjects and comments
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            url.Values
+		groupMember      bool
+		expectPrayers    bool
+		expectSubjects   bool
+		expectComments   bool
+		expectedStatus   int
+		expectedPrayers  int
+		expectedSubjects int
+		expectError      bool
+	}{
+		{
+			name:             "searches all types",
+			query:            url.Values{"q": {"surgery"}},
+			expectPrayers:    true,
+			expectSubjects:   true,
+			expectComments:   true,
+			expectedStatus:   http.StatusOK,
+			expectedPrayers:  1,
+			expectedSubjects: 1,
+		},
+		{
+			name:            "filters skip subjects",
+			query:           url.Values{"q": {"surgery"}, "answered": {"false"}, "categoryId": {"3"}},
+			expectPrayers:   true,
+			expectComments:  true,
+			expectedStatus:  http.StatusOK,
+			expectedPrayers: 1,
+		},
+		{
+			name:            "group filter for a member",
+			query:           url.Values{"q": {"surgery"}, "groupId": {"1"}, "types": {"prayers"}},
+			groupMember:     true,
+			expectPrayers:   true,
+			expectedStatus:  http.StatusOK,
+			expectedPrayers: 1,
+		},
+		{
+			name:           "group filter for a non-member",
+			query:          url.Values{"q": {"surgery"}, "groupId": {"1"}},
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
+		{
+			name:           "query too short",
+			query:          url.Values{"q": {" a "}},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "invalid answered filter",
+			query:          url.Values{"q": {"surgery"}, "answered": {"maybe"}},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "invalid type",
+			query:          url.Values{"q": {"surgery"}, "types": {"prayers,groups"}},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.query.Get("groupId") != "" {
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				memberCount := 0
+				if tt.groupMember {
+					memberCount = 1
+				}
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(memberCount))
+			}
+
+			if tt.expectPrayers {
+				mock.ExpectQuery("FROM \"prayer\" WHERE").
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_id", "title", "is_answered", "prayer_subject_id", "datetime_create", "snippet", "rank"}).
+						AddRow(4, "Surgery recovery", false, 2, time.Now(), "<mark>Surgery</mark> recovery - for Mom", 0.6))
+			}
+			if tt.expectSubjects {
+				mock.ExpectQuery("FROM \"prayer_subject\"").
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_subject_id", "prayer_subject_type", "prayer_subject_display_name", "snippet", "rank"}).
+						AddRow(2, "individual", "Mom", "Mom - knee <mark>surgery</mark> in May", 0.2))
+			}
+			if tt.expectComments {
+				mock.ExpectQuery("FROM \"prayer_comment\"").
+					WillReturnRows(sqlmock.NewRows([]string{"comment_id", "prayer_id", "prayer_title", "user_profile_id", "datetime_create", "snippet", "rank"}))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			c.Request = httptest.NewRequest("GET", "/search?"+tt.query.Encode(), nil)
+
+			Search(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectError {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Contains(t, response, "error")
+			} else {
+				var response struct {
+					Results models.SearchResults `json:"results"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Len(t, response.Results.Prayers, tt.expectedPrayers)
+				assert.Len(t, response.Results.Subjects, tt.expectedSubjects)
+				assert.NotNil(t, response.Results.Comments)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test searchHeadlineSource - User text is escaped before ts_headline adds <mark>
+func TestSearchHeadlineSource(t *testing.T) {
+	_, _, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	assert.Equal(t,
+		`replace(replace(replace(replace(replace(prayer_comment.comment_text, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`,
+		searchHeadlineSource("prayer_comment.comment_text"))
+
+	for _, dataset := range []*goqu.SelectDataset{
+		searchPrayers(1, "surgery", searchFilters{}, 10),
+		searchPrayerSubjects(1, "surgery", 10),
+		searchComments(1, "surgery", searchFilters{}, 10),
+	} {
+		query, _, err := dataset.ToSQL()
+		assert.NoError(t, err)
+		assert.Contains(t, query, "ts_headline('english', replace(replace(replace(replace(replace(")
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// generateAccessToken signs a short-lived JWT tied to a session. CheckAuth
+// rejects the token as soon as the session is revoked, even before it expires.
+func generateAccessToken(user models.UserProfile, sessionID int) (string, error) {
+	role := "user"
+	if user.Admin {
+		role = "admin"
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":   user.User_Profile_ID,
+		"sid":  sessionID,
+		"exp":  time.Now().Add(services.AccessTokenTTL).Unix(),
+		"role": role,
+	})
+
+	return token.SignedString([]byte(os.Getenv("SECRET")))
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new
+// refresh token. The old refresh token stops working immediately.
+func RefreshSession(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required", "details": err.Error()})
+		return
+	}
+
+	session, refreshToken, err := services.RotateSession(req.Refresh_Token)
+	if errors.Is(err, services.ErrSessionNotFound) || errors.Is(err, services.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to rotate session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session", "details": err.Error()})
+		return
+	}
+
+	var user models.UserProfile
+	found, err := initializers.DB.From("user_profile").
+		Select("*").
+		Where(goqu.C("user_profile_id").Eq(session.User_Profile_ID)).
+		ScanStruct(&user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user profile", "details": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	accessToken, err := generateAccessToken(user, session.User_Session_ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Session refreshed successfully.",
+		"token":        accessToken,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(services.AccessTokenTTL.Seconds()),
+	})
+}
+
+// Logout revokes the session that issued the current access token
+func Logout(c *gin.Context) {
+	sessionID := c.GetInt("sessionID")
+	if sessionID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No active session"})
+		return
+	}
+
+	if err := services.RevokeSession(sessionID); err != nil {
+		log.Printf("Failed to revoke session %d: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully."})
+}
+
+// LogoutAllDevices revokes every session for the current user, including this one
+func LogoutAllDevices(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	revoked, err := services.RevokeAllUserSessions(currentUser.User_Profile_ID, 0)
+	if err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", currentUser.User_Profile_ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out of all devices", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Logged out of all devices.",
+		"sessionsRevoked": revoked,
+	})
+}
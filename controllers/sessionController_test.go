@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test RefreshSession - Rotate refresh token and issue a new access token
+func TestRefreshSession(t *testing.T) {
+	os.Setenv("SECRET", "test-secret-key")
+	defer os.Unsetenv("SECRET")
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		tokenCurrent   bool
+		tokenReused    bool
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful refresh",
+			requestBody:    models.RefreshTokenRequest{Refresh_Token: "current-token"},
+			tokenCurrent:   true,
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "rotated token replayed - session revoked",
+			requestBody:    models.RefreshTokenRequest{Refresh_Token: "rotated-token"},
+			tokenReused:    true,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "token rotated out several refreshes ago - session revoked",
+			requestBody:    models.RefreshTokenRequest{Refresh_Token: "older-rotated-token"},
+			tokenReused:    true,
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "unknown or revoked token",
+			requestBody:    models.RefreshTokenRequest{Refresh_Token: "unknown-token"},
+			expectedStatus: http.StatusUnauthorized,
+			expectError:    true,
+		},
+		{
+			name:           "missing refresh token",
+			requestBody:    map[string]interface{}{},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if _, ok := tt.requestBody.(models.RefreshTokenRequest); ok {
+				rotateRows := sqlmock.NewRows([]string{"user_session_id", "user_profile_id"})
+				if tt.tokenCurrent {
+					rotateRows.AddRow(10, 1)
+				}
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE \"user_session\"").WillReturnRows(rotateRows)
+				if tt.tokenCurrent {
+					// The old token is remembered so a later replay can be detected
+					mock.ExpectExec("INSERT INTO \"user_session_rotated_token\"").
+						WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+				mock.ExpectCommit()
+
+				if tt.tokenCurrent {
+					now := time.Now()
+					mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{
+						"user_profile_id", "email", "first_name", "last_name", "password",
+						"datetime_create", "datetime_update", "created_by", "updated_by", "admin",
+					}).AddRow(1, "test@example.com", "Test", "User", "hashedpassword", now, now, 1, 1, false))
+				} else {
+					// Check whether the token was already rotated out
+					reuseRows := sqlmock.NewRows([]string{"user_session_id"})
+					if tt.tokenReused {
+						reuseRows.AddRow(10)
+					}
+					mock.ExpectQuery("SELECT \"user_session_rotated_token\".\"user_session_id\" FROM \"user_session_rotated_token\"").WillReturnRows(reuseRows)
+
+					if tt.tokenReused {
+						mock.ExpectExec("UPDATE \"user_session\"").
+							WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+				}
+			}
+
+			c, w := SetupTestContext()
+			jsonData, _ := json.Marshal(tt.requestBody)
+			c.Request = httptest.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			RefreshSession(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectError {
+				assert.NotNil(t, response["error"])
+			} else {
+				assert.NotEmpty(t, response["token"])
+				assert.NotEmpty(t, response["refreshToken"])
+				assert.NotEqual(t, "current-token", response["refreshToken"])
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test Logout - Revoke the current session
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		name           string
+		sessionID      int
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful logout",
+			sessionID:      10,
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "no session in context",
+			sessionID:      0,
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.sessionID != 0 {
+				mock.ExpectExec("UPDATE \"user_session\"").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			if tt.sessionID != 0 {
+				c.Set("sessionID", tt.sessionID)
+			}
+			c.Request = httptest.NewRequest("POST", "/auth/logout", nil)
+
+			Logout(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			if tt.expectError {
+				assert.NotNil(t, response["error"])
+			} else {
+				assert.NotNil(t, response["message"])
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// Test LogoutAllDevices - Revoke every session for the current user
+func TestLogoutAllDevices(t *testing.T) {
+	_, mock, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE \"user_session\"").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	c, w := SetupTestContext()
+	SetAuthenticatedUser(c, MockUser(), false)
+	c.Set("sessionID", 10)
+	c.Request = httptest.NewRequest("POST", "/auth/logout-all", nil)
+
+	LogoutAllDevices(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, float64(3), response["sessionsRevoked"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
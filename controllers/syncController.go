@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+const (
+	syncCursorKind = "sync"
+
+	// syncOverlap re-sends rows written shortly before the previous checkpoint,
+	// so a transaction that committed after that sync started is not skipped.
+	// Clients upsert by ID, so the duplicates are harmless.
+	syncOverlap = 30 * time.Second
+)
+
+// syncScope builds the visibility and "changed since" conditions shared by
+// every query in a sync. since is nil for a full sync.
+type syncScope struct {
+	userID int
+	since  *time.Time
+}
+
+// GetSync returns everything the current user can see that changed since the
+// cursor from their previous sync, plus tombstones for what was removed.
+// Without a cursor, or with one older than the tombstone retention, it
+// returns a full snapshot and the client should replace its cache.
+// GET /sync?since=
+func GetSync(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+
+	// Taken before any query runs so anything written during this sync is
+	// picked up by the next one
+	checkpoint := time.Now().UTC()
+
+	scope := syncScope{userID: currentUser.User_Profile_ID}
+	if sinceParam := c.Query("since"); sinceParam != "" {
+		since, err := decodeSyncCursor(sinceParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sync cursor"})
+			return
+		}
+		if checkpoint.Sub(since) <= services.SyncTombstoneRetention {
+			since = since.Add(-syncOverlap)
+			scope.since = &since
+		}
+	}
+
+	cursor, err := encodePageCursor(syncCursorKind, []interface{}{checkpoint.Format(time.RFC3339Nano)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create sync cursor", "details": err.Error()})
+		return
+	}
+
+	response := models.SyncResponse{
+		Cursor:         cursor,
+		Full_Sync:      scope.since == nil,
+		Prayers:        []models.Prayer{},
+		Prayer_Access:  []models.PrayerAccess{},
+		Categories:     []models.PrayerCategory{},
+		Category_Items: []models.PrayerCategoryItem{},
+		Subjects:       []models.PrayerSubject{},
+		Groups:         []models.GroupProfile{},
+		Memberships:    []models.UserGroup{},
+		Notifications:  []models.Notification{},
+		Deleted:        []models.SyncTombstone{},
+	}
+
+	steps := []struct {
+		name  string
+		query *goqu.SelectDataset
+		dest  interface{}
+	}{
+		{"prayers", scope.prayers(), &response.Prayers},
+		{"prayer access", scope.prayerAccess(), &response.Prayer_Access},
+		{"categories", scope.categories(), &response.Categories},
+		{"category items", scope.categoryItems(), &response.Category_Items},
+		{"prayer subjects", scope.prayerSubjects(), &response.Subjects},
+		{"groups", scope.groups(), &response.Groups},
+		{"memberships", scope.memberships(), &response.Memberships},
+		{"notifications", scope.notifications(), &response.Notifications},
+	}
+	if scope.since != nil {
+		steps = append(steps, struct {
+			name  string
+			query *goqu.SelectDataset
+			dest  interface{}
+		}{"deletions", scope.tombstones(), &response.Deleted})
+	}
+
+	for _, step := range steps {
+		if err := step.query.ScanStructsContext(c, step.dest); err != nil {
+			log.Printf("Failed to sync %s for user %d: %v", step.name, scope.userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync " + step.name, "details": err.Error()})
+			return
+		}
+	}
+
+	services.PruneSyncTombstones(scope.userID)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func decodeSyncCursor(cursor string) (time.Time, error) {
+	values, err := decodePageCursor(cursor, syncCursorKind)
+	if err != nil || len(values) != 1 {
+		return time.Time{}, errInvalidCursor
+	}
+	value, ok := values[0].(string)
+	if !ok {
+		return time.Time{}, errInvalidCursor
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// changedSince matches rows whose column moved past the cursor. A full sync
+// matches everything.
+func (s syncScope) changedSince(column string, alsoChanged ...exp.Expression) exp.Expression {
+	if s.since == nil {
+		return goqu.L("TRUE")
+	}
+	return goqu.Or(append([]exp.Expression{goqu.I(column).Gte(*s.since)}, alsoChanged...)...)
+}
+
+func (s syncScope) memberGroupIDs() *goqu.SelectDataset {
+	return initializers.DB.From("user_group").
+		Select("group_profile_id").
+		Where(goqu.C("user_profile_id").Eq(s.userID))
+}
+
+// joinedGroupIDs are groups the user joined since the cursor; everything
+// already in them is new to this client even though it did not change
+func (s syncScope) joinedGroupIDs() *goqu.SelectDataset {
+	return s.memberGroupIDs().Where(goqu.C("datetime_create").Gte(*s.since))
+}
+
+// visibleAccess matches the prayer_access rows the user sees: their own list
+// and every group they belong to
+func (s syncScope) visibleAccess() exp.Expression {
+	return goqu.Or(
+		goqu.Ex{"prayer_access.access_type": "user", "prayer_access.access_type_id": s.userID},
+		goqu.And(
+			goqu.Ex{"prayer_access.access_type": "group"},
+			goqu.I("prayer_access.access_type_id").In(s.memberGroupIDs()),
+		),
+	)
+}
+
+func (s syncScope) accessChanged() exp.Expression {
+	if s.since == nil {
+		return goqu.L("TRUE")
+	}
+	return s.changedSince("prayer_access.datetime_update", goqu.And(
+		goqu.Ex{"prayer_access.access_type": "group"},
+		goqu.I("prayer_access.access_type_id").In(s.joinedGroupIDs()),
+	))
+}
+
+func (s syncScope) visibleCategory() exp.Expression {
+	return goqu.Or(
+		goqu.Ex{"prayer_category.category_type": "user", "prayer_category.category_type_id": s.userID},
+		goqu.And(
+			goqu.Ex{"prayer_category.category_type": "group"},
+			goqu.I("prayer_category.category_type_id").In(s.memberGroupIDs()),
+		),
+	)
+}
+
+func (s syncScope) categoryChanged() exp.Expression {
+	if s.since == nil {
+		return goqu.L("TRUE")
+	}
+	return s.changedSince("prayer_category.datetime_update", goqu.And(
+		goqu.Ex{"prayer_category.category_type": "group"},
+		goqu.I("prayer_category.category_type_id").In(s.joinedGroupIDs()),
+	))
+}
+
+// prayers returns visible prayers that were edited or newly shared with the user
+func (s syncScope) prayers() *goqu.SelectDataset {
+	visiblePrayerIDs := initializers.DB.From("prayer_access").
+		Select("prayer_access.prayer_id").
+		Where(s.visibleAccess())
+
+	var changed exp.Expression = goqu.L("TRUE")
+	if s.since != nil {
+		changed = s.changedSince("prayer.datetime_update",
+			goqu.I("prayer.prayer_id").In(visiblePrayerIDs.Where(s.accessChanged())))
+	}
+
+	return initializers.DB.From("prayer").
+		Where(
+			goqu.Ex{"prayer.deleted": false},
+			goqu.I("prayer.prayer_id").In(visiblePrayerIDs),
+			changed,
+		).
+		Order(goqu.I("prayer.prayer_id").Asc())
+}
+
+func (s syncScope) prayerAccess() *goqu.SelectDataset {
+	return initializers.DB.From("prayer_access").
+		Where(s.visibleAccess(), s.accessChanged()).
+		Order(goqu.I("prayer_access.prayer_access_id").Asc())
+}
+
+func (s syncScope) categories() *goqu.SelectDataset {
+	return initializers.DB.From("prayer_category").
+		Where(s.visibleCategory(), s.categoryChanged()).
+		Order(goqu.I("prayer_category.prayer_category_id").Asc())
+}
+
+// categoryItems follow their category: an item is new to the client when it
+// changed or when its whole category is
+func (s syncScope) categoryItems() *goqu.SelectDataset {
+	visibleCategoryIDs := initializers.DB.From("prayer_category").
+		Select("prayer_category.prayer_category_id").
+		Where(s.visibleCategory())
+
+	var changed exp.Expression = goqu.L("TRUE")
+	if s.since != nil {
+		changed = s.changedSince("prayer_category_item.datetime_update",
+			goqu.I("prayer_category_item.prayer_category_id").In(visibleCategoryIDs.Where(s.categoryChanged())))
+	}
+
+	return initializers.DB.From("prayer_category_item").
+		Where(
+			goqu.I("prayer_category_item.prayer_category_id").In(visibleCategoryIDs),
+			changed,
+		).
+		Order(goqu.I("prayer_category_item.prayer_category_item_id").Asc())
+}
+
+// prayerSubjects only covers the user's own subjects, matching GetUserPrayerSubjects
+func (s syncScope) prayerSubjects() *goqu.SelectDataset {
+	return initializers.DB.From("prayer_subject").
+		Where(
+			goqu.I("prayer_subject.created_by").Eq(s.userID),
+			s.changedSince("prayer_subject.datetime_update"),
+		).
+		Order(goqu.I("prayer_subject.display_sequence").Asc())
+}
+
+// groups returns the user's groups in the same shape as GetUserGroups. Inactive
+// groups and memberships are included so the client learns about the change.
+func (s syncScope) groups() *goqu.SelectDataset {
+	return initializers.DB.From("user_group").
+		Select(
+			"group_profile.group_profile_id",
+			"group_profile.group_name",
+			"group_profile.group_description",
+			"group_profile.is_active",
+			"group_profile.datetime_create",
+			"group_profile.datetime_update",
+			"group_profile.created_by",
+			"group_profile.updated_by",
+			"group_profile.deleted",
+			"group_profile.prayer_subject_id",
+			"group_profile.requires_approval",
+			"user_group.group_display_sequence",
+			"user_group.role",
+		).
+		InnerJoin(
+			goqu.T("group_profile"),
+			goqu.On(goqu.Ex{"user_group.group_profile_id": goqu.I("group_profile.group_profile_id")}),
+		).
+		Where(
+			goqu.I("user_group.user_profile_id").Eq(s.userID),
+			s.changedSince("group_profile.datetime_update", s.changedSince("user_group.datetime_update")),
+		).
+		Order(goqu.I("user_group.group_display_sequence").Asc())
+}
+
+func (s syncScope) memberships() *goqu.SelectDataset {
+	return initializers.DB.From("user_group").
+		Where(
+			goqu.I("user_group.user_profile_id").Eq(s.userID),
+			s.changedSince("user_group.datetime_update"),
+		).
+		Order(goqu.I("user_group.group_display_sequence").Asc())
+}
+
+func (s syncScope) notifications() *goqu.SelectDataset {
+	return initializers.DB.From("notification").
+		Select("notification_id",
+			"user_profile_id",
+			"notification_type",
+			"notification_message",
+			"notification_status",
+			"datetime_create",
+			"datetime_update",
+			"created_by",
+			"updated_by",
+			"target_prayer_id",
+			"target_group_id").
+		Where(
+			goqu.C("user_profile_id").Eq(s.userID),
+			s.changedSince("datetime_update"),
+		).
+		Order(goqu.C("datetime_create").Desc())
+}
+
+// tombstones combines recorded hard deletes with prayers that were soft
+// deleted while still shared with the user
+func (s syncScope) tombstones() *goqu.SelectDataset {
+	recorded := initializers.DB.From("sync_tombstone").
+		Select("entity_type", "entity_id", "datetime_delete").
+		Where(
+			goqu.C("user_profile_id").Eq(s.userID),
+			goqu.C("datetime_delete").Gte(*s.since),
+		)
+
+	softDeleted := initializers.DB.From("prayer").
+		Select(
+			goqu.V(models.SyncEntityPrayer).As("entity_type"),
+			goqu.I("prayer.prayer_id").As("entity_id"),
+			goqu.I("prayer.datetime_update").As("datetime_delete"),
+		).
+		Where(
+			goqu.Ex{"prayer.deleted": true},
+			goqu.I("prayer.datetime_update").Gte(*s.since),
+			goqu.I("prayer.prayer_id").In(
+				initializers.DB.From("prayer_access").
+					Select("prayer_access.prayer_id").
+					Where(s.visibleAccess()),
+			),
+		)
+
+	return initializers.DB.From(recorded.UnionAll(softDeleted).As("deleted")).
+		Order(goqu.C("datetime_delete").Asc())
+}
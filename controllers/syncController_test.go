@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test GetSync - Delta sync for offline clients
+func TestGetSync(t *testing.T) {
+	now := time.Now().UTC()
+	recentCursor, _ := encodePageCursor(syncCursorKind, []interface{}{now.Add(-time.Hour).Format(time.RFC3339Nano)})
+	staleCursor, _ := encodePageCursor(syncCursorKind, []interface{}{now.Add(-services.SyncTombstoneRetention - time.Hour).Format(time.RFC3339Nano)})
+	otherKindCursor, _ := encodePageCursor("notifications", []interface{}{now.Format(time.RFC3339Nano)})
+
+	tests := []struct {
+		name             string
+		since            string
+		expectedStatus   int
+		expectFullSync   bool
+		expectTombstones bool
+		expectError      bool
+	}{
+		{
+			name:           "full sync without a cursor",
+			expectedStatus: http.StatusOK,
+			expectFullSync: true,
+		},
+		{
+			name:             "delta sync returns changes and tombstones",
+			since:            recentCursor,
+			expectedStatus:   http.StatusOK,
+			expectTombstones: true,
+		},
+		{
+			name:           "cursor past tombstone retention falls back to full sync",
+			since:          staleCursor,
+			expectedStatus: http.StatusOK,
+			expectFullSync: true,
+		},
+		{
+			name:           "cursor from another endpoint",
+			since:          otherKindCursor,
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:           "garbage cursor",
+			since:          "not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if tt.expectedStatus == http.StatusOK {
+				mock.ExpectQuery(`SELECT .* FROM "prayer" WHERE`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_id", "title", "deleted", "datetime_update"}).
+						AddRow(7, "Job interview", false, now))
+				mock.ExpectQuery(`SELECT .* FROM "prayer_access" WHERE`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_access_id", "prayer_id", "access_type", "access_type_id"}).
+						AddRow(11, 7, "group", 1))
+				mock.ExpectQuery(`SELECT .* FROM "prayer_category" WHERE`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_category_id"}))
+				mock.ExpectQuery(`SELECT .* FROM "prayer_category_item" WHERE`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_category_item_id"}))
+				mock.ExpectQuery(`SELECT .* FROM "prayer_subject" WHERE`).
+					WillReturnRows(sqlmock.NewRows([]string{"prayer_subject_id"}))
+				mock.ExpectQuery(`SELECT .* FROM "user_group" INNER JOIN "group_profile"`).
+					WillReturnRows(sqlmock.NewRows([]string{"group_profile_id", "group_name"}).AddRow(1, "Small Group"))
+				mock.ExpectQuery(`SELECT .* FROM "user_group" WHERE`).
+					WillReturnRows(sqlmock.NewRows([]string{"user_group_id", "user_profile_id", "group_profile_id"}).AddRow(4, 1, 1))
+				mock.ExpectQuery(`SELECT .* FROM "notification" WHERE`).
+					WillReturnRows(sqlmock.NewRows([]string{"notification_id"}))
+
+				if tt.expectTombstones {
+					mock.ExpectQuery(`SELECT .* FROM \(SELECT "entity_type", "entity_id", "datetime_delete" FROM "sync_tombstone" .* UNION ALL`).
+						WillReturnRows(sqlmock.NewRows([]string{"entity_type", "entity_id", "datetime_delete"}).
+							AddRow(models.SyncEntityPrayerAccess, 9, now).
+							AddRow(models.SyncEntityPrayer, 8, now))
+				}
+
+				mock.ExpectExec(`DELETE FROM "sync_tombstone"`).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), false)
+			target := "/sync"
+			if tt.since != "" {
+				target += "?" + url.Values{"since": {tt.since}}.Encode()
+			}
+			c.Request = httptest.NewRequest("GET", target, nil)
+
+			GetSync(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectError {
+				var response map[string]interface{}
+				_ = json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NotNil(t, response["error"])
+				return
+			}
+
+			var response models.SyncResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectFullSync, response.Full_Sync)
+			assert.Len(t, response.Prayers, 1)
+			assert.Len(t, response.Prayer_Access, 1)
+			assert.Len(t, response.Groups, 1)
+			assert.Len(t, response.Memberships, 1)
+			assert.NotNil(t, response.Categories)
+			assert.NotNil(t, response.Notifications)
+
+			if tt.expectTombstones {
+				assert.Len(t, response.Deleted, 2)
+				assert.Equal(t, models.SyncEntityPrayerAccess, response.Deleted[0].Entity_Type)
+			} else {
+				assert.Empty(t, response.Deleted)
+			}
+
+			next, err := decodeSyncCursor(response.Cursor)
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), next, time.Minute)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSyncScopeDelta(t *testing.T) {
+	_, _, cleanup := SetupTestDB(t)
+	defer cleanup()
+
+	since := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	scope := syncScope{userID: 1, since: &since}
+
+	sql, _, err := scope.prayerAccess().ToSQL()
+	assert.NoError(t, err)
+	// Rows edited since the cursor, or already in a group the user joined since then
+	assert.Contains(t, sql, `"prayer_access"."datetime_update" >= '2026-03-01T10:00:00Z'`)
+	assert.Contains(t, sql, `"datetime_create" >= '2026-03-01T10:00:00Z'`)
+
+	full := syncScope{userID: 1}
+	sql, _, err = full.prayerAccess().ToSQL()
+	assert.NoError(t, err)
+	assert.NotContains(t, sql, "datetime_update")
+}
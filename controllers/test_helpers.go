@@ -52,3 +52,31 @@ func SetAuthenticatedUser(c *gin.Context, user models.UserProfile, isAdmin bool)
 	c.Set("currentUser", user)
 	c.Set("admin", isAdmin)
 }
+
+// ExpectGroupRole mocks the getGroupRole lookup. An empty role means the user
+// is not an active member of the group.
+func ExpectGroupRole(mock sqlmock.Sqlmock, role string) {
+	rows := sqlmock.NewRows([]string{"role"})
+	if role != "" {
+		rows.AddRow(role)
+	}
+	mock.ExpectQuery(`SELECT "role" FROM "user_group"`).WillReturnRows(rows)
+}
+
+// ExpectNotifications mocks the notifications a handler sends inside its
+// transaction: the group name lookup, optionally the recipient lookup, and one
+// in-app row per recipient (tests run without preferences or an outbox)
+func ExpectNotifications(mock sqlmock.Sqlmock, lookupRecipients bool, recipientIDs ...int) {
+	mock.ExpectQuery(`SELECT "group_name" FROM "group_profile"`).
+		WillReturnRows(sqlmock.NewRows([]string{"group_name"}).AddRow("Test Group"))
+	if lookupRecipients {
+		rows := sqlmock.NewRows([]string{"user_profile_id"})
+		for _, id := range recipientIDs {
+			rows.AddRow(id)
+		}
+		mock.ExpectQuery(`SELECT "user_profile_id" FROM "user_group"`).WillReturnRows(rows)
+	}
+	for range recipientIDs {
+		mock.ExpectExec(`INSERT INTO "notification"`).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+}
@@ -7,11 +7,9 @@ import (
 	"strconv"
 	"strings"
 
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 
 	"github.com/PrayerLoop/initializers"
 	"github.com/PrayerLoop/models"
@@ -93,15 +91,25 @@ func PublicUserSignup(c *gin.Context) {
 		phoneNumber = &user.Phone_Number
 	}
 
+	// New accounts start unverified with a code already issued
+	verificationCode, err := generate6DigitCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate verification code"})
+		return
+	}
+	verificationExpiresAt := time.Now().Add(emailVerificationTTL)
+
 	newUser := models.UserProfile{
-		Username:     user.Username,
-		Password:     string(passwordHash),
-		Email:        user.Email,
-		First_Name:   user.First_Name,
-		Last_Name:    user.Last_Name,
-		Phone_Number: phoneNumber,
-		Created_By:   1,
-		Updated_By:   1,
+		Username:                user.Username,
+		Password:                string(passwordHash),
+		Email:                   user.Email,
+		First_Name:              user.First_Name,
+		Last_Name:               user.Last_Name,
+		Phone_Number:            phoneNumber,
+		Verification_Token:      &verificationCode,
+		Verification_Expires_At: &verificationExpiresAt,
+		Created_By:              1,
+		Updated_By:              1,
 	}
 
 	insert := initializers.DB.Insert("user_profile").Rows(newUser).Returning("user_profile_id")
@@ -136,6 +144,8 @@ func PublicUserSignup(c *gin.Context) {
 		}
 	}
 
+	sendEmailVerificationCode(user.Email, verificationCode, user.First_Name)
+
 	c.JSON(200, gin.H{
 		"message": "User created successfully.",
 		"user":    user,
@@ -304,29 +314,25 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	role := ""
-	if dbUser.Admin {
-		role = "admin"
-	} else {
-		role = "user"
+	sessionID, refreshToken, err := services.CreateSession(dbUser.User_Profile_ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		log.Printf("Failed to create session for user %d: %v", dbUser.User_Profile_ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session", "details": err.Error()})
+		return
 	}
 
-	generateToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":   dbUser.User_Profile_ID,
-		"exp":  time.Now().Add(time.Hour * 24).Unix(),
-		"role": role,
-	})
-
-	token, err := generateToken.SignedString([]byte(os.Getenv("SECRET")))
-
+	token, err := generateAccessToken(dbUser, sessionID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to generate token", "details": err.Error()})
+		return
 	}
 
 	c.JSON(200, gin.H{
-		"message": "User logged in successfully.",
-		"token":   token,
-		"user":    dbUser,
+		"message":      "User logged in successfully.",
+		"token":        token,
+		"refreshToken": refreshToken,
+		"expiresIn":    int(services.AccessTokenTTL.Seconds()),
+		"user":         dbUser,
 	})
 }
 
@@ -367,7 +373,9 @@ func GetUserGroups(c *gin.Context) {
 			"group_profile.updated_by",
 			"group_profile.deleted",
 			"group_profile.prayer_subject_id",
+			"group_profile.requires_approval",
 			"user_group.group_display_sequence",
+			"user_group.role",
 		).
 		InnerJoin(
 			goqu.T("group_profile"),
@@ -511,9 +519,21 @@ func GetUserPrayers(c *gin.Context) {
 		return
 	}
 
+	page, err := parsePageRequest(c, "user_prayers")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filters, err := parsePrayerListFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var userPrayers []models.UserPrayer
 
-	dbErr := initializers.DB.From("prayer_access").
+	query := initializers.DB.From("prayer_access").
 		Select(
 			goqu.L("?", currentUser.User_Profile_ID).As("user_profile_id"),
 			goqu.I("prayer.prayer_id"),
@@ -531,6 +551,7 @@ func GetUserPrayers(c *gin.Context) {
 			goqu.I("prayer.updated_by"),
 			goqu.I("prayer.datetime_update"),
 			goqu.I("prayer.deleted"),
+			goqu.I("prayer.is_archived"),
 			goqu.I("prayer_category.prayer_category_id"),
 			goqu.I("prayer_category.category_name"),
 			goqu.I("prayer_category.category_color"),
@@ -555,23 +576,33 @@ func GetUserPrayers(c *gin.Context) {
 				goqu.Ex{"prayer.deleted": false},
 			),
 		).
-		Order(goqu.I("prayer_access.display_sequence").Asc()).
-		ScanStructsContext(c, &userPrayers)
+		Where(filters.expressions()...).
+		Order(goqu.I("prayer_access.display_sequence").Asc())
+
+	query = page.apply(query, false, goqu.I("prayer_access.display_sequence"), goqu.I("prayer_access.prayer_access_id"))
+	dbErr := query.ScanStructsContext(c, &userPrayers)
 
 	if dbErr != nil {
 		c.JSON(500, gin.H{"error": dbErr.Error()})
 		return
 	}
 
+	userPrayers, pageInfo := pageResults(page, userPrayers, func(prayer models.UserPrayer) []interface{} {
+		return []interface{}{prayer.Display_Sequence, prayer.Prayer_Access_ID}
+	})
+
 	if len(userPrayers) == 0 {
-		c.JSON(200, gin.H{"message": "No prayer records found."})
+		c.JSON(200, withPageInfo(gin.H{
+			"message": "No prayer records found.",
+			"prayers": []models.UserPrayer{},
+		}, page, pageInfo))
 		return
 	}
 
-	c.JSON(200, gin.H{
+	c.JSON(200, withPageInfo(gin.H{
 		"message": "Prayer records retrieved successfully.",
 		"prayers": userPrayers,
-	})
+	}, page, pageInfo))
 }
 
 func CreateUserPrayer(c *gin.Context) {
@@ -633,22 +664,6 @@ func CreateUserPrayer(c *gin.Context) {
 		}
 	}
 
-	// Shift all existing prayers in this subject down by incrementing their subject_display_sequence
-	// This makes room for the new prayer at position 0 (top of subject list)
-	updateSubjectSeqQuery := initializers.DB.Update("prayer").
-		Set(goqu.Record{"subject_display_sequence": goqu.L("subject_display_sequence + 1")}).
-		Where(
-			goqu.C("prayer_subject_id").Eq(prayerSubjectID),
-			goqu.C("deleted").Eq(false),
-		)
-
-	_, err = updateSubjectSeqQuery.Executor().Exec()
-	if err != nil {
-		log.Println("Failed to update prayer subject display sequence:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder prayers in subject", "details": err.Error()})
-		return
-	}
-
 	newPrayerEntry := models.Prayer{
 		Prayer_Type:              newPrayer.Prayer_Type,
 		Is_Private:               newPrayer.Is_Private,
@@ -665,67 +680,87 @@ func CreateUserPrayer(c *gin.Context) {
 		Datetime_Update:          time.Now(),
 	}
 
-	prayerInsert := initializers.DB.Insert("prayer").Rows(newPrayerEntry).Returning("prayer_id")
-
-	var insertedPrayerID int
-	_, err = prayerInsert.Executor().ScanVal(&insertedPrayerID)
+	// The prayer, its place in the user's list and its history commit together
+	tx, err := initializers.DB.Begin()
 	if err != nil {
-		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer record", "details": err.Error()})
 		return
 	}
 
-	// Shift all existing prayers down by incrementing their display_sequence
-	// This makes room for the new prayer at position 0 (top of list)
-	updateQuery := initializers.DB.Update("prayer_access").
-		Set(goqu.Record{"display_sequence": goqu.L("display_sequence + 1")}).
-		Where(
-			goqu.C("access_type").Eq("user"),
-			goqu.C("access_type_id").Eq(userID),
-		)
-
-	_, err = updateQuery.Executor().Exec()
-	if err != nil {
-		log.Println("Failed to update prayer display sequence:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder prayers", "details": err.Error()})
-		return
-	}
+	var insertedPrayerID int
+	var insertedPrayerAccessID int
+	err = tx.Wrap(func() error {
+		// Shift all existing prayers in this subject down by incrementing their subject_display_sequence
+		// This makes room for the new prayer at position 0 (top of subject list)
+		_, err := tx.Update("prayer").
+			Set(goqu.Record{"subject_display_sequence": goqu.L("subject_display_sequence + 1")}).
+			Where(
+				goqu.C("prayer_subject_id").Eq(prayerSubjectID),
+				goqu.C("deleted").Eq(false),
+			).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to reorder prayers in subject: %w", err)
+		}
 
-	// Insert new prayer at position 0 (top of list)
-	newPrayerAccessEntry := models.PrayerAccess{
-		Prayer_ID:        insertedPrayerID,
-		Access_Type:      "user",
-		Access_Type_ID:   userID,
-		Display_Sequence: 0,
-		Created_By:       currentUser.User_Profile_ID,
-		Updated_By:       currentUser.User_Profile_ID,
-		Datetime_Create:  time.Now(),
-		Datetime_Update:  time.Now(),
-	}
+		_, err = tx.Insert("prayer").Rows(newPrayerEntry).Returning("prayer_id").Executor().ScanVal(&insertedPrayerID)
+		if err != nil {
+			return fmt.Errorf("failed to create prayer record: %w", err)
+		}
 
-	prayerAccessInsert := initializers.DB.Insert("prayer_access").Rows(newPrayerAccessEntry).Returning("prayer_access_id")
+		// Shift all existing prayers down by incrementing their display_sequence
+		// This makes room for the new prayer at position 0 (top of list)
+		_, err = tx.Update("prayer_access").
+			Set(goqu.Record{"display_sequence": goqu.L("display_sequence + 1")}).
+			Where(
+				goqu.C("access_type").Eq("user"),
+				goqu.C("access_type_id").Eq(userID),
+			).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to reorder prayers: %w", err)
+		}
 
-	var insertedPrayerAccessID int
-	_, err = prayerAccessInsert.Executor().ScanVal(&insertedPrayerAccessID)
-	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer access record", "details": err.Error()})
-		return
-	}
+		// Insert new prayer at position 0 (top of list)
+		newPrayerAccessEntry := models.PrayerAccess{
+			Prayer_ID:        insertedPrayerID,
+			Access_Type:      "user",
+			Access_Type_ID:   userID,
+			Display_Sequence: 0,
+			Created_By:       currentUser.User_Profile_ID,
+			Updated_By:       currentUser.User_Profile_ID,
+			Datetime_Create:  time.Now(),
+			Datetime_Update:  time.Now(),
+		}
+		_, err = tx.Insert("prayer_access").Rows(newPrayerAccessEntry).Returning("prayer_access_id").Executor().ScanVal(&insertedPrayerAccessID)
+		if err != nil {
+			return fmt.Errorf("failed to create prayer access record: %w", err)
+		}
 
-	// Log prayer creation to history (async, non-blocking)
-	go func(prayerID int, userID int) {
+		// Log prayer creation to history with its first version
 		historyEntry := models.PrayerEditHistory{
-			Prayer_ID:       prayerID,
-			User_Profile_ID: userID,
+			Prayer_ID:       insertedPrayerID,
+			User_Profile_ID: currentUser.User_Profile_ID,
 			Action_Type:     models.HistoryActionCreated,
+			New_Version:     encodePrayerVersion(prayerVersionOf(newPrayerEntry)),
 		}
-		insert := initializers.DB.Insert("prayer_edit_history").Rows(historyEntry)
-		_, err := insert.Executor().Exec()
+		_, err = tx.Insert("prayer_edit_history").Rows(historyEntry).Executor().Exec()
 		if err != nil {
-			log.Printf("Failed to log prayer creation to history: %v", err)
+			return fmt.Errorf("failed to log prayer creation to history: %w", err)
 		}
-	}(insertedPrayerID, currentUser.User_Profile_ID)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer record", "details": err.Error()})
+		return
+	}
+
+	// The user's other devices
+	services.PublishToUsers(services.EventPrayerCreated, services.EventData{
+		PrayerID: insertedPrayerID,
+		ActorID:  currentUser.User_Profile_ID,
+	}, currentUser.User_Profile_ID)
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Prayer created sucessfully!",
 		"prayerId":       insertedPrayerID,
@@ -1026,6 +1061,36 @@ func UpdateUserPreferences(c *gin.Context) {
 		return
 	}
 
+	if updatedPreference.Preference_Key == services.TimezonePreferenceKey {
+		if _, err := time.LoadLocation(updatedPreference.Preference_Value); err != nil || updatedPreference.Preference_Value == "" || updatedPreference.Preference_Value == "Local" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Invalid value for preference key 'timezone'. Expected an IANA timezone such as 'America/Chicago', but received '%s'",
+					updatedPreference.Preference_Value),
+			})
+			return
+		}
+	}
+
+	if (updatedPreference.Preference_Key == services.QuietHoursStartPreferenceKey ||
+		updatedPreference.Preference_Key == services.QuietHoursEndPreferenceKey) &&
+		!services.ValidQuietHoursTime(updatedPreference.Preference_Value) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid value for preference key '%s'. Expected a 24-hour time such as '22:00', or '' to turn quiet hours off, but received '%s'",
+				updatedPreference.Preference_Key,
+				updatedPreference.Preference_Value),
+		})
+		return
+	}
+
+	if updatedPreference.Preference_Key == services.DigestPreferenceKey &&
+		!services.ValidDigestMode(updatedPreference.Preference_Value) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid value for preference key 'notification_digest'. Allowed values are 'off', 'daily' or 'weekly', but received '%s'",
+				updatedPreference.Preference_Value),
+		})
+		return
+	}
+
 	// Check if this would be a no-op (same value)
 	if len(existingUserPrefs) > 0 {
 		existing := existingUserPrefs[0]
@@ -1125,35 +1190,75 @@ func StorePushToken(c *gin.Context) {
 	user := userClaim.(models.UserProfile)
 	userID := user.User_Profile_ID
 
-	// Use UPSERT (INSERT ... ON CONFLICT) to handle duplicate tokens atomically
-	// If the (user_profile_id, push_token) combination already exists, update the platform and timestamp
-	// Otherwise, insert a new record
+	// A token belongs to one install, so drop it from any account that had it
+	// before (e.g. after switching accounts on the device), and drop older
+	// tokens the same device registered. Then upsert this one.
 	log.Printf("Upserting push token for user %d", userID)
 
-	// Use goqu.Record to avoid including the auto-generated ID field
-	newTokenRecord := goqu.Record{
-		"user_profile_id": userID,
-		"push_token":      request.PushToken,
-		"platform":        request.Platform,
-		"created_at":      time.Now(),
-		"updated_at":      time.Now(),
+	var deviceID, deviceName interface{}
+	if request.DeviceID != "" {
+		deviceID = request.DeviceID
+	}
+	if request.DeviceName != "" {
+		deviceName = request.DeviceName
+	}
+	now := time.Now()
+
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store push token", "details": err.Error()})
+		return
 	}
 
-	// Build the INSERT query with ON CONFLICT clause
-	insert := initializers.DB.Insert("user_push_tokens").
-		Rows(newTokenRecord).
-		OnConflict(goqu.DoUpdate(
-			"user_profile_id, push_token", // The columns with the unique constraint
-			goqu.Record{
-				"platform":   request.Platform,
-				"updated_at": time.Now(),
-			},
-		))
+	err = tx.Wrap(func() error {
+		_, err := tx.Delete("user_push_tokens").
+			Where(
+				goqu.C("push_token").Eq(request.PushToken),
+				goqu.C("user_profile_id").Neq(userID),
+			).
+			Executor().Exec()
+		if err != nil {
+			return err
+		}
 
-	sql, params, _ := insert.ToSQL()
-	log.Printf("Executing upsert query: %s, params: %v", sql, params)
+		if deviceID != nil {
+			_, err = tx.Delete("user_push_tokens").
+				Where(
+					goqu.C("user_profile_id").Eq(userID),
+					goqu.C("device_id").Eq(deviceID),
+					goqu.C("push_token").Neq(request.PushToken),
+				).
+				Executor().Exec()
+			if err != nil {
+				return err
+			}
+		}
 
-	_, err := insert.Executor().Exec()
+		// Use goqu.Record to avoid including the auto-generated ID field
+		_, err = tx.Insert("user_push_tokens").
+			Rows(goqu.Record{
+				"user_profile_id": userID,
+				"push_token":      request.PushToken,
+				"platform":        request.Platform,
+				"device_id":       deviceID,
+				"device_name":     deviceName,
+				"last_seen_at":    now,
+				"created_at":      now,
+				"updated_at":      now,
+			}).
+			OnConflict(goqu.DoUpdate(
+				"user_profile_id, push_token", // The columns with the unique constraint
+				goqu.Record{
+					"platform":     request.Platform,
+					"device_id":    goqu.L("COALESCE(EXCLUDED.device_id, user_push_tokens.device_id)"),
+					"device_name":  goqu.L("COALESCE(EXCLUDED.device_name, user_push_tokens.device_name)"),
+					"last_seen_at": now,
+					"updated_at":   now,
+				},
+			)).
+			Executor().Exec()
+		return err
+	})
 	if err != nil {
 		log.Printf("Failed to upsert push token: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store push token", "details": err.Error()})
@@ -1237,6 +1342,16 @@ func ChangeUserPassword(c *gin.Context) {
 		return
 	}
 
+	// Sign out every other device. A user changing their own password keeps the
+	// session they made the change from; an admin reset signs the user out everywhere.
+	keepSessionID := 0
+	if userID == currentUser.User_Profile_ID {
+		keepSessionID = c.GetInt("sessionID")
+	}
+	if _, err := services.RevokeAllUserSessions(userID, keepSessionID); err != nil {
+		log.Printf("Failed to revoke sessions after password change for user %d: %v", userID, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Password changed successfully",
 	})
@@ -1274,6 +1389,9 @@ func UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
+	// Set when the email changes and a verification code needs to go out
+	newEmailVerificationCode := ""
+
 	// Build the update record with only provided fields
 	updateRecord := goqu.Record{
 		"updated_by":      currentUser.User_Profile_ID,
@@ -1332,8 +1450,17 @@ func UpdateUserProfile(c *gin.Context) {
 			}
 
 			updateRecord["email"] = email
-			// Reset email verification if email changed
-			updateRecord["email_verified"] = false
+
+			// Reset email verification and issue a code for the new address
+			code, verificationRecord, err := newEmailVerificationRecord()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate verification code"})
+				return
+			}
+			for column, value := range verificationRecord {
+				updateRecord[column] = value
+			}
+			newEmailVerificationCode = code
 		}
 	}
 
@@ -1425,6 +1552,10 @@ func UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
+	if newEmailVerificationCode != "" {
+		sendEmailVerificationCode(updatedUser.Email, newEmailVerificationCode, updatedUser.First_Name)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User profile updated successfully",
 		"user":    updatedUser,
@@ -1500,10 +1631,47 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 3. Delete prayer session details (must delete BEFORE prayer_session due to FK)
-	// prayer_session_detail links to prayer_session, not directly to user
+	// 3. Delete login sessions (optional table) so no refresh token outlives the account
+	err = safeDeleteOptional("user_session", goqu.C("user_profile_id").Eq(userID))
+	if err != nil {
+		log.Printf("Failed to delete user_session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sessions", "details": err.Error()})
+		return
+	}
+
+	// Queued push and email deliveries (optional table) would otherwise go out after the account is gone
+	err = safeDeleteOptional("notification_outbox", goqu.C("user_profile_id").Eq(userID))
+	if err != nil {
+		log.Printf("Failed to delete notification_outbox: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete queued notifications", "details": err.Error()})
+		return
+	}
+
+	// Prayer reminders (optional table) would otherwise keep firing
+	err = safeDeleteOptional("prayer_reminder", goqu.C("user_profile_id").Eq(userID))
+	if err != nil {
+		log.Printf("Failed to delete prayer_reminder: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer reminders", "details": err.Error()})
+		return
+	}
+
+	// Follow-up prompts sent for the user's prayers (optional table)
+	err = safeDeleteOptional("prayer_follow_up", goqu.C("user_profile_id").Eq(userID))
+	if err != nil {
+		log.Printf("Failed to delete prayer_follow_up: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer follow-ups", "details": err.Error()})
+		return
+	}
+
+	// 4. Delete prayer session details (must delete BEFORE prayer_session due to FK)
+	// prayer_session_detail links to prayer_session, not directly to user. Other
+	// users' group and category sessions can also point at this user's prayers,
+	// which are deleted in step 14.
 	_, err = initializers.DB.Delete("prayer_session_detail").
-		Where(goqu.L("prayer_session_id IN (SELECT prayer_session_id FROM prayer_session WHERE user_profile_id = ?)", userID)).
+		Where(goqu.Or(
+			goqu.L("prayer_session_id IN (SELECT prayer_session_id FROM prayer_session WHERE user_profile_id = ?)", userID),
+			goqu.L("prayer_id IN (SELECT prayer_id FROM prayer WHERE created_by = ?)", userID),
+		)).
 		Executor().Exec()
 	if err != nil {
 		log.Printf("Failed to delete prayer_session_detail: %v", err)
@@ -1511,7 +1679,7 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 4. Delete prayer sessions
+	// 5. Delete prayer sessions
 	_, err = initializers.DB.Delete("prayer_session").
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		Executor().Exec()
@@ -1521,7 +1689,7 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 5. Delete user stats
+	// 6. Delete user stats
 	_, err = initializers.DB.Delete("user_stats").
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		Executor().Exec()
@@ -1531,7 +1699,7 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 6. Delete user preferences
+	// 7. Delete user preferences
 	_, err = initializers.DB.Delete("user_preferences").
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		Executor().Exec()
@@ -1541,7 +1709,7 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 7. Delete notifications
+	// 8. Delete notifications
 	_, err = initializers.DB.Delete("notification").
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		Executor().Exec()
@@ -1551,7 +1719,7 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 8. Delete group invites created by this user
+	// 9. Delete group invites created by this user
 	// Note: group_invite has ON DELETE CASCADE for group_profile_id, so it will auto-delete when groups are deleted
 	// We only need to delete invites created by this user
 	_, err = initializers.DB.Delete("group_invite").
@@ -1563,7 +1731,27 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 9. Remove user from all groups
+	// Email invitations sent by this user (optional table)
+	err = safeDeleteOptional("group_invitation", goqu.C("invited_by").Eq(userID))
+	if err != nil {
+		log.Printf("Failed to delete group_invitation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group invitations", "details": err.Error()})
+		return
+	}
+
+	// 10. Tell everyone who can see this user's prayers that they are going away
+	services.RecordPrayerSyncTombstones(goqu.I("prayer_access.prayer_id").In(
+		initializers.DB.From("prayer").Select("prayer_id").Where(goqu.C("created_by").Eq(userID)),
+	))
+
+	// 11. Remove user from all groups. Groups they own pass to another member
+	// first so no group is left without an owner.
+	if err := promoteGroupSuccessors(userID); err != nil {
+		log.Printf("Failed to promote new group owners: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer group ownership", "details": err.Error()})
+		return
+	}
+
 	_, err = initializers.DB.Delete("user_group").
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		Executor().Exec()
@@ -1573,7 +1761,7 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 10. Delete user's personal prayer access records (access_type = 'user')
+	// 12. Delete user's personal prayer access records (access_type = 'user')
 	_, err = initializers.DB.Delete("prayer_access").
 		Where(
 			goqu.C("access_type").Eq("user"),
@@ -1586,7 +1774,40 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 11. Delete prayer analytics for prayers created by this user (optional table)
+	// 13. Delete prayer events logged by this user or on this user's prayers (optional table)
+	err = safeDeleteOptional("prayer_event", goqu.Or(
+		goqu.C("user_profile_id").Eq(userID),
+		goqu.L("prayer_id IN (SELECT prayer_id FROM prayer WHERE created_by = ?)", userID),
+	))
+	if err != nil {
+		log.Printf("Failed to delete prayer_event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer history", "details": err.Error()})
+		return
+	}
+
+	// Delete prayer updates posted by this user or on this user's prayers (optional table)
+	err = safeDeleteOptional("prayer_update", goqu.Or(
+		goqu.C("user_profile_id").Eq(userID),
+		goqu.L("prayer_id IN (SELECT prayer_id FROM prayer WHERE created_by = ?)", userID),
+	))
+	if err != nil {
+		log.Printf("Failed to delete prayer_update: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer updates", "details": err.Error()})
+		return
+	}
+
+	// 14. Delete per-user prayer counts, keyed the same way (optional table)
+	err = safeDeleteOptional("prayer_user_analytics", goqu.Or(
+		goqu.C("user_profile_id").Eq(userID),
+		goqu.L("prayer_id IN (SELECT prayer_id FROM prayer WHERE created_by = ?)", userID),
+	))
+	if err != nil {
+		log.Printf("Failed to delete prayer_user_analytics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer analytics", "details": err.Error()})
+		return
+	}
+
+	// 15. Delete prayer analytics for prayers created by this user (optional table)
 	// Must delete BEFORE deleting prayers due to FK constraint
 	err = safeDeleteOptional("prayer_analytics", goqu.L("prayer_id IN (SELECT prayer_id FROM prayer WHERE created_by = ?)", userID))
 	if err != nil {
@@ -1595,7 +1816,7 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 12. Delete prayers created by this user
+	// 16. Delete prayers created by this user
 	// Note: Group prayers will remain for other group members
 	_, err = initializers.DB.Delete("prayer").
 		Where(goqu.C("created_by").Eq(userID)).
@@ -1606,7 +1827,15 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
-	// 13. Finally, hard delete the user profile
+	// 17. Delete the user's own sync tombstones (optional table)
+	err = safeDeleteOptional("sync_tombstone", goqu.C("user_profile_id").Eq(userID))
+	if err != nil {
+		log.Printf("Failed to delete sync_tombstone: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sync history", "details": err.Error()})
+		return
+	}
+
+	// 18. Finally, hard delete the user profile
 	_, err = initializers.DB.Delete("user_profile").
 		Where(goqu.C("user_profile_id").Eq(userID)).
 		Executor().Exec()
@@ -1678,3 +1907,23 @@ func GetOrCreateSelfPrayerSubject(user models.UserProfile) (int, error) {
 	log.Printf("Created self prayer_subject %d for user %d", insertedID, user.User_Profile_ID)
 	return insertedID, nil
 }
+
+// userParamOwner parses :user_profile_id and checks the caller is that user or
+// an admin, for the user's own resources (devices, reminders). It writes the
+// error response and returns false otherwise.
+func userParamOwner(c *gin.Context, resource string) (int, bool) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	userID, err := strconv.Atoi(c.Param("user_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user profile ID", "details": err.Error()})
+		return 0, false
+	}
+
+	if userID != currentUser.User_Profile_ID && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage this user's " + resource})
+		return 0, false
+	}
+	return userID, true
+}
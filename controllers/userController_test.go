@@ -269,6 +269,12 @@ func TestUserLogin(t *testing.T) {
 				)
 
 				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+				// Mock session creation on successful login
+				if tt.expectToken {
+					mock.ExpectQuery("INSERT INTO \"user_session\"").
+						WillReturnRows(sqlmock.NewRows([]string{"user_session_id"}).AddRow(10))
+				}
 			} else {
 				// User not found - return error
 				mock.ExpectQuery("SELECT").WillReturnError(sqlmock.ErrCancelled)
@@ -299,6 +305,7 @@ func TestUserLogin(t *testing.T) {
 			if tt.expectToken {
 				assert.NotNil(t, response["token"], "Expected token in response")
 				assert.NotEmpty(t, response["token"], "Expected non-empty token")
+				assert.NotEmpty(t, response["refreshToken"], "Expected refresh token in response")
 				assert.NotNil(t, response["user"], "Expected user in response")
 				assert.Equal(t, "User logged in successfully.", response["message"])
 			}
@@ -732,6 +739,9 @@ func TestChangeUserPassword(t *testing.T) {
 				// Mock update execution if no validation errors expected
 				if tt.expectedStatus == http.StatusOK {
 					mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1))
+
+					// Mock revoking the user's other sessions
+					mock.ExpectExec("UPDATE \"user_session\"").WillReturnResult(sqlmock.NewResult(0, 2))
 				}
 			} else if tt.mockUser == nil && tt.userID != "invalid" {
 				// User not found
@@ -870,37 +880,66 @@ func TestDeleteUserAccount(t *testing.T) {
 					// 2. password_reset_tokens (optional)
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 0))
 
-					// 3. prayer_session_detail (via subquery)
+					// 3. user_session (optional)
+					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
+
+					// notification_outbox (optional)
+					mock.ExpectExec("DELETE FROM \"notification_outbox\"").WillReturnResult(sqlmock.NewResult(0, 0))
+
+					// prayer_reminder (optional)
+					mock.ExpectExec("DELETE FROM \"prayer_reminder\"").WillReturnResult(sqlmock.NewResult(0, 0))
+
+					// prayer_follow_up (optional)
+					mock.ExpectExec("DELETE FROM \"prayer_follow_up\"").WillReturnResult(sqlmock.NewResult(0, 0))
+
+					// 4. prayer_session_detail (via subquery)
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 0))
 
-					// 4. prayer_session
+					// 5. prayer_session
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
 
-					// 5. user_stats
+					// 6. user_stats
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
 
-					// 6. user_preferences
+					// 7. user_preferences
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
 
-					// 7. notification
+					// 8. notification
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 2))
 
-					// 8. group_invite (created_by only)
+					// 9. group_invite (created_by only)
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM \"group_invitation\"").WillReturnResult(sqlmock.NewResult(0, 0))
 
-					// 9. user_group
+					// 10. sync tombstones for everyone who sees the user's prayers
+					mock.ExpectExec("INSERT INTO \"sync_tombstone\"").WillReturnResult(sqlmock.NewResult(0, 4))
+
+					// 11. new owners for the user's groups, then user_group
+					mock.ExpectExec("UPDATE \"user_group\" SET \"role\"").WillReturnResult(sqlmock.NewResult(0, 1))
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 3))
 
-					// 10. prayer_access
+					// 12. prayer_access
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 5))
 
-					// 11. prayer_analytics (optional, via subquery)
+					// 13. prayer_event (optional, by user and via subquery)
+					mock.ExpectExec("DELETE FROM \"prayer_event\"").WillReturnResult(sqlmock.NewResult(0, 6))
+
+					// prayer_update (optional, by user and via subquery)
+					mock.ExpectExec("DELETE FROM \"prayer_update\"").WillReturnResult(sqlmock.NewResult(0, 0))
+
+					// 14. prayer_user_analytics (optional, by user and via subquery)
+					mock.ExpectExec("DELETE FROM \"prayer_user_analytics\"").WillReturnResult(sqlmock.NewResult(0, 2))
+
+					// 15. prayer_analytics (optional, via subquery)
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 0))
 
-					// 12. prayer
+					// 16. prayer
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 5))
 
-					// 13. user_profile
+					// 17. sync_tombstone (optional)
+					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 4))
+
+					// 18. user_profile
 					mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
 				}
 			} else if tt.mockUser == nil && tt.userID != "invalid" {
@@ -1331,6 +1370,9 @@ func TestPublicUserSignup(t *testing.T) {
 						// Mock the insert for creating self prayer_subject
 						mock.ExpectQuery("INSERT").
 							WillReturnRows(sqlmock.NewRows([]string{"prayer_subject_id"}).AddRow(1))
+
+						// Invitations wait until the email is verified, so no
+						// group_invitation lookup or user_group insert happens here
 					}
 				}
 			}
@@ -1351,7 +1393,9 @@ func TestPublicUserSignup(t *testing.T) {
 				assert.NotNil(t, response["error"])
 			} else {
 				assert.NotNil(t, response["message"])
+				assert.NotContains(t, response, "joinedGroupIds")
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -1560,6 +1604,9 @@ func TestCreateUserPrayer(t *testing.T) {
 				mock.ExpectQuery("SELECT \"prayer_subject_id\" FROM \"prayer_subject\"").
 					WillReturnRows(sqlmock.NewRows([]string{"prayer_subject_id"}).AddRow(1))
 
+				// The prayer, its access and its history share a transaction
+				mock.ExpectBegin()
+
 				// Mock subject_display_sequence update for prayers in this subject
 				mock.ExpectExec("UPDATE \"prayer\" SET \"subject_display_sequence\"").
 					WillReturnResult(sqlmock.NewResult(0, 0))
@@ -1575,6 +1622,11 @@ func TestCreateUserPrayer(t *testing.T) {
 				// Mock prayer access insert - return prayer access ID
 				mock.ExpectQuery("INSERT INTO \"prayer_access\"").
 					WillReturnRows(sqlmock.NewRows([]string{"prayer_access_id"}).AddRow(1))
+
+				// Mock the "created" history entry with the first version
+				mock.ExpectExec("INSERT INTO \"prayer_edit_history\" .*'created'").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			}
 
 			c, w := SetupTestContext()
@@ -1598,6 +1650,7 @@ func TestCreateUserPrayer(t *testing.T) {
 				assert.NotNil(t, response["prayerId"])
 				assert.NotNil(t, response["prayerAccessId"])
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -2066,6 +2119,54 @@ func TestUpdateUserPreferences(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
 		},
+		{
+			name:         "invalid timezone value",
+			userID:       "1",
+			preferenceID: "1",
+			currentUser:  MockUser(),
+			isAdmin:      false,
+			updateData: models.UserPreferencesUpdate{
+				Preference_Key:   "timezone",
+				Preference_Value: "Mars/Olympus_Mons",
+				Is_Active:        true,
+			},
+			prefKey:        "timezone",
+			prefType:       "string",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:         "invalid quiet hours value",
+			userID:       "1",
+			preferenceID: "1",
+			currentUser:  MockUser(),
+			isAdmin:      false,
+			updateData: models.UserPreferencesUpdate{
+				Preference_Key:   "quiet_hours_start",
+				Preference_Value: "10pm",
+				Is_Active:        true,
+			},
+			prefKey:        "quiet_hours_start",
+			prefType:       "string",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:         "invalid digest value",
+			userID:       "1",
+			preferenceID: "1",
+			currentUser:  MockUser(),
+			isAdmin:      false,
+			updateData: models.UserPreferencesUpdate{
+				Preference_Key:   "notification_digest",
+				Preference_Value: "hourly",
+				Is_Active:        true,
+			},
+			prefKey:        "notification_digest",
+			prefType:       "string",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -2096,13 +2197,14 @@ func TestUpdateUserPreferences(t *testing.T) {
 					mock.ExpectExec("INSERT").
 						WillReturnResult(sqlmock.NewResult(1, 1))
 				}
-			} else if tt.name == "preference key mismatch" || tt.name == "invalid boolean value" || tt.name == "invalid theme value" {
+			} else if tt.name == "preference key mismatch" || tt.name == "invalid boolean value" || tt.name == "invalid theme value" || tt.name == "invalid timezone value" ||
+				tt.name == "invalid quiet hours value" || tt.name == "invalid digest value" {
 				// Mock preference lookup for validation error cases
 				prefRows := sqlmock.NewRows([]string{"preference_id", "preference_key", "default_value", "description", "value_type", "datetime_create", "datetime_update", "created_by", "updated_by", "is_active"}).
 					AddRow(1, tt.prefKey, "light", "Theme preference", tt.prefType, time.Now(), time.Now(), 1, 1, true)
 				mock.ExpectQuery("SELECT").WillReturnRows(prefRows)
 
-				// For boolean/theme validation errors, we also need to mock existing preferences lookup
+				// For boolean/theme/timezone/quiet hours/digest validation errors, we also need to mock existing preferences lookup
 				// This happens before validation in the actual code flow
 				if tt.name != "preference key mismatch" {
 					mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{}))
@@ -2164,6 +2266,18 @@ func TestStorePushToken(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectError:    false,
 		},
+		{
+			name:        "successful token storage - with device",
+			currentUser: MockUser(),
+			tokenData: models.PushTokenRequest{
+				PushToken:  stringRepeater("b").Repeat(100),
+				Platform:   "ios",
+				DeviceID:   "device-123",
+				DeviceName: "Jane's iPhone",
+			},
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
 		{
 			name:        "token too short",
 			currentUser: MockUser(),
@@ -2202,9 +2316,19 @@ func TestStorePushToken(t *testing.T) {
 			defer cleanup()
 
 			if !tt.expectError {
+				mock.ExpectBegin()
+				// Token removed from other accounts
+				mock.ExpectExec("DELETE FROM \"user_push_tokens\"").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				if tt.tokenData.DeviceID != "" {
+					// Older tokens from the same device
+					mock.ExpectExec("DELETE FROM \"user_push_tokens\".*\"device_id\"").
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
 				// Mock upsert query
 				mock.ExpectExec("INSERT INTO \"user_push_tokens\"").
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			}
 
 			c, w := SetupTestContext()
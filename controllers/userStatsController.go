@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// GetUserStats returns streaks, prayer frequency and answered-prayer stats for a user
+// GET /users/:user_profile_id/stats
+func GetUserStats(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.UserProfile)
+	isAdmin := c.MustGet("admin").(bool)
+
+	userID, err := strconv.Atoi(c.Param("user_profile_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user profile ID", "details": err.Error()})
+		return
+	}
+
+	if userID != currentUser.User_Profile_ID && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this user's stats"})
+		return
+	}
+
+	location := services.GetUserLocation(userID)
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	stats := models.PersonalPrayerStats{
+		User_Profile_ID: userID,
+		Timezone:        location.String(),
+	}
+
+	// Distinct days the user prayed, in their local calendar
+	var prayedDays []time.Time
+	err = initializers.DB.From("prayer_event").
+		SelectDistinct(goqu.L("DATE(datetime_prayed AT TIME ZONE ?)", location.String()).As("day")).
+		Where(goqu.C("user_profile_id").Eq(userID)).
+		Order(goqu.C("day").Asc()).
+		ScanVals(&prayedDays)
+	if err != nil {
+		log.Printf("Failed to fetch prayer days for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user stats"})
+		return
+	}
+	stats.Current_Streak, stats.Longest_Streak = computePrayerStreaks(prayedDays, today)
+
+	// Windows include today, so "last 7 days" starts six local midnights ago
+	countsQuery := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE datetime_prayed >= $2),
+			COUNT(*) FILTER (WHERE datetime_prayed >= $3),
+			COUNT(*) FILTER (WHERE datetime_prayed >= $4)
+		FROM prayer_event
+		WHERE user_profile_id = $1
+	`
+	var prayersLast28Days int
+	err = initializers.DB.QueryRow(countsQuery, userID, today.AddDate(0, 0, -6), today.AddDate(0, 0, -29), today.AddDate(0, 0, -27)).
+		Scan(&stats.Total_Prayers, &stats.Prayers_Last_7_Days, &stats.Prayers_Last_30_Days, &prayersLast28Days)
+	if err != nil {
+		log.Printf("Failed to count prayers for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user stats"})
+		return
+	}
+	// Averaged over the last four weeks so one busy day doesn't dominate
+	stats.Prayers_Per_Week = float64(prayersLast28Days) / 4
+
+	answeredQuery := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE is_answered),
+			AVG(EXTRACT(EPOCH FROM (datetime_answered - datetime_create)))
+				FILTER (WHERE is_answered AND datetime_answered >= datetime_create)
+		FROM prayer
+		WHERE created_by = $1 AND deleted = false
+	`
+	var avgSecondsToAnswer sql.NullFloat64
+	err = initializers.DB.QueryRow(answeredQuery, userID).
+		Scan(&stats.Prayers_Created, &stats.Prayers_Answered, &avgSecondsToAnswer)
+	if err != nil {
+		log.Printf("Failed to fetch answered prayer stats for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user stats"})
+		return
+	}
+	if stats.Prayers_Created > 0 {
+		stats.Answered_Rate = float64(stats.Prayers_Answered) / float64(stats.Prayers_Created)
+	}
+	if avgSecondsToAnswer.Valid {
+		seconds := int64(avgSecondsToAnswer.Float64)
+		stats.Avg_Seconds_To_Answer = &seconds
+	}
+
+	// Session totals are maintained by FinishPrayerSession; no row means no sessions yet
+	var sessionStats models.UserStats
+	_, err = initializers.DB.From("user_stats").
+		Select("user_profile_id", "total_sessions", "total_prayers", "total_duration_seconds", "datetime_last_session").
+		Where(goqu.C("user_profile_id").Eq(userID)).
+		ScanStruct(&sessionStats)
+	if err != nil {
+		log.Printf("Failed to fetch session stats for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user stats"})
+		return
+	}
+	stats.Total_Sessions = sessionStats.Total_Sessions
+	stats.Total_Duration_Seconds = sessionStats.Total_Duration_Seconds
+	stats.Datetime_Last_Session = sessionStats.Datetime_Last_Session
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// computePrayerStreaks takes the distinct days a user prayed (ascending) and
+// returns the current and longest run of consecutive days. The current streak
+// survives until the end of today, so praying yesterday but not yet today
+// still counts.
+func computePrayerStreaks(prayedDays []time.Time, today time.Time) (int, int) {
+	current, longest, run := 0, 0, 0
+	var previous time.Time
+
+	for i, day := range prayedDays {
+		day = civilDate(day)
+		if i > 0 && day.Equal(previous.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		previous = day
+	}
+
+	if len(prayedDays) > 0 {
+		todayDate := civilDate(today)
+		if previous.Equal(todayDate) || previous.Equal(todayDate.AddDate(0, 0, -1)) {
+			current = run
+		}
+	}
+
+	return current, longest
+}
+
+// civilDate drops the time and zone so dates from Postgres and local
+// midnights can be compared directly
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test GetUserStats - Streaks, frequency and answered stats
+func TestGetUserStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		isAdmin        bool
+		timezone       string
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:           "successful fetch - own stats",
+			userID:         "1",
+			timezone:       "America/Chicago",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "successful fetch - admin viewing another user",
+			userID:         "2",
+			isAdmin:        true,
+			timezone:       "UTC",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "forbidden - another user's stats",
+			userID:         "2",
+			expectedStatus: http.StatusForbidden,
+			expectError:    true,
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mock, cleanup := SetupTestDB(t)
+			defer cleanup()
+
+			if !tt.expectError {
+				location, _ := time.LoadLocation(tt.timezone)
+				now := time.Now().In(location)
+				today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+				mock.ExpectQuery("SELECT COALESCE").
+					WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(tt.timezone))
+
+				// Prayed the last three days, plus an older four-day run
+				dayRows := sqlmock.NewRows([]string{"day"})
+				for _, offset := range []int{-20, -19, -18, -17, -2, -1, 0} {
+					dayRows.AddRow(today.AddDate(0, 0, offset))
+				}
+				mock.ExpectQuery("SELECT DISTINCT").WillReturnRows(dayRows)
+
+				mock.ExpectQuery("FROM prayer_event").
+					WillReturnRows(sqlmock.NewRows([]string{"total", "last_7", "last_30", "last_28"}).AddRow(40, 6, 18, 16))
+
+				mock.ExpectQuery("FROM prayer").
+					WillReturnRows(sqlmock.NewRows([]string{"created", "answered", "avg"}).AddRow(10, 4, 86400.0))
+
+				mock.ExpectQuery("FROM \"user_stats\"").
+					WillReturnRows(sqlmock.NewRows([]string{
+						"user_profile_id", "total_sessions", "total_prayers", "total_duration_seconds", "datetime_last_session",
+					}).AddRow(1, 3, 12, 600, time.Now()))
+			}
+
+			c, w := SetupTestContext()
+			SetAuthenticatedUser(c, MockUser(), tt.isAdmin)
+			c.Params = []gin.Param{{Key: "user_profile_id", Value: tt.userID}}
+			c.Request = httptest.NewRequest("GET", "/users/"+tt.userID+"/stats", nil)
+
+			GetUserStats(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectError {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Contains(t, response, "error")
+			} else {
+				var response struct {
+					Stats models.PersonalPrayerStats `json:"stats"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				stats := response.Stats
+				assert.Equal(t, tt.timezone, stats.Timezone)
+				assert.Equal(t, 3, stats.Current_Streak)
+				assert.Equal(t, 4, stats.Longest_Streak)
+				assert.Equal(t, 40, stats.Total_Prayers)
+				assert.Equal(t, 18, stats.Prayers_Last_30_Days)
+				assert.Equal(t, 4.0, stats.Prayers_Per_Week)
+				assert.Equal(t, 0.4, stats.Answered_Rate)
+				if assert.NotNil(t, stats.Avg_Seconds_To_Answer) {
+					assert.Equal(t, int64(86400), *stats.Avg_Seconds_To_Answer)
+				}
+				assert.Equal(t, 3, stats.Total_Sessions)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestComputePrayerStreaks(t *testing.T) {
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time { return today.AddDate(0, 0, offset) }
+
+	tests := []struct {
+		name            string
+		days            []time.Time
+		expectedCurrent int
+		expectedLongest int
+	}{
+		{name: "no prayers", days: nil},
+		{name: "prayed today only", days: []time.Time{day(0)}, expectedCurrent: 1, expectedLongest: 1},
+		{name: "streak ending yesterday is still current", days: []time.Time{day(-3), day(-2), day(-1)}, expectedCurrent: 3, expectedLongest: 3},
+		{name: "streak broken two days ago", days: []time.Time{day(-4), day(-3), day(-2)}, expectedCurrent: 0, expectedLongest: 3},
+		{name: "longest run in the past", days: []time.Time{day(-10), day(-9), day(-8), day(-7), day(-1), day(0)}, expectedCurrent: 2, expectedLongest: 4},
+		{name: "across a month boundary", days: []time.Time{day(-10), day(-9)}, expectedCurrent: 0, expectedLongest: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := computePrayerStreaks(tt.days, today)
+			assert.Equal(t, tt.expectedCurrent, current)
+			assert.Equal(t, tt.expectedLongest, longest)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -16,6 +17,7 @@ func init() {
 	initializers.ConnectDB()
 	services.InitPushNotificationService()
 	services.InitEmailService()
+	services.InitNotificationRouter()
 }
 
 func main() {
@@ -33,6 +35,11 @@ func main() {
 	router.GET("/check-username", middlewares.RateLimitMiddleware(5, 5, getKey), controllers.CheckUsernameAvailability)
 	router.GET("/ping", middlewares.RateLimitMiddleware(2, 2, getKey), controllers.Ping)
 
+	// Invite previews are public, so they get their own tighter bucket to slow down code guessing
+	router.GET("/invites/:code/preview", middlewares.RateLimitMiddleware(1, 5, func(c *gin.Context) string {
+		return "invite-preview:" + getKey(c)
+	}), controllers.PreviewInviteCode)
+
 	router.Static("/static", "./static")
 	router.GET("/privacy", func(c *gin.Context) {
 		c.File("./static/privacy.html")
@@ -43,6 +50,9 @@ func main() {
 	router.POST("/auth/verify-reset-code", middlewares.RateLimitMiddleware(5, 5, getKey), controllers.VerifyResetCode)
 	router.POST("/auth/reset-password", middlewares.RateLimitMiddleware(2, 2, getKey), controllers.ResetPassword)
 
+	// Session endpoints
+	router.POST("/auth/refresh", middlewares.RateLimitMiddleware(5, 5, getKey), controllers.RefreshSession)
+
 	// Test endpoint for email service (remove in production)
 	router.POST("/test/email", middlewares.RateLimitMiddleware(2, 2, getKey), controllers.TestEmailService)
 
@@ -51,6 +61,28 @@ func main() {
 	auth.Use(middlewares.RateLimitMiddleware(10, 10, getKey))
 	{
 
+		// real-time events
+		auth.GET("/events", controllers.StreamEvents)
+
+		// session routes
+		auth.POST("/auth/logout", controllers.Logout)
+		auth.POST("/auth/logout-all", controllers.LogoutAllDevices)
+
+		// email verification routes
+		// These need their own keys; with plain getKey they would share the auth group's limiter
+		auth.POST("/auth/verify-email", middlewares.RateLimitMiddleware(5, 5, func(c *gin.Context) string {
+			return "verify-email:" + getKey(c)
+		}), controllers.VerifyEmail)
+		auth.POST("/auth/resend-verification", middlewares.RateLimitMiddleware(2, 2, func(c *gin.Context) string {
+			return "resend-verification:" + getKey(c)
+		}), controllers.ResendEmailVerification)
+
+		// search routes
+		auth.GET("/search", controllers.Search)
+
+		// offline sync routes
+		auth.GET("/sync", controllers.GetSync)
+
 		// user routes
 		auth.GET("/users/me", controllers.GetUserProfile)
 		auth.PATCH("/users/:user_profile_id", controllers.UpdateUserProfile)
@@ -76,8 +108,19 @@ func main() {
 		auth.GET("/users/:user_profile_id/preferences", controllers.GetUserPreferencesWithDefaults)
 		auth.PATCH("/users/:user_profile_id/preferences/:preference_id", controllers.UpdateUserPreferences)
 
-		// push token route
+		auth.GET("/users/:user_profile_id/stats", controllers.GetUserStats)
+
+		// push token and device routes
 		auth.POST("/users/push-token", controllers.StorePushToken)
+		auth.GET("/users/:user_profile_id/devices", controllers.GetUserDevices)
+		auth.DELETE("/users/:user_profile_id/devices", controllers.DeleteUserDevices)
+		auth.DELETE("/users/:user_profile_id/devices/:user_push_tokens_id", controllers.DeleteUserDevice)
+
+		// prayer reminder routes
+		auth.GET("/users/:user_profile_id/reminders", controllers.GetUserReminders)
+		auth.POST("/users/:user_profile_id/reminders", controllers.CreateUserReminder)
+		auth.PATCH("/users/:user_profile_id/reminders/:prayer_reminder_id", controllers.UpdateUserReminder)
+		auth.DELETE("/users/:user_profile_id/reminders/:prayer_reminder_id", controllers.DeleteUserReminder)
 
 		// notification routes
 		auth.GET("/users/:user_profile_id/notifications", controllers.GetUserNotifications)
@@ -91,6 +134,7 @@ func main() {
 		auth.GET("/groups/:group_profile_id", controllers.GetGroup)
 		auth.PUT("/groups/:group_profile_id", controllers.UpdateGroup)
 		auth.DELETE("/groups/:group_profile_id", controllers.DeleteGroup)
+		auth.GET("/groups/:group_profile_id/stats", controllers.GetGroupStats)
 
 		auth.GET("/groups/:group_profile_id/prayers", controllers.GetGroupPrayers)
 		auth.POST("/groups/:group_profile_id/prayers", controllers.CreateGroupPrayer)
@@ -103,18 +147,33 @@ func main() {
 		auth.GET("/groups/:group_profile_id/users", controllers.GetGroupUsers)
 		auth.POST("/groups/:group_profile_id/users/:user_profile_id", controllers.AddUserToGroup)
 		auth.DELETE("/groups/:group_profile_id/users/:user_profile_id", controllers.RemoveUserFromGroup)
+		auth.PATCH("/groups/:group_profile_id/users/:user_profile_id/role", controllers.UpdateGroupUserRole)
+		auth.POST("/groups/:group_profile_id/transfer-ownership", controllers.TransferGroupOwnership)
 
 		// invite routes
 		auth.POST("/groups/:group_profile_id/invite", controllers.CreateGroupInviteCode)
+		auth.GET("/groups/:group_profile_id/invites", controllers.GetGroupInvites)
+		auth.DELETE("/groups/:group_profile_id/invites/:group_invite_id", controllers.RevokeGroupInvite)
 		auth.POST("/groups/:group_profile_id/join", controllers.JoinGroup)
+		auth.POST("/invites/redeem", controllers.RedeemInviteCode)
+		auth.GET("/groups/:group_profile_id/join-requests", controllers.GetGroupJoinRequests)
+		auth.PATCH("/groups/:group_profile_id/join-requests/:group_join_request_id", controllers.RespondToGroupJoinRequest)
+		auth.POST("/groups/:group_profile_id/invitations", middlewares.RateLimitMiddleware(1, 2, func(c *gin.Context) string {
+			return "group-invitations:" + getKey(c)
+		}), controllers.CreateGroupInvitations)
 
 		// prayer routes
 		auth.PUT("/prayers/:prayer_id", controllers.UpdatePrayer)
 		auth.DELETE("/prayers/:prayer_id", controllers.DeletePrayer)
+		auth.PATCH("/prayers/:prayer_id/archive", controllers.ArchivePrayer)
+		auth.GET("/prayers/:prayer_id/updates", controllers.GetPrayerUpdates)
+		auth.POST("/prayers/:prayer_id/updates", controllers.CreatePrayerUpdate)
 		auth.GET("/prayers/:prayer_id/access", controllers.GetPrayerAccessRecords)
 		auth.POST("/prayers/:prayer_id/access", controllers.AddPrayerAccess)
 		auth.DELETE("/prayers/:prayer_id/access/:prayer_access_id", controllers.RemovePrayerAccess)
 		auth.GET("/prayers/:prayer_id/history", controllers.GetPrayerHistory)
+		auth.GET("/prayers/:prayer_id/history/:history_id/diff", controllers.GetPrayerHistoryDiff)
+		auth.POST("/prayers/:prayer_id/history/:history_id/restore", controllers.RestorePrayerVersion)
 
 		// comment routes (under prayer resources)
 		auth.GET("/prayers/:prayer_id/comments", controllers.GetPrayerComments)
@@ -128,6 +187,12 @@ func main() {
 		auth.POST("/prayers/:prayer_id/analytics", controllers.RecordPrayer)
 		auth.GET("/prayers/:prayer_id/analytics", controllers.GetPrayerAnalytics)
 
+		// prayer session routes
+		auth.POST("/prayer-sessions", controllers.StartPrayerSession)
+		auth.GET("/prayer-sessions/:prayer_session_id", controllers.GetPrayerSession)
+		auth.POST("/prayer-sessions/:prayer_session_id/prayers", controllers.RecordSessionPrayer)
+		auth.POST("/prayer-sessions/:prayer_session_id/finish", controllers.FinishPrayerSession)
+
 		// prayer subject routes (resource-level operations)
 		auth.PATCH("/prayer-subjects/:prayer_subject_id", controllers.UpdatePrayerSubject)
 		auth.DELETE("/prayer-subjects/:prayer_subject_id", controllers.DeletePrayerSubject)
@@ -145,8 +210,8 @@ func main() {
 		auth.DELETE("/prayer-subjects/:prayer_subject_id/link", controllers.RemovePrayerSubjectLink)
 
 		// connection request routes
-		auth.GET("/users/search", controllers.SearchUserByEmail)
-		auth.POST("/connection-requests", controllers.SendConnectionRequest)
+		auth.GET("/users/search", middlewares.RequireVerifiedEmail, controllers.SearchUserByEmail)
+		auth.POST("/connection-requests", middlewares.RequireVerifiedEmail, controllers.SendConnectionRequest)
 		auth.GET("/users/:user_profile_id/connection-requests/incoming", controllers.GetIncomingConnectionRequests)
 		auth.GET("/users/:user_profile_id/connection-requests/outgoing", controllers.GetOutgoingConnectionRequests)
 		auth.GET("/users/:user_profile_id/connection-requests/count", controllers.GetPendingConnectionRequestCount)
@@ -170,9 +235,30 @@ func main() {
 
 			// push notification routes
 			admin.POST("/notifications/send", controllers.SendPushNotification)
+
+			// notification outbox routes
+			admin.GET("/notifications/outbox", controllers.GetNotificationOutbox)
+			admin.POST("/notifications/outbox/:outbox_id/replay", controllers.ReplayNotificationOutbox)
 		}
 	}
 
+	// Sends quiet hours catch-ups and digests from the queued notification rows
+	services.StartNotificationScheduler(time.Minute, nil)
+
+	// Sends queued push and email deliveries, retrying failures with backoff
+	if worker := services.GetOutboxWorker(); worker != nil {
+		worker.Start(5*time.Second, 4, nil)
+	}
+
+	// Removes push tokens Expo receipts report as unregistered, and stale ones
+	services.StartPushTokenMaintenance(15*time.Minute, nil)
+
+	// Sends prayer reminders as they come due
+	services.StartReminderScheduler(time.Minute, nil)
+
+	// Asks creators for an update on prayers with no activity for a while
+	services.StartPrayerFollowUps(time.Hour, services.PrayerFollowUpAge(), nil)
+
 	if err := router.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/PrayerLoop/initializers"
 	"github.com/PrayerLoop/models"
+	"github.com/PrayerLoop/services"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/gin-gonic/gin"
@@ -58,6 +59,28 @@ func CheckAuth(c *gin.Context) {
 		return
 	}
 
+	// Access tokens are bound to a session so that logout and credential
+	// changes take effect before the token expires
+	sessionID, ok := claims["sid"].(float64)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	active, err := services.IsSessionActive(int(sessionID), userIDFromClaims(claims))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session", "details": err.Error()})
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if !active {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
 	var user models.UserProfile
 	_, err = initializers.DB.From("user_profile").Select("*").Where(goqu.C("user_profile_id").Eq(claims["id"])).ScanStruct(&user)
 	if err != nil {
@@ -72,6 +95,7 @@ func CheckAuth(c *gin.Context) {
 	}
 
 	c.Set("currentUser", user)
+	c.Set("sessionID", int(sessionID))
 
 	if claims["role"] != nil {
 		c.Set("admin", claims["role"] == "admin")
@@ -82,3 +106,8 @@ func CheckAuth(c *gin.Context) {
 	c.Next()
 
 }
+
+func userIDFromClaims(claims jwt.MapClaims) int {
+	id, _ := claims["id"].(float64)
+	return int(id)
+}
@@ -16,6 +16,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// Session ID embedded in generated tokens
+const testSessionID = 42
+
 // Helper function to generate a valid JWT token
 func generateValidToken(userID int, role string, expiresIn time.Duration) string {
 	secret := os.Getenv("SECRET")
@@ -26,6 +29,7 @@ func generateValidToken(userID int, role string, expiresIn time.Duration) string
 
 	claims := jwt.MapClaims{
 		"id":   float64(userID),
+		"sid":  float64(testSessionID),
 		"exp":  float64(time.Now().Add(expiresIn).Unix()),
 		"role": role,
 	}
@@ -35,6 +39,25 @@ func generateValidToken(userID int, role string, expiresIn time.Duration) string
 	return tokenString
 }
 
+// Helper function to generate a pre-session token (no sid claim)
+func generateTokenWithoutSession(userID int, expiresIn time.Duration) string {
+	secret := os.Getenv("SECRET")
+	if secret == "" {
+		secret = "test-secret-key"
+		os.Setenv("SECRET", secret)
+	}
+
+	claims := jwt.MapClaims{
+		"id":   float64(userID),
+		"exp":  float64(time.Now().Add(expiresIn).Unix()),
+		"role": "user",
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, _ := token.SignedString([]byte(secret))
+	return tokenString
+}
+
 // Helper function to generate a token without role claim
 func generateTokenWithoutRole(userID int, expiresIn time.Duration) string {
 	secret := os.Getenv("SECRET")
@@ -45,6 +68,7 @@ func generateTokenWithoutRole(userID int, expiresIn time.Duration) string {
 
 	claims := jwt.MapClaims{
 		"id":  float64(userID),
+		"sid": float64(testSessionID),
 		"exp": float64(time.Now().Add(expiresIn).Unix()),
 	}
 
@@ -108,6 +132,7 @@ func TestCheckAuth(t *testing.T) {
 		name               string
 		authHeader         string
 		mockUserLookup     bool
+		sessionRevoked     bool
 		userExists         bool
 		expectedStatus     int
 		expectAbort        bool
@@ -170,6 +195,29 @@ func TestCheckAuth(t *testing.T) {
 			expectAdmin:        false,
 			adminRole:          false,
 		},
+		{
+			name:               "token without session claim",
+			authHeader:         "Bearer " + generateTokenWithoutSession(1, 24*time.Hour),
+			mockUserLookup:     false,
+			userExists:         false,
+			expectedStatus:     http.StatusUnauthorized,
+			expectAbort:        true,
+			expectCurrentUser:  false,
+			expectAdmin:        false,
+			adminRole:          false,
+		},
+		{
+			name:               "valid token - session revoked",
+			authHeader:         "Bearer " + generateValidToken(1, "user", 24*time.Hour),
+			mockUserLookup:     true,
+			sessionRevoked:     true,
+			userExists:         true,
+			expectedStatus:     http.StatusUnauthorized,
+			expectAbort:        true,
+			expectCurrentUser:  false,
+			expectAdmin:        false,
+			adminRole:          false,
+		},
 		{
 			name:               "valid token - user not found in database",
 			authHeader:         "Bearer " + generateValidToken(999, "user", 24*time.Hour),
@@ -221,8 +269,16 @@ func TestCheckAuth(t *testing.T) {
 			mock, cleanup := setupTestDB(t)
 			defer cleanup()
 
-			// Mock database user lookup if needed
+			// Mock database session and user lookup if needed
 			if tt.mockUserLookup {
+				sessionRows := sqlmock.NewRows([]string{"user_session_id"})
+				if !tt.sessionRevoked {
+					sessionRows.AddRow(testSessionID)
+				}
+				mock.ExpectQuery("SELECT \"user_session_id\" FROM \"user_session\"").WillReturnRows(sessionRows)
+			}
+
+			if tt.mockUserLookup && !tt.sessionRevoked {
 				now := time.Now()
 				if tt.userExists {
 					userRows := sqlmock.NewRows([]string{
@@ -277,6 +333,7 @@ func TestCheckAuth(t *testing.T) {
 					assert.Equal(t, 1, userProfile.User_Profile_ID)
 					assert.Equal(t, "test@example.com", userProfile.Email)
 				}
+				assert.Equal(t, testSessionID, c.GetInt("sessionID"))
 			} else {
 				_, exists := c.Get("currentUser")
 				assert.False(t, exists, "Expected currentUser not to be set")
@@ -291,6 +348,8 @@ func TestCheckAuth(t *testing.T) {
 				_, exists := c.Get("admin")
 				assert.False(t, exists, "Expected admin not to be set")
 			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/PrayerLoop/models"
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationRequired decides whether unverified accounts are restricted.
+// It defaults to the REQUIRE_EMAIL_VERIFICATION env var so the policy can be
+// switched on once existing users have had a chance to verify.
+var EmailVerificationRequired = func(user models.UserProfile) bool {
+	return os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true"
+}
+
+// RequireVerifiedEmail blocks unverified accounts from routes that expose other
+// users (search, connection requests) when the policy is enabled. Admins are exempt.
+func RequireVerifiedEmail(c *gin.Context) {
+	user := c.MustGet("currentUser").(models.UserProfile)
+
+	if user.Email_Verified || c.GetBool("admin") || !EmailVerificationRequired(user) {
+		c.Next()
+		return
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address to use this feature"})
+	c.Abort()
+}
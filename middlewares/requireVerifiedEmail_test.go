@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/PrayerLoop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test RequireVerifiedEmail middleware
+func TestRequireVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		name          string
+		policyEnabled bool
+		emailVerified bool
+		isAdmin       bool
+		expectAbort   bool
+	}{
+		{
+			name:          "policy disabled - unverified allowed",
+			policyEnabled: false,
+			emailVerified: false,
+			expectAbort:   false,
+		},
+		{
+			name:          "policy enabled - verified allowed",
+			policyEnabled: true,
+			emailVerified: true,
+			expectAbort:   false,
+		},
+		{
+			name:          "policy enabled - unverified blocked",
+			policyEnabled: true,
+			emailVerified: false,
+			expectAbort:   true,
+		},
+		{
+			name:          "policy enabled - unverified admin allowed",
+			policyEnabled: true,
+			emailVerified: false,
+			isAdmin:       true,
+			expectAbort:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.policyEnabled {
+				os.Setenv("REQUIRE_EMAIL_VERIFICATION", "true")
+			} else {
+				os.Unsetenv("REQUIRE_EMAIL_VERIFICATION")
+			}
+			defer os.Unsetenv("REQUIRE_EMAIL_VERIFICATION")
+
+			c, w := setupTestContext()
+			c.Set("currentUser", models.UserProfile{User_Profile_ID: 1, Email_Verified: tt.emailVerified})
+			c.Set("admin", tt.isAdmin)
+
+			RequireVerifiedEmail(c)
+
+			assert.Equal(t, tt.expectAbort, c.IsAborted())
+			if tt.expectAbort {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+			}
+		})
+	}
+}
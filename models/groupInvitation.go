@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Email invitation statuses. A pending invitation is claimed when someone
+// signs up with the invited address.
+const (
+	GroupInvitationStatusPending  = "pending"
+	GroupInvitationStatusAccepted = "accepted"
+)
+
+// Per-address outcomes reported by POST /groups/:group_profile_id/invitations
+const (
+	GroupInvitationResultInvited      = "invited"
+	GroupInvitationResultResent       = "resent"
+	GroupInvitationResultExistingUser = "existing_user"
+)
+
+// GroupInvitation is an email invitation for someone who doesn't have an
+// account yet
+type GroupInvitation struct {
+	Group_Invitation_ID      int        `json:"groupInvitationId" db:"group_invitation_id" goqu:"skipinsert"`
+	Group_Profile_ID         int        `json:"groupProfileId" db:"group_profile_id"`
+	Email                    string     `json:"email" db:"email"`
+	Invitation_Status        string     `json:"status" db:"invitation_status"`
+	Invited_By               int        `json:"invitedBy" db:"invited_by"`
+	Datetime_Expires         time.Time  `json:"datetimeExpires" db:"datetime_expires"`
+	Accepted_User_Profile_ID *int       `json:"acceptedUserProfileId" db:"accepted_user_profile_id"`
+	Datetime_Accepted        *time.Time `json:"datetimeAccepted" db:"datetime_accepted"`
+	Datetime_Create          time.Time  `json:"datetimeCreate" db:"datetime_create" goqu:"skipinsert"`
+	Datetime_Update          time.Time  `json:"datetimeUpdate" db:"datetime_update"`
+}
+
+type GroupInvitationCreate struct {
+	Emails []string `json:"emails" binding:"required"`
+}
+
+// GroupInvitationResult is the outcome for one address in a bulk invite
+type GroupInvitationResult struct {
+	Email               string `json:"email"`
+	Status              string `json:"status"`
+	Group_Invitation_ID int    `json:"groupInvitationId,omitempty"`
+	Email_Sent          bool   `json:"emailSent"`
+}
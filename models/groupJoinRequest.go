@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Join request statuses for groups that require approval
+const (
+	GroupJoinRequestStatusPending  = "pending"
+	GroupJoinRequestStatusApproved = "approved"
+	GroupJoinRequestStatusDenied   = "denied"
+)
+
+// GroupJoinRequest is someone asking to join a group that requires approval.
+// Group_Invite_ID is the code they used; its use is counted when they ask.
+type GroupJoinRequest struct {
+	Group_Join_Request_ID int        `json:"groupJoinRequestId" db:"group_join_request_id" goqu:"skipinsert"`
+	Group_Profile_ID      int        `json:"groupProfileId" db:"group_profile_id"`
+	User_Profile_ID       int        `json:"userProfileId" db:"user_profile_id"`
+	Group_Invite_ID       *int       `json:"groupInviteId" db:"group_invite_id"`
+	Request_Status        string     `json:"status" db:"request_status"`
+	Responded_By          *int       `json:"respondedBy" db:"responded_by"`
+	Datetime_Responded    *time.Time `json:"datetimeResponded" db:"datetime_responded"`
+	Datetime_Create       time.Time  `json:"datetimeCreate" db:"datetime_create" goqu:"skipinsert"`
+}
+
+// GroupJoinRequestDetail is a join request with the requester's name, as
+// listed for the group's owners and moderators
+type GroupJoinRequestDetail struct {
+	Group_Join_Request_ID int        `json:"groupJoinRequestId" db:"group_join_request_id"`
+	Group_Profile_ID      int        `json:"groupProfileId" db:"group_profile_id"`
+	User_Profile_ID       int        `json:"userProfileId" db:"user_profile_id"`
+	Group_Invite_ID       *int       `json:"groupInviteId" db:"group_invite_id"`
+	Request_Status        string     `json:"status" db:"request_status"`
+	Responded_By          *int       `json:"respondedBy" db:"responded_by"`
+	Datetime_Responded    *time.Time `json:"datetimeResponded" db:"datetime_responded"`
+	Datetime_Create       time.Time  `json:"datetimeCreate" db:"datetime_create"`
+	Username              string     `json:"username" db:"username"`
+	First_Name            string     `json:"firstName" db:"first_name"`
+	Last_Name             string     `json:"lastName" db:"last_name"`
+}
+
+type GroupJoinRequestResponse struct {
+	Status string `json:"status" binding:"required"`
+}
@@ -14,11 +14,14 @@ type GroupProfile struct {
 	Deleted                bool      `json:"deleted" goqu:"skipinsert"`
 	Prayer_Subject_ID      *int      `json:"prayerSubjectId" db:"prayer_subject_id" goqu:"skipinsert,skipupdate"`
 	Group_Display_Sequence int       `json:"groupDisplaySequence" db:"group_display_sequence" goqu:"skipinsert,skipupdate"`
+	Role                   string    `json:"role,omitempty" db:"role" goqu:"skipinsert,skipupdate"`
+	Requires_Approval      bool      `json:"requiresApproval" db:"requires_approval"`
 }
 
 type GroupCreate struct {
 	Group_Name        string `json:"groupName"`
 	Group_Description string `json:"groupDescription"`
+	Requires_Approval bool   `json:"requiresApproval"`
 }
 
 type GroupUpdate struct {
@@ -26,4 +29,28 @@ type GroupUpdate struct {
 	Group_Description string `json:"groupDescription"`
 	Is_Active         bool   `json:"isActive"`
 	Deleted           bool   `json:"deleted"`
+	Requires_Approval *bool  `json:"requiresApproval"` // left unchanged when omitted
+}
+
+// GroupStats is the engagement summary for GET /groups/:group_profile_id/stats.
+// It deliberately reports group totals only, with no per-member ranking.
+type GroupStats struct {
+	Group_Profile_ID     int              `json:"groupId"`
+	Member_Count         int              `json:"memberCount"`
+	Prayers_Posted       int              `json:"prayersPosted"`
+	Prayers_Answered     int              `json:"prayersAnswered"`
+	Prayer_Events        int              `json:"prayerEvents"`
+	Comment_Count        int              `json:"commentCount"`
+	Active_Members_7Day  int              `json:"activeMembers7Days"`
+	Active_Members_30Day int              `json:"activeMembers30Days"`
+	Most_Prayed_For      []GroupTopPrayer `json:"mostPrayedFor"`
+}
+
+// GroupTopPrayer is one entry in GroupStats.Most_Prayed_For
+type GroupTopPrayer struct {
+	Prayer_ID        int    `json:"prayerId" db:"prayer_id"`
+	Title            string `json:"title" db:"title"`
+	Is_Answered      *bool  `json:"isAnswered" db:"is_answered"`
+	Total_Prayers    int    `json:"totalPrayers" db:"total_prayers"`
+	Num_Unique_Users int    `json:"numUniqueUsers" db:"num_unique_users"`
 }
@@ -2,6 +2,14 @@ package models
 
 import "time"
 
+// Invite code statuses, derived when invites are listed
+const (
+	GroupInviteStatusActive  = "active"
+	GroupInviteStatusExpired = "expired"
+	GroupInviteStatusUsedUp  = "used_up"
+	GroupInviteStatusRevoked = "revoked"
+)
+
 type GroupInvite struct {
 	Group_Invite_ID  int       `json:"groupInviteId" goqu:"skipinsert"`
 	Group_Profile_ID int       `json:"groupProfileId"`
@@ -12,6 +20,42 @@ type GroupInvite struct {
 	Updated_By       int       `json:"updatedBy"`
 	Datetime_Expires time.Time `json:"datetimeExpires"`
 	Is_Active        bool      `json:"isActive"`
+	Max_Uses         *int      `json:"maxUses"` // nil means unlimited
+	Use_Count        int       `json:"useCount" goqu:"skipinsert"`
+}
+
+// GroupInviteCreate is the optional body of POST /groups/:group_profile_id/invite
+type GroupInviteCreate struct {
+	Expires_In_Hours *int `json:"expiresInHours"`
+	Max_Uses         *int `json:"maxUses"` // 0 for unlimited
+}
+
+// GroupInviteDetail is an invite as listed for the group's owner and moderators
+type GroupInviteDetail struct {
+	GroupInvite
+	Status      string           `json:"status"`
+	Invite_Link string           `json:"inviteLink,omitempty"`
+	Uses        []GroupInviteUse `json:"uses"`
+}
+
+// GroupInviteUse records who joined a group through which invite code
+type GroupInviteUse struct {
+	Group_Invite_Use_ID int       `json:"groupInviteUseId" db:"group_invite_use_id" goqu:"skipinsert"`
+	Group_Invite_ID     int       `json:"groupInviteId" db:"group_invite_id"`
+	Group_Profile_ID    int       `json:"groupProfileId" db:"group_profile_id"`
+	User_Profile_ID     int       `json:"userProfileId" db:"user_profile_id"`
+	Username            string    `json:"username" db:"username" goqu:"skipinsert"`
+	First_Name          string    `json:"firstName" db:"first_name" goqu:"skipinsert"`
+	Last_Name           string    `json:"lastName" db:"last_name" goqu:"skipinsert"`
+	Datetime_Create     time.Time `json:"datetimeCreate" db:"datetime_create" goqu:"skipinsert"`
+}
+
+// GroupInvitePreview is what GET /invites/:code/preview shows before joining
+type GroupInvitePreview struct {
+	Group_Name        string    `json:"groupName" db:"group_name"`
+	Group_Description string    `json:"groupDescription" db:"group_description"`
+	Member_Count      int       `json:"memberCount" db:"member_count"`
+	Datetime_Expires  time.Time `json:"expiresAt" db:"datetime_expires"`
 }
 
 type JoinRequest struct {
@@ -28,11 +28,90 @@ const (
 	// Recipients: All existing group members.
 	NotificationTypeGroupMemberJoined = "GROUP_MEMBER_JOINED"
 
+	// NotificationTypeGroupJoinRequested fires when someone asks to join a group that requires approval.
+	// Recipients: The group's owner and moderators.
+	NotificationTypeGroupJoinRequested = "GROUP_JOIN_REQUESTED"
+
+	// NotificationTypeGroupJoinApproved fires when a join request is approved.
+	// Recipient: The requester.
+	NotificationTypeGroupJoinApproved = "GROUP_JOIN_APPROVED"
+
+	// NotificationTypeGroupJoinDenied fires when a join request is denied.
+	// Recipient: The requester.
+	NotificationTypeGroupJoinDenied = "GROUP_JOIN_DENIED"
+
+	// NotificationTypeGroupMemberLeft fires when a member leaves or is removed from a group.
+	// Recipients: The remaining group members.
+	NotificationTypeGroupMemberLeft = "GROUP_MEMBER_LEFT"
+
+	// NotificationTypeGroupLeft fires when a user leaves a group, confirming it.
+	// Recipient: The user who left.
+	NotificationTypeGroupLeft = "GROUP_LEFT"
+
+	// NotificationTypeRemovedFromGroup fires when a group owner, moderator or admin removes a member.
+	// Recipient: The removed user.
+	NotificationTypeRemovedFromGroup = "REMOVED_FROM_GROUP"
+
+	// NotificationTypeGroupDeleted fires when a group is deleted.
+	// Recipients: Everyone who was a member.
+	NotificationTypeGroupDeleted = "GROUP_DELETED"
+
 	// NotificationTypePrayerRemovedFromGroup fires when a linked subject removes a prayer from a group.
 	// Recipient: The prayer creator.
 	NotificationTypePrayerRemovedFromGroup = "PRAYER_REMOVED_FROM_GROUP"
+
+	// NotificationTypePrayerUpdatePosted fires when the creator or linked subject posts an update
+	// (or an answered testimony) on a prayer.
+	// Recipients: Everyone the prayer is shared with, directly or through a group, except the author.
+	NotificationTypePrayerUpdatePosted = "PRAYER_UPDATE_POSTED"
+
+	// NotificationTypePrayerFollowUp fires when an open prayer has had no activity for a while
+	// (PRAYER_FOLLOW_UP_DAYS, default 30), asking for an update.
+	// Recipient: The prayer creator, unless they turned off the prayer_follow_ups preference.
+	NotificationTypePrayerFollowUp = "PRAYER_FOLLOW_UP"
+
+	// NotificationTypePrayerReminder fires when one of the user's own prayer reminders is due.
+	// Recipient: The user who set the reminder. Push only, and not in NotificationTypes:
+	// the user asked for it at that time, so channel preferences and quiet hours don't apply.
+	NotificationTypePrayerReminder = "PRAYER_REMINDER"
+
+	// NotificationTypeConnectionRequested fires when a user asks to link one of their prayer subjects to another user.
+	// Recipient: The target user.
+	NotificationTypeConnectionRequested = "CONNECTION_REQUESTED"
+
+	// NotificationTypeConnectionAccepted fires when a connection request is accepted.
+	// Recipient: The requester.
+	NotificationTypeConnectionAccepted = "CONNECTION_ACCEPTED"
+
+	// NotificationTypeConnectionDeclined fires when a connection request is declined.
+	// Recipient: The requester.
+	NotificationTypeConnectionDeclined = "CONNECTION_DECLINED"
 )
 
+// NotificationTypes lists every notification type with per-type channel
+// preferences
+var NotificationTypes = []string{
+	NotificationTypePrayerCreatedForYou,
+	NotificationTypePrayerEditedBySubject,
+	NotificationTypePrayerCommentAdded,
+	NotificationTypePrayerShared,
+	NotificationTypeGroupInvite,
+	NotificationTypeGroupMemberJoined,
+	NotificationTypeGroupJoinRequested,
+	NotificationTypeGroupJoinApproved,
+	NotificationTypeGroupJoinDenied,
+	NotificationTypeGroupMemberLeft,
+	NotificationTypeGroupLeft,
+	NotificationTypeRemovedFromGroup,
+	NotificationTypeGroupDeleted,
+	NotificationTypePrayerRemovedFromGroup,
+	NotificationTypePrayerFollowUp,
+	NotificationTypePrayerUpdatePosted,
+	NotificationTypeConnectionRequested,
+	NotificationTypeConnectionAccepted,
+	NotificationTypeConnectionDeclined,
+}
+
 // Notification status constants
 const (
 	NotificationStatusRead   = "READ"
@@ -52,4 +131,5 @@ type Notification struct {
 	Target_Prayer_ID     *int      `json:"targetPrayerId" db:"target_prayer_id" goqu:"skipupdate"`
 	Target_Group_ID      *int      `json:"targetGroupId" db:"target_group_id" goqu:"skipupdate"`
 	Target_Comment_ID    *int      `json:"targetCommentId" db:"target_comment_id" goqu:"skipupdate"`
+	Pending_Channels     *string   `json:"-" db:"pending_channels" goqu:"skipupdate"` // push/email held for quiet hours or a digest
 }
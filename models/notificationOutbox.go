@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Outbox delivery statuses. Pending rows are waiting for their next attempt,
+// processing rows are claimed by a worker, and dead rows ran out of attempts.
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusProcessing = "processing"
+	OutboxStatusSent       = "sent"
+	OutboxStatusDead       = "dead"
+)
+
+// NotificationOutbox is one push or email delivery waiting to be sent.
+// Payload is the JSON encoded delivery; Idempotency_Key is unique, so the
+// same delivery is only ever queued once. Delivered_To lists the notifiers
+// (e.g. fcm, expo) that already sent it, so a retry only goes to the rest.
+type NotificationOutbox struct {
+	Outbox_ID         int        `json:"outboxId" db:"outbox_id" goqu:"skipinsert"`
+	Idempotency_Key   string     `json:"idempotencyKey" db:"idempotency_key"`
+	User_Profile_ID   int        `json:"userProfileId" db:"user_profile_id"`
+	Notification_Type string     `json:"notificationType" db:"notification_type"`
+	Channel           string     `json:"channel" db:"channel"`
+	Payload           string     `json:"payload" db:"payload"`
+	Status            string     `json:"status" db:"status"`
+	Attempts          int        `json:"attempts" db:"attempts"`
+	Next_Attempt_At   time.Time  `json:"nextAttemptAt" db:"next_attempt_at"`
+	Locked_Until      *time.Time `json:"lockedUntil" db:"locked_until"`
+	Last_Error        *string    `json:"lastError" db:"last_error"`
+	Delivered_To      *string    `json:"deliveredTo" db:"delivered_to"`
+	Sent_At           *time.Time `json:"sentAt" db:"sent_at"`
+	Datetime_Create   time.Time  `json:"datetimeCreate" db:"datetime_create" goqu:"skipinsert"`
+	Datetime_Update   time.Time  `json:"datetimeUpdate" db:"datetime_update" goqu:"skipinsert"`
+}
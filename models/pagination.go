@@ -0,0 +1,9 @@
+package models
+
+// PageInfo is returned alongside a list whenever the request asked for a page
+// (limit or cursor). Pass NextCursor back as ?cursor= to fetch the next page.
+type PageInfo struct {
+	Limit      int     `json:"limit"`
+	HasMore    bool    `json:"hasMore"`
+	NextCursor *string `json:"nextCursor"`
+}
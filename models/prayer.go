@@ -18,6 +18,7 @@ type Prayer struct {
 	Updated_By               int        `json:"updatedBy" db:"updated_by"`
 	Datetime_Update          time.Time  `json:"datetimeUpdate" db:"datetime_update" goqu:"skipinsert"`
 	Deleted                  bool       `json:"deleted" db:"deleted" goqu:"skipinsert"`
+	Is_Archived              bool       `json:"isArchived" db:"is_archived" goqu:"skipinsert"`
 }
 
 type UserPrayer struct {
@@ -42,6 +43,7 @@ type UserPrayer struct {
 	Updated_By                     int        `json:"updatedBy" db:"updated_by"`
 	Datetime_Update                time.Time  `json:"datetimeUpdate" db:"datetime_update" goqu:"skipinsert"`
 	Deleted                        bool       `json:"deleted" db:"deleted" goqu:"skipinsert"`
+	Is_Archived                    bool       `json:"isArchived" db:"is_archived" goqu:"skipinsert"`
 	Prayer_Category_ID             *int       `json:"prayerCategoryId,omitempty" db:"prayer_category_id" goqu:"skipinsert"`
 	Category_Name                  *string    `json:"categoryName,omitempty" db:"category_name" goqu:"skipinsert"`
 	Category_Color                 *string    `json:"categoryColor,omitempty" db:"category_color" goqu:"skipinsert"`
@@ -60,6 +62,11 @@ type PrayerCreate struct {
 	Prayer_Subject_ID  *int       `json:"prayerSubjectId"`
 }
 
+// PrayerArchiveRequest archives a prayer or restores it
+type PrayerArchiveRequest struct {
+	Archived *bool `json:"archived" binding:"required"`
+}
+
 type PrayerAccess struct {
 	Prayer_Access_ID int       `json:"prayerAccessId" db:"prayer_access_id" goqu:"skipinsert"`
 	Prayer_ID        int       `json:"prayerId" db:"prayer_id"`
@@ -14,6 +14,14 @@ type PrayerAnalytics struct {
 
 // PrayerAnalyticsResponse is the response type for GET endpoint (subset of fields)
 type PrayerAnalyticsResponse struct {
-	TotalPrayers   int `json:"totalPrayers"`
-	NumUniqueUsers int `json:"numUniqueUsers"`
+	TotalPrayers   int                  `json:"totalPrayers"`
+	NumUniqueUsers int                  `json:"numUniqueUsers"`
+	Timeline       []PrayerAnalyticsDay `json:"timeline,omitempty"`
+}
+
+// PrayerAnalyticsDay is one day of the prayer timeline, built from prayer_event
+type PrayerAnalyticsDay struct {
+	Date        string `json:"date"`
+	Prayers     int    `json:"prayers"`
+	UniqueUsers int    `json:"uniqueUsers"`
 }
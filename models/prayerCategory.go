@@ -34,5 +34,6 @@ type PrayerCategoryItem struct {
 	Prayer_Category_ID      int       `json:"prayerCategoryId"`
 	Prayer_Access_ID        int       `json:"prayerAccessId"`
 	Datetime_Create         time.Time `json:"datetimeCreate" goqu:"skipinsert,skipupdate"`
+	Datetime_Update         time.Time `json:"datetimeUpdate" goqu:"skipinsert,skipupdate"`
 	Created_By              int       `json:"createdBy"`
 }
@@ -18,14 +18,57 @@ const (
 
 	// HistoryActionDeleted records when a prayer is deleted.
 	HistoryActionDeleted = "deleted"
+
+	// HistoryActionArchived records when a prayer is archived.
+	HistoryActionArchived = "archived"
+
+	// HistoryActionUnarchived records when an archived prayer is restored.
+	HistoryActionUnarchived = "unarchived"
+
+	// HistoryActionUpdatePosted records when the creator or linked subject posts a prayer update.
+	HistoryActionUpdatePosted = "update_posted"
+
+	// HistoryActionRestored records when the creator restores an earlier version.
+	HistoryActionRestored = "restored"
+)
+
+// Fields tracked in PrayerVersion, as named in field-level changes
+const (
+	PrayerFieldTitle       = "title"
+	PrayerFieldDescription = "prayerDescription"
+	PrayerFieldPriority    = "prayerPriority"
+	PrayerFieldSubject     = "prayerSubjectId"
+	PrayerFieldPrivate     = "isPrivate"
+	PrayerFieldAnswered    = "isAnswered"
 )
 
 // PrayerEditHistory represents an entry in the prayer_edit_history table.
-// Tracks who performed what action on a prayer and when.
+// Tracks who performed what action on a prayer and when. Edits and restores
+// also save the prayer's version before and after, as PrayerVersion JSON.
 type PrayerEditHistory struct {
 	Prayer_Edit_History_ID int       `json:"prayerEditHistoryId" goqu:"skipinsert"`
 	Prayer_ID              int       `json:"prayerId"`
 	User_Profile_ID        int       `json:"userProfileId"`
 	Action_Type            string    `json:"actionType"`
+	Previous_Version       *string   `json:"-" goqu:"omitnil"`
+	New_Version            *string   `json:"-" goqu:"omitnil"`
+	Restored_From_ID       *int      `json:"restoredFromId,omitempty" goqu:"omitnil"`
 	DateTime_Create        time.Time `json:"datetimeCreate" goqu:"skipinsert"`
 }
+
+// PrayerVersion is the editable content of a prayer at one point in its history
+type PrayerVersion struct {
+	Title              string `json:"title"`
+	Prayer_Description string `json:"prayerDescription"`
+	Prayer_Priority    *int   `json:"prayerPriority"`
+	Prayer_Subject_ID  *int   `json:"prayerSubjectId"`
+	Is_Private         *bool  `json:"isPrivate"`
+	Is_Answered        *bool  `json:"isAnswered"`
+}
+
+// PrayerFieldChange is one field that differs between two prayer versions
+type PrayerFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Reminder targets
+const (
+	ReminderTargetPrayer   = "prayer"
+	ReminderTargetSubject  = "subject"
+	ReminderTargetCategory = "category"
+)
+
+// Reminder schedules. Once fires on Remind_Date; daily every day; weekly and
+// custom on the days in Days_Of_Week (weekly has exactly one).
+const (
+	ReminderScheduleOnce   = "once"
+	ReminderScheduleDaily  = "daily"
+	ReminderScheduleWeekly = "weekly"
+	ReminderScheduleCustom = "custom"
+)
+
+// PrayerReminder is a user's reminder to pray for a prayer, prayer subject or
+// category. Remind_Time, Remind_Date and Days_Of_Week are local to Timezone;
+// Next_Fire_At is the next occurrence in UTC, or nil once it is done.
+type PrayerReminder struct {
+	Prayer_Reminder_ID int        `json:"prayerReminderId" db:"prayer_reminder_id" goqu:"skipinsert"`
+	User_Profile_ID    int        `json:"userProfileId" db:"user_profile_id"`
+	Target_Type        string     `json:"targetType" db:"target_type"`
+	Target_ID          int        `json:"targetId" db:"target_id"`
+	Schedule_Type      string     `json:"scheduleType" db:"schedule_type"`
+	Remind_Time        string     `json:"time" db:"remind_time"`
+	Remind_Date        *string    `json:"date" db:"remind_date"`
+	Days_Of_Week       *string    `json:"daysOfWeek" db:"days_of_week"`
+	Timezone           string     `json:"timezone" db:"timezone"`
+	Label              *string    `json:"label" db:"label"`
+	Is_Active          bool       `json:"isActive" db:"is_active"`
+	Next_Fire_At       *time.Time `json:"nextFireAt" db:"next_fire_at"`
+	Last_Fired_At      *time.Time `json:"lastFiredAt" db:"last_fired_at"`
+	Datetime_Create    time.Time  `json:"datetimeCreate" db:"datetime_create" goqu:"skipinsert"`
+	Datetime_Update    time.Time  `json:"datetimeUpdate" db:"datetime_update" goqu:"skipinsert"`
+}
+
+// PrayerReminderRequest creates a reminder, or with PATCH changes the fields
+// that are set. DaysOfWeek uses three-letter names (mon, tue, ...); Date is
+// YYYY-MM-DD and Time is HH:MM.
+type PrayerReminderRequest struct {
+	TargetType   *string  `json:"targetType"`
+	TargetID     *int     `json:"targetId"`
+	ScheduleType *string  `json:"scheduleType"`
+	Time         *string  `json:"time"`
+	Date         *string  `json:"date"`
+	DaysOfWeek   []string `json:"daysOfWeek"`
+	Timezone     *string  `json:"timezone"`
+	Label        *string  `json:"label" binding:"omitempty,max=200"`
+	IsActive     *bool    `json:"isActive"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Prayer session statuses
+const (
+	PrayerSessionActive    = "active"
+	PrayerSessionCompleted = "completed"
+)
+
+// Sources a prayer session can be started from
+const (
+	PrayerSessionSourceUser     = "user"
+	PrayerSessionSourceGroup    = "group"
+	PrayerSessionSourceSubject  = "subject"
+	PrayerSessionSourceCategory = "category"
+)
+
+type PrayerSession struct {
+	Prayer_Session_ID      int        `json:"prayerSessionId" db:"prayer_session_id" goqu:"skipinsert"`
+	User_Profile_ID        int        `json:"userProfileId" db:"user_profile_id"`
+	Source_Type            string     `json:"sourceType" db:"source_type"`
+	Source_ID              int        `json:"sourceId" db:"source_id"`
+	Session_Status         string     `json:"sessionStatus" db:"session_status"`
+	Datetime_Start         time.Time  `json:"datetimeStart" db:"datetime_start"`
+	Datetime_End           *time.Time `json:"datetimeEnd" db:"datetime_end"`
+	Prayer_Count           int        `json:"prayerCount" db:"prayer_count"`
+	Total_Duration_Seconds int        `json:"totalDurationSeconds" db:"total_duration_seconds"`
+}
+
+type PrayerSessionDetail struct {
+	Prayer_Session_Detail_ID int        `json:"prayerSessionDetailId" db:"prayer_session_detail_id" goqu:"skipinsert"`
+	Prayer_Session_ID        int        `json:"prayerSessionId" db:"prayer_session_id"`
+	Prayer_ID                int        `json:"prayerId" db:"prayer_id"`
+	Display_Sequence         int        `json:"displaySequence" db:"display_sequence"`
+	Duration_Seconds         *int       `json:"durationSeconds" db:"duration_seconds"`
+	Datetime_Prayed          *time.Time `json:"datetimePrayed" db:"datetime_prayed"`
+	Title                    string     `json:"title" db:"title" goqu:"skipinsert,skipupdate"`
+}
+
+type PrayerSessionStart struct {
+	Source_Type      string `json:"sourceType" binding:"required,oneof=user group subject category"`
+	Source_ID        int    `json:"sourceId"`
+	Include_Answered bool   `json:"includeAnswered"`
+}
+
+type PrayerSessionRecord struct {
+	Prayer_ID        int `json:"prayerId" binding:"required"`
+	Duration_Seconds int `json:"durationSeconds" binding:"min=0,max=86400"`
+}
+
+type UserStats struct {
+	User_Profile_ID        int        `json:"userProfileId" db:"user_profile_id"`
+	Total_Sessions         int        `json:"totalSessions" db:"total_sessions"`
+	Total_Prayers          int        `json:"totalPrayers" db:"total_prayers"`
+	Total_Duration_Seconds int        `json:"totalDurationSeconds" db:"total_duration_seconds"`
+	Datetime_Last_Session  *time.Time `json:"datetimeLastSession" db:"datetime_last_session"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Prayer update types. An answered update carries the testimony and marks the
+// prayer answered.
+const (
+	PrayerUpdateTypeUpdate   = "update"
+	PrayerUpdateTypeAnswered = "answered"
+)
+
+// PrayerUpdate is an append-only entry on a prayer's timeline, posted by its
+// creator or linked subject
+type PrayerUpdate struct {
+	Prayer_Update_ID int       `json:"prayerUpdateId" db:"prayer_update_id" goqu:"skipinsert"`
+	Prayer_ID        int       `json:"prayerId" db:"prayer_id"`
+	User_Profile_ID  int       `json:"userProfileId" db:"user_profile_id"`
+	Update_Type      string    `json:"updateType" db:"update_type"`
+	Update_Text      string    `json:"updateText" db:"update_text"`
+	Author_Name      string    `json:"authorName" db:"author_name" goqu:"skipinsert"`
+	Datetime_Create  time.Time `json:"datetimeCreate" db:"datetime_create" goqu:"skipinsert"`
+}
+
+// PrayerUpdateRequest posts an update. UpdateType defaults to "update".
+type PrayerUpdateRequest struct {
+	UpdateType string `json:"updateType"`
+	UpdateText string `json:"updateText" binding:"required,max=2000"`
+}
@@ -3,15 +3,32 @@ package models
 import "time"
 
 type PushToken struct {
-	UserPushTokenID int       `json:"userPushTokenId" db:"user_push_tokens_id"`
-	UserProfileID   int       `json:"userProfileId" db:"user_profile_id"`
-	PushToken       string    `json:"pushToken" db:"push_token"`
-	Platform        string    `json:"platform" db:"platform"`
-	CreatedAt       time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt       time.Time `json:"updatedAt" db:"updated_at"`
+	UserPushTokenID int        `json:"userPushTokenId" db:"user_push_tokens_id"`
+	UserProfileID   int        `json:"userProfileId" db:"user_profile_id"`
+	PushToken       string     `json:"pushToken" db:"push_token"`
+	Platform        string     `json:"platform" db:"platform"`
+	DeviceID        *string    `json:"deviceId" db:"device_id"`
+	DeviceName      *string    `json:"deviceName" db:"device_name"`
+	LastSeenAt      *time.Time `json:"lastSeenAt" db:"last_seen_at"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
 }
 
+// PushTokenRequest registers a token. DeviceID is a stable per-install ID from
+// the app; a new token for the same device replaces the old one.
 type PushTokenRequest struct {
-	PushToken string `json:"pushToken" binding:"required"`
-	Platform  string `json:"platform" binding:"required,oneof=ios android"`
+	PushToken  string `json:"pushToken" binding:"required"`
+	Platform   string `json:"platform" binding:"required,oneof=ios android"`
+	DeviceID   string `json:"deviceId" binding:"max=255"`
+	DeviceName string `json:"deviceName" binding:"max=255"`
+}
+
+// Device is a push token as shown to its owner, without the token itself
+type Device struct {
+	UserPushTokenID int        `json:"userPushTokenId" db:"user_push_tokens_id"`
+	Platform        string     `json:"platform" db:"platform"`
+	DeviceID        *string    `json:"deviceId" db:"device_id"`
+	DeviceName      *string    `json:"deviceName" db:"device_name"`
+	LastSeenAt      *time.Time `json:"lastSeenAt" db:"last_seen_at"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
 }
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Search result types returned by GET /search. Snippet is a ts_headline
+// excerpt of HTML-escaped text with matches wrapped in <mark></mark>.
+
+type PrayerSearchResult struct {
+	Prayer_ID         int       `json:"prayerId" db:"prayer_id"`
+	Title             string    `json:"title" db:"title"`
+	Is_Answered       *bool     `json:"isAnswered" db:"is_answered"`
+	Prayer_Subject_ID *int      `json:"prayerSubjectId" db:"prayer_subject_id"`
+	Datetime_Create   time.Time `json:"datetimeCreate" db:"datetime_create"`
+	Snippet           string    `json:"snippet" db:"snippet"`
+	Rank              float64   `json:"rank" db:"rank"`
+}
+
+type PrayerSubjectSearchResult struct {
+	Prayer_Subject_ID           int     `json:"prayerSubjectId" db:"prayer_subject_id"`
+	Prayer_Subject_Type         string  `json:"prayerSubjectType" db:"prayer_subject_type"`
+	Prayer_Subject_Display_Name string  `json:"prayerSubjectDisplayName" db:"prayer_subject_display_name"`
+	Snippet                     string  `json:"snippet" db:"snippet"`
+	Rank                        float64 `json:"rank" db:"rank"`
+}
+
+type CommentSearchResult struct {
+	Comment_ID      int       `json:"commentId" db:"comment_id"`
+	Prayer_ID       int       `json:"prayerId" db:"prayer_id"`
+	Prayer_Title    string    `json:"prayerTitle" db:"prayer_title"`
+	User_Profile_ID int       `json:"userProfileId" db:"user_profile_id"`
+	Datetime_Create time.Time `json:"datetimeCreate" db:"datetime_create"`
+	Snippet         string    `json:"snippet" db:"snippet"`
+	Rank            float64   `json:"rank" db:"rank"`
+}
+
+type SearchResults struct {
+	Prayers  []PrayerSearchResult        `json:"prayers"`
+	Subjects []PrayerSubjectSearchResult `json:"subjects"`
+	Comments []CommentSearchResult       `json:"comments"`
+}
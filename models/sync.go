@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Sync tombstone entity types. A tombstone tells one user's offline cache to
+// drop a row that was hard deleted or that the user can no longer see.
+const (
+	// SyncEntityPrayer drops the prayer and every access row the client holds for it
+	SyncEntityPrayer = "prayer"
+
+	// SyncEntityPrayerAccess drops a single prayer_access row
+	SyncEntityPrayerAccess = "prayer_access"
+
+	// SyncEntityPrayerCategory drops a category and its category items
+	SyncEntityPrayerCategory = "prayer_category"
+
+	// SyncEntityPrayerCategoryItem drops a single prayer_category_item row
+	SyncEntityPrayerCategoryItem = "prayer_category_item"
+
+	// SyncEntityPrayerSubject drops a prayer subject
+	SyncEntityPrayerSubject = "prayer_subject"
+
+	// SyncEntityGroup drops the group, the membership and everything shared through the group
+	SyncEntityGroup = "group"
+
+	// SyncEntityNotification drops a notification
+	SyncEntityNotification = "notification"
+)
+
+type SyncTombstone struct {
+	Sync_Tombstone_ID int       `json:"-" db:"sync_tombstone_id" goqu:"skipinsert"`
+	User_Profile_ID   int       `json:"-" db:"user_profile_id"`
+	Entity_Type       string    `json:"entityType" db:"entity_type"`
+	Entity_ID         int       `json:"entityId" db:"entity_id"`
+	Datetime_Delete   time.Time `json:"datetimeDelete" db:"datetime_delete" goqu:"skipinsert"`
+}
+
+// SyncResponse is everything visible to the user that changed since the
+// client's cursor. Pass Cursor back as ?since= on the next sync.
+type SyncResponse struct {
+	Cursor         string               `json:"cursor"`
+	Full_Sync      bool                 `json:"fullSync"`
+	Prayers        []Prayer             `json:"prayers"`
+	Prayer_Access  []PrayerAccess       `json:"prayerAccess"`
+	Categories     []PrayerCategory     `json:"categories"`
+	Category_Items []PrayerCategoryItem `json:"categoryItems"`
+	Subjects       []PrayerSubject      `json:"prayerSubjects"`
+	Groups         []GroupProfile       `json:"groups"`
+	Memberships    []UserGroup          `json:"memberships"`
+	Notifications  []Notification       `json:"notifications"`
+	Deleted        []SyncTombstone      `json:"deleted"`
+}
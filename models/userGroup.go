@@ -2,6 +2,14 @@ package models
 
 import "time"
 
+// Group roles. Every group has one owner; moderators help run it and members
+// take part. See controllers/groupRoles.go for what each role may do.
+const (
+	GroupRoleOwner     = "owner"
+	GroupRoleModerator = "moderator"
+	GroupRoleMember    = "member"
+)
+
 type UserGroup struct {
 	User_Group_ID          int       `json:"userGroupId" goqu:"skipinsert"`
 	User_Profile_ID        int       `json:"userId"`
@@ -9,8 +17,23 @@ type UserGroup struct {
 	Is_Active              bool      `json:"isActive"`
 	Mute_Notifications     bool      `json:"muteNotifications" goqu:"mute_notifications"`
 	Group_Display_Sequence int       `json:"groupDisplaySequence"`
+	Role                   string    `json:"role"`
 	Created_By             int       `json:"createdBy"`
 	Updated_By             int       `json:"updatedBy"`
 	Datetime_Create        time.Time `json:"datetimeCreate"`
 	Datetime_Update        time.Time `json:"datetimeUpdate"`
 }
+
+// GroupMember is a user as listed by GET /groups/:group_profile_id/users
+type GroupMember struct {
+	UserProfile
+	Role string `json:"role" db:"role"`
+}
+
+type GroupRoleUpdate struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type GroupOwnershipTransfer struct {
+	User_Profile_ID int `json:"userProfileId" binding:"required"`
+}
@@ -3,23 +3,25 @@ package models
 import "time"
 
 type UserProfile struct {
-	User_Profile_ID    int       `json:"userProfileId" goqu:"skipinsert"`
-	Username           string    `json:"username"`
-	Password           string    `json:"-"`
-	Email              string    `json:"email"`
-	First_Name         string    `json:"firstName"`
-	Last_Name          string    `json:"lastName"`
-	Phone_Number       *string   `json:"phoneNumber"`
-	Email_Verified     bool      `json:"emailVerified" goqu:"skipinsert"`
-	Phone_Verified     bool      `json:"phoneVerified" goqu:"skipinsert"`
-	Verification_Token *string   `json:"-"`
-	Admin              bool      `json:"admin" goqu:"skipinsert"`
-	Photo_S3_Key       *string   `json:"photoS3Key" goqu:"skipinsert"`
-	Created_By         int       `json:"createdBy"`
-	Datetime_Create    time.Time `json:"datetimeCreate" goqu:"skipinsert"`
-	Updated_By         int       `json:"updatedBy"`
-	Datetime_Update    time.Time `json:"datetimeUpdate" goqu:"skipinsert"`
-	Deleted            bool      `json:"deleted" goqu:"skipinsert"`
+	User_Profile_ID         int        `json:"userProfileId" goqu:"skipinsert"`
+	Username                string     `json:"username"`
+	Password                string     `json:"-"`
+	Email                   string     `json:"email"`
+	First_Name              string     `json:"firstName"`
+	Last_Name               string     `json:"lastName"`
+	Phone_Number            *string    `json:"phoneNumber"`
+	Email_Verified          bool       `json:"emailVerified" goqu:"skipinsert"`
+	Phone_Verified          bool       `json:"phoneVerified" goqu:"skipinsert"`
+	Verification_Token      *string    `json:"-"`
+	Verification_Expires_At *time.Time `json:"-"`
+	Verification_Attempts   int        `json:"-"`
+	Admin                   bool       `json:"admin" goqu:"skipinsert"`
+	Photo_S3_Key            *string    `json:"photoS3Key" goqu:"skipinsert"`
+	Created_By              int        `json:"createdBy"`
+	Datetime_Create         time.Time  `json:"datetimeCreate" goqu:"skipinsert"`
+	Updated_By              int        `json:"updatedBy"`
+	Datetime_Update         time.Time  `json:"datetimeUpdate" goqu:"skipinsert"`
+	Deleted                 bool       `json:"deleted" goqu:"skipinsert"`
 }
 
 type UserProfileSignup struct {
@@ -45,3 +47,7 @@ type UserProfileChangePassword struct {
 	Old_Password    string `json:"oldPassword"`
 	New_Password    string `json:"newPassword"`
 }
+
+type VerifyEmailRequest struct {
+	Code string `json:"code" binding:"required,len=6"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type UserSession struct {
+	User_Session_ID    int        `json:"sessionId" db:"user_session_id" goqu:"skipinsert"`
+	User_Profile_ID    int        `json:"userProfileId" db:"user_profile_id"`
+	Refresh_Token_Hash string     `json:"-" db:"refresh_token_hash"`
+	User_Agent         string     `json:"userAgent" db:"user_agent"`
+	IP_Address         string     `json:"ipAddress" db:"ip_address"`
+	Expires_At         time.Time  `json:"expiresAt" db:"expires_at"`
+	Revoked_At         *time.Time `json:"revokedAt" db:"revoked_at"`
+	Last_Used_At       time.Time  `json:"lastUsedAt" db:"last_used_at"`
+	Datetime_Create    time.Time  `json:"datetimeCreate" db:"datetime_create" goqu:"skipinsert"`
+}
+
+// UserSessionRotatedToken remembers a refresh token that has been rotated out,
+// so replaying it can be detected however many rotations ago it was issued
+type UserSessionRotatedToken struct {
+	User_Session_Rotated_Token_ID int       `json:"-" db:"user_session_rotated_token_id" goqu:"skipinsert"`
+	User_Session_ID               int       `json:"-" db:"user_session_id"`
+	Token_Hash                    string    `json:"-" db:"token_hash"`
+	Datetime_Create               time.Time `json:"-" db:"datetime_create" goqu:"skipinsert"`
+}
+
+type RefreshTokenRequest struct {
+	Refresh_Token string `json:"refreshToken" binding:"required"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PersonalPrayerStats is the response for GET /users/:user_profile_id/stats.
+// Streaks and the weekly windows use day boundaries in Timezone.
+type PersonalPrayerStats struct {
+	User_Profile_ID        int        `json:"userProfileId"`
+	Timezone               string     `json:"timezone"`
+	Current_Streak         int        `json:"currentStreak"`
+	Longest_Streak         int        `json:"longestStreak"`
+	Total_Prayers          int        `json:"totalPrayers"`
+	Prayers_Last_7_Days    int        `json:"prayersLast7Days"`
+	Prayers_Last_30_Days   int        `json:"prayersLast30Days"`
+	Prayers_Per_Week       float64    `json:"prayersPerWeek"`
+	Prayers_Created        int        `json:"prayersCreated"`
+	Prayers_Answered       int        `json:"prayersAnswered"`
+	Answered_Rate          float64    `json:"answeredRate"`
+	Avg_Seconds_To_Answer  *int64     `json:"averageSecondsToAnswer"`
+	Total_Sessions         int        `json:"totalSessions"`
+	Total_Duration_Seconds int        `json:"totalDurationSeconds"`
+	Datetime_Last_Session  *time.Time `json:"datetimeLastSession"`
+}
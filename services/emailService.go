@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"log"
 	"os"
 
@@ -173,6 +175,133 @@ The prayerloop Team
 	return nil
 }
 
+// SendVerificationEmail sends a 6-digit code to confirm ownership of an email address
+func (s *EmailService) SendVerificationEmail(toEmail string, code string, firstName string) error {
+	if s.client == nil {
+		return fmt.Errorf("email service not initialized")
+	}
+
+	// Build the email HTML with the 6-digit code
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            text-align: center;
+            padding: 20px 0;
+            border-bottom: 2px solid #90c590;
+        }
+        .header h1 {
+            color: #90c590;
+            margin: 0;
+        }
+        .content {
+            padding: 30px 0;
+        }
+        .code-container {
+            background-color: #f5f5f5;
+            border: 2px solid #90c590;
+            border-radius: 8px;
+            padding: 20px;
+            text-align: center;
+            margin: 20px 0;
+        }
+        .code {
+            font-size: 32px;
+            font-weight: bold;
+            letter-spacing: 8px;
+            color: #90c590;
+            font-family: monospace;
+        }
+        .footer {
+            text-align: center;
+            padding: 20px 0;
+            border-top: 1px solid #ddd;
+            font-size: 12px;
+            color: #666;
+        }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>prayerloop</h1>
+    </div>
+
+    <div class="content">
+        <h2>Confirm Your Email</h2>
+
+        <p>Hi %s,</p>
+
+        <p>Please confirm this email address for your prayerloop account by entering the code below in the app:</p>
+
+        <div class="code-container">
+            <div class="code">%s</div>
+        </div>
+
+        <p><strong>This code will expire in 24 hours.</strong></p>
+
+        <p>If you didn't create a prayerloop account or change your email address, you can safely ignore this email.</p>
+
+        <p>Need help? Reply to this email or contact our support team.</p>
+
+        <p>Blessings,<br>The prayerloop Team</p>
+    </div>
+
+    <div class="footer">
+        <p>&copy; 2025 prayerloop. All rights reserved.</p>
+        <p>This is an automated message, please do not reply directly to this email.</p>
+    </div>
+</body>
+</html>
+`, firstName, code)
+
+	// Plain text fallback
+	textBody := fmt.Sprintf(`
+Confirm Your Email
+
+Hi %s,
+
+Please confirm this email address for your prayerloop account by entering the code below in the app:
+
+Your verification code: %s
+
+This code will expire in 24 hours.
+
+If you didn't create a prayerloop account or change your email address, you can safely ignore this email.
+
+Need help? Reply to this email or contact our support team.
+
+Blessings,
+The prayerloop Team
+`, firstName, code)
+
+	params := &resend.SendEmailRequest{
+		From:    os.Getenv("RESEND_FROM_EMAIL"),
+		To:      []string{toEmail},
+		Subject: "Confirm Your prayerloop Email",
+		Html:    htmlBody,
+		Text:    textBody,
+	}
+
+	sent, err := s.client.Emails.Send(params)
+	if err != nil {
+		log.Printf("Failed to send verification email to %s: %v", toEmail, err)
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	log.Printf("Successfully sent verification email to %s. Email ID: %s", toEmail, sent.Id)
+	return nil
+}
+
 // SendWelcomeEmail sends a welcome email to new users (optional - for future use)
 func (s *EmailService) SendWelcomeEmail(toEmail string, firstName string) error {
 	if s.client == nil {
@@ -265,7 +394,7 @@ func (s *EmailService) SendWelcomeEmail(toEmail string, firstName string) error
 }
 
 // SendGroupLeftEmail sends an email when a user voluntarily leaves a group
-func (s *EmailService) SendGroupLeftEmail(toEmail string, firstName string, groupName string) error {
+func (s *EmailService) SendGroupLeftEmail(toEmail string, firstName string, groupName string, idempotencyKey string) error {
 	if s.client == nil {
 		return fmt.Errorf("email service not initialized")
 	}
@@ -316,7 +445,7 @@ func (s *EmailService) SendGroupLeftEmail(toEmail string, firstName string, grou
 
         <p>This is a confirmation that you have left the group <strong>"%s"</strong>.</p>
 
-        <p>You will no longer receive prayers or updates from this group. If you'd like to rejoin, please contact the group creator for a new invitation.</p>
+        <p>You will no longer receive prayers or updates from this group. If you'd like to rejoin, please contact the group owner for a new invitation.</p>
 
         <p>Blessings,<br>The prayerloop Team</p>
     </div>
@@ -335,7 +464,7 @@ Hi %s,
 
 This is a confirmation that you have left the group "%s".
 
-You will no longer receive prayers or updates from this group. If you'd like to rejoin, please contact the group creator for a new invitation.
+You will no longer receive prayers or updates from this group. If you'd like to rejoin, please contact the group owner for a new invitation.
 
 Blessings,
 The prayerloop Team
@@ -349,7 +478,7 @@ The prayerloop Team
 		Text:    textBody,
 	}
 
-	sent, err := s.client.Emails.Send(params)
+	sent, err := s.client.Emails.SendWithOptions(context.Background(), params, &resend.SendEmailOptions{IdempotencyKey: idempotencyKey})
 	if err != nil {
 		log.Printf("Failed to send group left email to %s: %v", toEmail, err)
 		return fmt.Errorf("failed to send email: %v", err)
@@ -360,7 +489,7 @@ The prayerloop Team
 }
 
 // SendGroupDeletedEmail sends an email to all members when a group is deleted
-func (s *EmailService) SendGroupDeletedEmail(toEmail string, firstName string, groupName string) error {
+func (s *EmailService) SendGroupDeletedEmail(toEmail string, firstName string, groupName string, idempotencyKey string) error {
 	if s.client == nil {
 		return fmt.Errorf("email service not initialized")
 	}
@@ -411,7 +540,7 @@ func (s *EmailService) SendGroupDeletedEmail(toEmail string, firstName string, g
 
         <p>The group <strong>"%s"</strong> has been deleted by its creator.</p>
 
-        <p>You no longer have access to this group or its prayers. If you believe this was done in error, please contact the group creator.</p>
+        <p>You no longer have access to this group or its prayers. If you believe this was done in error, please contact the group owner.</p>
 
         <p>Blessings,<br>The prayerloop Team</p>
     </div>
@@ -430,7 +559,7 @@ Hi %s,
 
 The group "%s" has been deleted by its creator.
 
-You no longer have access to this group or its prayers. If you believe this was done in error, please contact the group creator.
+You no longer have access to this group or its prayers. If you believe this was done in error, please contact the group owner.
 
 Blessings,
 The prayerloop Team
@@ -444,7 +573,7 @@ The prayerloop Team
 		Text:    textBody,
 	}
 
-	sent, err := s.client.Emails.Send(params)
+	sent, err := s.client.Emails.SendWithOptions(context.Background(), params, &resend.SendEmailOptions{IdempotencyKey: idempotencyKey})
 	if err != nil {
 		log.Printf("Failed to send group deleted email to %s: %v", toEmail, err)
 		return fmt.Errorf("failed to send email: %v", err)
@@ -455,7 +584,7 @@ The prayerloop Team
 }
 
 // SendRemovedFromGroupEmail sends an email when a user is removed from a group by the creator
-func (s *EmailService) SendRemovedFromGroupEmail(toEmail string, firstName string, groupName string) error {
+func (s *EmailService) SendRemovedFromGroupEmail(toEmail string, firstName string, groupName string, idempotencyKey string) error {
 	if s.client == nil {
 		return fmt.Errorf("email service not initialized")
 	}
@@ -504,9 +633,9 @@ func (s *EmailService) SendRemovedFromGroupEmail(toEmail string, firstName strin
 
         <p>Hi %s,</p>
 
-        <p>You have been removed from the group <strong>"%s"</strong> by the group creator.</p>
+        <p>You have been removed from the group <strong>"%s"</strong> by a group owner or moderator.</p>
 
-        <p>You no longer have access to this group or its prayers. If you have questions about this, please contact the group creator.</p>
+        <p>You no longer have access to this group or its prayers. If you have questions about this, please contact the group owner.</p>
 
         <p>Blessings,<br>The prayerloop Team</p>
     </div>
@@ -523,9 +652,9 @@ Removed from Group
 
 Hi %s,
 
-You have been removed from the group "%s" by the group creator.
+You have been removed from the group "%s" by a group owner or moderator.
 
-You no longer have access to this group or its prayers. If you have questions about this, please contact the group creator.
+You no longer have access to this group or its prayers. If you have questions about this, please contact the group owner.
 
 Blessings,
 The prayerloop Team
@@ -539,7 +668,7 @@ The prayerloop Team
 		Text:    textBody,
 	}
 
-	sent, err := s.client.Emails.Send(params)
+	sent, err := s.client.Emails.SendWithOptions(context.Background(), params, &resend.SendEmailOptions{IdempotencyKey: idempotencyKey})
 	if err != nil {
 		log.Printf("Failed to send removed from group email to %s: %v", toEmail, err)
 		return fmt.Errorf("failed to send email: %v", err)
@@ -548,3 +677,234 @@ The prayerloop Team
 	log.Printf("Successfully sent removed from group email to %s. Email ID: %s", toEmail, sent.Id)
 	return nil
 }
+
+// SendGroupInvitationEmail invites someone who isn't on prayerloop yet to a
+// group. Signing up with this email address adds them to the group.
+func (s *EmailService) SendGroupInvitationEmail(toEmail string, inviterName string, groupName string) error {
+	if s.client == nil {
+		return fmt.Errorf("email service not initialized")
+	}
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            text-align: center;
+            padding: 20px 0;
+            border-bottom: 2px solid #90c590;
+        }
+        .header h1 {
+            color: #90c590;
+            margin: 0;
+        }
+        .content {
+            padding: 30px 0;
+        }
+        .group-container {
+            background-color: #f5f5f5;
+            border: 2px solid #90c590;
+            border-radius: 8px;
+            padding: 20px;
+            text-align: center;
+            margin: 20px 0;
+        }
+        .group-name {
+            font-size: 24px;
+            font-weight: bold;
+            color: #90c590;
+        }
+        .footer {
+            text-align: center;
+            padding: 20px 0;
+            border-top: 1px solid #ddd;
+            font-size: 12px;
+            color: #666;
+        }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>prayerloop</h1>
+    </div>
+
+    <div class="content">
+        <h2>You're Invited to Pray Together</h2>
+
+        <p>Hi there,</p>
+
+        <p><strong>%s</strong> has invited you to join their prayer group on prayerloop:</p>
+
+        <div class="group-container">
+            <div class="group-name">%s</div>
+        </div>
+
+        <p>prayerloop helps friends, families and small groups keep track of prayer requests and pray for one another.</p>
+
+        <p>To accept, download prayerloop and sign up with this email address (<strong>%s</strong>). You'll find the group waiting for you as soon as you log in.</p>
+
+        <p><strong>This invitation will expire in 30 days.</strong></p>
+
+        <p>If you weren't expecting this invitation, you can safely ignore this email.</p>
+
+        <p>Blessings,<br>The prayerloop Team</p>
+    </div>
+
+    <div class="footer">
+        <p>&copy; 2025 prayerloop. All rights reserved.</p>
+        <p>This is an automated message, please do not reply directly to this email.</p>
+    </div>
+</body>
+</html>
+`, html.EscapeString(inviterName), html.EscapeString(groupName), html.EscapeString(toEmail))
+
+	textBody := fmt.Sprintf(`
+You're Invited to Pray Together
+
+Hi there,
+
+%s has invited you to join their prayer group on prayerloop: "%s"
+
+prayerloop helps friends, families and small groups keep track of prayer requests and pray for one another.
+
+To accept, download prayerloop and sign up with this email address (%s). You'll find the group waiting for you as soon as you log in.
+
+This invitation will expire in 30 days.
+
+If you weren't expecting this invitation, you can safely ignore this email.
+
+Blessings,
+The prayerloop Team
+`, inviterName, groupName, toEmail)
+
+	params := &resend.SendEmailRequest{
+		From:    os.Getenv("RESEND_FROM_EMAIL"),
+		To:      []string{toEmail},
+		Subject: fmt.Sprintf("%s invited you to \"%s\" on prayerloop", inviterName, groupName),
+		Html:    htmlBody,
+		Text:    textBody,
+	}
+
+	sent, err := s.client.Emails.Send(params)
+	if err != nil {
+		log.Printf("Failed to send group invitation email to %s: %v", toEmail, err)
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	log.Printf("Successfully sent group invitation email to %s. Email ID: %s", toEmail, sent.Id)
+	return nil
+}
+
+// SendNotificationEmail sends an in-app notification by email, for users who
+// get that notification type on the email channel. A non-empty
+// idempotencyKey makes Resend drop repeats of the same send.
+func (s *EmailService) SendNotificationEmail(toEmail string, firstName string, title string, message string, idempotencyKey string) error {
+	if s.client == nil {
+		return fmt.Errorf("email service not initialized")
+	}
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            text-align: center;
+            padding: 20px 0;
+            border-bottom: 2px solid #90c590;
+        }
+        .header h1 {
+            color: #90c590;
+            margin: 0;
+        }
+        .content {
+            padding: 30px 0;
+        }
+        .message-container {
+            background-color: #f5f5f5;
+            border-left: 4px solid #90c590;
+            border-radius: 4px;
+            padding: 15px 20px;
+            margin: 20px 0;
+        }
+        .footer {
+            text-align: center;
+            padding: 20px 0;
+            border-top: 1px solid #ddd;
+            font-size: 12px;
+            color: #666;
+        }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>prayerloop</h1>
+    </div>
+
+    <div class="content">
+        <h2>%s</h2>
+
+        <p>Hi %s,</p>
+
+        <div class="message-container">%s</div>
+
+        <p>Open prayerloop to see the details.</p>
+
+        <p>Blessings,<br>The prayerloop Team</p>
+    </div>
+
+    <div class="footer">
+        <p>&copy; 2025 prayerloop. All rights reserved.</p>
+        <p>You can turn these emails off for each kind of notification in your prayerloop preferences.</p>
+    </div>
+</body>
+</html>
+`, html.EscapeString(title), html.EscapeString(firstName), html.EscapeString(message))
+
+	textBody := fmt.Sprintf(`
+%s
+
+Hi %s,
+
+%s
+
+Open prayerloop to see the details.
+
+Blessings,
+The prayerloop Team
+`, title, firstName, message)
+
+	params := &resend.SendEmailRequest{
+		From:    os.Getenv("RESEND_FROM_EMAIL"),
+		To:      []string{toEmail},
+		Subject: fmt.Sprintf("%s - prayerloop", title),
+		Html:    htmlBody,
+		Text:    textBody,
+	}
+
+	sent, err := s.client.Emails.SendWithOptions(context.Background(), params, &resend.SendEmailOptions{IdempotencyKey: idempotencyKey})
+	if err != nil {
+		log.Printf("Failed to send notification email to %s: %v", toEmail, err)
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	log.Printf("Successfully sent notification email to %s. Email ID: %s", toEmail, sent.Id)
+	return nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a real-time update sent to connected clients. Events only carry
+// IDs; clients fetch the changed data through the normal endpoints, which
+// apply the usual visibility rules.
+type Event struct {
+	ID     string      `json:"id"`
+	Type   string      `json:"type"`
+	Data   interface{} `json:"data"`
+	seq    uint64
+	topics []string
+}
+
+// UserTopic and GroupTopic name the topics events are published on. Each
+// client subscribes to its own user topic and one per group it belongs to.
+func UserTopic(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func GroupTopic(groupID int) string {
+	return fmt.Sprintf("group:%d", groupID)
+}
+
+// EventHub fans events out to subscribers in this process. It keeps the last
+// historySize events so a client that reconnects with its last event ID gets
+// what it missed.
+type EventHub struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	history     []Event
+	historySize int
+	bufferSize  int
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+// NewEventHub creates a hub. bufferSize is how many events a subscriber may
+// fall behind before it is dropped.
+func NewEventHub(historySize int, bufferSize int) *EventHub {
+	return &EventHub{
+		// Event IDs start with the hub's epoch, so IDs from before a restart
+		// are recognised as unknown instead of matching new events
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: map[string]map[*Subscription]struct{}{},
+	}
+}
+
+var (
+	eventHub   = NewEventHub(5000, 64)
+	eventHubMu sync.RWMutex
+)
+
+func GetEventHub() *EventHub {
+	eventHubMu.RLock()
+	defer eventHubMu.RUnlock()
+	return eventHub
+}
+
+// SetEventHub replaces the hub, for tests
+func SetEventHub(hub *EventHub) {
+	eventHubMu.Lock()
+	defer eventHubMu.Unlock()
+	eventHub = hub
+}
+
+// Subscription receives the events published on its topics until it is closed
+// or falls too far behind
+type Subscription struct {
+	hub     *EventHub
+	events  chan Event
+	topics  []string
+	closed  bool
+	dropped bool
+}
+
+// Events is closed when the subscription ends
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped reports whether the subscription was closed because its client fell
+// behind. The client should reconnect with its last event ID.
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+// SetTopics replaces the subscription's topics, e.g. after the user joins or
+// leaves a group
+func (s *Subscription) SetTopics(topics []string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.hub.removeLocked(s)
+	s.topics = topics
+	s.hub.addLocked(s)
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.closeLocked(s)
+}
+
+func (h *EventHub) addLocked(s *Subscription) {
+	for _, topic := range s.topics {
+		if h.subscribers[topic] == nil {
+			h.subscribers[topic] = map[*Subscription]struct{}{}
+		}
+		h.subscribers[topic][s] = struct{}{}
+	}
+}
+
+func (h *EventHub) removeLocked(s *Subscription) {
+	for _, topic := range s.topics {
+		delete(h.subscribers[topic], s)
+		if len(h.subscribers[topic]) == 0 {
+			delete(h.subscribers, topic)
+		}
+	}
+}
+
+func (h *EventHub) closeLocked(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	h.removeLocked(s)
+	close(s.events)
+}
+
+// Subscribe registers a subscriber on topics. With a lastEventID it also
+// returns the buffered events after that one; ok is false when the ID is
+// unknown or too old to replay, and the client should resync instead.
+func (h *EventHub) Subscribe(topics []string, lastEventID string) (sub *Subscription, replay []Event, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ok = true
+	if lastEventID != "" {
+		replay, ok = h.replayLocked(topics, lastEventID)
+	}
+
+	sub = &Subscription{hub: h, events: make(chan Event, h.bufferSize), topics: topics}
+	h.addLocked(sub)
+	return sub, replay, ok
+}
+
+func (h *EventHub) replayLocked(topics []string, lastEventID string) ([]Event, bool) {
+	epoch, seqText, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != h.epoch {
+		return nil, false
+	}
+	lastSeq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || lastSeq > h.seq {
+		return nil, false
+	}
+	// Something after lastSeq has already left the buffer
+	if len(h.history) > 0 && h.history[0].seq > lastSeq+1 {
+		return nil, false
+	}
+
+	wanted := map[string]bool{}
+	for _, topic := range topics {
+		wanted[topic] = true
+	}
+
+	var replay []Event
+	for _, event := range h.history {
+		if event.seq <= lastSeq {
+			continue
+		}
+		for _, topic := range event.topics {
+			if wanted[topic] {
+				replay = append(replay, event)
+				break
+			}
+		}
+	}
+	return replay, true
+}
+
+// Publish sends an event to every subscriber of any of the topics, once each.
+// A subscriber whose buffer is full is dropped rather than blocking the
+// publisher; it catches up by reconnecting with its last event ID.
+func (h *EventHub) Publish(eventType string, data interface{}, topics ...string) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := Event{
+		ID:     fmt.Sprintf("%s-%d", h.epoch, h.seq),
+		Type:   eventType,
+		Data:   data,
+		seq:    h.seq,
+		topics: topics,
+	}
+
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	sent := map[*Subscription]bool{}
+	for _, topic := range topics {
+		for sub := range h.subscribers[topic] {
+			if sent[sub] {
+				continue
+			}
+			sent[sub] = true
+			select {
+			case sub.events <- event:
+			default:
+				sub.dropped = true
+				h.closeLocked(sub)
+			}
+		}
+	}
+	return event
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(sub *Subscription) []string {
+	var types []string
+	for {
+		select {
+		case event, open := <-sub.Events():
+			if !open {
+				return types
+			}
+			types = append(types, event.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestEventHubFanOut(t *testing.T) {
+	hub := NewEventHub(10, 10)
+	alice, _, _ := hub.Subscribe([]string{UserTopic(1), GroupTopic(7)}, "")
+	bob, _, _ := hub.Subscribe([]string{UserTopic(2)}, "")
+	defer alice.Close()
+	defer bob.Close()
+
+	hub.Publish("to-group", nil, GroupTopic(7))
+	hub.Publish("to-bob", nil, UserTopic(2))
+	// Alice is on both topics but gets the event once
+	hub.Publish("to-both", nil, UserTopic(1), GroupTopic(7), UserTopic(2))
+
+	assert.Equal(t, []string{"to-group", "to-both"}, receive(alice))
+	assert.Equal(t, []string{"to-bob", "to-both"}, receive(bob))
+
+	// After leaving the group
+	alice.SetTopics([]string{UserTopic(1)})
+	hub.Publish("to-group", nil, GroupTopic(7))
+	assert.Empty(t, receive(alice))
+}
+
+func TestEventHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewEventHub(10, 2)
+	slow, _, _ := hub.Subscribe([]string{UserTopic(1)}, "")
+
+	for i := 0; i < 3; i++ {
+		hub.Publish("update", nil, UserTopic(1))
+	}
+
+	assert.Equal(t, []string{"update", "update"}, receive(slow))
+	assert.True(t, slow.Dropped())
+	_, open := <-slow.Events()
+	assert.False(t, open)
+
+	// Publishing keeps working after the drop
+	hub.Publish("update", nil, UserTopic(1))
+	slow.Close()
+}
+
+func TestEventHubReplay(t *testing.T) {
+	hub := NewEventHub(3, 10)
+	first := hub.Publish("first", nil, UserTopic(1))
+	hub.Publish("other-user", nil, UserTopic(2))
+	hub.Publish("second", nil, GroupTopic(7))
+
+	sub, replay, ok := hub.Subscribe([]string{UserTopic(1), GroupTopic(7)}, first.ID)
+	sub.Close()
+	assert.True(t, ok)
+	if assert.Len(t, replay, 1) {
+		assert.Equal(t, "second", replay[0].Type)
+	}
+
+	// first has now left the buffer, so replaying from it would skip events
+	third := hub.Publish("third", nil, UserTopic(1))
+	hub.Publish("fourth", nil, UserTopic(1))
+	_, _, ok = hub.Subscribe([]string{UserTopic(1)}, first.ID)
+	assert.False(t, ok)
+
+	_, replay, ok = hub.Subscribe([]string{UserTopic(1)}, third.ID)
+	assert.True(t, ok)
+	assert.Len(t, replay, 1)
+
+	// IDs from another hub, e.g. before a restart
+	_, _, ok = hub.Subscribe([]string{UserTopic(1)}, "old-5")
+	assert.False(t, ok)
+	_, _, ok = hub.Subscribe([]string{UserTopic(1)}, "garbage")
+	assert.False(t, ok)
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// InAppNotifier records the notification row the app lists and deep links from
+type InAppNotifier struct{}
+
+func NewInAppNotifier() *InAppNotifier {
+	return &InAppNotifier{}
+}
+
+func (n *InAppNotifier) Channel() string {
+	return ChannelInApp
+}
+
+func (n *InAppNotifier) Notify(d Delivery) error {
+	if err := insertInAppNotification(initializers.DB.Insert("notification"), d); err != nil {
+		return err
+	}
+	PublishToUsers(EventNotificationCreated, deliveryEventData(d), d.UserID)
+	return nil
+}
+
+// NotifyTx doesn't publish notification.created, since the row isn't visible
+// until the caller commits. The caller publishes its own event after that.
+func (n *InAppNotifier) NotifyTx(tx *goqu.TxDatabase, d Delivery) error {
+	return insertInAppNotification(tx.Insert("notification"), d)
+}
+
+func deliveryEventData(d Delivery) EventData {
+	data := EventData{ActorID: d.ActorID, NotificationType: d.Type}
+	if d.TargetPrayerID != nil {
+		data.PrayerID = *d.TargetPrayerID
+	}
+	if d.TargetGroupID != nil {
+		data.GroupID = *d.TargetGroupID
+	}
+	if d.TargetCommentID != nil {
+		data.CommentID = *d.TargetCommentID
+	}
+	return data
+}
+
+func insertInAppNotification(insert *goqu.InsertDataset, d Delivery) error {
+	notification := models.Notification{
+		User_Profile_ID:      d.UserID,
+		Notification_Type:    d.Type,
+		Notification_Message: d.Message,
+		Notification_Status:  models.NotificationStatusUnread,
+		Created_By:           d.ActorID,
+		Updated_By:           d.ActorID,
+		Target_Prayer_ID:     d.TargetPrayerID,
+		Target_Group_ID:      d.TargetGroupID,
+		Target_Comment_ID:    d.TargetCommentID,
+	}
+	if len(d.PendingChannels) > 0 {
+		pending := strings.Join(d.PendingChannels, ",")
+		notification.Pending_Channels = &pending
+	}
+
+	_, err := insert.Rows(notification).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to create notification record: %v", err)
+	}
+	return nil
+}
+
+// FCMNotifier pushes to the user's native device tokens through Firebase
+type FCMNotifier struct {
+	service *PushNotificationService
+}
+
+func NewFCMNotifier(service *PushNotificationService) *FCMNotifier {
+	return &FCMNotifier{service: service}
+}
+
+func (n *FCMNotifier) Channel() string {
+	return ChannelPush
+}
+
+func (n *FCMNotifier) Name() string {
+	return "fcm"
+}
+
+func (n *FCMNotifier) Notify(d Delivery) error {
+	return sendToUserPushTokens(d, func(token models.PushToken) bool { return !isExpoPushToken(token.PushToken) },
+		n.service.sendToToken)
+}
+
+// ExpoNotifier pushes to the user's Expo Go tokens through the Expo push API
+type ExpoNotifier struct {
+	service *PushNotificationService
+}
+
+func NewExpoNotifier(service *PushNotificationService) *ExpoNotifier {
+	return &ExpoNotifier{service: service}
+}
+
+func (n *ExpoNotifier) Channel() string {
+	return ChannelPush
+}
+
+func (n *ExpoNotifier) Name() string {
+	return "expo"
+}
+
+func (n *ExpoNotifier) Notify(d Delivery) error {
+	return sendToUserPushTokens(d, func(token models.PushToken) bool { return isExpoPushToken(token.PushToken) },
+		n.service.sendExpoNotification)
+}
+
+// sendToUserPushTokens sends the delivery to each of the user's tokens that
+// match. Having no matching tokens is not an error; most users only have one
+// kind.
+func sendToUserPushTokens(d Delivery, match func(models.PushToken) bool, send func(models.PushToken, NotificationPayload) error) error {
+	tokens, err := getUserPushTokens(d.UserID)
+	if err != nil {
+		return err
+	}
+
+	sent, failed := 0, 0
+	for _, token := range tokens {
+		if !match(token) {
+			continue
+		}
+		err := send(token, d.payload())
+		if errors.Is(err, errPushTokenUnregistered) {
+			// Removed, so there is nothing left to retry for this token
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to send notification to token %s: %v", token.PushToken, err)
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	if failed > 0 && sent == 0 {
+		return fmt.Errorf("failed to send to all %d push tokens for user %d", failed, d.UserID)
+	}
+	return nil
+}
+
+// EmailNotifier emails the notification to the user's account address
+type EmailNotifier struct {
+	service *EmailService
+}
+
+func NewEmailNotifier(service *EmailService) *EmailNotifier {
+	return &EmailNotifier{service: service}
+}
+
+func (n *EmailNotifier) Channel() string {
+	return ChannelEmail
+}
+
+func (n *EmailNotifier) Notify(d Delivery) error {
+	var user models.UserProfile
+	found, err := initializers.DB.From("user_profile").
+		Select("email", "first_name").
+		Where(goqu.C("user_profile_id").Eq(d.UserID)).
+		ScanStruct(&user)
+	if err != nil {
+		return fmt.Errorf("failed to get email address: %v", err)
+	}
+	if !found || strings.TrimSpace(user.Email) == "" {
+		return nil
+	}
+
+	// Leaving, removal and deletion keep their own templates; their title is the group name
+	switch d.Type {
+	case models.NotificationTypeGroupLeft:
+		return n.service.SendGroupLeftEmail(user.Email, user.First_Name, d.Title, d.Key)
+	case models.NotificationTypeRemovedFromGroup:
+		return n.service.SendRemovedFromGroupEmail(user.Email, user.First_Name, d.Title, d.Key)
+	case models.NotificationTypeGroupDeleted:
+		return n.service.SendGroupDeletedEmail(user.Email, user.First_Name, d.Title, d.Key)
+	}
+	return n.service.SendNotificationEmail(user.Email, user.First_Name, d.Title, d.Message, d.Key)
+}
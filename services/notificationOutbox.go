@@ -0,0 +1,302 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Outbox retry settings. Attempt n waits outboxBaseBackoff * 2^(n-1), capped
+// at outboxMaxBackoff; after outboxMaxAttempts the delivery is dead-lettered.
+const (
+	outboxMaxAttempts = 8
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = 6 * time.Hour
+	// outboxLease is how long a claimed delivery stays with its worker. A
+	// worker that dies mid-send leaves the row to be claimed again after it.
+	outboxLease = 5 * time.Minute
+)
+
+// OutboxNotifier queues deliveries for its channel in notification_outbox
+// instead of sending them. The OutboxWorker sends them later with retries.
+type OutboxNotifier struct {
+	channel string
+}
+
+func NewOutboxNotifier(channel string) *OutboxNotifier {
+	return &OutboxNotifier{channel: channel}
+}
+
+func (n *OutboxNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *OutboxNotifier) Notify(d Delivery) error {
+	return enqueueOutbox(initializers.DB.Insert("notification_outbox"), d, n.channel)
+}
+
+func (n *OutboxNotifier) NotifyTx(tx *goqu.TxDatabase, d Delivery) error {
+	return enqueueOutbox(tx.Insert("notification_outbox"), d, n.channel)
+}
+
+// outboxIdempotencyKey is unique per recipient and channel. Deliveries without
+// a Key get a random one, so they are never merged with another.
+func outboxIdempotencyKey(d Delivery, channel string) (string, error) {
+	key := d.Key
+	if key == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		key = hex.EncodeToString(b)
+	}
+	return fmt.Sprintf("%s:%d:%s", key, d.UserID, channel), nil
+}
+
+func enqueueOutbox(insert *goqu.InsertDataset, d Delivery, channel string) error {
+	key, err := outboxIdempotencyKey(d, channel)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency key: %v", err)
+	}
+
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery: %v", err)
+	}
+
+	_, err = insert.Rows(models.NotificationOutbox{
+		Idempotency_Key:   key,
+		User_Profile_ID:   d.UserID,
+		Notification_Type: d.Type,
+		Channel:           channel,
+		Payload:           string(payload),
+		Status:            models.OutboxStatusPending,
+		Next_Attempt_At:   time.Now(),
+	}).
+		OnConflict(goqu.DoNothing()).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to queue %s delivery: %v", channel, err)
+	}
+	return nil
+}
+
+// outboxBackoff is the wait before the next attempt after attempts failures
+func outboxBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// OutboxWorker sends queued deliveries through the notifiers that talk to
+// Firebase, Expo and Resend
+type OutboxWorker struct {
+	mu        sync.RWMutex
+	notifiers map[string][]Notifier
+	batchSize int
+}
+
+func NewOutboxWorker(notifiers ...Notifier) *OutboxWorker {
+	w := &OutboxWorker{notifiers: map[string][]Notifier{}, batchSize: 50}
+	w.AddNotifier(notifiers...)
+	return w
+}
+
+// AddNotifier registers notifiers on their channels
+func (w *OutboxWorker) AddNotifier(notifiers ...Notifier) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, n := range notifiers {
+		w.notifiers[n.Channel()] = append(w.notifiers[n.Channel()], n)
+	}
+}
+
+// Start polls the outbox every interval and sends what it claims on a pool
+// of workers goroutines, until stop is closed
+func (w *OutboxWorker) Start(interval time.Duration, workers int, stop <-chan struct{}) {
+	jobs := make(chan models.NotificationOutbox)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for entry := range jobs {
+				w.deliver(entry)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				entries, err := w.claim()
+				if err != nil {
+					log.Printf("Failed to claim outbox deliveries: %v", err)
+					continue
+				}
+				for _, entry := range entries {
+					jobs <- entry
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	log.Printf("Notification outbox started (%d workers, every %s)", workers, interval)
+}
+
+// claim takes due deliveries, plus any whose worker's lease ran out, and
+// counts the attempt. SKIP LOCKED lets several server instances claim at once.
+func (w *OutboxWorker) claim() ([]models.NotificationOutbox, error) {
+	var entries []models.NotificationOutbox
+	err := initializers.DB.ScanStructs(&entries, `
+		UPDATE notification_outbox
+		SET status = $1, attempts = attempts + 1, locked_until = NOW() + ($2 || ' seconds')::INTERVAL, datetime_update = NOW()
+		WHERE outbox_id IN (
+			SELECT outbox_id FROM notification_outbox
+			WHERE (status = $3 AND next_attempt_at <= NOW())
+				OR (status = $1 AND locked_until < NOW())
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, models.OutboxStatusProcessing, strconv.Itoa(int(outboxLease.Seconds())), models.OutboxStatusPending, w.batchSize)
+	return entries, err
+}
+
+// deliver sends one claimed delivery and records the outcome
+func (w *OutboxWorker) deliver(entry models.NotificationOutbox) {
+	delivered, err := w.send(entry)
+	if err == nil {
+		_, err = initializers.DB.Update("notification_outbox").
+			Set(goqu.Record{
+				"status":          models.OutboxStatusSent,
+				"sent_at":         goqu.L("NOW()"),
+				"locked_until":    nil,
+				"last_error":      nil,
+				"datetime_update": goqu.L("NOW()"),
+			}).
+			Where(goqu.C("outbox_id").Eq(entry.Outbox_ID)).
+			Executor().Exec()
+		if err != nil {
+			log.Printf("Failed to mark outbox delivery %d sent: %v", entry.Outbox_ID, err)
+		}
+		return
+	}
+
+	record := goqu.Record{
+		"status":          models.OutboxStatusPending,
+		"next_attempt_at": time.Now().Add(outboxBackoff(entry.Attempts)),
+		"locked_until":    nil,
+		"last_error":      err.Error(),
+		"delivered_to":    nil,
+		"datetime_update": goqu.L("NOW()"),
+	}
+	if len(delivered) > 0 {
+		record["delivered_to"] = strings.Join(delivered, ",")
+	}
+	if entry.Attempts >= outboxMaxAttempts {
+		record["status"] = models.OutboxStatusDead
+		log.Printf("Outbox delivery %d dead-lettered after %d attempts: %v", entry.Outbox_ID, entry.Attempts, err)
+	} else {
+		log.Printf("Outbox delivery %d failed (attempt %d): %v", entry.Outbox_ID, entry.Attempts, err)
+	}
+
+	_, updateErr := initializers.DB.Update("notification_outbox").
+		Set(record).
+		Where(goqu.C("outbox_id").Eq(entry.Outbox_ID)).
+		Executor().Exec()
+	if updateErr != nil {
+		log.Printf("Failed to record outbox delivery %d failure: %v", entry.Outbox_ID, updateErr)
+	}
+}
+
+// send tries every notifier on the entry's channel that hasn't already sent
+// it, so a failure in one (say Expo) doesn't hold back or resend the others.
+// It returns the notifiers that have sent the delivery so far.
+func (w *OutboxWorker) send(entry models.NotificationOutbox) ([]string, error) {
+	var delivered []string
+	sent := map[string]bool{}
+	if entry.Delivered_To != nil && *entry.Delivered_To != "" {
+		delivered = strings.Split(*entry.Delivered_To, ",")
+		for _, name := range delivered {
+			sent[name] = true
+		}
+	}
+
+	var d Delivery
+	if err := json.Unmarshal([]byte(entry.Payload), &d); err != nil {
+		return delivered, fmt.Errorf("invalid payload: %v", err)
+	}
+	// Lets the email provider drop a resend of something it already sent
+	d.Key = entry.Idempotency_Key
+
+	w.mu.RLock()
+	notifiers := w.notifiers[entry.Channel]
+	w.mu.RUnlock()
+	if len(notifiers) == 0 {
+		return delivered, fmt.Errorf("no notifier for channel %s", entry.Channel)
+	}
+
+	var errs []error
+	for _, n := range notifiers {
+		name := notifierName(n)
+		if sent[name] {
+			continue
+		}
+		if err := n.Notify(d); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		delivered = append(delivered, name)
+	}
+	if len(errs) > 0 {
+		return delivered, fmt.Errorf("%v", errs)
+	}
+	return delivered, nil
+}
+
+// ReplayOutboxDelivery puts a dead or failing delivery back in the queue with
+// a fresh set of attempts. Sent deliveries can't be replayed.
+func ReplayOutboxDelivery(outboxID int) (bool, error) {
+	result, err := initializers.DB.Update("notification_outbox").
+		Set(goqu.Record{
+			"status":          models.OutboxStatusPending,
+			"attempts":        0,
+			"next_attempt_at": goqu.L("NOW()"),
+			"locked_until":    nil,
+			"datetime_update": goqu.L("NOW()"),
+		}).
+		Where(
+			goqu.C("outbox_id").Eq(outboxID),
+			goqu.C("status").In(models.OutboxStatusDead, models.OutboxStatusPending),
+		).
+		Executor().Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, outboxBackoff(1))
+	assert.Equal(t, time.Minute, outboxBackoff(2))
+	assert.Equal(t, 4*time.Minute, outboxBackoff(4))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(20))
+}
+
+func TestOutboxIdempotencyKey(t *testing.T) {
+	key, err := outboxIdempotencyKey(Delivery{UserID: 4, Key: "comment:9"}, ChannelPush)
+	assert.NoError(t, err)
+	assert.Equal(t, "comment:9:4:push", key)
+
+	// Without a Key every delivery is its own
+	first, _ := outboxIdempotencyKey(Delivery{UserID: 4}, ChannelPush)
+	second, _ := outboxIdempotencyKey(Delivery{UserID: 4}, ChannelPush)
+	assert.NotEqual(t, first, second)
+	assert.True(t, strings.HasSuffix(first, ":4:push"))
+}
+
+func TestOutboxWorkerDeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		failWith error
+		expected string
+	}{
+		{name: "sent", attempts: 1, expected: `"status"='sent'`},
+		{name: "failed - retried later", attempts: 3, failWith: errors.New("timeout"), expected: `"status"='pending'`},
+		{name: "failed - out of attempts", attempts: outboxMaxAttempts, failWith: errors.New("timeout"), expected: `"status"='dead'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			originalDB := initializers.DB
+			initializers.DB = goqu.New("postgres", db)
+			defer func() { initializers.DB = originalDB }()
+
+			push := NewRecorderNotifier(ChannelPush)
+			push.FailWith(tt.failWith)
+			worker := NewOutboxWorker(push)
+
+			mock.ExpectExec(`UPDATE "notification_outbox" SET .*` + tt.expected + `.* WHERE \("outbox_id" = 12\)`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			worker.deliver(models.NotificationOutbox{
+				Outbox_ID:       12,
+				Idempotency_Key: "comment:9:4:push",
+				Channel:         ChannelPush,
+				Payload:         `{"UserID":4,"Type":"PRAYER_COMMENT_ADDED","Message":"Sam commented on a prayer"}`,
+				Attempts:        tt.attempts,
+			})
+
+			deliveries := push.Deliveries()
+			assert.Len(t, deliveries, 1)
+			assert.Equal(t, 4, deliveries[0].UserID)
+			assert.Equal(t, "comment:9:4:push", deliveries[0].Key)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOutboxWorkerDeliverPartialFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	originalDB := initializers.DB
+	initializers.DB = goqu.New("postgres", db)
+	defer func() { initializers.DB = originalDB }()
+
+	fcm := NewNamedRecorderNotifier(ChannelPush, "fcm")
+	expo := NewNamedRecorderNotifier(ChannelPush, "expo")
+	expo.FailWith(errors.New("expo unavailable"))
+	worker := NewOutboxWorker(fcm, expo)
+
+	entry := models.NotificationOutbox{
+		Outbox_ID:       12,
+		Idempotency_Key: "comment:9:4:push",
+		Channel:         ChannelPush,
+		Payload:         `{"UserID":4,"Type":"PRAYER_COMMENT_ADDED","Message":"Sam commented on a prayer"}`,
+		Attempts:        1,
+	}
+
+	// FCM sends, Expo fails: the row is retried, remembering FCM is done
+	mock.ExpectExec(`UPDATE "notification_outbox" SET .*"delivered_to"='fcm'.*"status"='pending'.* WHERE \("outbox_id" = 12\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	worker.deliver(entry)
+
+	assert.Len(t, fcm.Deliveries(), 1)
+	assert.Len(t, expo.Deliveries(), 1)
+
+	// The retry only goes to Expo
+	delivered := "fcm"
+	entry.Delivered_To = &delivered
+	entry.Attempts = 2
+	expo.FailWith(nil)
+	mock.ExpectExec(`UPDATE "notification_outbox" SET .*"status"='sent'.* WHERE \("outbox_id" = 12\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	worker.deliver(entry)
+
+	assert.Len(t, fcm.Deliveries(), 1)
+	assert.Len(t, expo.Deliveries(), 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
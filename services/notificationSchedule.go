@@ -0,0 +1,357 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Quiet hours and digest preferences. Quiet hours are "HH:MM" in the user's
+// timezone and are off while either end is empty; a window may cross
+// midnight, e.g. 22:00 to 07:00.
+const (
+	QuietHoursStartPreferenceKey = "quiet_hours_start"
+	QuietHoursEndPreferenceKey   = "quiet_hours_end"
+	DigestPreferenceKey          = "notification_digest"
+)
+
+// Digest modes
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// digestHour is the local hour digests go out (weekly ones on Monday). They
+// only go out during that hour, so a digest that was empty at the start of
+// the hour doesn't turn into a feed for the rest of the day.
+const digestHour = 8
+
+// digestDebounceType keys the notification_debounce row that stops a digest
+// from going out twice in the same day, across ticks and server instances
+const digestDebounceType = "NOTIFICATION_DIGEST"
+
+// ValidQuietHoursTime reports whether value is an "HH:MM" time or empty
+func ValidQuietHoursTime(value string) bool {
+	if value == "" {
+		return true
+	}
+	_, err := time.Parse("15:04", value)
+	return err == nil
+}
+
+// ValidDigestMode reports whether value is a known digest mode
+func ValidDigestMode(value string) bool {
+	return value == DigestOff || value == DigestDaily || value == DigestWeekly
+}
+
+// inQuietHours reports whether local falls inside the start-end window
+func inQuietHours(local time.Time, start string, end string) bool {
+	if start == "" || end == "" {
+		return false
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return false
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	from := startTime.Hour()*60 + startTime.Minute()
+	to := endTime.Hour()*60 + endTime.Minute()
+
+	switch {
+	case from == to:
+		return false
+	case from < to:
+		return minute >= from && minute < to
+	default:
+		return minute >= from || minute < to
+	}
+}
+
+// digestDue reports whether a digest in the given mode should go out at local
+func digestDue(mode string, local time.Time) bool {
+	switch mode {
+	case DigestDaily:
+		return local.Hour() == digestHour
+	case DigestWeekly:
+		return local.Weekday() == time.Monday && local.Hour() == digestHour
+	}
+	return false
+}
+
+// notificationSchedule is the user's quiet hours and digest settings
+type notificationSchedule struct {
+	location   *time.Location
+	quietStart string
+	quietEnd   string
+	digest     string
+}
+
+func getNotificationSchedule(userID int) notificationSchedule {
+	schedule := notificationSchedule{location: GetUserLocation(userID), digest: DigestOff}
+
+	for key, target := range map[string]*string{
+		QuietHoursStartPreferenceKey: &schedule.quietStart,
+		QuietHoursEndPreferenceKey:   &schedule.quietEnd,
+		DigestPreferenceKey:          &schedule.digest,
+	} {
+		value, found, err := GetUserPreferenceValue(userID, key)
+		if err != nil {
+			log.Printf("Failed to load preference %s for user %d: %v", key, userID, err)
+			continue
+		}
+		if found && value != "" {
+			*target = value
+		}
+	}
+
+	if !ValidDigestMode(schedule.digest) {
+		schedule.digest = DigestOff
+	}
+	return schedule
+}
+
+// ShouldHoldNotifications reports whether push and email for the user should
+// wait in the queue instead of going out now: while they are in quiet hours,
+// or always when they get a digest
+func ShouldHoldNotifications(userID int) bool {
+	schedule := getNotificationSchedule(userID)
+	if schedule.digest != DigestOff {
+		return true
+	}
+	return inQuietHours(time.Now().In(schedule.location), schedule.quietStart, schedule.quietEnd)
+}
+
+// StartNotificationScheduler checks queued notifications every interval until
+// stop is closed, sending quiet hours catch-ups and digests once they're due
+func StartNotificationScheduler(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				FlushQueuedNotifications()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	log.Printf("Notification scheduler started (every %s)", interval)
+}
+
+// FlushQueuedNotifications sends a summary to each user whose queued
+// notifications are due: digest users at their digest time, everyone else
+// once their quiet hours are over
+func FlushQueuedNotifications() {
+	var userIDs []int
+	err := initializers.DB.From("notification").
+		SelectDistinct("user_profile_id").
+		Where(goqu.C("pending_channels").IsNotNull()).
+		ScanVals(&userIDs)
+	if err != nil {
+		log.Printf("Failed to find queued notifications: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		schedule := getNotificationSchedule(userID)
+		local := time.Now().In(schedule.location)
+
+		title := "While you were away"
+		if schedule.digest != DigestOff {
+			if !digestDue(schedule.digest, local) {
+				continue
+			}
+			// Claim today's digest so later ticks in the hour skip it. If the
+			// claim can't be checked, skip this tick rather than risk a second
+			// digest; the next tick in the hour tries again.
+			claimed, err := claimDebounceWindow(digestDebounceType, userID, 0, 20*60)
+			if err != nil {
+				log.Printf("Failed to claim digest for user %d: %v", userID, err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+			title = "Your daily prayerloop digest"
+			if schedule.digest == DigestWeekly {
+				title = "Your weekly prayerloop digest"
+			}
+		} else if inQuietHours(local, schedule.quietStart, schedule.quietEnd) {
+			continue
+		}
+
+		if err := sendQueuedSummary(userID, title); err != nil {
+			log.Printf("Failed to send queued notifications to user %d: %v", userID, err)
+		}
+	}
+}
+
+type queuedNotification struct {
+	Notification_ID      int     `db:"notification_id"`
+	Notification_Type    string  `db:"notification_type"`
+	Notification_Message string  `db:"notification_message"`
+	Target_Group_ID      *int    `db:"target_group_id"`
+	Pending_Channels     *string `db:"pending_channels"`
+}
+
+// sendQueuedSummary claims the user's queued rows and sends one summary on
+// the channels they were waiting for. The claim and the outbox rows for the
+// summary commit together, so a row is summarized exactly once.
+func sendQueuedSummary(userID int, title string) error {
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	return tx.Wrap(func() error {
+		var queued []queuedNotification
+		if err := tx.From("notification").
+			Select("notification_id", "notification_type", "notification_message", "target_group_id", "pending_channels").
+			Where(
+				goqu.C("user_profile_id").Eq(userID),
+				goqu.C("pending_channels").IsNotNull(),
+			).
+			Order(goqu.C("notification_id").Asc()).
+			ForUpdate(exp.SkipLocked).
+			ScanStructs(&queued); err != nil {
+			return fmt.Errorf("failed to claim queued notifications: %v", err)
+		}
+		if len(queued) == 0 {
+			return nil
+		}
+
+		ids := make([]int, len(queued))
+		for i, n := range queued {
+			ids[i] = n.Notification_ID
+		}
+		_, err := tx.Update("notification").
+			Set(goqu.Record{"pending_channels": nil}).
+			Where(goqu.C("notification_id").In(ids)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to claim queued notifications: %v", err)
+		}
+
+		channelSet := map[string]bool{}
+		for _, n := range queued {
+			if n.Pending_Channels == nil {
+				continue
+			}
+			for _, channel := range strings.Split(*n.Pending_Channels, ",") {
+				channelSet[channel] = true
+			}
+		}
+		var channels []string
+		for channel := range channelSet {
+			channels = append(channels, channel)
+		}
+		sort.Strings(channels)
+
+		d := Delivery{
+			UserID:  userID,
+			Type:    digestDebounceType,
+			Title:   title,
+			Message: queued[0].Notification_Message,
+			Data:    map[string]string{"type": digestDebounceType},
+			Key:     fmt.Sprintf("summary:%d", ids[len(ids)-1]),
+		}
+		if len(queued) > 1 {
+			d.Message = summarizeQueuedNotifications(queued, getGroupNames(queued))
+		}
+
+		return GetNotificationRouter().SendNowTx(tx, d, channels)
+	})
+}
+
+func getGroupNames(queued []queuedNotification) map[int]string {
+	var groupIDs []int
+	for _, n := range queued {
+		if n.Target_Group_ID != nil {
+			groupIDs = append(groupIDs, *n.Target_Group_ID)
+		}
+	}
+	names := map[int]string{}
+	if len(groupIDs) == 0 {
+		return names
+	}
+
+	var groups []struct {
+		Group_Profile_ID int    `db:"group_profile_id"`
+		Group_Name       string `db:"group_name"`
+	}
+	err := initializers.DB.From("group_profile").
+		Select("group_profile_id", "group_name").
+		Where(goqu.C("group_profile_id").In(groupIDs)).
+		ScanStructs(&groups)
+	if err != nil {
+		log.Printf("Failed to load group names for digest: %v", err)
+		return names
+	}
+	for _, g := range groups {
+		names[g.Group_Profile_ID] = g.Group_Name
+	}
+	return names
+}
+
+// summarizeQueuedNotifications collapses queued rows into one line, e.g.
+// "3 new prayers in Small Group, 5 comments"
+func summarizeQueuedNotifications(queued []queuedNotification, groupNames map[int]string) string {
+	var parts []string
+	counts := map[string]int{}
+	add := func(part string) {
+		if counts[part] == 0 {
+			parts = append(parts, part)
+		}
+		counts[part]++
+	}
+
+	for _, n := range queued {
+		switch n.Notification_Type {
+		case models.NotificationTypePrayerShared, models.NotificationTypePrayerCreatedForYou:
+			if n.Target_Group_ID != nil && groupNames[*n.Target_Group_ID] != "" {
+				add("new prayer in " + groupNames[*n.Target_Group_ID])
+			} else {
+				add("new prayer")
+			}
+		case models.NotificationTypePrayerCommentAdded:
+			add("comment")
+		case models.NotificationTypePrayerEditedBySubject, models.NotificationTypePrayerRemovedFromGroup:
+			add("prayer update")
+		default:
+			add("group update")
+		}
+	}
+
+	summary := make([]string, 0, len(parts))
+	for _, part := range parts {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[part], pluralizeSummaryPart(part, counts[part])))
+	}
+	return strings.Join(summary, ", ")
+}
+
+// pluralizeSummaryPart pluralizes the leading noun phrase ("new prayer in X"
+// becomes "new prayers in X")
+func pluralizeSummaryPart(part string, count int) string {
+	if count == 1 {
+		return part
+	}
+	if i := strings.Index(part, " in "); i >= 0 {
+		return part[:i] + "s" + part[i:]
+	}
+	return part + "s"
+}
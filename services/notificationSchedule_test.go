@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInQuietHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		local    time.Time
+		start    string
+		end      string
+		expected bool
+	}{
+		{name: "overnight window - late evening", local: at(23, 30), start: "22:00", end: "07:00", expected: true},
+		{name: "overnight window - early morning", local: at(6, 59), start: "22:00", end: "07:00", expected: true},
+		{name: "overnight window - end is exclusive", local: at(7, 0), start: "22:00", end: "07:00", expected: false},
+		{name: "overnight window - afternoon", local: at(15, 0), start: "22:00", end: "07:00", expected: false},
+		{name: "same day window", local: at(13, 15), start: "13:00", end: "14:00", expected: true},
+		{name: "off without an end", local: at(23, 30), start: "22:00", end: "", expected: false},
+		{name: "off when start equals end", local: at(22, 0), start: "22:00", end: "22:00", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, inQuietHours(tt.local, tt.start, tt.end))
+		})
+	}
+}
+
+func TestDigestDue(t *testing.T) {
+	monday := time.Date(2026, 3, 2, digestHour, 5, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	assert.True(t, digestDue(DigestDaily, tuesday))
+	assert.False(t, digestDue(DigestDaily, tuesday.Add(time.Hour)))
+	assert.True(t, digestDue(DigestWeekly, monday))
+	assert.False(t, digestDue(DigestWeekly, tuesday))
+	assert.False(t, digestDue(DigestOff, monday))
+}
+
+func TestSummarizeQueuedNotifications(t *testing.T) {
+	smallGroup := 4
+	queued := []queuedNotification{
+		{Notification_Type: models.NotificationTypePrayerShared, Target_Group_ID: &smallGroup},
+		{Notification_Type: models.NotificationTypePrayerCommentAdded},
+		{Notification_Type: models.NotificationTypePrayerShared, Target_Group_ID: &smallGroup},
+		{Notification_Type: models.NotificationTypePrayerCommentAdded},
+		{Notification_Type: models.NotificationTypePrayerShared, Target_Group_ID: &smallGroup},
+		{Notification_Type: models.NotificationTypeGroupMemberJoined, Target_Group_ID: &smallGroup},
+	}
+
+	summary := summarizeQueuedNotifications(queued, map[int]string{smallGroup: "Small Group"})
+	assert.Equal(t, "3 new prayers in Small Group, 2 comments, 1 group update", summary)
+}
+
+func TestClaimDebounceWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		err         error
+		claimed     bool
+		expectError bool
+	}{
+		{name: "first send in the window", rows: sqlmock.NewRows([]string{"debounce_id"}).AddRow(1), claimed: true},
+		{name: "already sent in the window", rows: sqlmock.NewRows([]string{"debounce_id"})},
+		{name: "database error", err: sqlmock.ErrCancelled, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			originalDB := initializers.DB
+			initializers.DB = goqu.New("postgres", db)
+			defer func() { initializers.DB = originalDB }()
+
+			expectClaim := func() {
+				mock.ExpectExec(`DELETE FROM "notification_debounce"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				query := mock.ExpectQuery(`INSERT INTO notification_debounce`)
+				if tt.err != nil {
+					query.WillReturnError(tt.err)
+				} else {
+					query.WillReturnRows(tt.rows)
+				}
+			}
+
+			expectClaim()
+			claimed, err := claimDebounceWindow(digestDebounceType, 1, 0, 20*60)
+			assert.Equal(t, tt.claimed, claimed)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Digests fail closed on an error; other notifications still go out
+			if tt.err != nil {
+				expectClaim()
+				assert.True(t, shouldSendDebounced(models.NotificationTypePrayerCommentAdded, 1, 4, 15))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
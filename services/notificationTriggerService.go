@@ -12,8 +12,20 @@ import (
 
 // shouldSendDebounced checks if a notification should be sent based on debounce window.
 // Uses atomic upsert to prevent race conditions. Also cleans up old records (>24h).
-// Returns true if notification should be sent.
+// Returns true if notification should be sent. If the check fails the
+// notification is allowed, since a duplicate is better than a missed one.
 func shouldSendDebounced(notifType string, targetUserID int, entityID int, windowMinutes int) bool {
+	send, err := claimDebounceWindow(notifType, targetUserID, entityID, windowMinutes)
+	if err != nil {
+		log.Printf("Error in debounce check: %v", err)
+		return true // On error, allow notification
+	}
+	return send
+}
+
+// claimDebounceWindow is shouldSendDebounced that leaves database errors to
+// the caller, for sends that should fail closed
+func claimDebounceWindow(notifType string, targetUserID int, entityID int, windowMinutes int) (bool, error) {
 	// Lazy cleanup of old records (older than 24 hours)
 	_, cleanupErr := initializers.DB.Delete("notification_debounce").
 		Where(goqu.L("last_triggered_at < NOW() - INTERVAL '24 hours'")).
@@ -43,28 +55,29 @@ func shouldSendDebounced(notifType string, targetUserID int, entityID int, windo
 		// 2. Database error
 		// Check if it's a "no rows" situation vs actual error
 		if err.Error() == "sql: no rows in result set" {
-			return false // Within debounce window
+			return false, nil // Within debounce window
 		}
-		log.Printf("Error in debounce check: %v", err)
-		return true // On error, allow notification
+		return false, err
 	}
 
-	return true // Row was inserted/updated, send notification
+	return true, nil // Row was inserted/updated, send notification
 }
 
 // NotifySubjectOfPrayerCreated sends PRAYER_CREATED_FOR_YOU to a linked subject.
 // Called when a prayer is shared to a circle and has a linked subject.
+// Runs in the share's transaction so the notification is queued only if the share commits.
 func NotifySubjectOfPrayerCreated(
+	tx *goqu.TxDatabase,
 	subjectUserID int,
 	prayerID int,
 	groupID int,
 	actorID int,
 	actorName string,
 	groupName string,
-) {
+) error {
 	// Don't notify if subject is the actor (creating prayer about themselves)
 	if subjectUserID == actorID {
-		return
+		return nil
 	}
 
 	// CRITICAL: Don't notify if subject is not a member of the circle
@@ -80,54 +93,30 @@ func NotifySubjectOfPrayerCreated(
 			),
 		)
 
-	_, memberCheckErr := checkQuery.Executor().ScanVal(&memberCount)
-	if memberCheckErr != nil || memberCount == 0 {
-		// Subject is not a member of this circle - don't notify them
-		// This maintains privacy: subjects shouldn't know about circles they're not in
-		return
-	}
-
-	notificationMessage := fmt.Sprintf("%s created a prayer for you in %s", actorName, groupName)
-
-	// Create notification record with target for navigation
-	notification := models.Notification{
-		User_Profile_ID:      subjectUserID,
-		Notification_Type:    models.NotificationTypePrayerCreatedForYou,
-		Notification_Message: notificationMessage,
-		Notification_Status:  models.NotificationStatusUnread,
-		Created_By:           actorID,
-		Updated_By:           actorID,
-		Target_Prayer_ID:     &prayerID,
-		Target_Group_ID:      &groupID,
-	}
-
-	insert := initializers.DB.Insert("notification").Rows(notification)
-	_, err := insert.Executor().Exec()
+	_, err := checkQuery.Executor().ScanVal(&memberCount)
 	if err != nil {
-		log.Printf("Failed to create PRAYER_CREATED_FOR_YOU notification for user %d: %v", subjectUserID, err)
+		return fmt.Errorf("failed to check subject's circle membership: %v", err)
 	}
-
-	// Send push notification
-	pushService := GetPushNotificationService()
-	if pushService == nil {
-		log.Println("Push notification service not available")
-		return
+	if memberCount == 0 {
+		// Subject is not a member of this circle - don't notify them
+		// This maintains privacy: subjects shouldn't know about circles they're not in
+		return nil
 	}
 
-	payload := NotificationPayload{
-		Title: groupName,
-		Body:  notificationMessage,
+	return GetNotificationRouter().SendTx(tx, Delivery{
+		UserID:         subjectUserID,
+		Type:           models.NotificationTypePrayerCreatedForYou,
+		Title:          groupName,
+		Message:        fmt.Sprintf("%s created a prayer for you in %s", actorName, groupName),
+		ActorID:        actorID,
+		TargetPrayerID: &prayerID,
+		TargetGroupID:  &groupID,
 		Data: map[string]string{
 			"type":     "prayer_created_for_you",
 			"prayerId": strconv.Itoa(prayerID),
 			"groupId":  strconv.Itoa(groupID),
 		},
-	}
-
-	err = pushService.SendNotificationToUser(subjectUserID, payload)
-	if err != nil {
-		log.Printf("Failed to send PRAYER_CREATED_FOR_YOU push notification: %v", err)
-	}
+	})
 }
 
 // GetCircleMembersForNotification returns active circle members excluding specified users
@@ -161,7 +150,9 @@ func GetCircleMembersForNotification(groupID int, excludeUserIDs []int) ([]int,
 // NotifyCircleOfPrayerShared sends PRAYER_SHARED notification to circle members.
 // Excludes: actor, prayer creator, and optionally the linked subject.
 // actorName should be the display name (first_name or username) of the actor.
+// Runs in the share's transaction so the notifications are queued only if the share commits.
 func NotifyCircleOfPrayerShared(
+	tx *goqu.TxDatabase,
 	groupID int,
 	groupName string,
 	actorID int,
@@ -169,7 +160,7 @@ func NotifyCircleOfPrayerShared(
 	prayerID int,
 	prayerCreatorID int,
 	linkedSubjectUserID *int,
-) {
+) error {
 	// Build exclusion list: actor and prayer creator
 	excludeIDs := []int{actorID}
 	if prayerCreatorID != actorID {
@@ -182,57 +173,112 @@ func NotifyCircleOfPrayerShared(
 
 	memberIDs, err := GetCircleMembersForNotification(groupID, excludeIDs)
 	if err != nil {
-		log.Printf("Failed to get circle members for notification: %v", err)
-		return
+		return err
 	}
 
 	if len(memberIDs) == 0 {
-		return
-	}
-
-	notificationMessage := fmt.Sprintf("%s shared a prayer with %s", actorName, groupName)
-
-	// Create notification records in database for each member with navigation targets
-	for _, memberID := range memberIDs {
-		notification := models.Notification{
-			User_Profile_ID:      memberID,
-			Notification_Type:    models.NotificationTypePrayerShared,
-			Notification_Message: notificationMessage,
-			Notification_Status:  models.NotificationStatusUnread,
-			Created_By:           actorID,
-			Updated_By:           actorID,
-			Target_Prayer_ID:     &prayerID,
-			Target_Group_ID:      &groupID,
-		}
-
-		insert := initializers.DB.Insert("notification").Rows(notification)
-		_, err := insert.Executor().Exec()
-		if err != nil {
-			log.Printf("Failed to create PRAYER_SHARED notification for user %d: %v", memberID, err)
-		}
-	}
-
-	// Send push notifications
-	pushService := GetPushNotificationService()
-	if pushService == nil {
-		log.Println("Push notification service not available")
-		return
+		return nil
 	}
 
-	payload := NotificationPayload{
-		Title: groupName,
-		Body:  notificationMessage,
+	return GetNotificationRouter().SendToUsersTx(tx, memberIDs, Delivery{
+		Type:           models.NotificationTypePrayerShared,
+		Title:          groupName,
+		Message:        fmt.Sprintf("%s shared a prayer with %s", actorName, groupName),
+		ActorID:        actorID,
+		TargetPrayerID: &prayerID,
+		TargetGroupID:  &groupID,
 		Data: map[string]string{
 			"type":     "prayer_shared",
 			"groupId":  strconv.Itoa(groupID),
 			"prayerId": strconv.Itoa(prayerID),
 		},
-	}
+	})
+}
 
-	err = pushService.SendNotificationToUsers(memberIDs, payload)
+// NotifyPrayerUpdatePosted sends PRAYER_UPDATE_POSTED to everyone the prayer
+// is shared with, in the caller's transaction. Group audiences come from
+// GetCircleMembersForNotification, as for PRAYER_SHARED, so muted groups are
+// skipped; a user reached through several groups is notified once.
+func NotifyPrayerUpdatePosted(
+	tx *goqu.TxDatabase,
+	prayerID int,
+	updateID int,
+	updateType string,
+	authorID int,
+	authorName string,
+) error {
+	var access []models.PrayerAccess
+	err := initializers.DB.From("prayer_access").
+		Select("access_type", "access_type_id").
+		Where(goqu.C("prayer_id").Eq(prayerID)).
+		Order(goqu.C("prayer_access_id").Asc()).
+		ScanStructs(&access)
 	if err != nil {
-		log.Printf("Failed to send PRAYER_SHARED push notifications: %v", err)
+		return fmt.Errorf("failed to get prayer audience for update notification: %v", err)
 	}
+
+	// Each recipient keeps the first group they were reached through, for navigation
+	recipientGroups := map[int]*int{}
+	var recipientIDs []int
+	addRecipient := func(userID int, groupID *int) {
+		if _, seen := recipientGroups[userID]; seen || userID == authorID {
+			return
+		}
+		recipientGroups[userID] = groupID
+		recipientIDs = append(recipientIDs, userID)
+	}
+
+	for _, a := range access {
+		switch a.Access_Type {
+		case "user":
+			addRecipient(a.Access_Type_ID, nil)
+		case "group":
+			groupID := a.Access_Type_ID
+			memberIDs, err := GetCircleMembersForNotification(groupID, []int{authorID})
+			if err != nil {
+				return err
+			}
+			for _, memberID := range memberIDs {
+				addRecipient(memberID, &groupID)
+			}
+		}
+	}
+
+	title := "Prayer Update"
+	message := fmt.Sprintf("%s posted an update on a prayer", authorName)
+	if updateType == models.PrayerUpdateTypeAnswered {
+		title = "Prayer Answered"
+		message = fmt.Sprintf("%s shared that a prayer was answered", authorName)
+	}
+
+	for _, recipientID := range recipientIDs {
+		data := map[string]string{
+			"type":           "prayer_update_posted",
+			"prayerId":       strconv.Itoa(prayerID),
+			"prayerUpdateId": strconv.Itoa(updateID),
+			"updateType":     updateType,
+		}
+		groupID := recipientGroups[recipientID]
+		if groupID != nil {
+			data["groupId"] = strconv.Itoa(*groupID)
+		}
+
+		err := GetNotificationRouter().SendTx(tx, Delivery{
+			UserID:         recipientID,
+			Type:           models.NotificationTypePrayerUpdatePosted,
+			Title:          title,
+			Message:        message,
+			ActorID:        authorID,
+			TargetPrayerID: &prayerID,
+			TargetGroupID:  groupID,
+			Data:           data,
+			Key:            fmt.Sprintf("prayer-update:%d", updateID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // NotifyCreatorOfPrayerRemovedFromGroup sends PRAYER_REMOVED_FROM_GROUP to the prayer creator.
@@ -250,111 +296,64 @@ func NotifyCreatorOfPrayerRemovedFromGroup(
 		return
 	}
 
-	notificationMessage := fmt.Sprintf("%s removed a prayer you made for them from %s", subjectName, groupName)
-
-	// Create notification record with target for navigation
-	notification := models.Notification{
-		User_Profile_ID:      creatorID,
-		Notification_Type:    models.NotificationTypePrayerRemovedFromGroup,
-		Notification_Message: notificationMessage,
-		Notification_Status:  models.NotificationStatusUnread,
-		Created_By:           subjectUserID,
-		Updated_By:           subjectUserID,
-		Target_Prayer_ID:     &prayerID,
-		Target_Group_ID:      &groupID,
-	}
-
-	insert := initializers.DB.Insert("notification").Rows(notification)
-	_, err := insert.Executor().Exec()
-	if err != nil {
-		log.Printf("Failed to create PRAYER_REMOVED_FROM_GROUP notification for user %d: %v", creatorID, err)
-	}
-
-	// Send push notification
-	pushService := GetPushNotificationService()
-	if pushService == nil {
-		log.Println("Push notification service not available")
-		return
-	}
-
-	payload := NotificationPayload{
-		Title: groupName,
-		Body:  notificationMessage,
+	err := GetNotificationRouter().Send(Delivery{
+		UserID:         creatorID,
+		Type:           models.NotificationTypePrayerRemovedFromGroup,
+		Title:          groupName,
+		Message:        fmt.Sprintf("%s removed a prayer you made for them from %s", subjectName, groupName),
+		ActorID:        subjectUserID,
+		TargetPrayerID: &prayerID,
+		TargetGroupID:  &groupID,
 		Data: map[string]string{
 			"type":     "prayer_removed_from_group",
 			"prayerId": strconv.Itoa(prayerID),
 			"groupId":  strconv.Itoa(groupID),
 		},
-	}
-
-	err = pushService.SendNotificationToUser(creatorID, payload)
+	})
 	if err != nil {
-		log.Printf("Failed to send PRAYER_REMOVED_FROM_GROUP push notification: %v", err)
+		log.Printf("Failed to send PRAYER_REMOVED_FROM_GROUP notification: %v", err)
 	}
 }
 
 // NotifyCreatorOfSubjectEdit sends PRAYER_EDITED_BY_SUBJECT to the prayer creator.
 // Debounced with 15-minute window to prevent notification spam from rapid edits.
+// Runs in the edit's transaction so the notification is queued only if the edit commits.
 func NotifyCreatorOfSubjectEdit(
+	tx *goqu.TxDatabase,
 	creatorID int,
 	prayerID int,
 	subjectUserID int,
 	subjectName string,
-) {
+) error {
 	// Check debounce - 15 minute window
 	if !shouldSendDebounced(models.NotificationTypePrayerEditedBySubject, creatorID, prayerID, 15) {
 		log.Printf("Debounced PRAYER_EDITED_BY_SUBJECT notification for creator %d, prayer %d", creatorID, prayerID)
-		return
+		return nil
 	}
 
 	// Find a shared group for better navigation context
 	sharedGroupID := getSharedGroupForCommentNotification(prayerID, subjectUserID, creatorID)
 
-	notificationMessage := fmt.Sprintf("%s edited a prayer about them", subjectName)
-
-	// Create notification record with target for navigation
-	notification := models.Notification{
-		User_Profile_ID:      creatorID,
-		Notification_Type:    models.NotificationTypePrayerEditedBySubject,
-		Notification_Message: notificationMessage,
-		Notification_Status:  models.NotificationStatusUnread,
-		Created_By:           subjectUserID,
-		Updated_By:           subjectUserID,
-		Target_Prayer_ID:     &prayerID,
-		Target_Group_ID:      sharedGroupID,
-	}
-
-	insert := initializers.DB.Insert("notification").Rows(notification)
-	_, err := insert.Executor().Exec()
-	if err != nil {
-		log.Printf("Failed to create PRAYER_EDITED_BY_SUBJECT notification for user %d: %v", creatorID, err)
-	}
-
-	// Send push notification
-	pushService := GetPushNotificationService()
-	if pushService == nil {
-		log.Println("Push notification service not available")
-		return
-	}
-
-	payload := NotificationPayload{
-		Title: "Prayer Edited",
-		Body:  notificationMessage,
-		Data: map[string]string{
-			"type":     "prayer_edited_by_subject",
-			"prayerId": strconv.Itoa(prayerID),
-		},
+	data := map[string]string{
+		"type":     "prayer_edited_by_subject",
+		"prayerId": strconv.Itoa(prayerID),
 	}
 
 	// Include groupId in push notification if we found a shared group
 	if sharedGroupID != nil {
-		payload.Data["groupId"] = strconv.Itoa(*sharedGroupID)
-	}
-
-	err = pushService.SendNotificationToUser(creatorID, payload)
-	if err != nil {
-		log.Printf("Failed to send PRAYER_EDITED_BY_SUBJECT push notification: %v", err)
-	}
+		data["groupId"] = strconv.Itoa(*sharedGroupID)
+	}
+
+	return GetNotificationRouter().SendTx(tx, Delivery{
+		UserID:         creatorID,
+		Type:           models.NotificationTypePrayerEditedBySubject,
+		Title:          "Prayer Edited",
+		Message:        fmt.Sprintf("%s edited a prayer about them", subjectName),
+		ActorID:        subjectUserID,
+		TargetPrayerID: &prayerID,
+		TargetGroupID:  sharedGroupID,
+		Data:           data,
+	})
 }
 
 // getSharedGroupForCommentNotification finds a group where both the commenter and recipient are members
@@ -389,7 +388,8 @@ func getSharedGroupForCommentNotification(prayerID int, commenterID int, recipie
 
 // NotifyUsersOfNewComment notifies prayer creator, subject, and previous commenters of new comment.
 // Debounced with 15-minute window to prevent notification spam from rapid comments.
-func NotifyUsersOfNewComment(prayerID int, commentID int, commenterID int) {
+// Runs in the comment's transaction so the notifications are queued only if the comment commits.
+func NotifyUsersOfNewComment(tx *goqu.TxDatabase, prayerID int, commentID int, commenterID int) error {
 	// Get commenter name for notification message
 	var commenterName string
 	_, _ = initializers.DB.From("user_profile").
@@ -407,8 +407,7 @@ func NotifyUsersOfNewComment(prayerID int, commentID int, commenterID int) {
 		Where(goqu.C("prayer_id").Eq(prayerID)).
 		ScanStruct(&prayer)
 	if err != nil {
-		log.Printf("Failed to fetch prayer for comment notification: %v", err)
-		return
+		return fmt.Errorf("failed to fetch prayer for comment notification: %v", err)
 	}
 
 	recipientIDs := []int{}
@@ -469,48 +468,32 @@ func NotifyUsersOfNewComment(prayerID int, commentID int, commenterID int) {
 		// Find a shared group for better navigation context
 		sharedGroupID := getSharedGroupForCommentNotification(prayerID, commenterID, recipientID)
 
-		notificationMessage := fmt.Sprintf("%s commented on a prayer", commenterName)
-
-		notification := models.Notification{
-			User_Profile_ID:      recipientID,
-			Notification_Type:    models.NotificationTypePrayerCommentAdded,
-			Notification_Message: notificationMessage,
-			Notification_Status:  models.NotificationStatusUnread,
-			Target_Prayer_ID:     &prayerID,
-			Target_Comment_ID:    &commentID,
-			Target_Group_ID:      sharedGroupID, // Include group context if found
-			Created_By:           commenterID,
-			Updated_By:           commenterID,
+		data := map[string]string{
+			"type":      models.NotificationTypePrayerCommentAdded,
+			"prayerId":  strconv.Itoa(prayerID),
+			"commentId": strconv.Itoa(commentID),
 		}
 
-		insert := initializers.DB.Insert("notification").Rows(notification)
-		_, insertErr := insert.Executor().Exec()
-		if insertErr != nil {
-			log.Printf("Failed to create comment notification: %v", insertErr)
-		} else {
-			// Successfully created notification, send push
-			pushService := GetPushNotificationService()
-			if pushService != nil {
-				payload := NotificationPayload{
-					Title: "New Comment",
-					Body:  notificationMessage,
-					Data: map[string]string{
-						"type":      models.NotificationTypePrayerCommentAdded,
-						"prayerId":  strconv.Itoa(prayerID),
-						"commentId": strconv.Itoa(commentID),
-					},
-				}
-
-				// Include groupId in push notification if we found a shared group
-				if sharedGroupID != nil {
-					payload.Data["groupId"] = strconv.Itoa(*sharedGroupID)
-				}
-
-				err = pushService.SendNotificationToUser(recipientID, payload)
-				if err != nil {
-					log.Printf("Failed to send comment push notification: %v", err)
-				}
-			}
+		// Include groupId in push notification if we found a shared group
+		if sharedGroupID != nil {
+			data["groupId"] = strconv.Itoa(*sharedGroupID)
+		}
+
+		err = GetNotificationRouter().SendTx(tx, Delivery{
+			UserID:          recipientID,
+			Type:            models.NotificationTypePrayerCommentAdded,
+			Title:           "New Comment",
+			Message:         fmt.Sprintf("%s commented on a prayer", commenterName),
+			ActorID:         commenterID,
+			TargetPrayerID:  &prayerID,
+			TargetCommentID: &commentID,
+			TargetGroupID:   sharedGroupID, // Include group context if found
+			Data:            data,
+			Key:             fmt.Sprintf("comment:%d", commentID),
+		})
+		if err != nil {
+			return err
 		}
 	}
+	return nil
 }
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Notification channels. A notification type is routed to one or more of
+// these, and every Notifier registered for a channel gets the delivery.
+const (
+	ChannelInApp = "in_app"
+	ChannelPush  = "push"
+	ChannelEmail = "email"
+)
+
+// defaultNotificationChannels is where a notification type goes unless the
+// router has a route for it. Users choose among these with their notification
+// preferences, where email starts off.
+var defaultNotificationChannels = []string{ChannelInApp, ChannelPush, ChannelEmail}
+
+// Delivery is one notification for one user
+type Delivery struct {
+	UserID          int
+	Type            string
+	Title           string // push title and email subject
+	Message         string // notification text and push body
+	ActorID         int
+	TargetPrayerID  *int
+	TargetGroupID   *int
+	TargetCommentID *int
+	Data            map[string]string // push data for deep links
+	PendingChannels []string          // channels held for quiet hours or a digest
+	Key             string            // identifies the event, so a retried handler doesn't queue it twice
+}
+
+func (d Delivery) payload() NotificationPayload {
+	return NotificationPayload{
+		Title: d.Title,
+		Body:  d.Message,
+		Data:  d.Data,
+	}
+}
+
+// Notifier delivers notifications over one channel
+type Notifier interface {
+	Channel() string
+	Notify(d Delivery) error
+}
+
+// NamedNotifier is a Notifier that shares its channel with others and needs
+// a name to tell them apart, like FCM and Expo on push. Notifiers without a
+// name go by their channel.
+type NamedNotifier interface {
+	Notifier
+	Name() string
+}
+
+func notifierName(n Notifier) string {
+	if named, ok := n.(NamedNotifier); ok {
+		return named.Name()
+	}
+	return n.Channel()
+}
+
+// TxNotifier is a Notifier that can also write its delivery in the caller's
+// transaction, so the notification commits or rolls back with the change
+// that caused it
+type TxNotifier interface {
+	Notifier
+	NotifyTx(tx *goqu.TxDatabase, d Delivery) error
+}
+
+// NotificationRouter sends each delivery to the channels routed for its
+// notification type, skipping channels the user's preferences turn off
+type NotificationRouter struct {
+	mu        sync.RWMutex
+	notifiers map[string][]Notifier
+	routes    map[string][]string
+	allow     func(userID int, notificationType string, channel string) bool
+	hold      func(userID int) bool
+}
+
+var notificationRouter *NotificationRouter
+var outboxWorker *OutboxWorker
+var notificationRouterMu sync.RWMutex
+
+// NewNotificationRouter returns a router with the given notifiers and the
+// default channels for every notification type
+func NewNotificationRouter(notifiers ...Notifier) *NotificationRouter {
+	r := &NotificationRouter{
+		notifiers: map[string][]Notifier{},
+		routes:    map[string][]string{},
+	}
+	r.AddNotifier(notifiers...)
+	return r
+}
+
+// InitNotificationRouter records in-app notifications directly and queues
+// push and email in the outbox when those services are available, checking
+// each user's notification preferences. The outbox worker does the sending.
+// Call it after InitPushNotificationService and InitEmailService.
+func InitNotificationRouter() {
+	router := NewNotificationRouter(NewInAppNotifier())
+	worker := NewOutboxWorker()
+
+	if push := GetPushNotificationService(); push != nil {
+		router.AddNotifier(NewOutboxNotifier(ChannelPush))
+		worker.AddNotifier(NewFCMNotifier(push), NewExpoNotifier(push))
+	}
+	if email := GetEmailService(); email != nil {
+		router.AddNotifier(NewOutboxNotifier(ChannelEmail))
+		worker.AddNotifier(NewEmailNotifier(email))
+	}
+	router.SetPreferenceFunc(NotificationChannelEnabled)
+	router.SetHoldFunc(ShouldHoldNotifications)
+
+	SetNotificationRouter(router)
+	SetOutboxWorker(worker)
+	log.Println("Notification router initialized")
+}
+
+// GetNotificationRouter returns the router set up by InitNotificationRouter.
+// Until then notifications are only recorded in-app.
+func GetNotificationRouter() *NotificationRouter {
+	notificationRouterMu.RLock()
+	router := notificationRouter
+	notificationRouterMu.RUnlock()
+
+	if router == nil {
+		return NewNotificationRouter(NewInAppNotifier())
+	}
+	return router
+}
+
+// GetOutboxWorker returns the worker set up by InitNotificationRouter, or nil
+func GetOutboxWorker() *OutboxWorker {
+	notificationRouterMu.RLock()
+	defer notificationRouterMu.RUnlock()
+	return outboxWorker
+}
+
+// SetOutboxWorker replaces the outbox worker
+func SetOutboxWorker(worker *OutboxWorker) {
+	notificationRouterMu.Lock()
+	outboxWorker = worker
+	notificationRouterMu.Unlock()
+}
+
+// SetNotificationRouter replaces the router, e.g. with recorders in tests
+func SetNotificationRouter(router *NotificationRouter) {
+	notificationRouterMu.Lock()
+	notificationRouter = router
+	notificationRouterMu.Unlock()
+}
+
+// AddNotifier registers notifiers on their channels
+func (r *NotificationRouter) AddNotifier(notifiers ...Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range notifiers {
+		r.notifiers[n.Channel()] = append(r.notifiers[n.Channel()], n)
+	}
+}
+
+// Route sets the channels a notification type is sent to
+func (r *NotificationRouter) Route(notificationType string, channels ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[notificationType] = channels
+}
+
+// SetPreferenceFunc sets the check for whether a user wants a notification
+// type on a channel. Without one every routed channel is used.
+func (r *NotificationRouter) SetPreferenceFunc(allow func(userID int, notificationType string, channel string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.allow = allow
+}
+
+// SetHoldFunc sets the check for whether a user's push and email should wait
+// in the queue (quiet hours or a digest). Without one nothing is held.
+func (r *NotificationRouter) SetHoldFunc(hold func(userID int) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hold = hold
+}
+
+// ChannelsFor returns the channels the notification type is routed to
+func (r *NotificationRouter) ChannelsFor(notificationType string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if channels, ok := r.routes[notificationType]; ok {
+		return channels
+	}
+	return defaultNotificationChannels
+}
+
+// Send delivers to every routed channel the user hasn't turned off. A failing
+// channel doesn't stop the others; the errors are returned together.
+//
+// While the user's notifications are held, push and email are not sent.
+// Instead they are listed in PendingChannels on the in-app row, which is the
+// queue the notification scheduler summarizes from, so that row is written
+// even when in-app is off for the type.
+func (r *NotificationRouter) Send(d Delivery) error {
+	return r.SendTx(nil, d)
+}
+
+// SendTx is Send inside the caller's transaction. Notifiers that can't join
+// a transaction are called directly. A nil tx is the same as Send.
+func (r *NotificationRouter) SendTx(tx *goqu.TxDatabase, d Delivery) error {
+	r.mu.RLock()
+	allow := r.allow
+	hold := r.hold
+	r.mu.RUnlock()
+
+	var channels []string
+	for _, channel := range r.ChannelsFor(d.Type) {
+		if allow != nil && !allow(d.UserID, d.Type, channel) {
+			continue
+		}
+		channels = append(channels, channel)
+	}
+
+	held := false
+	for _, channel := range channels {
+		if channel != ChannelInApp {
+			held = hold != nil && hold(d.UserID)
+			break
+		}
+	}
+	if !held {
+		return r.SendNowTx(tx, d, channels)
+	}
+
+	d.PendingChannels = nil
+	for _, channel := range channels {
+		if channel != ChannelInApp {
+			d.PendingChannels = append(d.PendingChannels, channel)
+		}
+	}
+	return r.SendNowTx(tx, d, []string{ChannelInApp})
+}
+
+// SendNow delivers on the given channels without checking routes,
+// preferences or holds
+func (r *NotificationRouter) SendNow(d Delivery, channels []string) error {
+	return r.SendNowTx(nil, d, channels)
+}
+
+// SendNowTx is SendNow inside the caller's transaction
+func (r *NotificationRouter) SendNowTx(tx *goqu.TxDatabase, d Delivery, channels []string) error {
+	var errs []error
+	for _, channel := range channels {
+		r.mu.RLock()
+		notifiers := r.notifiers[channel]
+		r.mu.RUnlock()
+
+		for _, n := range notifiers {
+			var err error
+			if txNotifier, ok := n.(TxNotifier); ok && tx != nil {
+				err = txNotifier.NotifyTx(tx, d)
+			} else {
+				err = n.Notify(d)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", channel, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to deliver %s to user %d: %v", d.Type, d.UserID, errs)
+	}
+	return nil
+}
+
+// SendToUsers sends the same notification to each user
+func (r *NotificationRouter) SendToUsers(userIDs []int, d Delivery) error {
+	return r.SendToUsersTx(nil, userIDs, d)
+}
+
+// SendToUsersTx is SendToUsers inside the caller's transaction
+func (r *NotificationRouter) SendToUsersTx(tx *goqu.TxDatabase, userIDs []int, d Delivery) error {
+	failed := 0
+	for _, userID := range userIDs {
+		d.UserID = userID
+		if err := r.SendTx(tx, d); err != nil {
+			log.Println(err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to deliver %s to %d users", d.Type, failed)
+	}
+	return nil
+}
+
+// RecorderNotifier keeps deliveries in memory instead of sending them, so
+// tests and local runs don't need Firebase or Resend credentials
+type RecorderNotifier struct {
+	mu         sync.Mutex
+	channel    string
+	name       string
+	deliveries []Delivery
+	err        error
+}
+
+func NewRecorderNotifier(channel string) *RecorderNotifier {
+	return &RecorderNotifier{channel: channel, name: channel}
+}
+
+// NewNamedRecorderNotifier is a recorder that shares its channel with others,
+// standing in for e.g. FCM or Expo
+func NewNamedRecorderNotifier(channel string, name string) *RecorderNotifier {
+	return &RecorderNotifier{channel: channel, name: name}
+}
+
+func (n *RecorderNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *RecorderNotifier) Name() string {
+	return n.name
+}
+
+func (n *RecorderNotifier) Notify(d Delivery) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deliveries = append(n.deliveries, d)
+	return n.err
+}
+
+// FailWith makes later deliveries return err (after recording them)
+func (n *RecorderNotifier) FailWith(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.err = err
+}
+
+// Deliveries returns a copy of everything recorded so far
+func (n *RecorderNotifier) Deliveries() []Delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Delivery(nil), n.deliveries...)
+}
+
+func (n *RecorderNotifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deliveries = nil
+}
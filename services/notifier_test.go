@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationRouterSend(t *testing.T) {
+	inApp := NewRecorderNotifier(ChannelInApp)
+	fcm := NewRecorderNotifier(ChannelPush)
+	expo := NewRecorderNotifier(ChannelPush)
+	email := NewRecorderNotifier(ChannelEmail)
+	router := NewNotificationRouter(inApp, fcm, expo, email)
+
+	// Without preferences every default channel is used
+	err := router.Send(Delivery{UserID: 7, Type: models.NotificationTypePrayerShared, Message: "shared"})
+	assert.NoError(t, err)
+	assert.Len(t, inApp.Deliveries(), 1)
+	assert.Len(t, fcm.Deliveries(), 1)
+	assert.Len(t, expo.Deliveries(), 1)
+	assert.Len(t, email.Deliveries(), 1)
+	assert.Equal(t, 7, inApp.Deliveries()[0].UserID)
+
+	// A route replaces the defaults for its type only
+	router.Route(models.NotificationTypeGroupInvite, ChannelInApp)
+	err = router.Send(Delivery{UserID: 7, Type: models.NotificationTypeGroupInvite})
+	assert.NoError(t, err)
+	assert.Len(t, inApp.Deliveries(), 2)
+	assert.Len(t, fcm.Deliveries(), 1)
+	assert.Len(t, email.Deliveries(), 1)
+}
+
+func TestNotificationRouterPreferences(t *testing.T) {
+	inApp := NewRecorderNotifier(ChannelInApp)
+	push := NewRecorderNotifier(ChannelPush)
+	router := NewNotificationRouter(inApp, push)
+
+	// User 2 has turned off push for shared prayers
+	router.SetPreferenceFunc(func(userID int, notificationType string, channel string) bool {
+		return !(userID == 2 && notificationType == models.NotificationTypePrayerShared && channel == ChannelPush)
+	})
+
+	err := router.SendToUsers([]int{1, 2, 3}, Delivery{Type: models.NotificationTypePrayerShared})
+	assert.NoError(t, err)
+	assert.Len(t, inApp.Deliveries(), 3)
+
+	var pushed []int
+	for _, d := range push.Deliveries() {
+		pushed = append(pushed, d.UserID)
+	}
+	assert.Equal(t, []int{1, 3}, pushed)
+}
+
+func TestNotificationRouterHold(t *testing.T) {
+	inApp := NewRecorderNotifier(ChannelInApp)
+	push := NewRecorderNotifier(ChannelPush)
+	email := NewRecorderNotifier(ChannelEmail)
+	router := NewNotificationRouter(inApp, push, email)
+
+	// User 2 is in quiet hours and has in-app turned off for shared prayers
+	router.SetHoldFunc(func(userID int) bool { return userID == 2 })
+	router.SetPreferenceFunc(func(userID int, notificationType string, channel string) bool {
+		return !(userID == 2 && channel == ChannelInApp)
+	})
+
+	err := router.SendToUsers([]int{1, 2}, Delivery{Type: models.NotificationTypePrayerShared})
+	assert.NoError(t, err)
+	assert.Len(t, push.Deliveries(), 1)
+	assert.Len(t, email.Deliveries(), 1)
+
+	// The held delivery is still recorded in-app, carrying the channels to send later
+	deliveries := inApp.Deliveries()
+	assert.Len(t, deliveries, 2)
+	assert.Empty(t, deliveries[0].PendingChannels)
+	assert.Equal(t, 2, deliveries[1].UserID)
+	assert.Equal(t, []string{ChannelPush, ChannelEmail}, deliveries[1].PendingChannels)
+}
+
+func TestNotificationRouterChannelFailure(t *testing.T) {
+	inApp := NewRecorderNotifier(ChannelInApp)
+	push := NewRecorderNotifier(ChannelPush)
+	push.FailWith(errors.New("no credentials"))
+	router := NewNotificationRouter(push, inApp)
+
+	// A failing channel is reported without stopping the others
+	err := router.SendToUsers([]int{1, 2}, Delivery{Type: models.NotificationTypeGroupMemberJoined})
+	assert.Error(t, err)
+	assert.Len(t, inApp.Deliveries(), 2)
+	assert.Len(t, push.Deliveries(), 2)
+}
+
+func TestNotificationPreferenceKey(t *testing.T) {
+	assert.Equal(t, "notify_prayer_shared_push", NotificationPreferenceKey(models.NotificationTypePrayerShared, ChannelPush))
+	assert.Equal(t, "notify_group_invite_email", NotificationPreferenceKey(models.NotificationTypeGroupInvite, ChannelEmail))
+	assert.Equal(t, "notify_prayer_comment_added_in_app", NotificationPreferenceKey(models.NotificationTypePrayerCommentAdded, ChannelInApp))
+}
+
+func TestNotificationChannelEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		channel  string
+		value    string
+		found    bool
+		expected bool
+	}{
+		{name: "push turned off", channel: ChannelPush, value: "false", found: true, expected: false},
+		{name: "email turned on", channel: ChannelEmail, value: "true", found: true, expected: true},
+		{name: "no preference row - push defaults on", channel: ChannelPush, expected: true},
+		{name: "no preference row - email defaults off", channel: ChannelEmail, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			originalDB := initializers.DB
+			initializers.DB = goqu.New("postgres", db)
+			defer func() { initializers.DB = originalDB }()
+
+			rows := sqlmock.NewRows([]string{"value"})
+			if tt.found {
+				rows.AddRow(tt.value)
+			}
+			mock.ExpectQuery(`WHERE \("p"."preference_key" = 'notify_prayer_shared_` + tt.channel + `'\)`).WillReturnRows(rows)
+
+			assert.Equal(t, tt.expected, NotificationChannelEnabled(4, models.NotificationTypePrayerShared, tt.channel))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
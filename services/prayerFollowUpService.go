@@ -0,0 +1,188 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// FollowUpPreferenceKey is the boolean preference that turns follow-up
+// prompts on or off for all of a user's prayers
+const FollowUpPreferenceKey = "prayer_follow_ups"
+
+// defaultFollowUpAge is how long a prayer goes without activity before its
+// creator is asked for an update, unless PRAYER_FOLLOW_UP_DAYS is set
+const defaultFollowUpAge = 30 * 24 * time.Hour
+
+// followUpUserGap is the least time between two follow-ups to the same user,
+// so someone with many old prayers isn't asked about all of them at once
+const followUpUserGap = 24 * time.Hour
+
+const followUpBatchSize = 100
+
+// PrayerFollowUpAge reads PRAYER_FOLLOW_UP_DAYS, falling back to 30 days
+func PrayerFollowUpAge() time.Duration {
+	value := os.Getenv("PRAYER_FOLLOW_UP_DAYS")
+	if value == "" {
+		return defaultFollowUpAge
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 {
+		log.Printf("Invalid PRAYER_FOLLOW_UP_DAYS %q, using %s", value, defaultFollowUpAge)
+		return defaultFollowUpAge
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// StartPrayerFollowUps asks creators about prayers that have been quiet for
+// age, checking every interval until stop is closed
+func StartPrayerFollowUps(interval time.Duration, age time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				SendPrayerFollowUps(time.Now(), age)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	log.Printf("Prayer follow-ups started (every %s, after %s without activity)", interval, age)
+}
+
+// SendPrayerFollowUps sends a PRAYER_FOLLOW_UP notification for each open
+// prayer created at least age ago with no prayer_edit_history entry and no
+// follow-up in the last age. Each user gets at most one a day, for their
+// oldest such prayer; the rest wait for later runs.
+func SendPrayerFollowUps(now time.Time, age time.Duration) {
+	for {
+		claimed, err := sendFollowUpBatch(now, age)
+		if err != nil {
+			log.Printf("Failed to send prayer follow-ups: %v", err)
+			return
+		}
+		if claimed < followUpBatchSize {
+			return
+		}
+	}
+}
+
+type followUpPrayer struct {
+	Prayer_ID  int    `db:"prayer_id"`
+	Title      string `db:"title"`
+	Created_By int    `db:"created_by"`
+}
+
+// sendFollowUpBatch claims a batch of quiet prayers, records the follow-up and
+// queues its notification in the same transaction, so several server
+// instances don't ask about the same prayer twice
+func sendFollowUpBatch(now time.Time, age time.Duration) (int, error) {
+	cutoff := now.Add(-age)
+
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var prayers []followUpPrayer
+	err = tx.Wrap(func() error {
+		if err := tx.From("prayer").
+			Select("prayer_id", "title", "created_by").
+			Where(followUpConditions(now, cutoff)...).
+			Order(goqu.C("datetime_create").Asc(), goqu.C("prayer_id").Asc()).
+			Limit(followUpBatchSize).
+			ForUpdate(exp.SkipLocked).
+			ScanStructs(&prayers); err != nil {
+			return fmt.Errorf("failed to claim prayers: %v", err)
+		}
+
+		asked := map[int]bool{}
+		for _, prayer := range prayers {
+			if asked[prayer.Created_By] {
+				continue
+			}
+			asked[prayer.Created_By] = true
+
+			if err := sendFollowUp(tx, prayer, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(prayers), err
+}
+
+// followUpConditions selects open prayers quiet since cutoff whose creators
+// haven't opted out or been asked about another prayer in the last day
+func followUpConditions(now time.Time, cutoff time.Time) []exp.Expression {
+	return []exp.Expression{
+		goqu.C("deleted").IsFalse(),
+		goqu.C("is_archived").IsFalse(),
+		goqu.C("is_answered").IsNotTrue(),
+		goqu.C("datetime_create").Lte(cutoff),
+		goqu.L(`NOT EXISTS (SELECT 1 FROM prayer_edit_history h
+			WHERE h.prayer_id = prayer.prayer_id AND h.datetime_create > ?)`, cutoff),
+		goqu.L(`NOT EXISTS (SELECT 1 FROM prayer_follow_up f
+			WHERE f.prayer_id = prayer.prayer_id AND f.datetime_prompted > ?)`, cutoff),
+		goqu.L(`NOT EXISTS (SELECT 1 FROM prayer_follow_up f
+			WHERE f.user_profile_id = prayer.created_by AND f.datetime_prompted > ?)`, now.Add(-followUpUserGap)),
+		goqu.L(`COALESCE(
+			(SELECT up.preference_value FROM user_preferences up
+				WHERE up.user_profile_id = prayer.created_by AND up.preference_key = ? AND up.is_active),
+			(SELECT p.default_value FROM preference p WHERE p.preference_key = ?),
+			'true') = 'true'`, FollowUpPreferenceKey, FollowUpPreferenceKey),
+	}
+}
+
+func sendFollowUp(tx *goqu.TxDatabase, prayer followUpPrayer, now time.Time) error {
+	_, err := tx.Insert("prayer_follow_up").
+		Rows(goqu.Record{
+			"prayer_id":         prayer.Prayer_ID,
+			"user_profile_id":   prayer.Created_By,
+			"datetime_prompted": now,
+		}).
+		OnConflict(goqu.DoUpdate("prayer_id", goqu.Record{
+			"user_profile_id":   prayer.Created_By,
+			"datetime_prompted": now,
+		})).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to record follow-up for prayer %d: %v", prayer.Prayer_ID, err)
+	}
+
+	if err := GetNotificationRouter().SendTx(tx, followUpDelivery(prayer, now)); err != nil {
+		return fmt.Errorf("failed to queue follow-up for prayer %d: %v", prayer.Prayer_ID, err)
+	}
+	return nil
+}
+
+// followUpDelivery asks for an update on the prayer. Data lists the quick
+// actions the app offers: mark answered and add an update (POST
+// /prayers/:id/updates) and archive (PATCH /prayers/:id/archive).
+func followUpDelivery(prayer followUpPrayer, now time.Time) Delivery {
+	prayerID := prayer.Prayer_ID
+	return Delivery{
+		UserID:         prayer.Created_By,
+		Type:           models.NotificationTypePrayerFollowUp,
+		Title:          "Any update?",
+		Message:        fmt.Sprintf("Any update on \"%s\"?", prayer.Title),
+		ActorID:        prayer.Created_By,
+		TargetPrayerID: &prayerID,
+		Data: map[string]string{
+			"type":     "prayer_follow_up",
+			"prayerId": strconv.Itoa(prayerID),
+			"actions":  "mark_answered,add_update,archive",
+		},
+		Key: fmt.Sprintf("follow-up:%d:%s", prayerID, now.UTC().Format("2006-01-02")),
+	}
+}
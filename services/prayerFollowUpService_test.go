@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrayerFollowUpAge(t *testing.T) {
+	t.Setenv("PRAYER_FOLLOW_UP_DAYS", "")
+	assert.Equal(t, 30*24*time.Hour, PrayerFollowUpAge())
+
+	t.Setenv("PRAYER_FOLLOW_UP_DAYS", "14")
+	assert.Equal(t, 14*24*time.Hour, PrayerFollowUpAge())
+
+	t.Setenv("PRAYER_FOLLOW_UP_DAYS", "soon")
+	assert.Equal(t, 30*24*time.Hour, PrayerFollowUpAge())
+}
+
+func TestSendPrayerFollowUps(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	originalDB := initializers.DB
+	initializers.DB = goqu.New("postgres", db)
+	defer func() { initializers.DB = originalDB }()
+
+	inApp := NewRecorderNotifier(ChannelInApp)
+	push := NewRecorderNotifier(ChannelPush)
+	originalRouter := GetNotificationRouter()
+	SetNotificationRouter(NewNotificationRouter(inApp, push))
+	defer SetNotificationRouter(originalRouter)
+
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "prayer_id", "title", "created_by" FROM "prayer" WHERE .*"is_archived" IS FALSE.*"is_answered" IS NOT TRUE.*"datetime_create" <= '2026-05-02T12:00:00Z'.*prayer_edit_history.*prayer_follow_up.*prayer_follow_ups.* FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows([]string{"prayer_id", "title", "created_by"}).
+			AddRow(4, "New job", 1).
+			AddRow(6, "Mum's surgery", 1).
+			AddRow(8, "Exams", 2))
+	// One follow-up per user per run: prayer 6 waits for a later run
+	mock.ExpectExec(`INSERT INTO "prayer_follow_up" .*VALUES \('2026-06-01T12:00:00Z', 4, 1\) ON CONFLICT \(prayer_id\) DO UPDATE`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO "prayer_follow_up" .*VALUES \('2026-06-01T12:00:00Z', 8, 2\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	SendPrayerFollowUps(now, 30*24*time.Hour)
+
+	assert.Len(t, inApp.Deliveries(), 2)
+	assert.Len(t, push.Deliveries(), 2)
+
+	d := push.Deliveries()[0]
+	assert.Equal(t, 1, d.UserID)
+	assert.Equal(t, models.NotificationTypePrayerFollowUp, d.Type)
+	assert.Equal(t, "Any update on \"New job\"?", d.Message)
+	assert.Equal(t, "4", d.Data["prayerId"])
+	assert.Equal(t, "mark_answered,add_update,archive", d.Data["actions"])
+	assert.Equal(t, 2, push.Deliveries()[1].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// reminderGracePeriod is how late a reminder may still go out. Occurrences
+// missed by more than this (the server was down) are skipped, and a reminder
+// never goes out more than once to catch up.
+const reminderGracePeriod = 2 * time.Hour
+
+const reminderBatchSize = 100
+
+var reminderDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// NormalizeReminderDays validates day names and returns them as stored, in
+// week order without duplicates (e.g. "mon,fri")
+func NormalizeReminderDays(days []string) (string, error) {
+	seen := map[int]bool{}
+	var indexes []int
+	for _, day := range days {
+		index := -1
+		for i, name := range reminderDays {
+			if strings.EqualFold(strings.TrimSpace(day), name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return "", fmt.Errorf("invalid day %q, use sun, mon, tue, wed, thu, fri or sat", day)
+		}
+		if !seen[index] {
+			seen[index] = true
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+
+	names := make([]string, len(indexes))
+	for i, index := range indexes {
+		names[i] = reminderDays[index]
+	}
+	return strings.Join(names, ","), nil
+}
+
+// ValidateReminderSchedule checks the reminder's schedule fields fit together
+func ValidateReminderSchedule(r models.PrayerReminder) error {
+	if _, err := time.Parse("15:04", r.Remind_Time); err != nil {
+		return errors.New("time must be HH:MM")
+	}
+	if r.Timezone == "" || r.Timezone == "Local" {
+		return errors.New("timezone must be an IANA zone name")
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return errors.New("timezone must be an IANA zone name")
+	}
+
+	dayCount := 0
+	if r.Days_Of_Week != nil && *r.Days_Of_Week != "" {
+		dayCount = len(strings.Split(*r.Days_Of_Week, ","))
+	}
+
+	switch r.Schedule_Type {
+	case models.ReminderScheduleOnce:
+		if r.Remind_Date == nil {
+			return errors.New("date is required for a one-off reminder")
+		}
+		if _, err := time.Parse("2006-01-02", *r.Remind_Date); err != nil {
+			return errors.New("date must be YYYY-MM-DD")
+		}
+		if dayCount > 0 {
+			return errors.New("daysOfWeek only applies to weekly and custom reminders")
+		}
+	case models.ReminderScheduleDaily:
+		if dayCount > 0 {
+			return errors.New("daysOfWeek only applies to weekly and custom reminders")
+		}
+	case models.ReminderScheduleWeekly:
+		if dayCount != 1 {
+			return errors.New("a weekly reminder needs exactly one day in daysOfWeek")
+		}
+	case models.ReminderScheduleCustom:
+		if dayCount == 0 {
+			return errors.New("a custom reminder needs at least one day in daysOfWeek")
+		}
+	default:
+		return errors.New("scheduleType must be once, daily, weekly or custom")
+	}
+	return nil
+}
+
+// NextReminderTime returns the reminder's first occurrence after after. ok is
+// false when there is none, i.e. a one-off reminder whose time has passed.
+// Times are computed in the reminder's timezone, so a 07:00 reminder stays at
+// 07:00 local across daylight saving changes.
+func NextReminderTime(r models.PrayerReminder, after time.Time) (next time.Time, ok bool, err error) {
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	clock, err := time.Parse("15:04", r.Remind_Time)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if r.Schedule_Type == models.ReminderScheduleOnce {
+		if r.Remind_Date == nil {
+			return time.Time{}, false, errors.New("one-off reminder has no date")
+		}
+		date, err := time.Parse("2006-01-02", *r.Remind_Date)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		at := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+		return at, at.After(after), nil
+	}
+
+	days := map[time.Weekday]bool{}
+	if r.Schedule_Type == models.ReminderScheduleDaily {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			days[day] = true
+		}
+	} else if r.Days_Of_Week != nil {
+		for _, name := range strings.Split(*r.Days_Of_Week, ",") {
+			for i, day := range reminderDays {
+				if name == day {
+					days[time.Weekday(i)] = true
+				}
+			}
+		}
+	}
+
+	local := after.In(location)
+	for i := 0; i <= 7; i++ {
+		at := time.Date(local.Year(), local.Month(), local.Day()+i, clock.Hour(), clock.Minute(), 0, 0, location)
+		if at.After(after) && days[at.Weekday()] {
+			return at, true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// ReminderTargetName returns the name of the prayer, prayer subject or
+// category a reminder is for. found is false when it doesn't exist (or was
+// deleted) or the user can no longer see it.
+func ReminderTargetName(userID int, targetType string, targetID int) (name string, found bool, err error) {
+	userGroups := initializers.DB.From("user_group").
+		Select("group_profile_id").
+		Where(
+			goqu.C("user_profile_id").Eq(userID),
+			goqu.C("is_active").IsTrue(),
+		)
+
+	switch targetType {
+	case models.ReminderTargetPrayer:
+		found, err = initializers.DB.From("prayer").
+			Select("title").
+			Where(
+				goqu.C("prayer_id").Eq(targetID),
+				goqu.C("deleted").IsFalse(),
+				goqu.C("prayer_id").In(
+					initializers.DB.From("prayer_access").
+						Select("prayer_id").
+						Where(goqu.Or(
+							goqu.Ex{"access_type": "user", "access_type_id": userID},
+							goqu.And(
+								goqu.C("access_type").Eq("group"),
+								goqu.C("access_type_id").In(userGroups),
+							),
+						)),
+				),
+			).
+			ScanVal(&name)
+	case models.ReminderTargetSubject:
+		found, err = initializers.DB.From("prayer_subject").
+			Select("prayer_subject_display_name").
+			Where(
+				goqu.C("prayer_subject_id").Eq(targetID),
+				goqu.C("created_by").Eq(userID),
+			).
+			ScanVal(&name)
+	case models.ReminderTargetCategory:
+		found, err = initializers.DB.From("prayer_category").
+			Select("category_name").
+			Where(
+				goqu.C("prayer_category_id").Eq(targetID),
+				goqu.Or(
+					goqu.Ex{"category_type": "user", "category_type_id": userID},
+					goqu.And(
+						goqu.C("category_type").Eq("group"),
+						goqu.C("category_type_id").In(userGroups),
+					),
+				),
+			).
+			ScanVal(&name)
+	default:
+		return "", false, fmt.Errorf("unknown reminder target %q", targetType)
+	}
+	return name, found, err
+}
+
+// StartReminderScheduler sends due prayer reminders every interval until stop
+// is closed
+func StartReminderScheduler(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				FireDueReminders(time.Now())
+			case <-stop:
+				return
+			}
+		}
+	}()
+	log.Printf("Prayer reminder scheduler started (every %s)", interval)
+}
+
+// FireDueReminders sends every reminder due at now, batch by batch. Reminders
+// due while the server was down are handled the same way: each one goes out
+// once if it is at most reminderGracePeriod late and is skipped otherwise,
+// then moves to its next occurrence after now.
+func FireDueReminders(now time.Time) {
+	for {
+		handled, err := fireReminderBatch(now)
+		if err != nil {
+			log.Printf("Failed to send prayer reminders: %v", err)
+			return
+		}
+		// A short batch means nothing else is due, or some reminders failed
+		// and are left for the next tick
+		if handled < reminderBatchSize {
+			return
+		}
+	}
+}
+
+// fireReminderBatch claims a batch of due reminders, moves each to its next
+// occurrence and queues its push in the same transaction, so a reminder is
+// sent once even with several server instances. Each reminder runs in its own
+// savepoint: one that fails is logged and left due for the next tick without
+// undoing the rest of the batch. It returns how many reminders were handled.
+func fireReminderBatch(now time.Time) (int, error) {
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var reminders []models.PrayerReminder
+	failed := 0
+	err = tx.Wrap(func() error {
+		if err := tx.From("prayer_reminder").
+			Where(
+				goqu.C("is_active").IsTrue(),
+				goqu.C("next_fire_at").Lte(now),
+			).
+			Order(goqu.C("next_fire_at").Asc()).
+			Limit(reminderBatchSize).
+			ForUpdate(exp.SkipLocked).
+			ScanStructs(&reminders); err != nil {
+			return fmt.Errorf("failed to claim reminders: %v", err)
+		}
+
+		for _, reminder := range reminders {
+			if _, err := tx.Exec("SAVEPOINT fire_reminder"); err != nil {
+				return fmt.Errorf("failed to create savepoint: %v", err)
+			}
+
+			if err := fireReminder(tx, reminder, now); err != nil {
+				log.Printf("Failed to send prayer reminder %d: %v", reminder.Prayer_Reminder_ID, err)
+				failed++
+				if _, err := tx.Exec("ROLLBACK TO SAVEPOINT fire_reminder"); err != nil {
+					return fmt.Errorf("failed to roll back reminder %d: %v", reminder.Prayer_Reminder_ID, err)
+				}
+				continue
+			}
+
+			if _, err := tx.Exec("RELEASE SAVEPOINT fire_reminder"); err != nil {
+				return fmt.Errorf("failed to release savepoint: %v", err)
+			}
+		}
+		return nil
+	})
+	return len(reminders) - failed, err
+}
+
+func fireReminder(tx *goqu.TxDatabase, reminder models.PrayerReminder, now time.Time) error {
+	record := goqu.Record{
+		"next_fire_at":    nil,
+		"is_active":       false,
+		"datetime_update": goqu.L("NOW()"),
+	}
+
+	next, hasNext, err := NextReminderTime(reminder, now)
+	if err != nil {
+		log.Printf("Deactivating reminder %d with an invalid schedule: %v", reminder.Prayer_Reminder_ID, err)
+	} else if hasNext {
+		record["next_fire_at"] = next
+		record["is_active"] = true
+	}
+
+	name, found, err := ReminderTargetName(reminder.User_Profile_ID, reminder.Target_Type, reminder.Target_ID)
+	if err != nil {
+		return fmt.Errorf("failed to load reminder %d target: %v", reminder.Prayer_Reminder_ID, err)
+	}
+
+	due := *reminder.Next_Fire_At
+	switch {
+	case !found:
+		// The prayer was deleted or unshared, so there is nothing to pray for
+		record["next_fire_at"] = nil
+		record["is_active"] = false
+	case now.Sub(due) > reminderGracePeriod:
+		log.Printf("Skipping reminder %d due at %s, %s late", reminder.Prayer_Reminder_ID, due.Format(time.RFC3339), now.Sub(due).Round(time.Minute))
+	default:
+		record["last_fired_at"] = now
+		if err := GetNotificationRouter().SendNowTx(tx, reminderDelivery(reminder, name, due), []string{ChannelPush}); err != nil {
+			return fmt.Errorf("failed to queue reminder %d: %v", reminder.Prayer_Reminder_ID, err)
+		}
+	}
+
+	_, err = tx.Update("prayer_reminder").
+		Set(record).
+		Where(goqu.C("prayer_reminder_id").Eq(reminder.Prayer_Reminder_ID)).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update reminder %d: %v", reminder.Prayer_Reminder_ID, err)
+	}
+	return nil
+}
+
+// reminderDelivery is the push for one occurrence. The occurrence time is in
+// the key, so the same occurrence is never queued twice.
+func reminderDelivery(reminder models.PrayerReminder, targetName string, due time.Time) Delivery {
+	message := targetName
+	if reminder.Label != nil && *reminder.Label != "" {
+		message = *reminder.Label
+	}
+
+	data := map[string]string{
+		"type":             "prayer_reminder",
+		"prayerReminderId": strconv.Itoa(reminder.Prayer_Reminder_ID),
+		"targetType":       reminder.Target_Type,
+	}
+	d := Delivery{
+		UserID:  reminder.User_Profile_ID,
+		Type:    models.NotificationTypePrayerReminder,
+		Title:   "Time to pray",
+		Message: message,
+		Data:    data,
+		Key:     fmt.Sprintf("reminder:%d:%d", reminder.Prayer_Reminder_ID, due.Unix()),
+	}
+
+	switch reminder.Target_Type {
+	case models.ReminderTargetPrayer:
+		data["prayerId"] = strconv.Itoa(reminder.Target_ID)
+		d.TargetPrayerID = &reminder.Target_ID
+	case models.ReminderTargetSubject:
+		data["prayerSubjectId"] = strconv.Itoa(reminder.Target_ID)
+	case models.ReminderTargetCategory:
+		data["prayerCategoryId"] = strconv.Itoa(reminder.Target_ID)
+	}
+	return d
+}
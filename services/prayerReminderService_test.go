@@ -0,0 +1,232 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeReminderDays(t *testing.T) {
+	days, err := NormalizeReminderDays([]string{"Fri", "mon", " fri "})
+	assert.NoError(t, err)
+	assert.Equal(t, "mon,fri", days)
+
+	_, err = NormalizeReminderDays([]string{"monday"})
+	assert.Error(t, err)
+}
+
+func TestNextReminderTime(t *testing.T) {
+	date := "2026-03-01"
+	weekly := "sun"
+	custom := "mon,wed"
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name     string
+		reminder models.PrayerReminder
+		after    time.Time
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:     "daily later today",
+			reminder: models.PrayerReminder{Schedule_Type: models.ReminderScheduleDaily, Remind_Time: "07:00", Timezone: "UTC"},
+			after:    time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "daily already past today",
+			reminder: models.PrayerReminder{Schedule_Type: models.ReminderScheduleDaily, Remind_Time: "07:00", Timezone: "UTC"},
+			after:    time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "daily keeps local time across daylight saving",
+			reminder: models.PrayerReminder{Schedule_Type: models.ReminderScheduleDaily, Remind_Time: "07:00", Timezone: "America/New_York"},
+			after:    time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			expected: time.Date(2026, 3, 8, 7, 0, 0, 0, newYork),
+			ok:       true,
+		},
+		{
+			name:     "weekly next week",
+			reminder: models.PrayerReminder{Schedule_Type: models.ReminderScheduleWeekly, Remind_Time: "20:30", Days_Of_Week: &weekly, Timezone: "UTC"},
+			after:    time.Date(2026, 3, 1, 21, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 3, 8, 20, 30, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "custom days",
+			reminder: models.PrayerReminder{Schedule_Type: models.ReminderScheduleCustom, Remind_Time: "08:00", Days_Of_Week: &custom, Timezone: "UTC"},
+			after:    time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "one-off in the future",
+			reminder: models.PrayerReminder{Schedule_Type: models.ReminderScheduleOnce, Remind_Time: "09:15", Remind_Date: &date, Timezone: "UTC"},
+			after:    time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "one-off already passed",
+			reminder: models.PrayerReminder{Schedule_Type: models.ReminderScheduleOnce, Remind_Time: "09:15", Remind_Date: &date, Timezone: "UTC"},
+			after:    time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC),
+			ok:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok, err := NextReminderTime(tt.reminder, tt.after)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.True(t, tt.expected.Equal(next), "expected %s, got %s", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestFireDueReminders(t *testing.T) {
+	now := time.Date(2026, 3, 2, 7, 1, 0, 0, time.UTC)
+	onTime := now.Add(-time.Minute)
+	late := now.Add(-3 * time.Hour)
+
+	tests := []struct {
+		name         string
+		due          time.Time
+		targetFound  bool
+		expectPush   bool
+		expectUpdate string
+	}{
+		{
+			name:         "due reminder is sent",
+			due:          onTime,
+			targetFound:  true,
+			expectPush:   true,
+			expectUpdate: `UPDATE "prayer_reminder" SET "datetime_update"=NOW\(\),"is_active"=TRUE,"last_fired_at"=.*,"next_fire_at"='2026-03-03T07:00:00Z'`,
+		},
+		{
+			name:         "missed by more than the grace period is skipped",
+			due:          late,
+			targetFound:  true,
+			expectUpdate: `UPDATE "prayer_reminder" SET "datetime_update"=NOW\(\),"is_active"=TRUE,"next_fire_at"='2026-03-03T07:00:00Z'`,
+		},
+		{
+			name:         "deleted prayer deactivates the reminder",
+			due:          onTime,
+			expectUpdate: `UPDATE "prayer_reminder" SET "datetime_update"=NOW\(\),"is_active"=FALSE,"next_fire_at"=NULL`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			originalDB := initializers.DB
+			initializers.DB = goqu.New("postgres", db)
+			defer func() { initializers.DB = originalDB }()
+
+			push := NewRecorderNotifier(ChannelPush)
+			originalRouter := GetNotificationRouter()
+			SetNotificationRouter(NewNotificationRouter(push))
+			defer SetNotificationRouter(originalRouter)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT .* FROM "prayer_reminder" .* FOR UPDATE SKIP LOCKED`).
+				WillReturnRows(sqlmock.NewRows([]string{
+					"prayer_reminder_id", "user_profile_id", "target_type", "target_id", "schedule_type",
+					"remind_time", "remind_date", "days_of_week", "timezone", "label", "is_active",
+					"next_fire_at", "last_fired_at", "datetime_create", "datetime_update",
+				}).AddRow(5, 1, "prayer", 9, "daily", "07:00", nil, nil, "UTC", nil, true, tt.due, nil, now, now))
+
+			titles := sqlmock.NewRows([]string{"title"})
+			if tt.targetFound {
+				titles.AddRow("Healing for Sam")
+			}
+			mock.ExpectExec(`SAVEPOINT fire_reminder`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(`SELECT "title" FROM "prayer"`).WillReturnRows(titles)
+			mock.ExpectExec(tt.expectUpdate).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`RELEASE SAVEPOINT fire_reminder`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+
+			FireDueReminders(now)
+
+			if tt.expectPush {
+				assert.Len(t, push.Deliveries(), 1)
+				d := push.Deliveries()[0]
+				assert.Equal(t, models.NotificationTypePrayerReminder, d.Type)
+				assert.Equal(t, "Healing for Sam", d.Message)
+				assert.Equal(t, "9", d.Data["prayerId"])
+				assert.Equal(t, "reminder:5:"+strconv.FormatInt(onTime.Unix(), 10), d.Key)
+			} else {
+				assert.Empty(t, push.Deliveries())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFireDueRemindersSkipsFailingReminder(t *testing.T) {
+	now := time.Date(2026, 3, 2, 7, 1, 0, 0, time.UTC)
+	due := now.Add(-time.Minute)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	originalDB := initializers.DB
+	initializers.DB = goqu.New("postgres", db)
+	defer func() { initializers.DB = originalDB }()
+
+	push := NewRecorderNotifier(ChannelPush)
+	originalRouter := GetNotificationRouter()
+	SetNotificationRouter(NewNotificationRouter(push))
+	defer SetNotificationRouter(originalRouter)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM "prayer_reminder" .* FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"prayer_reminder_id", "user_profile_id", "target_type", "target_id", "schedule_type",
+			"remind_time", "remind_date", "days_of_week", "timezone", "label", "is_active",
+			"next_fire_at", "last_fired_at", "datetime_create", "datetime_update",
+		}).
+			AddRow(5, 1, "prayer", 9, "daily", "07:00", nil, nil, "UTC", nil, true, due, nil, now, now).
+			AddRow(6, 2, "prayer", 11, "daily", "07:00", nil, nil, "UTC", nil, true, due, nil, now, now))
+
+	// Reminder 5 fails and is rolled back on its own; it stays due for the next tick
+	mock.ExpectExec(`SAVEPOINT fire_reminder`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT "title" FROM "prayer"`).WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT fire_reminder`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Reminder 6 still goes out in the same batch
+	mock.ExpectExec(`SAVEPOINT fire_reminder`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT "title" FROM "prayer"`).
+		WillReturnRows(sqlmock.NewRows([]string{"title"}).AddRow("Exams"))
+	mock.ExpectExec(`UPDATE "prayer_reminder" SET .* WHERE \("prayer_reminder_id" = 6\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT fire_reminder`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	FireDueReminders(now)
+
+	assert.Len(t, push.Deliveries(), 1)
+	assert.Equal(t, 2, push.Deliveries()[0].UserID)
+	assert.Equal(t, "Exams", push.Deliveries()[0].Message)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -79,15 +80,30 @@ func GetPushNotificationService() *PushNotificationService {
 	return pushService
 }
 
-func (s *PushNotificationService) SendNotificationToUser(userID int, payload NotificationPayload) error {
-	// Get user's push tokens from database
+// getUserPushTokens returns every device token registered for the user
+func getUserPushTokens(userID int) ([]models.PushToken, error) {
 	var tokens []models.PushToken
 	query := initializers.DB.From("user_push_tokens").
 		Where(goqu.C("user_profile_id").Eq(userID))
 
 	err := query.ScanStructs(&tokens)
 	if err != nil {
-		return fmt.Errorf("failed to get push tokens for user %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to get push tokens for user %d: %v", userID, err)
+	}
+	return tokens, nil
+}
+
+// isExpoPushToken reports whether the token came from Expo Go rather than a
+// native build
+func isExpoPushToken(token string) bool {
+	return strings.HasPrefix(token, "ExponentPushToken[")
+}
+
+func (s *PushNotificationService) SendNotificationToUser(userID int, payload NotificationPayload) error {
+	// Get user's push tokens from database
+	tokens, err := getUserPushTokens(userID)
+	if err != nil {
+		return err
 	}
 
 	if len(tokens) == 0 {
@@ -97,7 +113,7 @@ func (s *PushNotificationService) SendNotificationToUser(userID int, payload Not
 	// Send notification to each token
 	for _, token := range tokens {
 		err := s.sendToToken(token, payload)
-		if err != nil {
+		if err != nil && !errors.Is(err, errPushTokenUnregistered) {
 			log.Printf("Failed to send notification to token %s: %v", token.PushToken, err)
 			// Continue with other tokens even if one fails
 		}
@@ -126,7 +142,7 @@ func (s *PushNotificationService) SendNotificationToUsers(userIDs []int, payload
 
 func (s *PushNotificationService) sendToToken(pushToken models.PushToken, payload NotificationPayload) error {
 	// Check if this is an Expo token (for Expo Go testing)
-	if strings.HasPrefix(pushToken.PushToken, "ExponentPushToken[") {
+	if isExpoPushToken(pushToken.PushToken) {
 		return s.sendExpoNotification(pushToken, payload)
 	}
 
@@ -215,6 +231,10 @@ func (s *PushNotificationService) sendToToken(pushToken models.PushToken, payloa
 	log.Printf("FCM message payload: %+v", message)
 	
 	response, err := s.fcmClient.Send(ctx, message)
+	if messaging.IsUnregistered(err) {
+		removePushToken(pushToken.PushToken)
+		return errPushTokenUnregistered
+	}
 	if err != nil {
 		log.Printf("FCM send error: %v", err)
 		return fmt.Errorf("failed to send FCM message: %v", err)
@@ -357,7 +377,7 @@ func (s *PushNotificationService) sendExpoNotification(pushToken models.PushToke
 		return fmt.Errorf("failed to marshal Expo message: %v", err)
 	}
 
-	resp, err := http.Post("https://exp.host/--/api/v2/push/send", "application/json", bytes.NewBuffer(jsonBody))
+	resp, err := http.Post(expoPushURL, "application/json", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to send Expo notification: %v", err)
 	}
@@ -371,6 +391,24 @@ func (s *PushNotificationService) sendExpoNotification(pushToken models.PushToke
 		return fmt.Errorf("Expo push API returned status %d: %s", resp.StatusCode, string(responseBody))
 	}
 
+	// A single message gets a single ticket back. DeviceNotRegistered can show
+	// up here or, later, in the ticket's receipt.
+	var ticket struct {
+		Data expoPushStatus `json:"data"`
+	}
+	if err := json.Unmarshal(responseBody, &ticket); err == nil {
+		if ticket.Data.deviceNotRegistered() {
+			removePushToken(pushToken.PushToken)
+			return errPushTokenUnregistered
+		}
+		if ticket.Data.Status == "error" {
+			return fmt.Errorf("Expo push ticket error: %s", ticket.Data.Message)
+		}
+		if ticket.Data.ID != "" {
+			recordExpoTicket(ticket.Data.ID, pushToken.PushToken)
+		}
+	}
+
 	log.Printf("Successfully sent Expo notification to %s", pushToken.PushToken)
 	return nil
 }
\ No newline at end of file
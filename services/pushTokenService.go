@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// errPushTokenUnregistered means FCM or Expo said the token will never work
+// again (the app was uninstalled or the token rotated). The token has been
+// removed, so the send is not retried.
+var errPushTokenUnregistered = errors.New("push token is no longer registered")
+
+// Expo push API endpoints, variables so tests can point them at a local server
+var (
+	expoPushURL     = "https://exp.host/--/api/v2/push/send"
+	expoReceiptsURL = "https://exp.host/--/api/v2/push/getReceipts"
+)
+
+const (
+	// Expo receipts are ready about 15 minutes after the send and kept for a day
+	expoReceiptDelay  = 15 * time.Minute
+	expoReceiptExpiry = 24 * time.Hour
+	expoReceiptBatch  = 1000
+
+	// pushTokenStaleAfter matches FCM, which expires tokens after 270 days
+	// without the app connecting
+	pushTokenStaleAfter = 270 * 24 * time.Hour
+)
+
+// expoPushStatus is a push ticket or receipt from Expo
+type expoPushStatus struct {
+	Status  string `json:"status"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Details struct {
+		Error string `json:"error"`
+	} `json:"details"`
+}
+
+func (s expoPushStatus) deviceNotRegistered() bool {
+	return s.Status == "error" && s.Details.Error == "DeviceNotRegistered"
+}
+
+// removePushToken deletes a token FCM or Expo reported as unregistered, for
+// every user that has it
+func removePushToken(token string) {
+	result, err := initializers.DB.Delete("user_push_tokens").
+		Where(goqu.C("push_token").Eq(token)).
+		Executor().Exec()
+	if err != nil {
+		log.Printf("Failed to remove unregistered push token: %v", err)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		log.Printf("Removed unregistered push token %s", truncateToken(token))
+	}
+}
+
+func truncateToken(token string) string {
+	if len(token) <= 20 {
+		return token
+	}
+	return token[:20] + "..."
+}
+
+// recordExpoTicket keeps the ticket so CheckExpoPushReceipts can look at its
+// receipt later; DeviceNotRegistered often only shows up there
+func recordExpoTicket(ticketID string, token string) {
+	_, err := initializers.DB.Insert("expo_push_ticket").
+		Rows(goqu.Record{"ticket_id": ticketID, "push_token": token}).
+		OnConflict(goqu.DoNothing()).
+		Executor().Exec()
+	if err != nil {
+		log.Printf("Failed to record Expo push ticket %s: %v", ticketID, err)
+	}
+}
+
+// CheckExpoPushReceipts fetches receipts for tickets old enough to have one
+// and removes tokens Expo reports as DeviceNotRegistered. Checked tickets are
+// deleted; ones without a receipt yet are kept until they expire.
+func CheckExpoPushReceipts() {
+	var tickets []struct {
+		Ticket_ID  string `db:"ticket_id"`
+		Push_Token string `db:"push_token"`
+	}
+	err := initializers.DB.From("expo_push_ticket").
+		Select("ticket_id", "push_token").
+		Where(goqu.C("datetime_create").Lte(time.Now().Add(-expoReceiptDelay))).
+		Order(goqu.C("datetime_create").Asc()).
+		Limit(expoReceiptBatch).
+		ScanStructs(&tickets)
+	if err != nil {
+		log.Printf("Failed to load Expo push tickets: %v", err)
+		return
+	}
+	if len(tickets) == 0 {
+		return
+	}
+
+	ids := make([]string, len(tickets))
+	for i, ticket := range tickets {
+		ids[i] = ticket.Ticket_ID
+	}
+
+	receipts, err := fetchExpoReceipts(ids)
+	if err != nil {
+		log.Printf("Failed to fetch Expo push receipts: %v", err)
+		return
+	}
+
+	var checked []string
+	for _, ticket := range tickets {
+		receipt, ok := receipts[ticket.Ticket_ID]
+		if !ok {
+			continue
+		}
+		if receipt.deviceNotRegistered() {
+			removePushToken(ticket.Push_Token)
+		} else if receipt.Status == "error" {
+			log.Printf("Expo push receipt %s error: %s", ticket.Ticket_ID, receipt.Message)
+		}
+		checked = append(checked, ticket.Ticket_ID)
+	}
+
+	done := []goqu.Expression{goqu.C("datetime_create").Lt(time.Now().Add(-expoReceiptExpiry))}
+	if len(checked) > 0 {
+		done = append(done, goqu.C("ticket_id").In(checked))
+	}
+	_, err = initializers.DB.Delete("expo_push_ticket").
+		Where(goqu.Or(done...)).
+		Executor().Exec()
+	if err != nil {
+		log.Printf("Failed to delete checked Expo push tickets: %v", err)
+	}
+}
+
+func fetchExpoReceipts(ids []string) (map[string]expoPushStatus, error) {
+	body, err := json.Marshal(map[string][]string{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(expoReceiptsURL, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Expo receipts API returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+
+	var decoded struct {
+		Data map[string]expoPushStatus `json:"data"`
+	}
+	if err := json.Unmarshal(responseBody, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode Expo receipts: %v", err)
+	}
+	return decoded.Data, nil
+}
+
+// CleanupStalePushTokens removes tokens whose device hasn't registered in
+// pushTokenStaleAfter; FCM has expired them by then
+func CleanupStalePushTokens() {
+	result, err := initializers.DB.Delete("user_push_tokens").
+		Where(goqu.COALESCE(goqu.C("last_seen_at"), goqu.C("updated_at")).Lt(time.Now().Add(-pushTokenStaleAfter))).
+		Executor().Exec()
+	if err != nil {
+		log.Printf("Failed to clean up stale push tokens: %v", err)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		log.Printf("Removed %d stale push tokens", rows)
+	}
+}
+
+// StartPushTokenMaintenance checks Expo receipts and removes stale tokens
+// every interval until stop is closed
+func StartPushTokenMaintenance(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				CheckExpoPushReceipts()
+				CleanupStalePushTokens()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	log.Printf("Push token maintenance started (every %s)", interval)
+}
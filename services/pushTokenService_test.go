@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendExpoNotificationTicket(t *testing.T) {
+	tests := []struct {
+		name        string
+		ticket      string
+		expectSQL   string
+		expectedErr error
+	}{
+		{
+			name:      "ok - ticket kept for the receipt check",
+			ticket:    `{"data":{"status":"ok","id":"ticket-1"}}`,
+			expectSQL: `INSERT INTO "expo_push_ticket"`,
+		},
+		{
+			name:        "device not registered - token removed",
+			ticket:      `{"data":{"status":"error","message":"not registered","details":{"error":"DeviceNotRegistered"}}}`,
+			expectSQL:   `DELETE FROM "user_push_tokens" WHERE \("push_token" = 'ExponentPushToken\[abc\]'\)`,
+			expectedErr: errPushTokenUnregistered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			originalDB := initializers.DB
+			initializers.DB = goqu.New("postgres", db)
+			defer func() { initializers.DB = originalDB }()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.ticket)
+			}))
+			defer server.Close()
+			originalURL := expoPushURL
+			expoPushURL = server.URL
+			defer func() { expoPushURL = originalURL }()
+
+			mock.ExpectExec(tt.expectSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			s := &PushNotificationService{}
+			err = s.sendExpoNotification(models.PushToken{PushToken: "ExponentPushToken[abc]"}, NotificationPayload{Title: "Hi"})
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCheckExpoPushReceipts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	originalDB := initializers.DB
+	initializers.DB = goqu.New("postgres", db)
+	defer func() { initializers.DB = originalDB }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ticket-3 has no receipt yet
+		fmt.Fprint(w, `{"data":{
+			"ticket-1":{"status":"ok"},
+			"ticket-2":{"status":"error","details":{"error":"DeviceNotRegistered"}}
+		}}`)
+	}))
+	defer server.Close()
+	originalURL := expoReceiptsURL
+	expoReceiptsURL = server.URL
+	defer func() { expoReceiptsURL = originalURL }()
+
+	mock.ExpectQuery(`SELECT "ticket_id", "push_token" FROM "expo_push_ticket"`).
+		WillReturnRows(sqlmock.NewRows([]string{"ticket_id", "push_token"}).
+			AddRow("ticket-1", "ExponentPushToken[one]").
+			AddRow("ticket-2", "ExponentPushToken[two]").
+			AddRow("ticket-3", "ExponentPushToken[three]"))
+	mock.ExpectExec(`DELETE FROM "user_push_tokens" WHERE \("push_token" = 'ExponentPushToken\[two\]'\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "expo_push_ticket" WHERE .*"ticket_id" IN \('ticket-1', 'ticket-2'\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	CheckExpoPushReceipts()
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
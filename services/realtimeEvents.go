@@ -0,0 +1,119 @@
+package services
+
+import (
+	"log"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Real-time event types sent over GET /events
+const (
+	EventNotificationCreated = "notification.created"
+	EventCommentCreated      = "comment.created"
+	EventPrayerCreated       = "prayer.created"
+	EventPrayerShared        = "prayer.shared"
+	EventPrayerUnshared      = "prayer.unshared"
+	EventPrayerUpdated       = "prayer.updated"
+	EventPrayerUpdatePosted  = "prayer.update_posted"
+	EventPrayerAnswered      = "prayer.answered"
+	EventPrayerDeleted       = "prayer.deleted"
+	EventGroupMemberJoined   = "group.member_joined"
+	EventGroupMemberLeft     = "group.member_left"
+	EventGroupRoleChanged    = "group.role_changed"
+	EventGroupDeleted        = "group.deleted"
+	EventGroupJoinRequested  = "group.join_requested"
+	EventGroupJoinResponded  = "group.join_responded"
+)
+
+// EventData identifies what changed. Only the IDs that apply are set.
+type EventData struct {
+	PrayerID         int    `json:"prayerId,omitempty"`
+	CommentID        int    `json:"commentId,omitempty"`
+	UpdateID         int    `json:"prayerUpdateId,omitempty"`
+	GroupID          int    `json:"groupId,omitempty"`
+	UserID           int    `json:"userId,omitempty"`
+	ActorID          int    `json:"actorId,omitempty"`
+	RequestID        int    `json:"groupJoinRequestId,omitempty"`
+	NotificationType string `json:"notificationType,omitempty"`
+	Status           string `json:"status,omitempty"`
+}
+
+// PublishToUsers sends an event to each user's connected clients
+func PublishToUsers(eventType string, data EventData, userIDs ...int) {
+	if len(userIDs) == 0 {
+		return
+	}
+	topics := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		topics[i] = UserTopic(userID)
+	}
+	GetEventHub().Publish(eventType, data, topics...)
+}
+
+// PublishGroupEvent sends an event to the group's members, plus any users
+// given (e.g. someone who just joined or left and isn't subscribed to the
+// group's topic)
+func PublishGroupEvent(eventType string, data EventData, groupID int, userIDs ...int) {
+	topics := []string{GroupTopic(groupID)}
+	for _, userID := range userIDs {
+		topics = append(topics, UserTopic(userID))
+	}
+	GetEventHub().Publish(eventType, data, topics...)
+}
+
+// PublishPrayerEvent sends an event to everyone the prayer is shared with,
+// directly or through a group, plus any users given
+func PublishPrayerEvent(eventType string, data EventData, userIDs ...int) {
+	var access []struct {
+		Access_Type    string `db:"access_type"`
+		Access_Type_ID int    `db:"access_type_id"`
+	}
+	err := initializers.DB.From("prayer_access").
+		Select("access_type", "access_type_id").
+		Where(goqu.C("prayer_id").Eq(data.PrayerID)).
+		ScanStructs(&access)
+	if err != nil {
+		log.Printf("Failed to load prayer %d audience for %s event: %v", data.PrayerID, eventType, err)
+		return
+	}
+
+	var topics []string
+	for _, a := range access {
+		if topic := prayerAccessTopic(a.Access_Type, a.Access_Type_ID); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	for _, userID := range userIDs {
+		topics = append(topics, UserTopic(userID))
+	}
+	if len(topics) == 0 {
+		return
+	}
+	GetEventHub().Publish(eventType, data, topics...)
+}
+
+// PublishPrayerAccessEvent sends an event about one prayer_access row (a share
+// being added or removed) to whoever it grants access, plus any users given
+func PublishPrayerAccessEvent(eventType string, data EventData, accessType string, accessTypeID int, userIDs ...int) {
+	var topics []string
+	if topic := prayerAccessTopic(accessType, accessTypeID); topic != "" {
+		topics = append(topics, topic)
+	}
+	for _, userID := range userIDs {
+		topics = append(topics, UserTopic(userID))
+	}
+	GetEventHub().Publish(eventType, data, topics...)
+}
+
+// prayerAccessTopic is the topic for a prayer_access row, or "" for access
+// types no client subscribes to (prayer subjects belong to their creator)
+func prayerAccessTopic(accessType string, accessTypeID int) string {
+	switch accessType {
+	case "user":
+		return UserTopic(accessTypeID)
+	case "group":
+		return GroupTopic(accessTypeID)
+	}
+	return ""
+}
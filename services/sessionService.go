@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+)
+
+const (
+	// AccessTokenTTL is the lifetime of the JWT sent on every request
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a session survives without being refreshed
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrSessionNotFound    = errors.New("session not found or expired")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
+
+// CreateSession stores a new session for the user and returns its ID along with
+// the plaintext refresh token. Only a SHA-256 hash of the token is persisted.
+func CreateSession(userID int, userAgent string, ipAddress string) (int, string, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return 0, "", err
+	}
+
+	now := time.Now()
+	session := models.UserSession{
+		User_Profile_ID:    userID,
+		Refresh_Token_Hash: hashRefreshToken(refreshToken),
+		User_Agent:         userAgent,
+		IP_Address:         ipAddress,
+		Expires_At:         now.Add(RefreshTokenTTL),
+		Last_Used_At:       now,
+	}
+
+	var sessionID int
+	_, err = initializers.DB.Insert("user_session").
+		Rows(session).
+		Returning("user_session_id").
+		Executor().
+		ScanVal(&sessionID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return sessionID, refreshToken, nil
+}
+
+// RotateSession exchanges a refresh token for a new one. The swap is a single
+// conditional UPDATE, so two concurrent refreshes with the same token cannot
+// both succeed. Every token rotated out is remembered in
+// user_session_rotated_token, and presenting any of them again revokes the
+// session, since it means an old token leaked.
+func RotateSession(refreshToken string) (models.UserSession, string, error) {
+	var session models.UserSession
+
+	newToken, err := generateRefreshToken()
+	if err != nil {
+		return session, "", err
+	}
+
+	oldHash := hashRefreshToken(refreshToken)
+	now := time.Now()
+
+	tx, err := initializers.DB.Begin()
+	if err != nil {
+		return session, "", err
+	}
+
+	found := false
+	err = tx.Wrap(func() error {
+		var err error
+		found, err = tx.Update("user_session").
+			Set(goqu.Record{
+				"refresh_token_hash": hashRefreshToken(newToken),
+				"expires_at":         now.Add(RefreshTokenTTL),
+				"last_used_at":       now,
+			}).
+			Where(goqu.And(
+				goqu.C("refresh_token_hash").Eq(oldHash),
+				goqu.C("revoked_at").IsNull(),
+				goqu.C("expires_at").Gt(now),
+			)).
+			Returning("user_session_id", "user_profile_id").
+			Executor().
+			ScanStruct(&session)
+		if err != nil || !found {
+			return err
+		}
+
+		_, err = tx.Insert("user_session_rotated_token").
+			Rows(models.UserSessionRotatedToken{
+				User_Session_ID: session.User_Session_ID,
+				Token_Hash:      oldHash,
+			}).
+			Executor().Exec()
+		return err
+	})
+	if err != nil {
+		return models.UserSession{}, "", err
+	}
+
+	if found {
+		return session, newToken, nil
+	}
+
+	// Not a current token; check whether it is one we already rotated away from
+	var reusedSessionID int
+	reused, err := initializers.DB.From("user_session_rotated_token").
+		Select("user_session_rotated_token.user_session_id").
+		InnerJoin(
+			goqu.T("user_session"),
+			goqu.On(goqu.Ex{"user_session.user_session_id": goqu.I("user_session_rotated_token.user_session_id")}),
+		).
+		Where(goqu.And(
+			goqu.I("user_session_rotated_token.token_hash").Eq(oldHash),
+			goqu.I("user_session.revoked_at").IsNull(),
+		)).
+		ScanVal(&reusedSessionID)
+	if err != nil {
+		return session, "", err
+	}
+
+	if reused {
+		log.Printf("Refresh token reuse detected for session %d, revoking", reusedSessionID)
+		if err := RevokeSession(reusedSessionID); err != nil {
+			log.Printf("Failed to revoke session %d after token reuse: %v", reusedSessionID, err)
+		}
+		return session, "", ErrRefreshTokenReused
+	}
+
+	return session, "", ErrSessionNotFound
+}
+
+// IsSessionActive reports whether the session exists for the user and has not
+// been revoked or allowed to expire
+func IsSessionActive(sessionID int, userID int) (bool, error) {
+	var activeSessionID int
+	found, err := initializers.DB.From("user_session").
+		Select("user_session_id").
+		Where(goqu.And(
+			goqu.C("user_session_id").Eq(sessionID),
+			goqu.C("user_profile_id").Eq(userID),
+			goqu.C("revoked_at").IsNull(),
+			goqu.C("expires_at").Gt(time.Now()),
+		)).
+		ScanVal(&activeSessionID)
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// RevokeSession ends a single session (logout on one device)
+func RevokeSession(sessionID int) error {
+	_, err := initializers.DB.Update("user_session").
+		Set(goqu.Record{"revoked_at": time.Now()}).
+		Where(goqu.And(
+			goqu.C("user_session_id").Eq(sessionID),
+			goqu.C("revoked_at").IsNull(),
+		)).
+		Executor().Exec()
+	return err
+}
+
+// RevokeAllUserSessions ends every active session for the user except
+// keepSessionID. Pass 0 to revoke all of them.
+func RevokeAllUserSessions(userID int, keepSessionID int) (int64, error) {
+	conditions := []goqu.Expression{
+		goqu.C("user_profile_id").Eq(userID),
+		goqu.C("revoked_at").IsNull(),
+	}
+	if keepSessionID != 0 {
+		conditions = append(conditions, goqu.C("user_session_id").Neq(keepSessionID))
+	}
+
+	result, err := initializers.DB.Update("user_session").
+		Set(goqu.Record{"revoked_at": time.Now()}).
+		Where(goqu.And(conditions...)).
+		Executor().Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/PrayerLoop/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// SyncTombstoneRetention is how long tombstones are kept. A client whose last
+// sync is older than this has to do a full sync instead of a delta.
+const SyncTombstoneRetention = 90 * 24 * time.Hour
+
+// RecordSyncTombstoneTx tells one user's offline cache that a row is gone.
+// Write it in the transaction that deletes the row, so the delete never
+// commits without its tombstone.
+func RecordSyncTombstoneTx(tx *goqu.TxDatabase, userID int, entityType string, entityID int) error {
+	_, err := tx.Insert("sync_tombstone").
+		Rows(models.SyncTombstone{
+			User_Profile_ID: userID,
+			Entity_Type:     entityType,
+			Entity_ID:       entityID,
+		}).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to record %s tombstone %d for user %d: %v", entityType, entityID, userID, err)
+	}
+	return nil
+}
+
+// RecordGroupSyncTombstonesTx records the tombstone for every current member
+// of the group. Call it before the memberships themselves are removed.
+func RecordGroupSyncTombstonesTx(tx *goqu.TxDatabase, groupID int, entityType string, entityID int) error {
+	members := tx.From("user_group").
+		Select(goqu.C("user_profile_id"), goqu.V(entityType), goqu.V(entityID)).
+		Where(goqu.C("group_profile_id").Eq(groupID))
+
+	_, err := tx.Insert("sync_tombstone").
+		Cols("user_profile_id", "entity_type", "entity_id").
+		FromQuery(members).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to record %s tombstone %d for group %d: %v", entityType, entityID, groupID, err)
+	}
+	return nil
+}
+
+// RecordCategorySyncTombstonesTx records the tombstone for whoever can see the
+// category: its owner for user categories, every member for group categories.
+func RecordCategorySyncTombstonesTx(tx *goqu.TxDatabase, category models.PrayerCategory, entityType string, entityID int) error {
+	if category.Category_Type == "group" {
+		return RecordGroupSyncTombstonesTx(tx, category.Category_Type_ID, entityType, entityID)
+	}
+	return RecordSyncTombstoneTx(tx, category.Category_Type_ID, entityType, entityID)
+}
+
+// RecordPrayerAccessSyncTombstoneTx records a prayer_access tombstone for
+// everyone who sees the prayer through that access row. Call it before the row
+// is deleted.
+func RecordPrayerAccessSyncTombstoneTx(tx *goqu.TxDatabase, prayerAccessID int) error {
+	return recordPrayerViewerTombstones(
+		tx.Insert("sync_tombstone"),
+		models.SyncEntityPrayerAccess,
+		"prayer_access.prayer_access_id",
+		goqu.I("prayer_access.prayer_access_id").Eq(prayerAccessID),
+	)
+}
+
+// RecordPrayerSyncTombstones records a prayer tombstone for everyone who sees
+// the matching prayers through any access row. accessFilter applies to
+// prayer_access; call it before those rows are deleted. Failures are logged
+// rather than returned: a missed tombstone only leaves a stale row on the
+// device until its next full sync.
+func RecordPrayerSyncTombstones(accessFilter exp.Expression) {
+	err := recordPrayerViewerTombstones(initializers.DB.Insert("sync_tombstone"), models.SyncEntityPrayer, "prayer_access.prayer_id", accessFilter)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// RecordPrayerSyncTombstonesTx is RecordPrayerSyncTombstones inside the
+// transaction that deletes the access rows
+func RecordPrayerSyncTombstonesTx(tx *goqu.TxDatabase, accessFilter exp.Expression) error {
+	return recordPrayerViewerTombstones(tx.Insert("sync_tombstone"), models.SyncEntityPrayer, "prayer_access.prayer_id", accessFilter)
+}
+
+// recordPrayerViewerTombstones resolves prayer_access rows to the users who see
+// them, matching the visibility used by GET /sync: the user for user access,
+// every member for group access
+func recordPrayerViewerTombstones(insert *goqu.InsertDataset, entityType string, entityColumn string, accessFilter exp.Expression) error {
+	direct := initializers.DB.From("prayer_access").
+		Select(goqu.I("prayer_access.access_type_id").As("user_profile_id"), goqu.I(entityColumn).As("entity_id")).
+		Where(goqu.Ex{"prayer_access.access_type": "user"}, accessFilter)
+
+	throughGroup := initializers.DB.From("prayer_access").
+		Select(goqu.I("user_group.user_profile_id"), goqu.I(entityColumn).As("entity_id")).
+		Join(
+			goqu.T("user_group"),
+			goqu.On(goqu.Ex{"user_group.group_profile_id": goqu.I("prayer_access.access_type_id")}),
+		).
+		Where(goqu.Ex{"prayer_access.access_type": "group"}, accessFilter)
+
+	viewers := initializers.DB.From(direct.UnionAll(throughGroup).As("viewer")).
+		SelectDistinct(goqu.I("viewer.user_profile_id"), goqu.V(entityType), goqu.I("viewer.entity_id"))
+
+	_, err := insert.
+		Cols("user_profile_id", "entity_type", "entity_id").
+		FromQuery(viewers).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to record %s tombstones: %v", entityType, err)
+	}
+	return nil
+}
+
+// PruneSyncTombstones drops the user's tombstones that are past retention.
+// Any cursor that old already gets a full sync.
+func PruneSyncTombstones(userID int) {
+	_, err := initializers.DB.Delete("sync_tombstone").
+		Where(
+			goqu.C("user_profile_id").Eq(userID),
+			goqu.C("datetime_delete").Lt(time.Now().Add(-SyncTombstoneRetention)),
+		).
+		Executor().Exec()
+	if err != nil {
+		log.Printf("Failed to prune sync tombstones for user %d: %v", userID, err)
+	}
+}
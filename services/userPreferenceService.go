@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/PrayerLoop/initializers"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// TimezonePreferenceKey holds an IANA zone name (e.g. "America/Chicago") used
+// wherever the backend needs the user's local day
+const TimezonePreferenceKey = "timezone"
+
+// GetUserPreferenceValue returns the user's active value for a preference,
+// falling back to the preference default. found is false when the preference
+// key does not exist at all.
+func GetUserPreferenceValue(userID int, key string) (string, bool, error) {
+	var value string
+	found, err := initializers.DB.From(goqu.T("preference").As("p")).
+		Select(goqu.COALESCE(goqu.I("up.preference_value"), goqu.I("p.default_value"))).
+		LeftJoin(
+			goqu.T("user_preferences").As("up"),
+			goqu.On(goqu.And(
+				goqu.I("up.preference_key").Eq(goqu.I("p.preference_key")),
+				goqu.I("up.user_profile_id").Eq(userID),
+				goqu.I("up.is_active").IsTrue(),
+			)),
+		).
+		Where(goqu.I("p.preference_key").Eq(key)).
+		ScanVal(&value)
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, found, nil
+}
+
+// GetUserLocation returns the user's configured timezone. Missing or invalid
+// values fall back to UTC so callers always get a usable location.
+func GetUserLocation(userID int) *time.Location {
+	value, found, err := GetUserPreferenceValue(userID, TimezonePreferenceKey)
+	if err != nil {
+		log.Printf("Failed to load timezone preference for user %d: %v", userID, err)
+		return time.UTC
+	}
+	if !found || value == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		log.Printf("Invalid timezone %q for user %d, using UTC", value, userID)
+		return time.UTC
+	}
+
+	return location
+}
+
+// NotificationPreferenceKey is the boolean preference that turns a channel on
+// or off for one notification type, e.g. notify_prayer_shared_push
+func NotificationPreferenceKey(notificationType string, channel string) string {
+	return fmt.Sprintf("notify_%s_%s", strings.ToLower(notificationType), channel)
+}
+
+// NotificationChannelEnabled reports whether the user wants the notification
+// type on the channel. Types without a preference row yet, and lookup errors,
+// fall back to the defaults: in-app and push on, email off.
+func NotificationChannelEnabled(userID int, notificationType string, channel string) bool {
+	key := NotificationPreferenceKey(notificationType, channel)
+	value, found, err := GetUserPreferenceValue(userID, key)
+	if err != nil {
+		log.Printf("Failed to load preference %s for user %d: %v", key, userID, err)
+	}
+	if err != nil || !found {
+		return channel != ChannelEmail
+	}
+
+	return value == "true"
+}